@@ -40,7 +40,19 @@ func NewCmd(ctx context.Context) *cobra.Command {
   $ werf build --introspect-error
 
   # Build images and store/use stages from repo
-  $ werf build --repo harbor.company.io/werf`,
+  $ werf build --repo harbor.company.io/werf
+
+  # Build images and attach CycloneDX SBOM to each final image in the repo
+  $ werf build --repo harbor.company.io/werf --sbom --sbom-format cyclonedx
+
+  # Build images and sign each final image in the repo with the cosign key
+  $ WERF_SIGN_KEY_PASSWORD=password werf build --repo harbor.company.io/werf --sign-key cosign.key
+
+  # Build images and attach signed SLSA provenance to each final image in the repo
+  $ WERF_SIGN_KEY_PASSWORD=password werf build --repo harbor.company.io/werf --provenance --sign-key cosign.key
+
+  # Build images and export each final image as OCI image layout into the ./out directory
+  $ werf build --repo harbor.company.io/werf --output type=oci,dest=./out`,
 		Long:                  common.GetLongCommandDescription(GetBuildDocs().Long),
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
@@ -105,10 +117,16 @@ func NewCmd(ctx context.Context) *cobra.Command {
 	common.SetupDeprecatedReportPath(&commonCmdData, cmd)
 	common.SetupDeprecatedReportFormat(&commonCmdData, cmd)
 
+	common.SetupSBOM(&commonCmdData, cmd)
+	common.SetupSignKey(&commonCmdData, cmd)
+	common.SetupProvenance(&commonCmdData, cmd)
+	common.SetupOutput(&commonCmdData, cmd)
+
 	common.SetupAddCustomTag(&commonCmdData, cmd)
 	common.SetupVirtualMerge(&commonCmdData, cmd)
 
 	common.SetupParallelOptions(&commonCmdData, cmd, common.DefaultBuildParallelTasksLimit)
+	common.SetupKeepGoing(&commonCmdData, cmd)
 	common.SetupFollow(&commonCmdData, cmd)
 
 	common.SetupDisableAutoHostCleanup(&commonCmdData, cmd)
@@ -12,6 +12,7 @@ import (
 	helm_v3 "helm.sh/helm/v3/cmd/helm"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli/values"
 
 	"github.com/werf/kubedog/pkg/kube"
@@ -90,6 +91,8 @@ func NewCmd(ctx context.Context) *cobra.Command {
 
 	commonCmdData.SetupSkipDependenciesRepoRefresh(cmd)
 
+	common.SetupBundleVerification(&commonCmdData, cmd)
+
 	common.SetupSaveDeployReport(&commonCmdData, cmd)
 	common.SetupDeployReportPath(&commonCmdData, cmd)
 
@@ -102,6 +105,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 	common.SetupStatusProgressPeriod(&commonCmdData, cmd)
 	common.SetupHooksStatusProgressPeriod(&commonCmdData, cmd)
 	common.SetupReleasesHistoryMax(&commonCmdData, cmd)
+	common.SetupReadinessRules(&commonCmdData, cmd)
 
 	defaultTag := os.Getenv("WERF_TAG")
 	if defaultTag == "" {
@@ -170,6 +174,11 @@ func runApply(ctx context.Context) error {
 		return err
 	}
 
+	readinessRules, err := common.GetReadinessRules(&commonCmdData)
+	if err != nil {
+		return err
+	}
+
 	actionConfig := new(action.Configuration)
 	if err := helm.InitActionConfig(ctx, common.GetOndemandKubeInitializer(), namespace, helm_v3.Settings, actionConfig, helm.InitActionConfigOptions{
 		StatusProgressPeriod:      time.Duration(*commonCmdData.StatusProgressPeriodSeconds) * time.Second,
@@ -182,6 +191,7 @@ func runApply(ctx context.Context) error {
 		},
 		ReleasesHistoryMax: *commonCmdData.ReleasesHistoryMax,
 		RegistryClient:     helmRegistryClient,
+		ReadinessRules:     readinessRules,
 	}); err != nil {
 		return err
 	}
@@ -193,6 +203,15 @@ func runApply(ctx context.Context) error {
 		return fmt.Errorf("unable to pull bundle: %w", err)
 	}
 
+	var verifiedValues map[string]interface{}
+	if verifyOptions, err := common.GetBundleVerifyOptions(ctx, &commonCmdData); err != nil {
+		return err
+	} else if verifyOptions != nil {
+		if verifiedValues, err = bundles.VerifyBundleDir(ctx, bundleTmpDir, *verifyOptions); err != nil {
+			return err
+		}
+	}
+
 	var lockManager *lock_manager.LockManager
 	if m, err := lock_manager.NewLockManager(namespace); err != nil {
 		return fmt.Errorf("unable to create lock manager: %w", err)
@@ -228,6 +247,10 @@ func runApply(ctx context.Context) error {
 	}); err != nil {
 		return fmt.Errorf("error creating service values: %w", err)
 	} else {
+		// the verified images are pinned by the digests to deploy exactly the signed images
+		if verifiedValues != nil {
+			vals = chartutil.CoalesceTables(verifiedValues, vals)
+		}
 		bundle.SetServiceValues(vals)
 	}
 
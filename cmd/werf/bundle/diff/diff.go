@@ -0,0 +1,247 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	helm_v3 "helm.sh/helm/v3/cmd/helm"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/pkg/deploy/bundles"
+	"github.com/werf/werf/pkg/deploy/helm"
+	"github.com/werf/werf/pkg/deploy/helm/chart_extender"
+	"github.com/werf/werf/pkg/deploy/helm/chart_extender/helpers"
+	"github.com/werf/werf/pkg/deploy/helm/command_helpers"
+	"github.com/werf/werf/pkg/deploy/secrets_manager"
+	"github.com/werf/werf/pkg/docker_registry"
+	"github.com/werf/werf/pkg/util"
+	"github.com/werf/werf/pkg/werf"
+	"github.com/werf/werf/pkg/werf/global_warnings"
+)
+
+var cmdData struct {
+	From             string
+	To               string
+	DetailedExitCode bool
+}
+
+var commonCmdData common.CmdData
+
+func NewCmd(ctx context.Context) *cobra.Command {
+	ctx = common.NewContextWithCmdData(ctx, &commonCmdData)
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:                   "diff",
+		Short:                 "Show changes between two bundles",
+		Long:                  common.GetLongCommandDescription(`Compare two bundles and show the changes of the chart templates, the default values, the manifests rendered with the specified values and the digests of the bundle images. The bundle images are compared by the digests, so the image references changed by werf bundle copy are not reported as changes.`),
+		DisableFlagsInUseLine: true,
+		Annotations: map[string]string{
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			defer global_warnings.PrintGlobalWarnings(ctx)
+
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			common.LogVersion()
+
+			return common.LogRunningTime(func() error { return runDiff(ctx) })
+		},
+	})
+
+	common.SetupEnvironment(&commonCmdData, cmd)
+	common.SetupTmpDir(&commonCmdData, cmd, common.SetupTmpDirOptions{})
+	common.SetupHomeDir(&commonCmdData, cmd, common.SetupHomeDirOptions{})
+
+	common.SetupDockerConfig(&commonCmdData, cmd, "Command needs granted permissions to read and pull images from the specified repos")
+	common.SetupInsecureRegistry(&commonCmdData, cmd)
+	common.SetupInsecureHelmDependencies(&commonCmdData, cmd)
+	common.SetupSkipTlsVerifyRegistry(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+	common.SetupLogProjectDir(&commonCmdData, cmd)
+
+	common.SetupSet(&commonCmdData, cmd)
+	common.SetupSetString(&commonCmdData, cmd)
+	common.SetupSetFile(&commonCmdData, cmd)
+	common.SetupValues(&commonCmdData, cmd)
+	common.SetupSecretValues(&commonCmdData, cmd)
+	common.SetupIgnoreSecretKey(&commonCmdData, cmd)
+	commonCmdData.SetupDisableDefaultSecretValues(cmd)
+
+	common.SetupRelease(&commonCmdData, cmd)
+	common.SetupNamespace(&commonCmdData, cmd)
+
+	common.SetupKubeVersion(&commonCmdData, cmd)
+
+	cmd.Flags().StringVarP(&cmdData.From, "from", "", os.Getenv("WERF_FROM"), "Address of the bundle to compare from, specify bundle archive using schema `archive:PATH_TO_ARCHIVE.tar.gz`, specify remote bundle with schema `[docker://]REPO:TAG` or without schema.")
+	cmd.Flags().StringVarP(&cmdData.To, "to", "", os.Getenv("WERF_TO"), "Address of the bundle to compare to, specify bundle archive using schema `archive:PATH_TO_ARCHIVE.tar.gz`, specify remote bundle with schema `[docker://]REPO:TAG` or without schema.")
+	cmd.Flags().BoolVarP(&cmdData.DetailedExitCode, "exit-code", "", util.GetBoolEnvironmentDefaultFalse("WERF_EXIT_CODE"), "If true, returns exit code 0 if no changes, exit code 2 if bundles differ or exit code 1 in case of an error (default $WERF_EXIT_CODE or false)")
+
+	return cmd
+}
+
+func runDiff(ctx context.Context) error {
+	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+
+	if err := common.DockerRegistryInit(ctx, &commonCmdData); err != nil {
+		return err
+	}
+
+	helm_v3.Settings.Debug = *commonCmdData.LogDebug
+
+	if cmdData.From == "" {
+		return fmt.Errorf("--from=ADDRESS param required")
+	}
+	if cmdData.To == "" {
+		return fmt.Errorf("--to=ADDRESS param required")
+	}
+
+	fromAddr, err := bundles.ParseAddr(cmdData.From)
+	if err != nil {
+		return fmt.Errorf("invalid from addr %q: %w", cmdData.From, err)
+	}
+
+	toAddr, err := bundles.ParseAddr(cmdData.To)
+	if err != nil {
+		return fmt.Errorf("invalid to addr %q: %w", cmdData.To, err)
+	}
+
+	var fromRegistry, toRegistry docker_registry.Interface
+
+	if fromAddr.RegistryAddress != nil {
+		fromRegistry, err = common.CreateDockerRegistry(fromAddr.RegistryAddress.Repo, *commonCmdData.InsecureRegistry, *commonCmdData.SkipTlsVerifyRegistry)
+		if err != nil {
+			return err
+		}
+	}
+
+	if toAddr.RegistryAddress != nil {
+		toRegistry, err = common.CreateDockerRegistry(toAddr.RegistryAddress.Repo, *commonCmdData.InsecureRegistry, *commonCmdData.SkipTlsVerifyRegistry)
+		if err != nil {
+			return err
+		}
+	}
+
+	bundlesRegistryClient, err := common.NewBundlesRegistryClient(ctx, &commonCmdData)
+	if err != nil {
+		return err
+	}
+
+	var diff *bundles.BundleDiff
+	if err := logboek.Context(ctx).LogProcess("Compare bundles").DoError(func() error {
+		logboek.Context(ctx).LogFDetails("From: %s\n", fromAddr.String())
+		logboek.Context(ctx).LogFDetails("To: %s\n", toAddr.String())
+
+		diff, err = bundles.Diff(ctx, fromAddr, toAddr, bundles.DiffOptions{
+			BundlesRegistryClient: bundlesRegistryClient,
+			FromRegistryClient:    fromRegistry,
+			ToRegistryClient:      toRegistry,
+			RenderManifests:       renderManifests,
+		})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	bundles.PrintDiff(ctx, diff)
+
+	if cmdData.DetailedExitCode && diff.HasChanges() {
+		return bundles.ErrBundlesDiffer
+	}
+
+	return nil
+}
+
+// renderManifests renders the bundle chart the same way as werf bundle render does.
+func renderManifests(ctx context.Context, ch *chart.Chart) (string, error) {
+	bundleDir := filepath.Join(werf.GetServiceDir(), "tmp", "bundles", uuid.NewString())
+	defer os.RemoveAll(bundleDir)
+
+	if err := chartutil.SaveIntoDir(ch, bundleDir); err != nil {
+		return "", fmt.Errorf("unable to save chart into the directory %q: %w", bundleDir, err)
+	}
+
+	helmRegistryClient, err := common.NewHelmRegistryClient(ctx, *commonCmdData.DockerConfig, *commonCmdData.InsecureHelmDependencies)
+	if err != nil {
+		return "", fmt.Errorf("unable to create helm registry client: %w", err)
+	}
+
+	namespace := common.GetNamespace(&commonCmdData)
+	releaseName := common.GetOptionalRelease(&commonCmdData)
+
+	actionConfig := new(action.Configuration)
+	if err := helm.InitActionConfig(ctx, nil, namespace, helm_v3.Settings, actionConfig, helm.InitActionConfigOptions{RegistryClient: helmRegistryClient}); err != nil {
+		return "", err
+	}
+
+	secretsManager := secrets_manager.NewSecretsManager(secrets_manager.SecretsManagerOptions{DisableSecretsDecryption: *commonCmdData.IgnoreSecretKey})
+
+	bundle, err := chart_extender.NewBundle(ctx, bundleDir, helm_v3.Settings, helmRegistryClient, secretsManager, chart_extender.BundleOptions{
+		SecretValueFiles:           common.GetSecretValues(&commonCmdData),
+		BuildChartDependenciesOpts: command_helpers.BuildChartDependenciesOptions{IgnoreInvalidAnnotationsAndLabels: false},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if vals, err := helpers.GetBundleServiceValues(ctx, helpers.ServiceValuesOptions{
+		Env:              *commonCmdData.Environment,
+		Namespace:        namespace,
+		DockerConfigPath: *commonCmdData.DockerConfig,
+	}); err != nil {
+		return "", fmt.Errorf("error creating service values: %w", err)
+	} else {
+		bundle.SetServiceValues(vals)
+	}
+
+	loader.GlobalLoadOptions = &loader.LoadOptions{
+		ChartExtender: bundle,
+		SubchartExtenderFactoryFunc: func() chart.ChartExtender {
+			return chart_extender.NewWerfSubchart(ctx, secretsManager, chart_extender.WerfSubchartOptions{
+				DisableDefaultSecretValues: *commonCmdData.DisableDefaultSecretValues,
+			})
+		},
+	}
+
+	output := bytes.NewBuffer(nil)
+	includeCRDs := true
+	validate := false
+
+	helmTemplateCmd, _ := helm_v3.NewTemplateCmd(actionConfig, output, helm_v3.TemplateCmdOptions{
+		StagesSplitter:    helm.NewStagesSplitter(),
+		ChainPostRenderer: bundle.ChainPostRenderer,
+		ValueOpts: &values.Options{
+			ValueFiles:   common.GetValues(&commonCmdData),
+			StringValues: common.GetSetString(&commonCmdData),
+			Values:       common.GetSet(&commonCmdData),
+			FileValues:   common.GetSetFile(&commonCmdData),
+		},
+		Validate:    &validate,
+		IncludeCrds: &includeCRDs,
+		KubeVersion: commonCmdData.KubeVersion,
+	})
+
+	if err := helmTemplateCmd.RunE(helmTemplateCmd, []string{releaseName, bundleDir}); err != nil {
+		return "", fmt.Errorf("helm templates rendering failed: %w", err)
+	}
+
+	return output.String(), nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/werf/werf/pkg/deploy/helm/chart_extender/helpers"
 	"github.com/werf/werf/pkg/deploy/helm/command_helpers"
 	"github.com/werf/werf/pkg/deploy/secrets_manager"
+	"github.com/werf/werf/pkg/docker_registry"
 	"github.com/werf/werf/pkg/git_repo"
 	"github.com/werf/werf/pkg/git_repo/gitdata"
 	"github.com/werf/werf/pkg/image"
@@ -119,6 +120,8 @@ func NewCmd(ctx context.Context) *cobra.Command {
 	commonCmdData.SetupDisableDefaultSecretValues(cmd)
 	commonCmdData.SetupSkipDependenciesRepoRefresh(cmd)
 
+	common.SetupBundleSignKey(&commonCmdData, cmd)
+
 	common.SetupSaveBuildReport(&commonCmdData, cmd)
 	common.SetupBuildReportPath(&commonCmdData, cmd)
 	common.SetupDeprecatedReportPath(&commonCmdData, cmd)
@@ -129,6 +132,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 	common.SetupVirtualMerge(&commonCmdData, cmd)
 
 	common.SetupParallelOptions(&commonCmdData, cmd, common.DefaultBuildParallelTasksLimit)
+	common.SetupKeepGoing(&commonCmdData, cmd)
 
 	common.SetupDisableAutoHostCleanup(&commonCmdData, cmd)
 	common.SetupAllowedDockerStorageVolumeUsage(&commonCmdData, cmd)
@@ -419,6 +423,11 @@ func runPublish(ctx context.Context, imagesToProcess build.ImagesToProcess) erro
 		return fmt.Errorf("unable to create bundle: %w", err)
 	}
 
+	signer, err := common.GetSigner(&commonCmdData)
+	if err != nil {
+		return err
+	}
+
 	var bundleRepo string
 	if finalStagesStorage != nil {
 		bundleRepo = finalStagesStorage.Address()
@@ -429,5 +438,7 @@ func runPublish(ctx context.Context, imagesToProcess build.ImagesToProcess) erro
 	return bundles.Publish(ctx, bundle, fmt.Sprintf("%s:%s", bundleRepo, cmdData.Tag), bundlesRegistryClient, bundles.PublishOptions{
 		HelmCompatibleChart: *commonCmdData.HelmCompatibleChart,
 		RenameChart:         *commonCmdData.RenameChart,
+		Signer:              signer,
+		Registry:            docker_registry.API(),
 	})
 }
@@ -13,6 +13,7 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli/values"
 
 	"github.com/werf/werf/cmd/werf/common"
@@ -46,7 +47,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 		Long:                  common.GetLongCommandDescription(`Take locally extracted bundle or download bundle from the specified container registry using specified version tag or version mask and render it as Kubernetes manifests.`),
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
@@ -90,6 +91,8 @@ func NewCmd(ctx context.Context) *cobra.Command {
 	common.SetupIgnoreSecretKey(&commonCmdData, cmd)
 	commonCmdData.SetupDisableDefaultSecretValues(cmd)
 
+	common.SetupBundleVerification(&commonCmdData, cmd)
+
 	common.SetupRelease(&commonCmdData, cmd)
 	common.SetupNamespace(&commonCmdData, cmd)
 
@@ -186,6 +189,24 @@ func runRender(ctx context.Context) error {
 		}
 	}
 
+	var verifiedValues map[string]interface{}
+	if *commonCmdData.VerifyKey != "" {
+		if isLocal {
+			if err := common.DockerRegistryInit(ctx, &commonCmdData); err != nil {
+				return err
+			}
+		}
+
+		verifyOptions, err := common.GetBundleVerifyOptions(ctx, &commonCmdData)
+		if err != nil {
+			return err
+		}
+
+		if verifiedValues, err = bundles.VerifyBundleDir(ctx, bundleDir, *verifyOptions); err != nil {
+			return err
+		}
+	}
+
 	if *commonCmdData.Environment != "" {
 		userExtraAnnotations["project.werf.io/env"] = *commonCmdData.Environment
 	}
@@ -211,6 +232,10 @@ func runRender(ctx context.Context) error {
 	}); err != nil {
 		return fmt.Errorf("error creating service values: %w", err)
 	} else {
+		// the verified images are pinned by the digests to render exactly the signed images
+		if verifiedValues != nil {
+			vals = chartutil.CoalesceTables(verifiedValues, vals)
+		}
 		bundle.SetServiceValues(vals)
 	}
 
@@ -26,6 +26,8 @@ var commonCmdData common.CmdData
 
 var cmdData struct {
 	ScanContextOnly string
+	PlanOutput      string
+	ApplyPlan       string
 }
 
 func NewCmd(ctx context.Context) *cobra.Command {
@@ -103,10 +105,17 @@ func NewCmd(ctx context.Context) *cobra.Command {
 	cmd.PersistentFlags().StringVarP(&cmdData.ScanContextOnly, "scan-context-only", "", os.Getenv("WERF_SCAN_CONTEXT_ONLY"), "Scan for used images only in the specified kube context, scan all contexts from kube config otherwise (default false or $WERF_SCAN_CONTEXT_ONLY)")
 	cmd.PersistentFlags().StringVarP(&cmdData.ScanContextOnly, "kube-context", "", os.Getenv("WERF_SCAN_CONTEXT_ONLY"), "Scan for used images only in the specified kube context, scan all contexts from kube config otherwise (default false or $WERF_SCAN_CONTEXT_ONLY)")
 
+	cmd.Flags().StringVarP(&cmdData.PlanOutput, "plan-output", "", os.Getenv("WERF_PLAN_OUTPUT"), "Save the cleanup plan describing every kept and deleted stage, custom tag and metadata with the reason in JSON format to the specified file (default $WERF_PLAN_OUTPUT)")
+	cmd.Flags().StringVarP(&cmdData.ApplyPlan, "apply-plan", "", os.Getenv("WERF_APPLY_PLAN"), "Delete exactly the stages, custom tags and metadata from the cleanup plan saved with --plan-output without evaluating cleanup policies, except for the images used in Kubernetes at the time of applying (default $WERF_APPLY_PLAN)")
+
 	return cmd
 }
 
 func runCleanup(ctx context.Context) error {
+	if cmdData.PlanOutput != "" && cmdData.ApplyPlan != "" {
+		return fmt.Errorf("--plan-output and --apply-plan options cannot be used together")
+	}
+
 	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
 		return fmt.Errorf("initialization error: %w", err)
 	}
@@ -171,7 +180,7 @@ func runCleanup(ctx context.Context) error {
 		return fmt.Errorf("unable to load werf config: %w", err)
 	}
 
-	if !werfConfig.Meta.Cleanup.DisableGitHistoryBasedPolicy {
+	if !werfConfig.Meta.Cleanup.DisableGitHistoryBasedPolicy && cmdData.ApplyPlan == "" {
 		if !werfConfig.Meta.GitWorktree.GetForceShallowClone() && !werfConfig.Meta.GitWorktree.GetAllowFetchingOriginBranchesAndTags() {
 			isShallow, err := giterminismManager.LocalGitRepo().IsShallowClone(ctx)
 			if err != nil {
@@ -235,12 +244,6 @@ It is worth noting that auto-cleaning is enabled by default, and manual use is u
 		storageManager.EnableParallel(int(*commonCmdData.ParallelTasksLimit))
 	}
 
-	imagesNames, err := common.GetManagedImagesNames(ctx, projectName, stagesStorage, werfConfig)
-	if err != nil {
-		return err
-	}
-	logboek.Debug().LogF("Managed images names: %v\n", imagesNames)
-
 	var kubernetesContextClients []*kube.ContextClient
 	var kubernetesNamespaceRestrictionByContext map[string]string
 	if !(*commonCmdData.WithoutKube || werfConfig.Meta.Cleanup.DisableKubernetesBasedPolicy) {
@@ -252,6 +255,27 @@ It is worth noting that auto-cleaning is enabled by default, and manual use is u
 		kubernetesNamespaceRestrictionByContext = common.GetKubernetesNamespaceRestrictionByContext(&commonCmdData, kubernetesContextClients)
 	}
 
+	if cmdData.ApplyPlan != "" {
+		plan, err := cleaning.LoadCleanupPlan(cmdData.ApplyPlan)
+		if err != nil {
+			return err
+		}
+
+		logboek.LogOptionalLn()
+		return cleaning.ApplyCleanupPlan(ctx, projectName, storageManager, plan, cleaning.ApplyCleanupPlanOptions{
+			KubernetesContextClients:                kubernetesContextClients,
+			KubernetesNamespaceRestrictionByContext: kubernetesNamespaceRestrictionByContext,
+			WithoutKube:                             *commonCmdData.WithoutKube || werfConfig.Meta.Cleanup.DisableKubernetesBasedPolicy,
+			DryRun:                                  *commonCmdData.DryRun,
+		})
+	}
+
+	imagesNames, err := common.GetManagedImagesNames(ctx, projectName, stagesStorage, werfConfig)
+	if err != nil {
+		return err
+	}
+	logboek.Debug().LogF("Managed images names: %v\n", imagesNames)
+
 	cleanupOptions := cleaning.CleanupOptions{
 		ImageNameList:                           imagesNames,
 		LocalGit:                                giterminismManager.LocalGitRepo().(*git_repo.Local),
@@ -261,6 +285,7 @@ It is worth noting that auto-cleaning is enabled by default, and manual use is u
 		ConfigMetaCleanup:                       werfConfig.Meta.Cleanup,
 		KeepStagesBuiltWithinLastNHours:         *commonCmdData.KeepStagesBuiltWithinLastNHours,
 		DryRun:                                  *commonCmdData.DryRun,
+		PlanOutput:                              cmdData.PlanOutput,
 	}
 
 	logboek.LogOptionalLn()
@@ -35,6 +35,7 @@ type CmdData struct {
 	StatusProgressPeriodSeconds      *int64
 	HooksStatusProgressPeriodSeconds *int64
 	ReleasesHistoryMax               *int
+	ReadinessRules                   *string
 
 	SetDockerConfigJsonValue   *bool
 	Set                        *[]string
@@ -65,6 +66,7 @@ type CmdData struct {
 	Synchronization    *string
 	Parallel           *bool
 	ParallelTasksLimit *int64
+	KeepGoing          *bool
 	NetworkParallelism *int
 
 	DockerConfig                    *string
@@ -103,6 +105,17 @@ type CmdData struct {
 	SaveBuildReport *bool
 	BuildReportPath *string
 
+	SBOM       *bool
+	SBOMFormat *string
+
+	SignKey    *string
+	Provenance *bool
+
+	VerifyKey  *string
+	VerifyMode *string
+
+	Output *string
+
 	SaveDeployReport *bool
 	UseDeployReport  *bool
 	DeployReportPath *string
@@ -19,10 +19,13 @@ import (
 	"github.com/werf/werf/pkg/build/stage"
 	"github.com/werf/werf/pkg/config"
 	"github.com/werf/werf/pkg/container_backend"
+	"github.com/werf/werf/pkg/deploy/bundles"
 	"github.com/werf/werf/pkg/docker_registry"
 	"github.com/werf/werf/pkg/git_repo"
 	"github.com/werf/werf/pkg/giterminism_manager"
 	"github.com/werf/werf/pkg/logging"
+	"github.com/werf/werf/pkg/sbom"
+	"github.com/werf/werf/pkg/signature"
 	"github.com/werf/werf/pkg/storage"
 	"github.com/werf/werf/pkg/true_git"
 	"github.com/werf/werf/pkg/util"
@@ -248,6 +251,107 @@ func GetBuildReportPathAndFormat(cmdData *CmdData) (string, build.ReportFormat,
 	}
 }
 
+func SetupSBOM(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.SBOM = new(bool)
+	cmd.Flags().BoolVarP(cmdData.SBOM, "sbom", "", util.GetBoolEnvironmentDefaultFalse("WERF_SBOM"), "Generate SBOM for each final image and push it into the container registry as an OCI artifact referring to the image (default $WERF_SBOM or false). Requires --repo. Its format configured with --sbom-format")
+
+	cmdData.SBOMFormat = new(string)
+	cmd.Flags().StringVarP(cmdData.SBOMFormat, "sbom-format", "", os.Getenv("WERF_SBOM_FORMAT"), fmt.Sprintf("SBOM format: %q or %q (default $WERF_SBOM_FORMAT or %q)", sbom.FormatSPDX, sbom.FormatCycloneDX, sbom.FormatSPDX))
+}
+
+func GetSBOMFormat(cmdData *CmdData) (sbom.Format, error) {
+	if cmdData.SBOMFormat == nil || *cmdData.SBOMFormat == "" {
+		return sbom.FormatSPDX, nil
+	}
+
+	format, err := sbom.ParseFormat(*cmdData.SBOMFormat)
+	if err != nil {
+		return "", fmt.Errorf("invalid --sbom-format: %w", err)
+	}
+
+	return format, nil
+}
+
+func SetupSignKey(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.SignKey = new(string)
+	cmd.Flags().StringVarP(cmdData.SignKey, "sign-key", "", os.Getenv("WERF_SIGN_KEY"), fmt.Sprintf("Sign each final image with the cosign-compatible ECDSA key and push the signature into the container registry (default $WERF_SIGN_KEY). The value is either the path to the private key file (password of the encrypted key is read from $WERF_SIGN_KEY_PASSWORD) or %sPLUGIN to delegate signing to an external program. Requires --repo", signature.PluginKeyRefPrefix))
+}
+
+func SetupBundleSignKey(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.SignKey = new(string)
+	cmd.Flags().StringVarP(cmdData.SignKey, "sign-key", "", os.Getenv("WERF_SIGN_KEY"), fmt.Sprintf("Sign the bundle and each final image with the cosign-compatible ECDSA key (default $WERF_SIGN_KEY). The bundle signature covers the chart and the digests of all bundle images and is checked by the bundle commands with --verify-key. The value is either the path to the private key file (password of the encrypted key is read from $WERF_SIGN_KEY_PASSWORD) or %sPLUGIN to delegate signing to an external program", signature.PluginKeyRefPrefix))
+}
+
+func SetupBundleVerification(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.VerifyKey = new(string)
+	cmd.Flags().StringVarP(cmdData.VerifyKey, "verify-key", "", os.Getenv("WERF_VERIFY_KEY"), fmt.Sprintf("Verify the bundle signature, the chart and the digests of the bundle images with the public key (default $WERF_VERIFY_KEY). The value is either the path to the PEM encoded public key file or %sPLUGIN to get the public key from the signing plugin. The bundle is not verified if not specified", signature.PluginKeyRefPrefix))
+
+	cmdData.VerifyMode = new(string)
+	cmd.Flags().StringVarP(cmdData.VerifyMode, "verify-mode", "", os.Getenv("WERF_VERIFY_MODE"), fmt.Sprintf("How to handle the failed bundle verification (default $WERF_VERIFY_MODE or %q): %q to refuse using the bundle or %q to print the warning and continue", bundles.VerifyModeEnforce, bundles.VerifyModeEnforce, bundles.VerifyModeWarn))
+}
+
+// GetBundleVerifyOptions returns nil if the bundle verification is disabled, the docker registry should be initialized before the verification.
+func GetBundleVerifyOptions(ctx context.Context, cmdData *CmdData) (*bundles.VerifyOptions, error) {
+	if cmdData.VerifyKey == nil || *cmdData.VerifyKey == "" {
+		return nil, nil
+	}
+
+	mode := bundles.VerifyModeEnforce
+	if *cmdData.VerifyMode != "" {
+		var err error
+		if mode, err = bundles.ParseVerifyMode(*cmdData.VerifyMode); err != nil {
+			return nil, fmt.Errorf("invalid --verify-mode: %w", err)
+		}
+	}
+
+	publicKey, err := signature.LoadPublicKeyRef(ctx, *cmdData.VerifyKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --verify-key: %w", err)
+	}
+
+	return &bundles.VerifyOptions{
+		PublicKey: publicKey,
+		Mode:      mode,
+		Registry:  docker_registry.API(),
+	}, nil
+}
+
+func SetupProvenance(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.Provenance = new(bool)
+	cmd.Flags().BoolVarP(cmdData.Provenance, "provenance", "", util.GetBoolEnvironmentDefaultFalse("WERF_PROVENANCE"), "Generate SLSA provenance for each final image and push it into the container registry as an in-toto attestation referring to the image (default $WERF_PROVENANCE or false). The attestation is signed with --sign-key if specified. Requires --repo")
+}
+
+func SetupOutput(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.Output = new(string)
+	cmd.Flags().StringVarP(cmdData.Output, "output", "", os.Getenv("WERF_OUTPUT"), fmt.Sprintf("Export each final image after the build (default $WERF_OUTPUT). The value is type=TYPE,dest=DIR, where TYPE is %q to write OCI image layout DIR/IMAGE_NAME (multi-platform images as image index) or %q to write DIR/IMAGE_NAME.tar loadable by docker load (one archive per platform)", build.OutputOCI, build.OutputDockerArchive))
+}
+
+func GetOutputOptions(cmdData *CmdData) (*build.OutputOptions, error) {
+	if cmdData.Output == nil || *cmdData.Output == "" {
+		return nil, nil
+	}
+
+	opts, err := build.ParseOutputOptions(*cmdData.Output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --output: %w", err)
+	}
+
+	return opts, nil
+}
+
+func GetSigner(cmdData *CmdData) (signature.Signer, error) {
+	if cmdData.SignKey == nil || *cmdData.SignKey == "" {
+		return nil, nil
+	}
+
+	signer, err := signature.NewSigner(*cmdData.SignKey, []byte(os.Getenv("WERF_SIGN_KEY_PASSWORD")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --sign-key: %w", err)
+	}
+
+	return signer, nil
+}
+
 func SetupSaveDeployReport(cmdData *CmdData, cmd *cobra.Command) {
 	cmdData.SaveDeployReport = new(bool)
 	cmd.Flags().BoolVarP(cmdData.SaveDeployReport, "save-deploy-report", "", util.GetBoolEnvironmentDefaultFalse("WERF_SAVE_DEPLOY_REPORT"), fmt.Sprintf("Save deploy report (by default $WERF_SAVE_DEPLOY_REPORT or %t). Its path and format configured with --deploy-report-path", DefaultSaveDeployReport))
@@ -538,6 +642,11 @@ func SetupReleasesHistoryMax(cmdData *CmdData, cmd *cobra.Command) {
 	)
 }
 
+func SetupReadinessRules(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.ReadinessRules = new(string)
+	cmd.Flags().StringVarP(cmdData.ReadinessRules, "readiness-rules", "", os.Getenv("WERF_READINESS_RULES"), "Path to the file with the readiness conditions of the custom resources by the group and the kind (default $WERF_READINESS_RULES)")
+}
+
 func statusProgressPeriodDefaultValue() *int64 {
 	defaultValue := int64(5)
 
@@ -869,6 +978,11 @@ func SetupParallelTasksLimit(cmdData *CmdData, cmd *cobra.Command, defaultValue
 	cmd.Flags().Int64VarP(cmdData.ParallelTasksLimit, "parallel-tasks-limit", "", defaultValue, "Parallel tasks limit, set -1 to remove the limitation (default $WERF_PARALLEL_TASKS_LIMIT or 5)")
 }
 
+func SetupKeepGoing(cmdData *CmdData, cmd *cobra.Command) {
+	cmdData.KeepGoing = new(bool)
+	cmd.Flags().BoolVarP(cmdData.KeepGoing, "keep-going", "", util.GetBoolEnvironmentDefaultFalse("WERF_KEEP_GOING"), "Continue building images which do not depend on the failed ones instead of stopping on the first error (default $WERF_KEEP_GOING)")
+}
+
 func SetupLogProjectDir(cmdData *CmdData, cmd *cobra.Command) {
 	cmdData.LogProjectDir = new(bool)
 	cmd.Flags().BoolVarP(cmdData.LogProjectDir, "log-project-dir", "", util.GetBoolEnvironmentDefaultFalse("WERF_LOG_PROJECT_DIR"), `Print current project directory path (default $WERF_LOG_PROJECT_DIR)`)
@@ -1436,7 +1550,33 @@ func ProcessLogTerminalWidth(cmdData *CmdData) error {
 }
 
 func DockerRegistryInit(ctx context.Context, cmdData *CmdData) error {
-	return docker_registry.Init(ctx, *cmdData.InsecureRegistry, *cmdData.SkipTlsVerifyRegistry)
+	if err := docker_registry.Init(ctx, *cmdData.InsecureRegistry, *cmdData.SkipTlsVerifyRegistry); err != nil {
+		return err
+	}
+
+	registryMirrorsConfigPath := os.Getenv("WERF_REGISTRY_MIRRORS_CONFIG")
+	if registryMirrorsConfigPath == "" {
+		registryMirrorsConfigPath = filepath.Join(werf.GetHomeDir(), "registry_mirrors.yaml")
+	}
+
+	registryMirrors, err := docker_registry.LoadRegistryMirrorsConfig(registryMirrorsConfigPath)
+	if err != nil {
+		return err
+	}
+	docker_registry.AddRegistryMirrors(registryMirrors...)
+
+	registryAuthConfigPath := os.Getenv("WERF_REGISTRY_AUTH_CONFIG")
+	if registryAuthConfigPath == "" {
+		registryAuthConfigPath = filepath.Join(werf.GetHomeDir(), "registry_auth.yaml")
+	}
+
+	registryAuth, err := docker_registry.LoadRegistryAuthConfig(registryAuthConfigPath)
+	if err != nil {
+		return err
+	}
+	docker_registry.AddRegistryAuth(registryAuth...)
+
+	return nil
 }
 
 func ValidateMinimumNArgs(minArgs int, args []string, cmd *cobra.Command) error {
@@ -65,6 +65,7 @@ func GetConveyorOptionsWithParallel(ctx context.Context, commonCmdData *CmdData,
 		return conveyorOptions, fmt.Errorf("getting parallel tasks limit failed: %w", err)
 	}
 	conveyorOptions.ParallelTasksLimit = parallelTasksLimit
+	conveyorOptions.KeepGoing = commonCmdData.KeepGoing != nil && *commonCmdData.KeepGoing
 
 	return conveyorOptions, nil
 }
@@ -122,6 +123,41 @@ func GetBuildOptions(ctx context.Context, commonCmdData *CmdData, werfConfig *co
 		buildOptions.ReportPath = GetDeprecatedReportPath(ctx, commonCmdData)
 	}
 
+	if commonCmdData.SBOM != nil && *commonCmdData.SBOM {
+		if *commonCmdData.Repo.Address == "" || *commonCmdData.Repo.Address == storage.LocalStorageAddress {
+			return buildOptions, fmt.Errorf("SBOM can only be generated with remote storage: --repo=ADDRESS param required")
+		}
+
+		buildOptions.SBOM = true
+		if buildOptions.SBOMFormat, err = GetSBOMFormat(commonCmdData); err != nil {
+			return buildOptions, err
+		}
+	}
+
+	if commonCmdData.SignKey != nil && *commonCmdData.SignKey != "" {
+		if *commonCmdData.Repo.Address == "" || *commonCmdData.Repo.Address == storage.LocalStorageAddress {
+			return buildOptions, fmt.Errorf("images can only be signed with remote storage: --repo=ADDRESS param required")
+		}
+
+		if buildOptions.Signer, err = GetSigner(commonCmdData); err != nil {
+			return buildOptions, err
+		}
+	}
+
+	if commonCmdData.Provenance != nil && *commonCmdData.Provenance {
+		if *commonCmdData.Repo.Address == "" || *commonCmdData.Repo.Address == storage.LocalStorageAddress {
+			return buildOptions, fmt.Errorf("provenance can only be generated with remote storage: --repo=ADDRESS param required")
+		}
+
+		buildOptions.Provenance = true
+	}
+
+	if commonCmdData.Output != nil && *commonCmdData.Output != "" {
+		if buildOptions.Output, err = GetOutputOptions(commonCmdData); err != nil {
+			return buildOptions, err
+		}
+	}
+
 	return buildOptions, nil
 }
 
@@ -16,9 +16,10 @@ const (
 
 	DocsLongMD string = "docsLongMD"
 
-	WerfDebugAnsibleArgs Env = "WERF_DEBUG_ANSIBLE_ARGS"
-	WerfSecretKey        Env = "WERF_SECRET_KEY"
-	WerfOldSecretKey     Env = "WERF_OLD_SECRET_KEY"
+	WerfDebugAnsibleArgs  Env = "WERF_DEBUG_ANSIBLE_ARGS"
+	WerfSecretKey         Env = "WERF_SECRET_KEY"
+	WerfSecretKeyProvider Env = "WERF_SECRET_KEY_PROVIDER"
+	WerfOldSecretKey      Env = "WERF_OLD_SECRET_KEY"
 )
 
 var envDescription = map[Env]string{
@@ -28,6 +29,10 @@ var envDescription = map[Env]string{
 Secret key also can be defined in files:
 * ~/.werf/global_secret_key (globally),
 * .werf_secret_key (per project)`,
+	WerfSecretKeyProvider: `Use specified provider to load secret key instead of $WERF_SECRET_KEY and secret key files:
+* exec:HELPER (credential helper: executable path, werf-secret-key-HELPER program from $PATH or shell command prefixed with !),
+* kubernetes:NAMESPACE/SECRET[#KEY] (key of Kubernetes Secret, secret_key by default),
+* vault:URL[#FIELD] (field of Vault KV secret, secret_key by default, token is taken from $WERF_SECRET_KEY_VAULT_TOKEN or $VAULT_TOKEN)`,
 	WerfOldSecretKey: "Use specified old secret key to rotate secrets",
 }
 
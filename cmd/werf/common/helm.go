@@ -13,6 +13,7 @@ import (
 	bundles_registry "github.com/werf/werf/pkg/deploy/bundles/registry"
 	"github.com/werf/werf/pkg/deploy/helm"
 	"github.com/werf/werf/pkg/docker"
+	"github.com/werf/werf/pkg/docker_registry"
 )
 
 func NewHelmRegistryClient(ctx context.Context, dockerConfig string, insecureHelmDependencies bool) (*registry.Client, error) {
@@ -45,6 +46,7 @@ func NewBundlesRegistryClient(ctx context.Context, commonCmdData *CmdData) (*bun
 
 	return bundles_registry.NewClient(
 		bundles_registry.ClientOptCredentialsFile(docker.GetDockerConfigCredentialsFile(*commonCmdData.DockerConfig)),
+		bundles_registry.ClientOptAuthConfigFunc(docker_registry.RegistryAuthConfig),
 		bundles_registry.ClientOptDebug(*commonCmdData.LogDebug),
 		bundles_registry.ClientOptInsecure(*commonCmdData.InsecureRegistry),
 		bundles_registry.ClientOptSkipTlsVerify(*commonCmdData.SkipTlsVerifyRegistry),
@@ -52,7 +54,20 @@ func NewBundlesRegistryClient(ctx context.Context, commonCmdData *CmdData) (*bun
 	)
 }
 
+func GetReadinessRules(cmdData *CmdData) ([]*helm.ReadinessRule, error) {
+	if cmdData.ReadinessRules == nil || *cmdData.ReadinessRules == "" {
+		return nil, nil
+	}
+
+	return helm.LoadReadinessRules(*cmdData.ReadinessRules)
+}
+
 func NewActionConfig(ctx context.Context, kubeInitializer helm.KubeInitializer, namespace string, commonCmdData *CmdData, registryClient *registry.Client) (*action.Configuration, error) {
+	readinessRules, err := GetReadinessRules(commonCmdData)
+	if err != nil {
+		return nil, err
+	}
+
 	actionConfig := new(action.Configuration)
 
 	if err := helm.InitActionConfig(ctx, kubeInitializer, namespace, helm_v3.Settings, actionConfig, helm.InitActionConfigOptions{
@@ -66,6 +81,7 @@ func NewActionConfig(ctx context.Context, kubeInitializer helm.KubeInitializer,
 		},
 		ReleasesHistoryMax: *commonCmdData.ReleasesHistoryMax,
 		RegistryClient:     registryClient,
+		ReadinessRules:     readinessRules,
 	}); err != nil {
 		return nil, err
 	}
@@ -22,6 +22,11 @@ func RunAutoHostCleanup(ctx context.Context, cmdData *CmdData, containerBackend
 		cleanupDockerServer = true
 	}
 
+	cleanupBuildahStorage := false
+	if _, match := containerBackend.(*container_backend.BuildahBackend); match {
+		cleanupBuildahStorage = true
+	}
+
 	if *cmdData.AllowedDockerStorageVolumeUsageMargin >= *cmdData.AllowedDockerStorageVolumeUsage {
 		return fmt.Errorf("incompatible params --allowed-docker-storage-volume-usage=%d and --allowed-docker-storage-volume-usage-margin=%d: margin percentage should be less than allowed volume usage level percentage", *cmdData.AllowedDockerStorageVolumeUsage, *cmdData.AllowedDockerStorageVolumeUsageMargin)
 	}
@@ -30,11 +35,12 @@ func RunAutoHostCleanup(ctx context.Context, cmdData *CmdData, containerBackend
 		return fmt.Errorf("incompatible params --allowed-local-cache-volume-usage=%d and --allowed-local-cache-volume-usage-margin=%d: margin percentage should be less than allowed volume usage level percentage", *cmdData.AllowedLocalCacheVolumeUsage, *cmdData.AllowedLocalCacheVolumeUsageMargin)
 	}
 
-	return host_cleaning.RunAutoHostCleanup(ctx, host_cleaning.AutoHostCleanupOptions{
+	return host_cleaning.RunAutoHostCleanup(ctx, containerBackend, host_cleaning.AutoHostCleanupOptions{
 		HostCleanupOptions: host_cleaning.HostCleanupOptions{
-			DryRun:              false,
-			Force:               false,
-			CleanupDockerServer: cleanupDockerServer,
+			DryRun:                false,
+			Force:                 false,
+			CleanupDockerServer:   cleanupDockerServer,
+			CleanupBuildahStorage: cleanupBuildahStorage,
 			AllowedDockerStorageVolumeUsagePercentage:       cmdData.AllowedDockerStorageVolumeUsage,
 			AllowedDockerStorageVolumeUsageMarginPercentage: cmdData.AllowedDockerStorageVolumeUsageMargin,
 			AllowedLocalCacheVolumeUsagePercentage:          cmdData.AllowedLocalCacheVolumeUsage,
@@ -63,7 +69,7 @@ func SetupAllowedDockerStorageVolumeUsage(cmdData *CmdData, cmd *cobra.Command)
 	}
 
 	cmdData.AllowedDockerStorageVolumeUsage = new(uint)
-	cmd.Flags().UintVarP(cmdData.AllowedDockerStorageVolumeUsage, "allowed-docker-storage-volume-usage", "", defaultVal, fmt.Sprintf("Set allowed percentage of docker storage volume usage which will cause cleanup of least recently used local docker images (default %d%% or $%s)", uint(host_cleaning.DefaultAllowedDockerStorageVolumeUsagePercentage), envVarName))
+	cmd.Flags().UintVarP(cmdData.AllowedDockerStorageVolumeUsage, "allowed-docker-storage-volume-usage", "", defaultVal, fmt.Sprintf("Set allowed percentage of docker storage volume usage (or buildah storage volume usage when buildah backend is used) which will cause cleanup of least recently used local images (default %d%% or $%s)", uint(host_cleaning.DefaultAllowedDockerStorageVolumeUsagePercentage), envVarName))
 }
 
 func SetupAllowedDockerStorageVolumeUsageMargin(cmdData *CmdData, cmd *cobra.Command) {
@@ -80,7 +86,7 @@ func SetupAllowedDockerStorageVolumeUsageMargin(cmdData *CmdData, cmd *cobra.Com
 	}
 
 	cmdData.AllowedDockerStorageVolumeUsageMargin = new(uint)
-	cmd.Flags().UintVarP(cmdData.AllowedDockerStorageVolumeUsageMargin, "allowed-docker-storage-volume-usage-margin", "", defaultVal, fmt.Sprintf("During cleanup of least recently used local docker images werf would delete images until volume usage becomes below \"allowed-docker-storage-volume-usage - allowed-docker-storage-volume-usage-margin\" level (default %d%% or $%s)", uint(host_cleaning.DefaultAllowedDockerStorageVolumeUsageMarginPercentage), envVarName))
+	cmd.Flags().UintVarP(cmdData.AllowedDockerStorageVolumeUsageMargin, "allowed-docker-storage-volume-usage-margin", "", defaultVal, fmt.Sprintf("During cleanup of least recently used local images werf would delete images until docker (or buildah) storage volume usage becomes below \"allowed-docker-storage-volume-usage - allowed-docker-storage-volume-usage-margin\" level (default %d%% or $%s)", uint(host_cleaning.DefaultAllowedDockerStorageVolumeUsageMarginPercentage), envVarName))
 }
 
 func SetupDockerServerStoragePath(cmdData *CmdData, cmd *cobra.Command) {
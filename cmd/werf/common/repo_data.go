@@ -56,6 +56,8 @@ func (repoData *RepoData) CreateStagesStorage(ctx context.Context, containerBack
 
 	if addr == storage.LocalStorageAddress {
 		return storage.NewLocalStagesStorage(containerBackend), nil
+	} else if storage.IsOCILayoutStorageAddress(addr) {
+		return storage.NewOCILayoutStagesStorage(addr, containerBackend, repoData.GetDockerRegistryOptions(insecureRegistry, skipTlsVerifyRegistry))
 	} else {
 		dockerRegistry, err := repoData.CreateDockerRegistry(ctx, insecureRegistry, skipTlsVerifyRegistry)
 		if err != nil {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/werf/kubedog/pkg/kube"
 	"github.com/werf/lockgate/pkg/distributed_locker"
+	"github.com/werf/lockgate/pkg/file_locker"
 	"github.com/werf/logboek"
 	"github.com/werf/werf/pkg/storage"
 	"github.com/werf/werf/pkg/storage/synchronization_server"
@@ -30,9 +31,12 @@ func SetupSynchronization(cmdData *CmdData, cmd *cobra.Command) {
 Default:
  - $WERF_SYNCHRONIZATION, or
  - :local if --repo is not specified, or
+ - the file locks in the DIR if --repo=oci:DIR has been specified, or
  - %s if --repo has been specified.
 
-The same address should be specified for all werf processes that work with a single repo. :local address allows execution of werf processes from a single host only`, storage.DefaultHttpSynchronizationServer))
+The same address should be specified for all werf processes that work with a single repo. :local address allows execution of werf processes from a single host only.
+
+Token for the http synchronization server with enabled authentication can be passed in the address (https://TOKEN@HOST:PORT) or with $WERF_SYNCHRONIZATION_TOKEN`, storage.DefaultHttpSynchronizationServer))
 }
 
 type SynchronizationType string
@@ -41,6 +45,7 @@ const (
 	LocalSynchronization      SynchronizationType = "LocalSynchronization"
 	KubernetesSynchronization SynchronizationType = "KubernetesSynchronization"
 	HttpSynchronization       SynchronizationType = "HttpSynchronization"
+	OCILayoutSynchronization  SynchronizationType = "OCILayoutSynchronization"
 )
 
 type SynchronizationParams struct {
@@ -130,7 +135,12 @@ func GetSynchronization(ctx context.Context, cmdData *CmdData, projectName strin
 		return res, nil
 	}
 
-	getHttpParamsFunc := func(synchronization string, stagesStorage storage.StagesStorage) (*SynchronizationParams, error) {
+	getHttpParamsFunc := func(synchronization string, stagesStorage storage.StagesStorage, defaultToken string) (*SynchronizationParams, error) {
+		synchronization, err := synchronization_server.AddressWithCredentials(synchronization, projectName, defaultToken)
+		if err != nil {
+			return nil, err
+		}
+
 		var address string
 		if err := logboek.Info().LogProcess(fmt.Sprintf("Getting client id for the http synchronization server")).
 			DoError(func() error {
@@ -138,7 +148,7 @@ func GetSynchronization(ctx context.Context, cmdData *CmdData, projectName strin
 					return fmt.Errorf("unable to get synchronization client id: %w", err)
 				} else {
 					address = fmt.Sprintf("%s/%s", synchronization, clientID)
-					logboek.Info().LogF("Using clientID %q for http synchronization server at address %s\n", clientID, address)
+					logboek.Info().LogF("Using clientID %q for http synchronization server at address %s\n", clientID, synchronization_server.RedactAddress(address))
 					return nil
 				}
 			}); err != nil {
@@ -154,14 +164,19 @@ func GetSynchronization(ctx context.Context, cmdData *CmdData, projectName strin
 			return &SynchronizationParams{SynchronizationType: LocalSynchronization, Address: storage.LocalStorageAddress}, nil
 		}
 
-		return getHttpParamsFunc(storage.DefaultHttpSynchronizationServer, stagesStorage)
+		// the OCI layout is expected to be used offline, the builders sharing the layout are synchronized by the file locks inside it
+		if ociLayoutStagesStorage, ok := stagesStorage.(*storage.OCILayoutStagesStorage); ok {
+			return &SynchronizationParams{SynchronizationType: OCILayoutSynchronization, Address: ociLayoutStagesStorage.SynchronizationLocksDir()}, nil
+		}
+
+		return getHttpParamsFunc(storage.DefaultHttpSynchronizationServer, stagesStorage, "")
 	case *cmdData.Synchronization == storage.LocalStorageAddress:
 		return &SynchronizationParams{Address: *cmdData.Synchronization, SynchronizationType: LocalSynchronization}, nil
 	case strings.HasPrefix(*cmdData.Synchronization, "kubernetes://"):
 		checkSynchronizationKubernetesParamsForWarnings(cmdData)
 		return getKubeParamsFunc(*cmdData.Synchronization, GetOndemandKubeInitializer())
 	case strings.HasPrefix(*cmdData.Synchronization, "http://") || strings.HasPrefix(*cmdData.Synchronization, "https://"):
-		return getHttpParamsFunc(*cmdData.Synchronization, stagesStorage)
+		return getHttpParamsFunc(*cmdData.Synchronization, stagesStorage, os.Getenv("WERF_SYNCHRONIZATION_TOKEN"))
 	default:
 		return nil, fmt.Errorf("only --synchronization=%s or --synchronization=kubernetes://NAMESPACE or --synchronization=http[s]://HOST:PORT/CLIENT_ID is supported, got %q", storage.LocalStorageAddress, *cmdData.Synchronization)
 	}
@@ -192,7 +207,13 @@ func GetStorageLockManager(ctx context.Context, synchronization *Synchronization
 		locker := distributed_locker.NewHttpLocker(fmt.Sprintf("%s/locker", synchronization.Address))
 		lockerWithRetry := locker_with_retry.NewLockerWithRetry(ctx, locker, locker_with_retry.LockerWithRetryOptions{MaxAcquireAttempts: 10, MaxReleaseAttempts: 10})
 		return storage.NewGenericLockManager(lockerWithRetry), nil
+	case OCILayoutSynchronization:
+		locker, err := file_locker.NewFileLocker(synchronization.Address)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create synchronization file locker in %q: %w", synchronization.Address, err)
+		}
+		return storage.NewGenericLockManager(locker), nil
 	default:
-		panic(fmt.Sprintf("unsupported synchronization address %q", synchronization.Address))
+		panic(fmt.Sprintf("unsupported synchronization address %q", synchronization_server.RedactAddress(synchronization.Address)))
 	}
 }
@@ -97,7 +97,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 werf converge --repo registry.mydomain.com/web --env production`,
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfDebugAnsibleArgs, common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfDebugAnsibleArgs, common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: GetConvergeDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -166,6 +166,7 @@ werf converge --repo registry.mydomain.com/web --env production`,
 	common.SetupStatusProgressPeriod(&commonCmdData, cmd)
 	common.SetupHooksStatusProgressPeriod(&commonCmdData, cmd)
 	common.SetupReleasesHistoryMax(&commonCmdData, cmd)
+	common.SetupReadinessRules(&commonCmdData, cmd)
 
 	common.SetupRelease(&commonCmdData, cmd)
 	common.SetupNamespace(&commonCmdData, cmd)
@@ -197,6 +198,7 @@ werf converge --repo registry.mydomain.com/web --env production`,
 	common.SetupVirtualMerge(&commonCmdData, cmd)
 
 	common.SetupParallelOptions(&commonCmdData, cmd, common.DefaultBuildParallelTasksLimit)
+	common.SetupKeepGoing(&commonCmdData, cmd)
 	common.SetupSkipBuild(&commonCmdData, cmd)
 	common.SetupRequireBuiltImages(&commonCmdData, cmd)
 	commonCmdData.SetupPlatform(cmd)
@@ -523,10 +523,61 @@ func GetHelmSecretGenerateSecretKeyDocs() structs.DocsStruct {
 	var docs structs.DocsStruct
 
 	docs.Long = `Generate hex encryption key.
-For further usage, the encryption key should be saved in $WERF_SECRET_KEY or .werf_secret_key file.`
+For further usage, the encryption key should be saved in $WERF_SECRET_KEY or .werf_secret_key file.
+
+With --age option generate age identity instead. The identity is used as the secret key for projects, which secrets are encrypted for recipients listed in the .werf_secret_recipients file. The public key of the identity is printed in the comment and should be added to the project recipients with the werf helm secret recipients add command.`
 
 	docs.LongMD = "Generate hex encryption key.\n\n" +
-		"For further usage, the encryption key should be saved in `$WERF_SECRET_KEY` or `.werf_secret_key file`."
+		"For further usage, the encryption key should be saved in `$WERF_SECRET_KEY` or `.werf_secret_key file`.\n\n" +
+		"With `--age` option generate age identity instead. The identity is used as the secret key for projects, which secrets are encrypted for recipients listed in the `.werf_secret_recipients` file. The public key of the identity is printed in the comment and should be added to the project recipients with the `werf helm secret recipients add` command."
+
+	return docs
+}
+
+func GetHelmSecretRecipientsAddDocs() structs.DocsStruct {
+	var docs structs.DocsStruct
+
+	docs.Long = `Add age recipients (age1...) to the .werf_secret_recipients file and re-encrypt secret files, so that any of the recipients is able to decrypt them with the own age identity.
+
+Secrets are decrypted with the age identity from the $WERF_SECRET_KEY, .werf_secret_key file or secret key provider. When the project does not use recipients yet, secrets are decrypted with the hex encryption key from the $WERF_OLD_SECRET_KEY.
+
+Command will rewrite files:
+* standard raw Secret files in the .helm/secret folder;
+* standard secret Values YAML file .helm/secret-values.yaml;
+* additional secret Values YAML files specified with --secret-values option.`
+
+	docs.LongMD = "Add age recipients (`age1...`) to the `.werf_secret_recipients` file and re-encrypt secret files, so that any of the recipients is able to decrypt them with the own age identity.\n\n" +
+		"Secrets are decrypted with the age identity from the `$WERF_SECRET_KEY`, `.werf_secret_key` file or secret key provider. When the project does not use recipients yet, secrets are decrypted with the hex encryption key from the `$WERF_OLD_SECRET_KEY`.\n\n" +
+		"Command will rewrite files:\n" +
+		"* standard raw Secret files in the `.helm/secret` folder;\n" +
+		"* standard Secret Values YAML file `.helm/secret-values.yaml`;\n" +
+		"* additional Secret Values YAML files specified with `--secret-values` option."
+
+	return docs
+}
+
+func GetHelmSecretRecipientsRemoveDocs() structs.DocsStruct {
+	var docs structs.DocsStruct
+
+	docs.Long = `Remove age recipients from the .werf_secret_recipients file and re-encrypt secret files for the rest of recipients.
+
+Secrets are decrypted with the age identity from the $WERF_SECRET_KEY, .werf_secret_key file or secret key provider.
+
+Note that removed recipients are still able to decrypt secrets from the previous commits, so the secret values themselves should be changed as well.`
+
+	docs.LongMD = "Remove age recipients from the `.werf_secret_recipients` file and re-encrypt secret files for the rest of recipients.\n\n" +
+		"Secrets are decrypted with the age identity from the `$WERF_SECRET_KEY`, `.werf_secret_key` file or secret key provider.\n\n" +
+		"Note that removed recipients are still able to decrypt secrets from the previous commits, so the secret values themselves should be changed as well."
+
+	return docs
+}
+
+func GetHelmSecretRecipientsListDocs() structs.DocsStruct {
+	var docs structs.DocsStruct
+
+	docs.Long = `List age recipients from the .werf_secret_recipients file.`
+
+	docs.LongMD = "List age recipients from the `.werf_secret_recipients` file."
 
 	return docs
 }
@@ -603,6 +654,23 @@ Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file.`
 	return docs
 }
 
+func GetHelmSecretValuesDiffDocs() structs.DocsStruct {
+	var docs structs.DocsStruct
+
+	docs.Long = `Show changes of decrypted secret values between two secret values files.
+Each file is specified either as FILE_PATH or as REVISION:FILE_PATH to read the file from the git revision. With a single FILE_PATH changes between the HEAD commit and the working tree are shown.
+Encryption key should be in $WERF_SECRET_KEY or .werf_secret_key file.
+
+With --textconv option the decrypted FILE_PATH is printed, so that werf can be registered as the textconv program of the git diff driver for secret values files.`
+
+	docs.LongMD = "Show changes of decrypted secret values between two secret values files.\n\n" +
+		"Each file is specified either as `FILE_PATH` or as `REVISION:FILE_PATH` to read the file from the git revision. With a single `FILE_PATH` changes between the `HEAD` commit and the working tree are shown.\n\n" +
+		"Encryption key should be in `$WERF_SECRET_KEY` or `.werf_secret_key file`.\n\n" +
+		"With `--textconv` option the decrypted `FILE_PATH` is printed, so that werf can be registered as the textconv program of the git diff driver for secret values files."
+
+	return docs
+}
+
 func GetHelmSecretValuesEditDocs() structs.DocsStruct {
 	var docs structs.DocsStruct
 
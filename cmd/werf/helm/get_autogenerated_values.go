@@ -34,7 +34,7 @@ func NewGetAutogeneratedValuesCmd(ctx context.Context) *cobra.Command {
 		Long:                  common.GetLongCommandDescription(GetGetAutogeneratedValuesDocs().Long),
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: GetGetAutogeneratedValuesDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
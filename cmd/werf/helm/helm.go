@@ -22,8 +22,12 @@ import (
 	helm_secret_file_edit "github.com/werf/werf/cmd/werf/helm/secret/file/edit"
 	helm_secret_file_encrypt "github.com/werf/werf/cmd/werf/helm/secret/file/encrypt"
 	helm_secret_generate_secret_key "github.com/werf/werf/cmd/werf/helm/secret/generate_secret_key"
+	helm_secret_recipients_add "github.com/werf/werf/cmd/werf/helm/secret/recipients/add"
+	helm_secret_recipients_list "github.com/werf/werf/cmd/werf/helm/secret/recipients/list"
+	helm_secret_recipients_remove "github.com/werf/werf/cmd/werf/helm/secret/recipients/remove"
 	helm_secret_rotate_secret_key "github.com/werf/werf/cmd/werf/helm/secret/rotate_secret_key"
 	helm_secret_values_decrypt "github.com/werf/werf/cmd/werf/helm/secret/values/decrypt"
+	helm_secret_values_diff "github.com/werf/werf/cmd/werf/helm/secret/values/diff"
 	helm_secret_values_edit "github.com/werf/werf/cmd/werf/helm/secret/values/edit"
 	helm_secret_values_encrypt "github.com/werf/werf/cmd/werf/helm/secret/values/encrypt"
 	"github.com/werf/werf/pkg/deploy/helm"
@@ -258,11 +262,24 @@ func secretCmd(ctx context.Context) *cobra.Command {
 		helm_secret_values_encrypt.NewCmd(ctx),
 		helm_secret_values_decrypt.NewCmd(ctx),
 		helm_secret_values_edit.NewCmd(ctx),
+		helm_secret_values_diff.NewCmd(ctx),
+	)
+
+	recipientsCmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:   "recipients",
+		Short: "Work with age recipients of secrets",
+	})
+
+	recipientsCmd.AddCommand(
+		helm_secret_recipients_add.NewCmd(ctx),
+		helm_secret_recipients_remove.NewCmd(ctx),
+		helm_secret_recipients_list.NewCmd(ctx),
 	)
 
 	cmd.AddCommand(
 		fileCmd,
 		valuesCmd,
+		recipientsCmd,
 		helm_secret_generate_secret_key.NewCmd(ctx),
 		helm_secret_encrypt.NewCmd(ctx),
 		helm_secret_decrypt.NewCmd(ctx),
@@ -0,0 +1,116 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/werf/werf/pkg/deploy/secrets_manager"
+	"github.com/werf/werf/pkg/secret"
+	"github.com/werf/werf/pkg/true_git"
+	"github.com/werf/werf/pkg/util"
+)
+
+// SecretValuesDiff prints changes of decrypted values between the old and the new secret values files.
+// Each source is either a file path or REVISION:FILE_PATH, the file which does not exist at the revision is considered empty.
+func SecretValuesDiff(ctx context.Context, m *secrets_manager.SecretsManager, workingDir, oldSource, newSource string) error {
+	encoder, err := m.GetYamlEncoder(ctx, workingDir)
+	if err != nil {
+		return err
+	}
+
+	var sourcesData [][]byte
+	for _, source := range []string{oldSource, newSource} {
+		data, err := readSecretValuesSource(ctx, encoder, source)
+		if err != nil {
+			return fmt.Errorf("unable to read %q: %w", source, err)
+		}
+		sourcesData = append(sourcesData, data)
+	}
+
+	entries, err := secret.DiffYamlData(sourcesData[0], sourcesData[1])
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch entry.Type {
+		case secret.YamlDiffAdded:
+			printSecretValuesDiffValue(entry.Type, entry.Path, entry.NewValue)
+		case secret.YamlDiffRemoved:
+			printSecretValuesDiffValue(entry.Type, entry.Path, entry.OldValue)
+		case secret.YamlDiffChanged:
+			if strings.Contains(entry.OldValue, "\n") || strings.Contains(entry.NewValue, "\n") {
+				fmt.Printf("%s %s:\n", entry.Type, entry.Path)
+				printSecretValuesDiffLines(secret.YamlDiffRemoved, entry.OldValue)
+				printSecretValuesDiffLines(secret.YamlDiffAdded, entry.NewValue)
+			} else {
+				fmt.Printf("%s %s: %s -> %s\n", entry.Type, entry.Path, entry.OldValue, entry.NewValue)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SecretValuesTextconv prints decrypted secret values file, so that werf can be used as the textconv program of the git diff driver.
+func SecretValuesTextconv(ctx context.Context, m *secrets_manager.SecretsManager, workingDir, filePath string) error {
+	encoder, err := m.GetYamlEncoder(ctx, workingDir)
+	if err != nil {
+		return err
+	}
+
+	data, err := readSecretValuesSource(ctx, encoder, filePath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", string(data))
+
+	return nil
+}
+
+func readSecretValuesSource(ctx context.Context, encoder *secret.YamlEncoder, source string) ([]byte, error) {
+	var encodedData []byte
+
+	exist, err := util.FileExists(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision, filePath, isRevisionSource := strings.Cut(source, ":"); !exist && isRevisionSource && revision != "" {
+		encodedData, err = true_git.ReadRevisionFile(ctx, "", revision, filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	} else if encodedData, err = ReadFileData(source); err != nil {
+		return nil, err
+	}
+
+	encodedData = bytes.TrimSpace(encodedData)
+	if len(encodedData) == 0 {
+		return nil, nil
+	}
+
+	return encoder.DecryptYamlData(encodedData)
+}
+
+func printSecretValuesDiffValue(diffType secret.YamlDiffType, path, value string) {
+	if strings.Contains(value, "\n") {
+		fmt.Printf("%s %s:\n", diffType, path)
+		printSecretValuesDiffLines(diffType, value)
+	} else {
+		fmt.Printf("%s %s: %s\n", diffType, path, value)
+	}
+}
+
+func printSecretValuesDiffLines(diffType secret.YamlDiffType, value string) {
+	for _, line := range strings.Split(strings.TrimSuffix(value, "\n"), "\n") {
+		fmt.Printf("  %s %s\n", diffType, line)
+	}
+}
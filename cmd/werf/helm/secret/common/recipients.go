@@ -0,0 +1,105 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/pkg/deploy/secrets_manager"
+	"github.com/werf/werf/pkg/git_repo"
+	"github.com/werf/werf/pkg/git_repo/gitdata"
+	"github.com/werf/werf/pkg/secret"
+	"github.com/werf/werf/pkg/true_git"
+	"github.com/werf/werf/pkg/werf"
+)
+
+// UpdateSecretRecipients changes the project secret recipients and re-encrypts chart secret files
+// and extra secret values files for the resulting recipients.
+func UpdateSecretRecipients(ctx context.Context, commonCmdData *common.CmdData, add, remove, secretValuesPaths []string) error {
+	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+
+	gitDataManager, err := gitdata.GetHostGitDataManager(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting host git data manager: %w", err)
+	}
+
+	if err := git_repo.Init(gitDataManager); err != nil {
+		return err
+	}
+
+	if err := true_git.Init(ctx, true_git.Options{LiveGitOutput: *commonCmdData.LogDebug}); err != nil {
+		return err
+	}
+
+	giterminismManager, err := common.GetGiterminismManager(ctx, commonCmdData)
+	if err != nil {
+		return err
+	}
+
+	werfConfigPath, werfConfig, err := common.GetRequiredWerfConfig(ctx, commonCmdData, giterminismManager, common.GetWerfConfigOptions(commonCmdData, true))
+	if err != nil {
+		return fmt.Errorf("unable to load werf config: %w", err)
+	}
+
+	helmChartDir, err := common.GetHelmChartDir(werfConfigPath, werfConfig, giterminismManager)
+	if err != nil {
+		return fmt.Errorf("getting helm chart dir failed: %w", err)
+	}
+
+	recipientsFile, err := secrets_manager.LoadSecretRecipientsFile(giterminismManager.ProjectDir())
+	if err != nil {
+		return err
+	}
+
+	// Existing secrets are decrypted either with the age identity of one of the current recipients,
+	// or with the old AES key when the project switches to recipients.
+	secretsManager := secrets_manager.NewSecretsManager(secrets_manager.SecretsManagerOptions{})
+
+	var oldEncoder *secret.YamlEncoder
+	switch {
+	case recipientsFile.Exists():
+		if oldEncoder, err = secretsManager.GetYamlEncoderForRecipients(giterminismManager.ProjectDir(), recipientsFile.Recipients()); err != nil {
+			return err
+		}
+	case os.Getenv("WERF_OLD_SECRET_KEY") != "":
+		if oldEncoder, err = secretsManager.GetYamlEncoderForOldKey(ctx); err != nil {
+			return err
+		}
+	default:
+		oldEncoder = secret.NewYamlEncoder(oldSecretKeyRequiredEncoder{})
+	}
+
+	recipientsFile.Add(add...)
+	if err := recipientsFile.Remove(remove...); err != nil {
+		return err
+	}
+
+	newEnc, err := secret.NewAgeEncoder(recipientsFile.Recipients(), nil)
+	if err != nil {
+		return err
+	}
+
+	if err := SecretsRegenerate(secret.NewYamlEncoder(newEnc), oldEncoder, helmChartDir, secretValuesPaths...); err != nil {
+		return err
+	}
+
+	return recipientsFile.Save()
+}
+
+// oldSecretKeyRequiredEncoder fails only if there are secrets to re-encrypt, which were encrypted before the project switched to recipients.
+type oldSecretKeyRequiredEncoder struct{}
+
+func (oldSecretKeyRequiredEncoder) Encrypt(_ []byte) ([]byte, error) {
+	return nil, fmt.Errorf("encryption is not supported")
+}
+
+func (oldSecretKeyRequiredEncoder) Decrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("WERF_OLD_SECRET_KEY environment required to re-encrypt existing secrets for recipients")
+}
@@ -41,7 +41,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
   $ cat .helm/secret/date | werf helm secret decrypt
   Tue Jun 26 09:58:10 PDT 1990`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: helm.GetHelmSecretDecryptDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
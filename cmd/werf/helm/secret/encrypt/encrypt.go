@@ -40,7 +40,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
   # Encrypt from a pipe and save result in file
   $ date | werf helm secret encrypt -o .helm/secret/date`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: helm.GetHelmSecretEncryptDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
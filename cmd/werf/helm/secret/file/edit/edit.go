@@ -27,7 +27,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 		Example: `  # Create/edit existing secret file
   $ werf helm secret file edit .helm/secret/privacy`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: helm.GetHelmSecretFileEditDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
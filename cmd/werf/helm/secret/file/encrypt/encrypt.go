@@ -32,7 +32,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 		Example: `  # Encrypt and save result in file
   $ werf helm secret file encrypt tls.crt -o .helm/secret/tls.crt`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: helm.GetHelmSecretFileEncryptDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -11,6 +11,10 @@ import (
 	"github.com/werf/werf/pkg/deploy/secrets_manager"
 )
 
+var cmdData struct {
+	Age bool
+}
+
 var commonCmdData common.CmdData
 
 func NewCmd(ctx context.Context) *cobra.Command {
@@ -27,7 +31,11 @@ func NewCmd(ctx context.Context) *cobra.Command {
   $ export WERF_SECRET_KEY=$(werf helm secret generate-secret-key)
 
   # Save encryption key in .werf_secret_key file
-  $ werf helm secret generate-secret-key > .werf_secret_key`,
+  $ werf helm secret generate-secret-key > .werf_secret_key
+
+  # Generate age identity and add its public key to the project recipients
+  $ werf helm secret generate-secret-key --age > ~/.werf/global_secret_key
+  $ werf helm secret recipients add $(grep -o 'age1.*' ~/.werf/global_secret_key)`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
 				common.PrintHelp(cmd)
@@ -40,10 +48,24 @@ func NewCmd(ctx context.Context) *cobra.Command {
 
 	common.SetupLogOptions(&commonCmdData, cmd)
 
+	cmd.Flags().BoolVarP(&cmdData.Age, "age", "", false, "Generate age identity for secrets encrypted for recipients instead of hex encryption key")
+
 	return cmd
 }
 
 func runGenerateSecretKey() error {
+	if cmdData.Age {
+		identity, recipient, err := secrets_manager.GenerateAgeIdentity()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("# public key: %s\n", recipient)
+		fmt.Println(identity)
+
+		return nil
+	}
+
 	key, err := secrets_manager.GenerateSecretKey()
 	if err != nil {
 		return err
@@ -0,0 +1,70 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/cmd/werf/docs/replacers/helm"
+	secret_common "github.com/werf/werf/cmd/werf/helm/secret/common"
+)
+
+var cmdData struct {
+	SecretValuesPaths []string
+}
+
+var commonCmdData common.CmdData
+
+func NewCmd(ctx context.Context) *cobra.Command {
+	ctx = common.NewContextWithCmdData(ctx, &commonCmdData)
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:                   "add RECIPIENT...",
+		DisableFlagsInUseLine: true,
+		Short:                 "Add age recipients and re-encrypt secret files for them",
+		Long:                  common.GetLongCommandDescription(helm.GetHelmSecretRecipientsAddDocs().Long),
+		Annotations: map[string]string{
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider, common.WerfOldSecretKey),
+			common.DocsLongMD: helm.GetHelmSecretRecipientsAddDocs().LongMD,
+		},
+		Example: `  # Add recipients and re-encrypt secrets, which are currently encrypted with the AES key
+  $ WERF_OLD_SECRET_KEY=$(cat .werf_secret_key) werf helm secret recipients add age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+
+  # Add recipient using own age identity to decrypt secrets
+  $ WERF_SECRET_KEY=$(cat ~/.config/age/identity.txt) werf helm secret recipients add age1lggyhqrw2nlhcxprm67z43rta597azn8gknawjehu9d9dl0jq3yqqvfafg`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			if len(args) == 0 {
+				common.PrintHelp(cmd)
+				return fmt.Errorf("at least one recipient required")
+			}
+
+			return secret_common.UpdateSecretRecipients(ctx, &commonCmdData, args, nil, cmdData.SecretValuesPaths)
+		},
+	})
+
+	common.SetupTmpDir(&commonCmdData, cmd, common.SetupTmpDirOptions{})
+	common.SetupHomeDir(&commonCmdData, cmd, common.SetupHomeDirOptions{})
+
+	common.SetupDir(&commonCmdData, cmd)
+	common.SetupGitWorkTree(&commonCmdData, cmd)
+	common.SetupConfigTemplatesDir(&commonCmdData, cmd)
+	common.SetupConfigPath(&commonCmdData, cmd)
+	common.SetupGiterminismConfigPath(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
+
+	common.SetupGiterminismOptions(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	cmd.Flags().StringArrayVarP(&cmdData.SecretValuesPaths, "secret-values", "", []string{}, "Additional secret values files to re-encrypt besides the chart secret-values.yaml (can specify multiple)")
+
+	return cmd
+}
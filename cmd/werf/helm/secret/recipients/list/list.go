@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/cmd/werf/docs/replacers/helm"
+	"github.com/werf/werf/pkg/deploy/secrets_manager"
+)
+
+var commonCmdData common.CmdData
+
+func NewCmd(ctx context.Context) *cobra.Command {
+	ctx = common.NewContextWithCmdData(ctx, &commonCmdData)
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:                   "list",
+		DisableFlagsInUseLine: true,
+		Short:                 "List age recipients of project secrets",
+		Long:                  common.GetLongCommandDescription(helm.GetHelmSecretRecipientsListDocs().Long),
+		Annotations: map[string]string{
+			common.DocsLongMD: helm.GetHelmSecretRecipientsListDocs().LongMD,
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			return runList()
+		},
+	})
+
+	common.SetupDir(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	return cmd
+}
+
+func runList() error {
+	recipients, err := secrets_manager.ReadSecretRecipients(common.GetWorkingDir(&commonCmdData))
+	if err != nil {
+		return err
+	}
+
+	if recipients == nil {
+		return fmt.Errorf("project secrets are not encrypted for recipients: %s file not found", secrets_manager.SecretRecipientsFileName)
+	}
+
+	for _, recipient := range recipients {
+		fmt.Println(recipient)
+	}
+
+	return nil
+}
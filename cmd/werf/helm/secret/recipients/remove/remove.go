@@ -0,0 +1,67 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/cmd/werf/docs/replacers/helm"
+	secret_common "github.com/werf/werf/cmd/werf/helm/secret/common"
+)
+
+var cmdData struct {
+	SecretValuesPaths []string
+}
+
+var commonCmdData common.CmdData
+
+func NewCmd(ctx context.Context) *cobra.Command {
+	ctx = common.NewContextWithCmdData(ctx, &commonCmdData)
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:                   "remove RECIPIENT...",
+		DisableFlagsInUseLine: true,
+		Short:                 "Remove age recipients and re-encrypt secret files for the rest",
+		Long:                  common.GetLongCommandDescription(helm.GetHelmSecretRecipientsRemoveDocs().Long),
+		Annotations: map[string]string{
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
+			common.DocsLongMD: helm.GetHelmSecretRecipientsRemoveDocs().LongMD,
+		},
+		Example: `  # Remove recipient and re-encrypt secrets for the rest of recipients
+  $ werf helm secret recipients remove age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			if len(args) == 0 {
+				common.PrintHelp(cmd)
+				return fmt.Errorf("at least one recipient required")
+			}
+
+			return secret_common.UpdateSecretRecipients(ctx, &commonCmdData, nil, args, cmdData.SecretValuesPaths)
+		},
+	})
+
+	common.SetupTmpDir(&commonCmdData, cmd, common.SetupTmpDirOptions{})
+	common.SetupHomeDir(&commonCmdData, cmd, common.SetupHomeDirOptions{})
+
+	common.SetupDir(&commonCmdData, cmd)
+	common.SetupGitWorkTree(&commonCmdData, cmd)
+	common.SetupConfigTemplatesDir(&commonCmdData, cmd)
+	common.SetupConfigPath(&commonCmdData, cmd)
+	common.SetupGiterminismConfigPath(&commonCmdData, cmd)
+	common.SetupEnvironment(&commonCmdData, cmd)
+
+	common.SetupGiterminismOptions(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	cmd.Flags().StringArrayVarP(&cmdData.SecretValuesPaths, "secret-values", "", []string{}, "Additional secret values files to re-encrypt besides the chart secret-values.yaml (can specify multiple)")
+
+	return cmd
+}
@@ -1,24 +1,18 @@
 package secret
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 
-	"github.com/werf/logboek"
 	"github.com/werf/werf/cmd/werf/common"
 	"github.com/werf/werf/cmd/werf/docs/replacers/helm"
+	secret_common "github.com/werf/werf/cmd/werf/helm/secret/common"
 	"github.com/werf/werf/pkg/deploy/secrets_manager"
 	"github.com/werf/werf/pkg/git_repo"
 	"github.com/werf/werf/pkg/git_repo/gitdata"
-	"github.com/werf/werf/pkg/secret"
 	"github.com/werf/werf/pkg/true_git"
-	"github.com/werf/werf/pkg/util"
 	"github.com/werf/werf/pkg/werf"
 )
 
@@ -32,7 +26,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 		Short:                 "Regenerate secret files with new secret key",
 		Long:                  common.GetLongCommandDescription(helm.GetHelmSecretRotateSecretKeyDocs().Long),
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfOldSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider, common.WerfOldSecretKey),
 			common.DocsLongMD: helm.GetHelmSecretRotateSecretKeyDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -111,140 +105,5 @@ func runRotateSecretKey(ctx context.Context, cmd *cobra.Command, secretValuesPat
 		return err
 	}
 
-	return secretsRegenerate(newEncoder, oldEncoder, helmChartDir, secretValuesPaths...)
-}
-
-func secretsRegenerate(newEncoder, oldEncoder *secret.YamlEncoder, helmChartDir string, secretValuesPaths ...string) error {
-	var secretFilesPaths []string
-	var secretFilesData map[string][]byte
-	var secretValuesFilesData map[string][]byte
-	regeneratedFilesData := map[string][]byte{}
-
-	isHelmChartDirExist, err := util.FileExists(helmChartDir)
-	if err != nil {
-		return err
-	}
-
-	if isHelmChartDirExist {
-		defaultSecretValuesPath := filepath.Join(helmChartDir, "secret-values.yaml")
-		isDefaultSecretValuesExist, err := util.FileExists(defaultSecretValuesPath)
-		if err != nil {
-			return err
-		}
-
-		if isDefaultSecretValuesExist {
-			secretValuesPaths = append(secretValuesPaths, defaultSecretValuesPath)
-		}
-
-		secretDirectory := filepath.Join(helmChartDir, "secret")
-		isSecretDirectoryExist, err := util.FileExists(secretDirectory)
-		if err != nil {
-			return err
-		}
-
-		if isSecretDirectoryExist {
-			err = filepath.Walk(secretDirectory,
-				func(path string, info os.FileInfo, err error) error {
-					if err != nil {
-						return err
-					}
-
-					fileInfo, err := os.Stat(path)
-					if err != nil {
-						return err
-					}
-
-					if !fileInfo.IsDir() {
-						secretFilesPaths = append(secretFilesPaths, path)
-					}
-
-					return nil
-				})
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	pwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	secretFilesData, err = readFilesToDecode(secretFilesPaths, pwd)
-	if err != nil {
-		return err
-	}
-
-	secretValuesFilesData, err = readFilesToDecode(secretValuesPaths, pwd)
-	if err != nil {
-		return err
-	}
-
-	if err := regenerateSecrets(secretFilesData, regeneratedFilesData, oldEncoder.Decrypt, newEncoder.Encrypt); err != nil {
-		return err
-	}
-
-	if err := regenerateSecrets(secretValuesFilesData, regeneratedFilesData, oldEncoder.DecryptYamlData, newEncoder.EncryptYamlData); err != nil {
-		return err
-	}
-
-	for filePath, fileData := range regeneratedFilesData {
-		err := logboek.LogProcess(fmt.Sprintf("Saving file %q", filePath)).DoError(func() error {
-			fileData = append(bytes.TrimSpace(fileData), []byte("\n")...)
-			return ioutil.WriteFile(filePath, fileData, 0o644)
-		})
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func regenerateSecrets(filesData, regeneratedFilesData map[string][]byte, decodeFunc, encodeFunc func([]byte) ([]byte, error)) error {
-	for filePath, fileData := range filesData {
-		err := logboek.LogProcess(fmt.Sprintf("Regenerating file %q", filePath)).
-			DoError(func() error {
-				data, err := decodeFunc(fileData)
-				if err != nil {
-					return fmt.Errorf("check old encryption key and file data: %w", err)
-				}
-
-				resultData, err := encodeFunc(data)
-				if err != nil {
-					return err
-				}
-
-				regeneratedFilesData[filePath] = resultData
-
-				return nil
-			})
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func readFilesToDecode(filePaths []string, pwd string) (map[string][]byte, error) {
-	filesData := map[string][]byte{}
-	for _, filePath := range filePaths {
-		fileData, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			return nil, err
-		}
-
-		if filepath.IsAbs(filePath) {
-			filePath, err = filepath.Rel(pwd, filePath)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		filesData[filePath] = bytes.TrimSpace(fileData)
-	}
-
-	return filesData, nil
+	return secret_common.SecretsRegenerate(newEncoder, oldEncoder, helmChartDir, secretValuesPaths...)
 }
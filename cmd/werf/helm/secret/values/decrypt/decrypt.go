@@ -41,7 +41,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
     user: root
     password: root`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: helm.GetHelmSecretValuesDecryptDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
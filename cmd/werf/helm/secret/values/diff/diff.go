@@ -0,0 +1,115 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/cmd/werf/docs/replacers/helm"
+	secret_common "github.com/werf/werf/cmd/werf/helm/secret/common"
+	"github.com/werf/werf/pkg/deploy/secrets_manager"
+	"github.com/werf/werf/pkg/git_repo"
+	"github.com/werf/werf/pkg/git_repo/gitdata"
+	"github.com/werf/werf/pkg/true_git"
+	"github.com/werf/werf/pkg/werf"
+)
+
+var cmdData struct {
+	Textconv bool
+}
+
+var commonCmdData common.CmdData
+
+func NewCmd(ctx context.Context) *cobra.Command {
+	ctx = common.NewContextWithCmdData(ctx, &commonCmdData)
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:                   "diff [REVISION:]FILE_PATH [[REVISION:]FILE_PATH]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Show changes of decrypted secret values",
+		Long:                  common.GetLongCommandDescription(helm.GetHelmSecretValuesDiffDocs().Long),
+		Example: `  # Show uncommitted changes of secret values file
+  $ werf helm secret values diff .helm/secret-values.yaml
+  ~ mysql.password: root -> s3cr3t
+  + mysql.user: admin
+
+  # Show changes between the main branch and the working tree
+  $ werf helm secret values diff main:.helm/secret-values.yaml .helm/secret-values.yaml
+
+  # Show changes between two files
+  $ werf helm secret values diff .helm/secret-values-staging.yaml .helm/secret-values-production.yaml
+
+  # Register git diff driver for secret values files
+  $ git config diff.werf-secret-values.textconv "werf helm secret values diff --textconv"
+  $ echo '.helm/secret-values*.yaml diff=werf-secret-values' >> .gitattributes`,
+		Annotations: map[string]string{
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
+			common.DocsLongMD: helm.GetHelmSecretValuesDiffDocs().LongMD,
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if err := common.ProcessLogOptions(&commonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			switch {
+			case cmdData.Textconv && len(args) != 1:
+				common.PrintHelp(cmd)
+				return fmt.Errorf("exactly one FILE_PATH expected with --textconv option")
+			case len(args) == 0 || len(args) > 2:
+				common.PrintHelp(cmd)
+				return fmt.Errorf("one or two FILE_PATH expected")
+			}
+
+			return runSecretValuesDiff(ctx, args)
+		},
+	})
+
+	common.SetupDir(&commonCmdData, cmd)
+	common.SetupTmpDir(&commonCmdData, cmd, common.SetupTmpDirOptions{})
+	common.SetupHomeDir(&commonCmdData, cmd, common.SetupHomeDirOptions{})
+
+	common.SetupGiterminismOptions(&commonCmdData, cmd)
+
+	common.SetupLogOptions(&commonCmdData, cmd)
+
+	cmd.Flags().BoolVarP(&cmdData.Textconv, "textconv", "", false, "Print decrypted FILE_PATH to be used as textconv program of the git diff driver")
+
+	return cmd
+}
+
+func runSecretValuesDiff(ctx context.Context, args []string) error {
+	if err := werf.Init(*commonCmdData.TmpDir, *commonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+
+	gitDataManager, err := gitdata.GetHostGitDataManager(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting host git data manager: %w", err)
+	}
+
+	if err := git_repo.Init(gitDataManager); err != nil {
+		return err
+	}
+
+	if err := true_git.Init(ctx, true_git.Options{}); err != nil {
+		return err
+	}
+
+	workingDir := common.GetWorkingDir(&commonCmdData)
+	secretsManager := secrets_manager.NewSecretsManager(secrets_manager.SecretsManagerOptions{})
+
+	if cmdData.Textconv {
+		return secret_common.SecretValuesTextconv(ctx, secretsManager, workingDir, args[0])
+	}
+
+	oldSource, newSource := "HEAD:"+args[0], args[0]
+	if len(args) == 2 {
+		oldSource, newSource = args[0], args[1]
+	}
+
+	return secret_common.SecretValuesDiff(ctx, secretsManager, workingDir, oldSource, newSource)
+}
@@ -27,7 +27,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 		Example: `  # Create/edit existing secret values file
   $ werf helm secret values edit .helm/secret-values.yaml`,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: helm.GetHelmSecretValuesEditDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
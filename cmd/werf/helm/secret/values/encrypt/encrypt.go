@@ -30,7 +30,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 		Short:                 "Encrypt values file data",
 		Long:                  common.GetLongCommandDescription(helm.GetHelmSecretValuesEncryptDocs().Long),
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: helm.GetHelmSecretValuesEncryptDocs().LongMD,
 		},
 		Example: `  # Encrypt and save result in file
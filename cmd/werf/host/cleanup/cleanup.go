@@ -121,10 +121,16 @@ func runCleanup(ctx context.Context) error {
 		cleanupDockerServer = true
 	}
 
+	cleanupBuildahStorage := false
+	if _, match := containerBackend.(*container_backend.BuildahBackend); match {
+		cleanupBuildahStorage = true
+	}
+
 	hostCleanupOptions := host_cleaning.HostCleanupOptions{
-		DryRun:              *commonCmdData.DryRun,
-		Force:               cmdData.Force,
-		CleanupDockerServer: cleanupDockerServer,
+		DryRun:                *commonCmdData.DryRun,
+		Force:                 cmdData.Force,
+		CleanupDockerServer:   cleanupDockerServer,
+		CleanupBuildahStorage: cleanupBuildahStorage,
 		AllowedDockerStorageVolumeUsagePercentage:       commonCmdData.AllowedDockerStorageVolumeUsage,
 		AllowedDockerStorageVolumeUsageMarginPercentage: commonCmdData.AllowedDockerStorageVolumeUsageMargin,
 		AllowedLocalCacheVolumeUsagePercentage:          commonCmdData.AllowedLocalCacheVolumeUsage,
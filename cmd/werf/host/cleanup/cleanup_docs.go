@@ -8,7 +8,7 @@ func GetCleanupDocs() structs.DocsStruct {
 	docs.Long = `Cleanup old unused werf cache and data of all projects on host machine.
 
 The data include:
-* Lost Docker containers and images from interrupted builds.
+* Lost Docker containers and images (or Buildah working containers and images when Buildah backend is used) from interrupted builds.
 * Old service tmp dirs, which werf creates during every build, converge and other commands.
 * Local cache:
   * remote Git clones cache;
@@ -18,7 +18,7 @@ It is safe to run this command periodically by automated cleanup job in parallel
 
 	docs.LongMD = "Cleanup old unused werf cache and data of all projects on host machine.\n\n" +
 		"The data include:\n" +
-		"* Lost Docker containers and images from interrupted builds.\n" +
+		"* Lost Docker containers and images (or Buildah working containers and images when Buildah backend is used) from interrupted builds.\n" +
 		"* Old service tmp dirs, which werf creates during every `build`, `converge` and other commands.\n" +
 		"* Local cache:\n" +
 		"  * remote Git clones cache;\n" +
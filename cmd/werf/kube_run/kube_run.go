@@ -1,7 +1,6 @@
 package kube_run
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -19,9 +18,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
-	"k8s.io/kubectl/pkg/scheme"
 
 	"github.com/werf/kubedog/pkg/kube"
 	"github.com/werf/logboek"
@@ -138,6 +135,10 @@ func NewCmd(ctx context.Context) *cobra.Command {
 				}
 			}
 
+			if cmdData.RunExtraOptions != "" {
+				return fmt.Errorf("--extra-options ($WERF_EXTRA_OPTIONS) is not supported anymore: Pod is created without kubectl, use --overrides instead")
+			}
+
 			if err := validateCopyFrom(); err != nil {
 				return fmt.Errorf("error validating --copy-from: %w", err)
 			}
@@ -196,7 +197,8 @@ func NewCmd(ctx context.Context) *cobra.Command {
 
 	cmd.Flags().StringVarP(&cmdData.Pod, "pod", "", os.Getenv("WERF_POD"), "Set created pod name (default $WERF_POD or autogenerated if not specified)")
 	cmd.Flags().StringVarP(&cmdData.Overrides, "overrides", "", os.Getenv("WERF_OVERRIDES"), "Inline JSON to override/extend any fields in created Pod, e.g. to add imagePullSecrets field (default $WERF_OVERRIDES). %pod_name% and %container_name% will be replaced with names of a created pod and a container.")
-	cmd.Flags().StringVarP(&cmdData.RunExtraOptions, "extra-options", "", os.Getenv("WERF_EXTRA_OPTIONS"), "Pass extra options to \"kubectl run\" command, which will create a Pod (default $WERF_EXTRA_OPTIONS)")
+	cmd.Flags().StringVarP(&cmdData.RunExtraOptions, "extra-options", "", os.Getenv("WERF_EXTRA_OPTIONS"), "NOT SUPPORTED: Pod is created without kubectl, use --overrides instead (default $WERF_EXTRA_OPTIONS)")
+	_ = cmd.Flags().MarkHidden("extra-options")
 	cmd.Flags().BoolVarP(&cmdData.Rm, "rm", "", util.GetBoolEnvironmentDefaultTrue("WERF_RM"), "Remove pod and other created resources after command completion (default $WERF_RM or true if not specified)")
 	cmd.Flags().BoolVarP(&cmdData.RmWithNamespace, "rm-with-namespace", "", util.GetBoolEnvironmentDefaultFalse("WERF_RM_WITH_NAMESPACE"), "Remove also a namespace after command completion (default $WERF_RM_WITH_NAMESPACE or false if not specified)")
 	cmd.Flags().BoolVarP(&cmdData.Interactive, "interactive", "i", util.GetBoolEnvironmentDefaultFalse("WERF_INTERACTIVE"), "Enable interactive mode (default $WERF_INTERACTIVE or false if not specified)")
@@ -443,9 +445,9 @@ func run(ctx context.Context, pod, secret, namespace string, werfConfig *config.
 		}
 	}
 
-	commonArgs, err := createCommonKubectlArgs(namespace)
+	runner, err := newKubeRunPodRunner(namespace)
 	if err != nil {
-		return fmt.Errorf("error creating common kubectl args: %w", err)
+		return err
 	}
 
 	if err := createNamespace(ctx, namespace); err != nil {
@@ -458,29 +460,38 @@ func run(ctx context.Context, pod, secret, namespace string, werfConfig *config.
 
 	return logboek.Streams().DoErrorWithoutProxyStreamDataFormatting(func() error {
 		return common.WithoutTerminationSignalsTrap(func() error {
-			if err := createPod(ctx, namespace, pod, image, secret, commonArgs, userExtraAnnotations, userExtraLabels); err != nil {
+			if err := runner.CreatePod(ctx, podSpecOptions{
+				Name:               pod,
+				Container:          pod,
+				Image:              image,
+				ImagePullSecret:    secret,
+				AddImagePullSecret: cmdData.AutoPullSecret && cmdData.registryCredsFound,
+				Annotations:        userExtraAnnotations,
+				Labels:             userExtraLabels,
+				OverridesJSON:      cmdData.Overrides,
+			}); err != nil {
 				return fmt.Errorf("error creating Pod: %w", err)
 			}
 
-			if err := waitPodReadiness(ctx, namespace, pod, commonArgs); err != nil {
+			if err := runner.WaitPodReadiness(ctx, pod); err != nil {
 				return fmt.Errorf("error waiting for Pod readiness: %w", err)
 			}
 
-			defer stopContainer(ctx, namespace, pod, pod, commonArgs)
+			defer runner.StopContainer(ctx, pod, pod)
 
 			for _, copyTo := range getCopyTo() {
-				if err := copyToPod(ctx, namespace, pod, pod, copyTo, commonArgs); err != nil {
+				if err := runner.CopyToPod(ctx, pod, pod, copyTo); err != nil {
 					return fmt.Errorf("error copying to Pod: %w", err)
 				}
 			}
 
 			defer func() {
 				for _, copyFrom := range getCopyFrom() {
-					copyFromPod(ctx, namespace, pod, pod, copyFrom, commonArgs)
+					runner.CopyFromPod(ctx, pod, pod, copyFrom)
 				}
 			}()
 
-			if err := execCommandInPod(ctx, namespace, pod, pod, cmdData.Command, commonArgs); err != nil {
+			if err := runner.ExecCommand(ctx, pod, pod, cmdData.Command, cmdData.Interactive, cmdData.AllocateTty); err != nil {
 				return fmt.Errorf("error running command in Pod: %w", err)
 			}
 
@@ -489,109 +500,20 @@ func run(ctx context.Context, pod, secret, namespace string, werfConfig *config.
 	})
 }
 
-func createCommonKubectlArgs(namespace string) ([]string, error) {
-	commonArgs := []string{
-		"--namespace", namespace,
-	}
-
-	if *commonCmdData.KubeContext != "" {
-		commonArgs = append(commonArgs, "--context", *commonCmdData.KubeContext)
-	}
-
-	if *commonCmdData.KubeConfigBase64 != "" {
-		commonArgs = append(commonArgs, "--kube-config-base64", *commonCmdData.KubeConfigBase64)
-	} else if *commonCmdData.KubeConfig != "" {
-		if err := os.Setenv("KUBECONFIG", *commonCmdData.KubeConfig); err != nil {
-			return nil, fmt.Errorf("unable to set $KUBECONFIG env var: %w", err)
-		}
-	} else if len(*commonCmdData.KubeConfigPathMergeList) > 0 {
-		if err := os.Setenv("KUBECONFIG", common.GetFirstExistingKubeConfigEnvVar()); err != nil {
-			return nil, fmt.Errorf("unable to set $KUBECONFIG env var: %w", err)
-		}
-	}
-
-	return commonArgs, nil
-}
-
-func createPod(ctx context.Context, namespace, pod, image, secret string, extraArgs []string, extraAnnos, extraLabels map[string]string) error {
-	logboek.Context(ctx).LogF("Running pod %q ...\n", pod)
-
-	args, err := createKubectlRunArgs(pod, image, secret, extraArgs, extraAnnos, extraLabels)
-	if err != nil {
-		return fmt.Errorf("error creating kubectl run args: %w", err)
-	}
-
-	cmd := util.ExecKubectlCmd(args...)
-
-	if *commonCmdData.DryRun {
-		fmt.Println(cmd.String())
-		return nil
-	}
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error running pod: %w", err)
-	}
-
-	return nil
-}
-
-func createKubectlRunArgs(pod, image, secret string, extraArgs []string, extraAnnos, extraLabels map[string]string) ([]string, error) {
-	args := []string{
-		"run",
-		pod,
-		"--image", image,
-		"--command",
-		"--restart", "Never",
-		"--quiet",
-		"--pod-running-timeout=6h",
-	}
-
-	args = append(args, extraArgs...)
-
-	if overrides, err := generateOverrides(pod, secret, extraAnnos, extraLabels); err != nil {
-		return nil, fmt.Errorf("error generating --overrides: %w", err)
-	} else if overrides != nil {
-		args = append(args, "--overrides", string(overrides), "--override-type", "strategic")
-	}
-
-	if cmdData.RunExtraOptions != "" {
-		args = append(args, strings.Fields(cmdData.RunExtraOptions)...)
-	}
-
-	return args, nil
-}
-
-// Can return nil overrides.
-func generateOverrides(container, secret string, extraAnnos, extraLabels map[string]string) ([]byte, error) {
-	codec := runtime.NewCodec(scheme.DefaultJSONEncoder(), scheme.Codecs.UniversalDeserializer())
-
-	podOverrides := &corev1.Pod{}
-	if err := runtime.DecodeInto(codec, []byte(cmdData.Overrides), podOverrides); err != nil {
-		return nil, fmt.Errorf("error decoding --overrides: %w", err)
-	}
-
-	createMainContainer(podOverrides, container)
-
-	addAnnotations(extraAnnos, podOverrides)
-	addLabels(extraLabels, podOverrides)
-
-	if cmdData.AutoPullSecret && cmdData.registryCredsFound {
-		if err := addImagePullSecret(secret, podOverrides); err != nil {
-			return nil, fmt.Errorf("error adding imagePullSecret to --overrides: %w", err)
-		}
-	}
-
-	overrides, err := runtime.Encode(codec, podOverrides)
-	if err != nil {
-		return nil, fmt.Errorf("error encoding generated --overrides: %w", err)
-	}
-
-	overrides, err = cleanPodManifest(overrides)
+func newKubeRunPodRunner(namespace string) (*podRunner, error) {
+	kubeConfig, err := kube.GetKubeConfig(kube.KubeConfigOptions{
+		Context:             *commonCmdData.KubeContext,
+		ConfigPath:          *commonCmdData.KubeConfig,
+		ConfigDataBase64:    *commonCmdData.KubeConfigBase64,
+		ConfigPathMergeList: *commonCmdData.KubeConfigPathMergeList,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error cleaning --overrides: %w", err)
+		return nil, fmt.Errorf("unable to get kube config: %w", err)
+	} else if kubeConfig == nil {
+		return nil, fmt.Errorf("kube config not found")
 	}
 
-	return overrides, nil
+	return newPodRunner(kube.Client, kubeConfig.Config, namespace, *commonCmdData.DryRun), nil
 }
 
 func createMainContainer(pod *corev1.Pod, container string) {
@@ -605,7 +527,7 @@ func createMainContainer(pod *corev1.Pod, container string) {
 		"sh", "-euc",
 	}
 	pod.Spec.Containers[getContainerIndex(pod, container)].Args = []string{
-		"until [ -f /tmp/werf-kube-run-quit ]; do sleep 1; done",
+		podKeepAliveLoop,
 	}
 }
 
@@ -615,201 +537,6 @@ func getContainerIndex(pod *corev1.Pod, container string) int {
 	})
 }
 
-func cleanPodManifest(podJsonManifest []byte) ([]byte, error) {
-	podJsonManifest = []byte(strings.TrimSpace(string(podJsonManifest)))
-
-	var pod map[string]interface{}
-	if err := json.Unmarshal(podJsonManifest, &pod); err != nil {
-		return nil, fmt.Errorf("error unmarshaling pod json manifest: %w", err)
-	}
-
-	if pod["spec"].(map[string]interface{})["containers"] != nil {
-		return podJsonManifest, nil
-	}
-
-	delete(pod["spec"].(map[string]interface{}), "containers")
-	if result, err := json.Marshal(pod); err != nil {
-		return nil, fmt.Errorf("error marshaling cleaned pod json manifest: %w", err)
-	} else {
-		return result, nil
-	}
-}
-
-func waitPodReadiness(ctx context.Context, namespace, pod string, extraArgs []string) error {
-	if *commonCmdData.DryRun {
-		return nil
-	}
-
-	logboek.Context(ctx).LogF("Waiting for pod to be ready ...\n")
-
-	for {
-		phase, err := getPodPhase(namespace, pod, extraArgs)
-		if err != nil {
-			return fmt.Errorf("error getting Pod phase: %w", err)
-		}
-
-		switch phase {
-		case corev1.PodFailed:
-			return fmt.Errorf("pod %s/%s failed", namespace, pod)
-		case corev1.PodSucceeded:
-			return fmt.Errorf("pod %s/%s stopped too early", namespace, pod)
-		case corev1.PodRunning:
-			if ready, err := isPodReady(namespace, pod, extraArgs); err != nil {
-				return fmt.Errorf("error checking pod readiness: %w", err)
-			} else if ready {
-				return nil
-			} else {
-				continue
-			}
-		default:
-			continue
-		}
-	}
-}
-
-func getPodPhase(namespace, pod string, extraArgs []string) (corev1.PodPhase, error) {
-	args := []string{
-		"get", "pod", "--template", "{{.status.phase}}", pod,
-	}
-
-	args = append(args, extraArgs...)
-
-	cmd := util.ExecKubectlCmd(args...)
-
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("error getting pod %s/%s spec: %w", namespace, pod, err)
-	}
-
-	return corev1.PodPhase(strings.TrimSpace(stdout.String())), nil
-}
-
-func isPodReady(namespace, pod string, extraArgs []string) (bool, error) {
-	args := []string{
-		"get", "pod", "--template", "{{range .status.conditions}}{{if eq .type \"Ready\"}}{{.status}}{{end}}{{end}}", pod,
-	}
-
-	args = append(args, extraArgs...)
-
-	cmd := util.ExecKubectlCmd(args...)
-
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
-		return false, fmt.Errorf("error getting pod %s/%s spec: %w", namespace, pod, err)
-	}
-
-	switch strings.TrimSpace(stdout.String()) {
-	case "True":
-		return true, nil
-	default:
-		return false, nil
-	}
-}
-
-func copyFromPod(ctx context.Context, namespace, pod, container string, copyFrom copyFromTo, extraArgs []string) {
-	logboek.Context(ctx).LogF("Copying %q from pod to %q ...\n", copyFrom.Src, copyFrom.Dst)
-
-	args := []string{
-		"cp", fmt.Sprint(namespace, "/", pod, ":", copyFrom.Src), copyFrom.Dst, "-c", container,
-	}
-
-	args = append(args, extraArgs...)
-
-	cmd := util.ExecKubectlCmd(args...)
-
-	if *commonCmdData.DryRun {
-		fmt.Println(cmd.String())
-		return
-	}
-
-	if err := cmd.Run(); err != nil {
-		logboek.Context(ctx).Warn().LogF("Error copying %q from pod %s/s: %s\n", copyFrom.Src, namespace, pod, err)
-	}
-}
-
-func copyToPod(ctx context.Context, namespace, pod, container string, copyFrom copyFromTo, extraArgs []string) error {
-	logboek.Context(ctx).LogF("Copying %q to %q in pod ...\n", copyFrom.Src, copyFrom.Dst)
-
-	args := []string{
-		"cp", copyFrom.Src, fmt.Sprint(namespace, "/", pod, ":", copyFrom.Dst), "-c", container,
-	}
-
-	args = append(args, extraArgs...)
-
-	cmd := util.ExecKubectlCmd(args...)
-
-	if *commonCmdData.DryRun {
-		fmt.Println(cmd.String())
-		return nil
-	}
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error copying %q to pod %s/%s: %w", copyFrom.Src, namespace, pod, err)
-	}
-
-	return nil
-}
-
-func stopContainer(ctx context.Context, namespace, pod, container string, extraArgs []string) {
-	logboek.Context(ctx).LogF("Stopping container %q in pod ...\n", container)
-
-	args := []string{
-		"exec", pod, "-q", "--pod-running-timeout", "5h", "-c", container,
-	}
-
-	args = append(args, extraArgs...)
-	args = append(args, "--", "touch", "/tmp/werf-kube-run-quit")
-
-	cmd := util.ExecKubectlCmd(args...)
-
-	if *commonCmdData.DryRun {
-		fmt.Println(cmd.String())
-		return
-	}
-
-	if err := cmd.Run(); err != nil {
-		logboek.Context(ctx).Warn().LogF("Error stopping service container %s/%s/%s for copying files: %s\n", namespace, pod, container, err)
-	}
-}
-
-func execCommandInPod(ctx context.Context, namespace, pod, container string, command, extraArgs []string) error {
-	logboek.Context(ctx).LogF("Execing into pod ...\n")
-
-	args := []string{
-		"exec", pod, "-q", "--pod-running-timeout", "5h", "-c", container,
-	}
-
-	args = append(args, extraArgs...)
-
-	if cmdData.Interactive {
-		args = append(args, "-i")
-	}
-
-	if cmdData.AllocateTty {
-		args = append(args, "-t")
-	}
-
-	args = append(args, "--")
-	args = append(args, command...)
-
-	cmd := util.ExecKubectlCmd(args...)
-
-	if *commonCmdData.DryRun {
-		fmt.Println(cmd.String())
-		return nil
-	}
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error running command %q in pod %s/%s: %w", cmd, namespace, pod, err)
-	}
-
-	return nil
-}
-
 func cleanupResources(ctx context.Context, pod, secret, namespace string) {
 	if !cmdData.Rm || *commonCmdData.DryRun {
 		return
@@ -979,14 +706,6 @@ func getDockerConfigCredentials(ref string) (reference.Named, imgtypes.DockerAut
 	return namedRef, dockerAuthConf, nil
 }
 
-func addAnnotations(annotations map[string]string, podOverrides *corev1.Pod) {
-	podOverrides.Annotations = util.MergeMaps(annotations, podOverrides.Annotations)
-}
-
-func addLabels(labels map[string]string, podOverrides *corev1.Pod) {
-	podOverrides.Labels = util.MergeMaps(labels, podOverrides.Labels)
-}
-
 func addImagePullSecret(secret string, podOverrides *corev1.Pod) error {
 	if secret == "" {
 		panic("secret name can't be empty")
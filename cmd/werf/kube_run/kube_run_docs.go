@@ -5,9 +5,12 @@ import "github.com/werf/werf/cmd/werf/docs/structs"
 func GetKubeRunDocs() structs.DocsStruct {
 	var docs structs.DocsStruct
 
-	docs.Long = `Run container in Kubernetes for specified project image from werf.yaml (build if needed).`
+	docs.Long = `Run container in Kubernetes for specified project image from werf.yaml (build if needed).
 
-	docs.LongMD = "Run container in Kubernetes for specified project image from `werf.yaml` (build if needed)."
+Pod is created with Kubernetes API directly, kubectl is not required. The --extra-options option with extra "kubectl run" options is not supported anymore, use --overrides option to customize the Pod.`
+
+	docs.LongMD = "Run container in Kubernetes for specified project image from `werf.yaml` (build if needed).\n\n" +
+		"Pod is created with Kubernetes API directly, `kubectl` is not required. The `--extra-options` option with extra `kubectl run` options is not supported anymore, use `--overrides` option to customize the Pod."
 
 	return docs
 }
@@ -0,0 +1,277 @@
+package kube_run
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/werf/logboek"
+)
+
+// CopyToPod streams local file or directory as a tar archive into the container, where it is unpacked
+// by the tar utility, so the container image must provide tar (the same requirement as for "kubectl cp").
+func (r *podRunner) CopyToPod(ctx context.Context, pod, container string, copyTo copyFromTo) error {
+	logboek.Context(ctx).LogF("Copying %q to %q in pod ...\n", copyTo.Src, copyTo.Dst)
+
+	dstDir := path.Dir(copyTo.Dst)
+	command := []string{"sh", "-euc", `mkdir -p "$1" && exec tar -xmf - -C "$1"`, "sh", dstDir}
+
+	if r.DryRun {
+		fmt.Printf("exec %s/%s/%s: %q\n", r.Namespace, pod, container, command)
+		return nil
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeLocalPathAsTar(writer, copyTo.Src, path.Base(copyTo.Dst)))
+	}()
+	defer reader.Close()
+
+	if err := r.exec(ctx, r.Namespace, pod, container, command, execStreams{Stdin: reader, Stdout: io.Discard, Stderr: os.Stderr}); err != nil {
+		return fmt.Errorf("error copying %q to pod %s/%s: %w", copyTo.Src, r.Namespace, pod, err)
+	}
+
+	return nil
+}
+
+func (r *podRunner) CopyFromPod(ctx context.Context, pod, container string, copyFrom copyFromTo) {
+	logboek.Context(ctx).LogF("Copying %q from pod to %q ...\n", copyFrom.Src, copyFrom.Dst)
+
+	srcBase := path.Base(copyFrom.Src)
+	command := []string{"tar", "-cf", "-", "-C", path.Dir(copyFrom.Src), srcBase}
+
+	if r.DryRun {
+		fmt.Printf("exec %s/%s/%s: %q\n", r.Namespace, pod, container, command)
+		return
+	}
+
+	reader, writer := io.Pipe()
+	extractErrCh := make(chan error, 1)
+	go func() {
+		err := extractTarToLocalPath(reader, srcBase, copyFrom.Dst)
+		// Drain the rest of the stream so that the exec is not blocked on write.
+		_, _ = io.Copy(io.Discard, reader)
+		extractErrCh <- err
+	}()
+
+	execErr := r.exec(ctx, r.Namespace, pod, container, command, execStreams{Stdout: writer, Stderr: os.Stderr})
+	writer.CloseWithError(execErr)
+	extractErr := <-extractErrCh
+
+	switch {
+	case execErr != nil:
+		logboek.Context(ctx).Warn().LogF("Error copying %q from pod %s/%s: %s\n", copyFrom.Src, r.Namespace, pod, execErr)
+	case extractErr != nil:
+		logboek.Context(ctx).Warn().LogF("Error copying %q from pod %s/%s: %s\n", copyFrom.Src, r.Namespace, pod, extractErr)
+	}
+}
+
+// writeLocalPathAsTar writes file or directory srcPath into tar stream, all entries are prefixed with archiveRoot.
+func writeLocalPathAsTar(w io.Writer, srcPath, archiveRoot string) error {
+	tw := tar.NewWriter(w)
+
+	if err := filepath.Walk(srcPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("error accessing %q: %w", p, err)
+		}
+
+		relPath, err := filepath.Rel(srcPath, p)
+		if err != nil {
+			return err
+		}
+
+		var linkname string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if linkname, err = os.Readlink(p); err != nil {
+				return fmt.Errorf("unable to read link %q: %w", p, err)
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, linkname)
+		if err != nil {
+			return fmt.Errorf("unable to create tar header for %q: %w", p, err)
+		}
+		header.Name = path.Join(archiveRoot, filepath.ToSlash(relPath))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("unable to write tar header for %q: %w", p, err)
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("unable to open %q: %w", p, err)
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(tw, f); err != nil {
+			return fmt.Errorf("unable to write %q into tar: %w", p, err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// extractTarToLocalPath extracts tar stream which entries are rooted at archiveRoot into dstPath,
+// so that archiveRoot itself becomes dstPath. Entries escaping dstPath, symlinks pointing outside of dstPath
+// and entries written through symlinks are rejected.
+func extractTarToLocalPath(r io.Reader, archiveRoot, dstPath string) error {
+	tr := tar.NewReader(r)
+	dstPath = filepath.Clean(dstPath)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to read tar stream: %w", err)
+		}
+
+		name := path.Clean(header.Name)
+		var relPath string
+		switch {
+		case name == archiveRoot:
+			relPath = "."
+		case strings.HasPrefix(name, archiveRoot+"/"):
+			relPath = strings.TrimPrefix(name, archiveRoot+"/")
+		default:
+			return fmt.Errorf("unexpected tar entry %q", header.Name)
+		}
+
+		entryPath := filepath.Join(dstPath, filepath.FromSlash(relPath))
+		if !isPathWithin(entryPath, dstPath) {
+			return fmt.Errorf("tar entry %q points outside of destination %q", header.Name, dstPath)
+		}
+
+		if err := checkNoSymlinksInPath(dstPath, entryPath); err != nil {
+			return fmt.Errorf("tar entry %q: %w", header.Name, err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := removeSymlink(dstPath, entryPath); err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(entryPath, os.FileMode(header.Mode).Perm()|0o700); err != nil {
+				return fmt.Errorf("unable to create dir %q: %w", entryPath, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(entryPath), os.ModePerm); err != nil {
+				return fmt.Errorf("unable to create dir %q: %w", filepath.Dir(entryPath), err)
+			}
+
+			if err := removeSymlink(dstPath, entryPath); err != nil {
+				return err
+			}
+
+			if err := writeFileFromReader(entryPath, os.FileMode(header.Mode).Perm(), tr); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			linkTarget := filepath.FromSlash(header.Linkname)
+			if !filepath.IsAbs(linkTarget) {
+				linkTarget = filepath.Join(filepath.Dir(entryPath), linkTarget)
+			}
+			if entryPath == dstPath || !isPathWithin(linkTarget, dstPath) {
+				return fmt.Errorf("tar entry %q is a symlink to %q pointing outside of destination %q", header.Name, header.Linkname, dstPath)
+			}
+
+			if err := os.MkdirAll(filepath.Dir(entryPath), os.ModePerm); err != nil {
+				return fmt.Errorf("unable to create dir %q: %w", filepath.Dir(entryPath), err)
+			}
+
+			if err := os.RemoveAll(entryPath); err != nil {
+				return fmt.Errorf("unable to remove %q: %w", entryPath, err)
+			}
+
+			if err := os.Symlink(header.Linkname, entryPath); err != nil {
+				return fmt.Errorf("unable to create symlink %q: %w", entryPath, err)
+			}
+		}
+	}
+}
+
+func isPathWithin(p, dir string) bool {
+	p = filepath.Clean(p)
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+// checkNoSymlinksInPath checks that the parent directories of entryPath below dstPath are not symlinks,
+// so that the entry cannot be written outside of dstPath through a symlink created by previous entries.
+func checkNoSymlinksInPath(dstPath, entryPath string) error {
+	relDir, err := filepath.Rel(dstPath, filepath.Dir(entryPath))
+	if err != nil || relDir == "." || entryPath == dstPath {
+		return err
+	}
+
+	current := dstPath
+	for _, part := range strings.Split(relDir, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to stat %q: %w", current, err)
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to write through symlink %q", current)
+		}
+	}
+
+	return nil
+}
+
+// removeSymlink removes existing symlink at filePath below dstPath, so that the following write does not follow it.
+// Destination itself is specified by the user, so it is allowed to be a symlink.
+func removeSymlink(dstPath, filePath string) error {
+	if filePath == dstPath {
+		return nil
+	}
+
+	info, err := os.Lstat(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to stat %q: %w", filePath, err)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(filePath); err != nil {
+			return fmt.Errorf("unable to remove symlink %q: %w", filePath, err)
+		}
+	}
+
+	return nil
+}
+
+func writeFileFromReader(filePath string, mode os.FileMode, r io.Reader) error {
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return fmt.Errorf("unable to create file %q: %w", filePath, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("unable to write file %q: %w", filePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package kube_run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/remotecommand"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/kubectl/pkg/util/term"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/logboek"
+)
+
+const (
+	podQuitFile      = "/tmp/werf-kube-run-quit"
+	podKeepAliveLoop = "until [ -f " + podQuitFile + " ]; do sleep 1; done"
+)
+
+type execStreams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Tty    bool
+}
+
+type execFunc func(ctx context.Context, namespace, pod, container string, command []string, streams execStreams) error
+
+// podRunner manages kube-run Pod lifecycle using the Kubernetes API directly, without the kubectl binary.
+type podRunner struct {
+	Client    kubernetes.Interface
+	Namespace string
+	DryRun    bool
+
+	exec execFunc
+}
+
+func newPodRunner(client kubernetes.Interface, restConfig *rest.Config, namespace string, dryRun bool) *podRunner {
+	return &podRunner{
+		Client:    client,
+		Namespace: namespace,
+		DryRun:    dryRun,
+		exec: func(ctx context.Context, namespace, pod, container string, command []string, streams execStreams) error {
+			return spdyExec(ctx, client, restConfig, namespace, pod, container, command, streams)
+		},
+	}
+}
+
+type podSpecOptions struct {
+	Name               string
+	Container          string
+	Image              string
+	ImagePullSecret    string
+	Annotations        map[string]string
+	Labels             map[string]string
+	OverridesJSON      string
+	AddImagePullSecret bool
+}
+
+func (r *podRunner) CreatePod(ctx context.Context, opts podSpecOptions) error {
+	logboek.Context(ctx).LogF("Running pod %q ...\n", opts.Name)
+
+	pod, err := r.GeneratePod(opts)
+	if err != nil {
+		return fmt.Errorf("error generating pod manifest: %w", err)
+	}
+
+	if r.DryRun {
+		manifest, err := yaml.Marshal(pod)
+		if err != nil {
+			return fmt.Errorf("error marshaling pod manifest: %w", err)
+		}
+		fmt.Printf("---\n%s", manifest)
+		return nil
+	}
+
+	if _, err := r.Client.CoreV1().Pods(r.Namespace).Create(ctx, pod, v1.CreateOptions{}); err != nil {
+		return fmt.Errorf("error creating pod %s/%s: %w", r.Namespace, opts.Name, err)
+	}
+
+	return nil
+}
+
+// GeneratePod builds the Pod to be created. User overrides are applied as a strategic merge patch, but
+// the command of the main container is always enforced, because werf relies on it to keep the Pod alive.
+func (r *podRunner) GeneratePod(opts podSpecOptions) (*corev1.Pod, error) {
+	pod := &corev1.Pod{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:        opts.Name,
+			Namespace:   r.Namespace,
+			Annotations: opts.Annotations,
+			Labels:      opts.Labels,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:  opts.Container,
+					Image: opts.Image,
+				},
+			},
+		},
+	}
+
+	if opts.AddImagePullSecret {
+		if err := addImagePullSecret(opts.ImagePullSecret, pod); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.OverridesJSON != "" {
+		var err error
+		pod, err = applyPodOverrides(pod, opts.OverridesJSON)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	createMainContainer(pod, opts.Container)
+
+	pod.Name = opts.Name
+	pod.Namespace = r.Namespace
+
+	return pod, nil
+}
+
+func applyPodOverrides(pod *corev1.Pod, overridesJSON string) (*corev1.Pod, error) {
+	if err := json.Unmarshal([]byte(overridesJSON), &corev1.Pod{}); err != nil {
+		return nil, fmt.Errorf("error decoding --overrides: %w", err)
+	}
+
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling pod: %w", err)
+	}
+
+	patched, err := strategicpatch.StrategicMergePatch(original, []byte(overridesJSON), corev1.Pod{})
+	if err != nil {
+		return nil, fmt.Errorf("error applying --overrides: %w", err)
+	}
+
+	result := &corev1.Pod{}
+	if err := json.Unmarshal(patched, result); err != nil {
+		return nil, fmt.Errorf("error unmarshaling pod with applied --overrides: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *podRunner) WaitPodReadiness(ctx context.Context, pod string) error {
+	if r.DryRun {
+		return nil
+	}
+
+	logboek.Context(ctx).LogF("Waiting for pod to be ready ...\n")
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", pod).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return r.Client.CoreV1().Pods(r.Namespace).List(ctx, options)
+		},
+		WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return r.Client.CoreV1().Pods(r.Namespace).Watch(ctx, options)
+		},
+	}
+
+	_, err := watchtools.UntilWithSync(ctx, lw, &corev1.Pod{}, nil, func(event watch.Event) (bool, error) {
+		p, ok := event.Object.(*corev1.Pod)
+		if !ok || p.Name != pod {
+			return false, nil
+		}
+
+		if event.Type == watch.Deleted {
+			return false, fmt.Errorf("pod %s/%s was deleted", r.Namespace, pod)
+		}
+
+		return isPodReady(p)
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for pod %s/%s readiness: %w", r.Namespace, pod, err)
+	}
+
+	return nil
+}
+
+func isPodReady(pod *corev1.Pod) (bool, error) {
+	switch pod.Status.Phase {
+	case corev1.PodFailed:
+		return false, fmt.Errorf("pod %s/%s failed", pod.Namespace, pod.Name)
+	case corev1.PodSucceeded:
+		return false, fmt.Errorf("pod %s/%s stopped too early", pod.Namespace, pod.Name)
+	case corev1.PodRunning:
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady {
+				return cond.Status == corev1.ConditionTrue, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (r *podRunner) ExecCommand(ctx context.Context, pod, container string, command []string, interactive, tty bool) error {
+	logboek.Context(ctx).LogF("Execing into pod ...\n")
+
+	if r.DryRun {
+		fmt.Printf("exec %s/%s/%s: %q\n", r.Namespace, pod, container, command)
+		return nil
+	}
+
+	streams := execStreams{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Tty:    tty,
+	}
+	if interactive {
+		streams.Stdin = os.Stdin
+	}
+
+	if err := r.exec(ctx, r.Namespace, pod, container, command, streams); err != nil {
+		return fmt.Errorf("error running command %q in pod %s/%s: %w", command, r.Namespace, pod, err)
+	}
+
+	return nil
+}
+
+func (r *podRunner) StopContainer(ctx context.Context, pod, container string) {
+	logboek.Context(ctx).LogF("Stopping container %q in pod ...\n", container)
+
+	command := []string{"touch", podQuitFile}
+
+	if r.DryRun {
+		fmt.Printf("exec %s/%s/%s: %q\n", r.Namespace, pod, container, command)
+		return
+	}
+
+	if err := r.exec(ctx, r.Namespace, pod, container, command, execStreams{Stdout: io.Discard, Stderr: os.Stderr}); err != nil {
+		logboek.Context(ctx).Warn().LogF("Error stopping service container %s/%s/%s for copying files: %s\n", r.Namespace, pod, container, err)
+	}
+}
+
+func spdyExec(ctx context.Context, client kubernetes.Interface, restConfig *rest.Config, namespace, pod, container string, command []string, streams execStreams) error {
+	req := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    streams.Stdout != nil,
+			Stderr:    streams.Stderr != nil && !streams.Tty,
+			TTY:       streams.Tty,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("unable to create executor: %w", err)
+	}
+
+	streamOptions := remotecommand.StreamOptions{
+		Stdin:  streams.Stdin,
+		Stdout: streams.Stdout,
+		Tty:    streams.Tty,
+	}
+	if !streams.Tty {
+		streamOptions.Stderr = streams.Stderr
+	}
+
+	if !streams.Tty {
+		return executor.StreamWithContext(ctx, streamOptions)
+	}
+
+	t := term.TTY{
+		In:     streams.Stdin,
+		Out:    streams.Stdout,
+		Raw:    streams.Stdin != nil,
+		TryDev: true,
+	}
+	streamOptions.TerminalSizeQueue = t.MonitorSize(t.GetSize())
+
+	return t.Safe(func() error {
+		return executor.StreamWithContext(ctx, streamOptions)
+	})
+}
@@ -0,0 +1,280 @@
+package kube_run
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestPodRunner(objects ...*corev1.Pod) *podRunner {
+	client := fake.NewSimpleClientset()
+	for _, obj := range objects {
+		_, _ = client.CoreV1().Pods(obj.Namespace).Create(context.Background(), obj, v1.CreateOptions{})
+	}
+
+	return &podRunner{
+		Client:    client,
+		Namespace: "test",
+		exec: func(ctx context.Context, namespace, pod, container string, command []string, streams execStreams) error {
+			return nil
+		},
+	}
+}
+
+func TestGeneratePod(t *testing.T) {
+	runner := newTestPodRunner()
+
+	pod, err := runner.GeneratePod(podSpecOptions{
+		Name:               "werf-run-1",
+		Container:          "werf-run-1",
+		Image:              "registry.example.com/app:tag",
+		ImagePullSecret:    "werf-run-1",
+		AddImagePullSecret: true,
+		Annotations:        map[string]string{"anno": "value"},
+		Labels:             map[string]string{"label": "value"},
+		OverridesJSON:      `{"metadata":{"labels":{"label":"overridden"}},"spec":{"serviceAccountName":"sa","containers":[{"name":"werf-run-1","command":["false"],"env":[{"name":"A","value":"B"}]}]}}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if pod.Namespace != "test" || pod.Name != "werf-run-1" {
+		t.Errorf("unexpected pod %s/%s", pod.Namespace, pod.Name)
+	}
+	if pod.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("unexpected restart policy %q", pod.Spec.RestartPolicy)
+	}
+	if pod.Spec.ServiceAccountName != "sa" {
+		t.Errorf("overrides are not applied: service account %q", pod.Spec.ServiceAccountName)
+	}
+	if pod.Labels["label"] != "overridden" || pod.Annotations["anno"] != "value" {
+		t.Errorf("unexpected labels %v and annotations %v", pod.Labels, pod.Annotations)
+	}
+	if len(pod.Spec.ImagePullSecrets) != 1 || pod.Spec.ImagePullSecrets[0].Name != "werf-run-1" {
+		t.Errorf("unexpected image pull secrets %v", pod.Spec.ImagePullSecrets)
+	}
+
+	if len(pod.Spec.Containers) != 1 {
+		t.Fatalf("expected 1 container, got %d", len(pod.Spec.Containers))
+	}
+	container := pod.Spec.Containers[0]
+	if container.Image != "registry.example.com/app:tag" {
+		t.Errorf("unexpected image %q", container.Image)
+	}
+	if len(container.Env) != 1 {
+		t.Errorf("overrides are not applied: env %v", container.Env)
+	}
+	if len(container.Args) != 1 || container.Args[0] != podKeepAliveLoop {
+		t.Errorf("main container command is not enforced: %v %v", container.Command, container.Args)
+	}
+}
+
+func TestWaitPodReadiness(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  corev1.PodStatus
+		wantErr bool
+	}{
+		{
+			name: "ready",
+			status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		{
+			name:    "failed",
+			status:  corev1.PodStatus{Phase: corev1.PodFailed},
+			wantErr: true,
+		},
+		{
+			name:    "succeeded",
+			status:  corev1.PodStatus{Phase: corev1.PodSucceeded},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newTestPodRunner(&corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: "test"},
+				Status:     tt.status,
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			if err := runner.WaitPodReadiness(ctx, "pod"); (err != nil) != tt.wantErr {
+				t.Errorf("WaitPodReadiness() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "src")
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "sub", "file"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeLocalPathAsTar(&buf, srcDir, "app"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dstDir := filepath.Join(t.TempDir(), "dst")
+	if err := extractTarToLocalPath(&buf, "app", dstDir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dstDir, "sub", "file"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(data) != "data" {
+		t.Errorf("unexpected file content %q", data)
+	}
+}
+
+func TestExtractTarToLocalPathRejectsForeignEntries(t *testing.T) {
+	srcFile := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(srcFile, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeLocalPathAsTar(&buf, srcFile, "../escape"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := extractTarToLocalPath(&buf, "file", filepath.Join(t.TempDir(), "dst")); err == nil {
+		t.Errorf("expected error for entry outside of archive root")
+	}
+}
+
+func writeTestTar(t *testing.T, headers ...*tar.Header) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, header := range headers {
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len("data"))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte("data")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &buf
+}
+
+func TestExtractTarToLocalPathRejectsTraversal(t *testing.T) {
+	baseDir := t.TempDir()
+	dstDir := filepath.Join(baseDir, "dst")
+
+	buf := writeTestTar(t,
+		&tar.Header{Name: "root/", Typeflag: tar.TypeDir, Mode: 0o755},
+		&tar.Header{Name: "root/../../escape", Typeflag: tar.TypeReg, Mode: 0o644},
+	)
+
+	if err := extractTarToLocalPath(buf, "root", dstDir); err == nil {
+		t.Errorf("expected error for entry with parent directory references")
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "escape")); !os.IsNotExist(err) {
+		t.Errorf("file written outside of destination")
+	}
+}
+
+func TestExtractTarToLocalPathRejectsSymlinkEscape(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{
+			name: "absolute symlink",
+			headers: []*tar.Header{
+				{Name: "root/", Typeflag: tar.TypeDir, Mode: 0o755},
+				{Name: "root/link", Typeflag: tar.TypeSymlink, Linkname: "OUTSIDE"},
+				{Name: "root/link/.bashrc", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+		},
+		{
+			name: "relative symlink",
+			headers: []*tar.Header{
+				{Name: "root/", Typeflag: tar.TypeDir, Mode: 0o755},
+				{Name: "root/link", Typeflag: tar.TypeSymlink, Linkname: "../outside"},
+				{Name: "root/link/.bashrc", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+		},
+		{
+			name: "chained symlinks",
+			headers: []*tar.Header{
+				{Name: "root/a/b/", Typeflag: tar.TypeDir, Mode: 0o755},
+				{Name: "root/a/b/up", Typeflag: tar.TypeSymlink, Linkname: "../.."},
+				{Name: "root/a/b/link", Typeflag: tar.TypeSymlink, Linkname: "up/.."},
+				{Name: "root/a/b/link/outside/.bashrc", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseDir := t.TempDir()
+			outsideDir := filepath.Join(baseDir, "outside")
+			if err := os.MkdirAll(outsideDir, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, header := range tt.headers {
+				if header.Linkname == "OUTSIDE" {
+					header.Linkname = outsideDir
+				}
+			}
+
+			if err := extractTarToLocalPath(writeTestTar(t, tt.headers...), "root", filepath.Join(baseDir, "dst")); err == nil {
+				t.Errorf("expected error for symlink pointing outside of destination")
+			}
+			if _, err := os.Stat(filepath.Join(outsideDir, ".bashrc")); !os.IsNotExist(err) {
+				t.Errorf("file written outside of destination through symlink")
+			}
+		})
+	}
+}
+
+func TestExtractTarToLocalPathKeepsInternalSymlinks(t *testing.T) {
+	dstDir := filepath.Join(t.TempDir(), "dst")
+
+	buf := writeTestTar(t,
+		&tar.Header{Name: "root/", Typeflag: tar.TypeDir, Mode: 0o755},
+		&tar.Header{Name: "root/file", Typeflag: tar.TypeReg, Mode: 0o644},
+		&tar.Header{Name: "root/link", Typeflag: tar.TypeSymlink, Linkname: "file"},
+	)
+
+	if err := extractTarToLocalPath(buf, "root", dstDir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if linkname, err := os.Readlink(filepath.Join(dstDir, "link")); err != nil || linkname != "file" {
+		t.Errorf("unexpected symlink %q: %v", linkname, err)
+	}
+}
@@ -14,9 +14,11 @@ import (
 
 	"github.com/werf/logboek"
 	"github.com/werf/nelm/pkg/resrcchangcalc"
+	attest_verify "github.com/werf/werf/cmd/werf/attest/verify"
 	"github.com/werf/werf/cmd/werf/build"
 	bundle_apply "github.com/werf/werf/cmd/werf/bundle/apply"
 	bundle_copy "github.com/werf/werf/cmd/werf/bundle/copy"
+	bundle_diff "github.com/werf/werf/cmd/werf/bundle/diff"
 	bundle_download "github.com/werf/werf/cmd/werf/bundle/download"
 	bundle_export "github.com/werf/werf/cmd/werf/bundle/export"
 	bundle_publish "github.com/werf/werf/cmd/werf/bundle/publish"
@@ -53,6 +55,7 @@ import (
 	stage_image "github.com/werf/werf/cmd/werf/stage/image"
 	"github.com/werf/werf/cmd/werf/synchronization"
 	"github.com/werf/werf/cmd/werf/version"
+	"github.com/werf/werf/pkg/deploy/bundles"
 	dhelm "github.com/werf/werf/pkg/deploy/helm"
 	"github.com/werf/werf/pkg/process_exterminator"
 	"github.com/werf/werf/pkg/telemetry"
@@ -89,7 +92,7 @@ func main() {
 		if helm_v3.IsPluginError(err) {
 			common.ShutdownTelemetry(ctx, helm_v3.PluginErrorCode(err))
 			common.TerminateWithError(err.Error(), helm_v3.PluginErrorCode(err))
-		} else if errors.Is(err, resrcchangcalc.ErrChangesPlanned) {
+		} else if errors.Is(err, resrcchangcalc.ErrChangesPlanned) || errors.Is(err, bundles.ErrBundlesDiffer) {
 			common.ShutdownTelemetry(ctx, 2)
 			os.Exit(2)
 		} else {
@@ -156,6 +159,7 @@ Find more information at https://werf.io`),
 				ci_env.NewCmd(ctx),
 				build.NewCmd(ctx),
 				export.NewExportCmd(ctx),
+				attestCmd(ctx),
 				run.NewCmd(ctx),
 				kube_run.NewCmd(ctx),
 				dockerComposeCmd(ctx),
@@ -220,6 +224,18 @@ func crCmd(ctx context.Context) *cobra.Command {
 	return cmd
 }
 
+func attestCmd(ctx context.Context) *cobra.Command {
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:   "attest",
+		Short: "Work with images attestations",
+	})
+	cmd.AddCommand(
+		attest_verify.NewCmd(ctx),
+	)
+
+	return cmd
+}
+
 func bundleCmd(ctx context.Context) *cobra.Command {
 	cmd := common.SetCommandContext(ctx, &cobra.Command{
 		Use:   "bundle",
@@ -232,6 +248,7 @@ func bundleCmd(ctx context.Context) *cobra.Command {
 		bundle_download.NewCmd(ctx),
 		bundle_render.NewCmd(ctx),
 		bundle_copy.NewCmd(ctx),
+		bundle_diff.NewCmd(ctx),
 	)
 
 	return cmd
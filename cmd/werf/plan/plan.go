@@ -85,7 +85,7 @@ func NewCmd(ctx context.Context) *cobra.Command {
 werf plan --repo registry.mydomain.com/web --env production`,
 		DisableFlagsInUseLine: true,
 		Annotations: map[string]string{
-			common.CmdEnvAnno: common.EnvsDescription(common.WerfDebugAnsibleArgs, common.WerfSecretKey),
+			common.CmdEnvAnno: common.EnvsDescription(common.WerfDebugAnsibleArgs, common.WerfSecretKey, common.WerfSecretKeyProvider),
 			common.DocsLongMD: GetPlanDocs().LongMD,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -182,6 +182,7 @@ werf plan --repo registry.mydomain.com/web --env production`,
 	common.SetupVirtualMerge(&commonCmdData, cmd)
 
 	common.SetupParallelOptions(&commonCmdData, cmd, common.DefaultBuildParallelTasksLimit)
+	common.SetupKeepGoing(&commonCmdData, cmd)
 	common.SetupSkipBuild(&commonCmdData, cmd)
 	common.SetupRequireBuiltImages(&commonCmdData, cmd)
 	commonCmdData.SetupPlatform(cmd)
@@ -0,0 +1,57 @@
+package synchronization
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/pkg/storage"
+	"github.com/werf/werf/pkg/werf"
+)
+
+type dumpCmdData struct {
+	CommonCmdData common.CmdData
+
+	File string
+
+	DBFile                         string
+	LocalStagesStorageCacheBaseDir string
+}
+
+func setupDumpOptions(data *dumpCmdData, cmd *cobra.Command) {
+	common.SetupTmpDir(&data.CommonCmdData, cmd, common.SetupTmpDirOptions{})
+	common.SetupHomeDir(&data.CommonCmdData, cmd, common.SetupHomeDirOptions{})
+	common.SetupLogOptions(&data.CommonCmdData, cmd)
+
+	cmd.Flags().StringVarP(&data.DBFile, "db-file", "", os.Getenv("WERF_DB_FILE"), "Use embedded database file of the synchronization server started with the --db-file option (default $WERF_DB_FILE)")
+	cmd.Flags().StringVarP(&data.LocalStagesStorageCacheBaseDir, "local-stages-storage-cache-base-dir", "", os.Getenv("WERF_LOCAL_STAGES_STORAGE_CACHE_BASE_DIR"), "Use file stages-storage-cache from the specified directory when --db-file option is not specified (~/.werf/synchronization_server/stages_storage_cache by default or $WERF_LOCAL_STAGES_STORAGE_CACHE_BASE_DIR)")
+}
+
+// withStagesStorageCacheDumper opens the backend selected by the command options.
+// The synchronization server should be stopped, because the database file is locked exclusively by the server.
+func withStagesStorageCacheDumper(ctx context.Context, data *dumpCmdData, readOnly bool, f func(dumper storage.StagesStorageCacheDumper) error) error {
+	if err := werf.Init(*data.CommonCmdData.TmpDir, *data.CommonCmdData.HomeDir); err != nil {
+		return fmt.Errorf("initialization error: %w", err)
+	}
+
+	if data.DBFile != "" {
+		db, err := storage.OpenBoltSynchronizationDB(ctx, data.DBFile, storage.BoltSynchronizationDBOptions{ReadOnly: readOnly})
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return f(db)
+	}
+
+	baseDir := data.LocalStagesStorageCacheBaseDir
+	if baseDir == "" {
+		baseDir = filepath.Join(werf.GetHomeDir(), "synchronization_server", "stages_storage_cache")
+	}
+
+	return f(storage.NewFileStagesStorageCacheDumper(baseDir))
+}
@@ -0,0 +1,83 @@
+package synchronization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/pkg/storage"
+)
+
+var exportCmdData dumpCmdData
+
+func NewExportCmd(ctx context.Context) *cobra.Command {
+	ctx = common.NewContextWithCmdData(ctx, &exportCmdData.CommonCmdData)
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:   "export",
+		Short: "Export stages-storage-cache records of the synchronization server",
+		Long: common.GetLongCommandDescription(`Export stages-storage-cache records of all synchronization server clients into the JSON dump.
+
+The dump can be loaded into another synchronization server backend with the "werf synchronization import" command. Export is supported for the file stages-storage-cache and for the embedded database (--db-file option), the synchronization server using the database file should be stopped. Kubernetes stages-storage-cache is not supported, because it keeps the records of all projects of the client without the project names.
+
+Lock-manager locks are not exported: locks are only held while werf processes are running.`),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if err := common.ProcessLogOptions(&exportCmdData.CommonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			return runExport(ctx)
+		},
+	})
+
+	setupDumpOptions(&exportCmdData, cmd)
+	cmd.Flags().StringVarP(&exportCmdData.File, "file", "", os.Getenv("WERF_FILE"), "Write the dump into the specified file, use \"-\" to write to stdout (default $WERF_FILE or stdout)")
+
+	return cmd
+}
+
+func runExport(ctx context.Context) error {
+	toStdout := exportCmdData.File == "" || exportCmdData.File == "-"
+	if toStdout {
+		// Keep stdout clean for the dump.
+		ctx = logboek.NewContext(ctx, logboek.Context(ctx).NewSubLogger(os.Stderr, os.Stderr))
+	}
+
+	return withStagesStorageCacheDumper(ctx, &exportCmdData, true, func(dumper storage.StagesStorageCacheDumper) error {
+		records, err := dumper.ExportStagesStorageCache(ctx)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(storage.StagesStorageCacheDump{
+			Version: storage.StagesStorageCacheDumpVersion,
+			Records: records,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal dump: %w", err)
+		}
+		data = append(data, '\n')
+
+		if !toStdout {
+			if err := os.WriteFile(exportCmdData.File, data, 0o644); err != nil {
+				return fmt.Errorf("unable to write %q: %w", exportCmdData.File, err)
+			}
+			logboek.Context(ctx).Default().LogF("Exported %d records into %s\n", len(records), exportCmdData.File)
+			return nil
+		}
+
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("unable to write dump: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package synchronization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/cmd/werf/common"
+	"github.com/werf/werf/pkg/storage"
+)
+
+var importCmdData dumpCmdData
+
+func NewImportCmd(ctx context.Context) *cobra.Command {
+	ctx = common.NewContextWithCmdData(ctx, &importCmdData.CommonCmdData)
+	cmd := common.SetCommandContext(ctx, &cobra.Command{
+		Use:   "import",
+		Short: "Import stages-storage-cache records into the synchronization server",
+		Long: common.GetLongCommandDescription(`Import stages-storage-cache records of synchronization server clients from the JSON dump created by the "werf synchronization export" command.
+
+Existing records with the same client, project and digest are overwritten. Import is supported for the file stages-storage-cache and for the embedded database (--db-file option), the synchronization server using the database file should be stopped. Kubernetes stages-storage-cache is not supported, because it keeps the records of all projects of the client without the project names.`),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if err := common.ProcessLogOptions(&importCmdData.CommonCmdData); err != nil {
+				common.PrintHelp(cmd)
+				return err
+			}
+
+			return runImport(ctx)
+		},
+	})
+
+	setupDumpOptions(&importCmdData, cmd)
+	cmd.Flags().StringVarP(&importCmdData.File, "file", "", os.Getenv("WERF_FILE"), "Read the dump from the specified file, use \"-\" to read from stdin (default $WERF_FILE or stdin)")
+
+	return cmd
+}
+
+func runImport(ctx context.Context) error {
+	var data []byte
+	var err error
+	if importCmdData.File != "" && importCmdData.File != "-" {
+		data, err = os.ReadFile(importCmdData.File)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read dump: %w", err)
+	}
+
+	var dump storage.StagesStorageCacheDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return fmt.Errorf("unable to unmarshal dump: %w", err)
+	}
+
+	if dump.Version != storage.StagesStorageCacheDumpVersion {
+		return fmt.Errorf("unsupported dump version %d, expected %d", dump.Version, storage.StagesStorageCacheDumpVersion)
+	}
+
+	return withStagesStorageCacheDumper(ctx, &importCmdData, false, func(dumper storage.StagesStorageCacheDumper) error {
+		if err := dumper.ImportStagesStorageCache(ctx, dump.Records); err != nil {
+			return err
+		}
+
+		logboek.Context(ctx).Default().LogF("Imported %d records\n", len(dump.Records))
+
+		return nil
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -30,9 +31,16 @@ var cmdData struct {
 	LocalLockManagerBaseDir        string
 	LocalStagesStorageCacheBaseDir string
 
+	DBFile string
+
 	TTL  string
 	Host string
 	Port string
+
+	AuthConfig  string
+	AuthToken   string
+	TLSCertFile string
+	TLSKeyFile  string
 }
 
 var commonCmdData common.CmdData
@@ -74,13 +82,25 @@ func NewCmd(ctx context.Context) *cobra.Command {
 	cmd.Flags().StringVarP(&cmdData.LocalLockManagerBaseDir, "local-lock-manager-base-dir", "", os.Getenv("WERF_LOCAL_LOCK_MANAGER_BASE_DIR"), "Use specified directory as base for file lock-manager (~/.werf/synchronization_server/lock_manager by default or $WERF_LOCAL_LOCK_MANAGER_BASE_DIR)")
 	cmd.Flags().StringVarP(&cmdData.LocalStagesStorageCacheBaseDir, "local-stages-storage-cache-base-dir", "", os.Getenv("WERF_LOCAL_STAGES_STORAGE_CACHE_BASE_DIR"), "Use specified directory as base for file stages-storage-cache (~/.werf/synchronization_server/stages_storage_cache by default or $WERF_LOCAL_STAGES_STORAGE_CACHE_BASE_DIR)")
 
+	cmd.Flags().StringVarP(&cmdData.DBFile, "db-file", "", os.Getenv("WERF_DB_FILE"), "Use embedded database file as persistent lock-manager and stages-storage-cache, which survives restarts of the server (default $WERF_DB_FILE)")
+
 	cmd.Flags().BoolVarP(&cmdData.Kubernetes, "kubernetes", "", util.GetBoolEnvironmentDefaultFalse("WERF_KUBERNETES"), "Use kubernetes lock-manager stages-storage-cache (default $WERF_KUBERNETES)")
 	cmd.Flags().StringVarP(&cmdData.KubernetesNamespacePrefix, "kubernetes-namespace-prefix", "", os.Getenv("WERF_KUBERNETES_NAMESPACE_PREFIX"), "Use specified prefix for namespaces created for lock-manager and stages-storage-cache (defaults to 'werf-synchronization-' when --kubernetes option is used or $WERF_KUBERNETES_NAMESPACE_PREFIX)")
 
-	cmd.Flags().StringVarP(&cmdData.TTL, "ttl", "", os.Getenv("WERF_TTL"), "Time to live for lock-manager locks and stages-storage-cache records (default $WERF_TTL)")
+	cmd.Flags().StringVarP(&cmdData.TTL, "ttl", "", os.Getenv("WERF_TTL"), "Time to live for lock-manager locks and stages-storage-cache records, e.g. 72h. Only supported with --db-file option (default $WERF_TTL)")
 	cmd.Flags().StringVarP(&cmdData.Host, "host", "", os.Getenv("WERF_HOST"), "Bind synchronization server to the specified host (default localhost or $WERF_HOST)")
 	cmd.Flags().StringVarP(&cmdData.Port, "port", "", os.Getenv("WERF_PORT"), "Bind synchronization server to the specified port (default 55581 or $WERF_PORT)")
 
+	cmd.Flags().StringVarP(&cmdData.AuthConfig, "auth-config", "", os.Getenv("WERF_SYNCHRONIZATION_AUTH_CONFIG"), "Enable authentication with tokens and projects allowed for each token from the specified YAML file (default $WERF_SYNCHRONIZATION_AUTH_CONFIG)")
+	cmd.Flags().StringVarP(&cmdData.AuthToken, "auth-token", "", os.Getenv("WERF_SYNCHRONIZATION_AUTH_TOKEN"), "Enable authentication with the specified token allowed for all projects (default $WERF_SYNCHRONIZATION_AUTH_TOKEN)")
+	cmd.Flags().StringVarP(&cmdData.TLSCertFile, "tls-cert-file", "", os.Getenv("WERF_TLS_CERT_FILE"), "Serve HTTPS using the specified certificate file, requires --tls-key-file (default $WERF_TLS_CERT_FILE)")
+	cmd.Flags().StringVarP(&cmdData.TLSKeyFile, "tls-key-file", "", os.Getenv("WERF_TLS_KEY_FILE"), "Serve HTTPS using the specified private key file, requires --tls-cert-file (default $WERF_TLS_KEY_FILE)")
+
+	cmd.AddCommand(
+		NewExportCmd(ctx),
+		NewImportCmd(ctx),
+	)
+
 	return cmd
 }
 
@@ -106,10 +126,40 @@ func runSynchronization(ctx context.Context) error {
 		port = "55581"
 	}
 
+	serverOptions, err := getRunSynchronizationServerOptions()
+	if err != nil {
+		return err
+	}
+
 	var distributedLockerBackendFactoryFunc func(clientID string) (distributed_locker.DistributedLockerBackend, error)
 	var stagesStorageCacheFactoryFunc func(clientID string) (synchronization_server.StagesStorageCacheInterface, error)
 
-	if cmdData.Kubernetes {
+	if cmdData.DBFile != "" {
+		var ttl time.Duration
+		if cmdData.TTL != "" {
+			if ttl, err = time.ParseDuration(cmdData.TTL); err != nil {
+				return fmt.Errorf("bad --ttl value %q: %w", cmdData.TTL, err)
+			}
+		}
+
+		db, err := storage.OpenBoltSynchronizationDB(ctx, cmdData.DBFile, storage.BoltSynchronizationDBOptions{TTL: ttl, Compact: true})
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if ttl != 0 {
+			go db.RunExpiredRecordsCleaner(ctx, expiredRecordsCleanerInterval(ttl))
+		}
+
+		distributedLockerBackendFactoryFunc = func(clientID string) (distributed_locker.DistributedLockerBackend, error) {
+			return distributed_locker.NewOptimisticLockingStorageBasedBackend(storage.NewBoltLockStore(db, clientID)), nil
+		}
+
+		stagesStorageCacheFactoryFunc = func(clientID string) (synchronization_server.StagesStorageCacheInterface, error) {
+			return storage.NewBoltStagesStorageCache(db, clientID), nil
+		}
+	} else if cmdData.Kubernetes {
 		if err := kube.Init(kube.InitOptions{kube.KubeConfigOptions{
 			Context:          *commonCmdData.KubeContext,
 			ConfigPath:       *commonCmdData.KubeConfig,
@@ -161,5 +211,43 @@ func runSynchronization(ctx context.Context) error {
 		}
 	}
 
-	return synchronization_server.RunSynchronizationServer(ctx, host, port, distributedLockerBackendFactoryFunc, stagesStorageCacheFactoryFunc)
+	return synchronization_server.RunSynchronizationServer(ctx, host, port, distributedLockerBackendFactoryFunc, stagesStorageCacheFactoryFunc, serverOptions)
+}
+
+func expiredRecordsCleanerInterval(ttl time.Duration) time.Duration {
+	if ttl < time.Hour {
+		return ttl
+	}
+	return time.Hour
+}
+
+func getRunSynchronizationServerOptions() (synchronization_server.RunSynchronizationServerOptions, error) {
+	opts := synchronization_server.RunSynchronizationServerOptions{
+		TLSCertFile: cmdData.TLSCertFile,
+		TLSKeyFile:  cmdData.TLSKeyFile,
+	}
+
+	if (cmdData.TLSCertFile == "") != (cmdData.TLSKeyFile == "") {
+		return opts, fmt.Errorf("both --tls-cert-file and --tls-key-file options should be specified")
+	}
+
+	if cmdData.AuthConfig != "" {
+		authConfig, err := synchronization_server.LoadAuthConfig(cmdData.AuthConfig)
+		if err != nil {
+			return opts, err
+		}
+		opts.Auth = authConfig
+	}
+
+	if cmdData.AuthToken != "" {
+		if opts.Auth == nil {
+			opts.Auth = &synchronization_server.AuthConfig{}
+		}
+		opts.Auth.Tokens = append(opts.Auth.Tokens, synchronization_server.AuthToken{
+			Token:    cmdData.AuthToken,
+			Projects: []string{synchronization_server.AnyProject},
+		})
+	}
+
+	return opts, nil
 }
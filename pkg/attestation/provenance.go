@@ -0,0 +1,122 @@
+package attestation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// SLSAProvenancePredicateType is the predicate type of the SLSA v1 provenance.
+	SLSAProvenancePredicateType = "https://slsa.dev/provenance/v1"
+
+	// BuildType identifies the werf build process, its parameters are described by ExternalParameters and InternalParameters.
+	BuildType = "https://werf.io/slsa/werf-build/v1"
+	BuilderID = "https://werf.io"
+
+	GitCommitDigestAlgorithm = "gitCommit"
+)
+
+// Provenance is the SLSA v1 provenance predicate.
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   ExternalParameters   `json:"externalParameters"`
+	InternalParameters   InternalParameters   `json:"internalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// ExternalParameters are the werf.yaml image parameters the image is built with.
+type ExternalParameters struct {
+	Project    string                `json:"project"`
+	Image      string                `json:"image"`
+	Platform   string                `json:"platform,omitempty"`
+	Dockerfile *DockerfileParameters `json:"dockerfile,omitempty"`
+}
+
+type DockerfileParameters struct {
+	Path      string            `json:"path"`
+	Context   string            `json:"context,omitempty"`
+	Target    string            `json:"target,omitempty"`
+	BuildArgs map[string]string `json:"buildArgs,omitempty"`
+}
+
+// InternalParameters are the image stages calculated by werf, the stage digests depend on the instructions and the files the stage is built from.
+type InternalParameters struct {
+	Stages []Stage `json:"stages"`
+}
+
+type Stage struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+	Image  string `json:"image"`
+}
+
+type ResourceDescriptor struct {
+	Name        string            `json:"name,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Digest      map[string]string `json:"digest,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type RunDetails struct {
+	Builder  Builder        `json:"builder"`
+	Metadata *BuildMetadata `json:"metadata,omitempty"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type BuildMetadata struct {
+	StartedOn  *time.Time `json:"startedOn,omitempty"`
+	FinishedOn *time.Time `json:"finishedOn,omitempty"`
+}
+
+// NewDigestSet converts the "algorithm:hex" digest into the in-toto digest set.
+func NewDigestSet(digest string) map[string]string {
+	algorithm, hex, found := strings.Cut(digest, ":")
+	if !found {
+		return nil
+	}
+
+	return map[string]string{algorithm: hex}
+}
+
+// Match checks that the image has been built by werf with the same parameters and stages as expected.
+// Git commits are not compared: the image built from the previous commit is still valid if none of its stages have changed.
+func (p *Provenance) Match(expected *Provenance) error {
+	if p.BuildDefinition.BuildType != expected.BuildDefinition.BuildType {
+		return fmt.Errorf("build type %q does not match expected %q", p.BuildDefinition.BuildType, expected.BuildDefinition.BuildType)
+	}
+
+	if !reflect.DeepEqual(p.BuildDefinition.ExternalParameters, expected.BuildDefinition.ExternalParameters) {
+		params, _ := json.Marshal(p.BuildDefinition.ExternalParameters)
+		expectedParams, _ := json.Marshal(expected.BuildDefinition.ExternalParameters)
+		return fmt.Errorf("build parameters %s do not match expected %s", params, expectedParams)
+	}
+
+	stages, expectedStages := p.BuildDefinition.InternalParameters.Stages, expected.BuildDefinition.InternalParameters.Stages
+	for i, expectedStage := range expectedStages {
+		if i >= len(stages) {
+			return fmt.Errorf("stage %q is missing", expectedStage.Name)
+		}
+
+		if stages[i].Name != expectedStage.Name || stages[i].Digest != expectedStage.Digest {
+			return fmt.Errorf("stage %s %s does not match expected stage %s %s", stages[i].Name, stages[i].Digest, expectedStage.Name, expectedStage.Digest)
+		}
+	}
+
+	if len(stages) > len(expectedStages) {
+		return fmt.Errorf("unexpected stage %q", stages[len(expectedStages)].Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package attestation
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/werf/werf/pkg/signature"
+)
+
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+
+	InTotoPayloadType = "application/vnd.in-toto+json"
+
+	// DSSEEnvelopeMediaType is the media type of the attestation layer, the layer is annotated with the predicate type as cosign does.
+	DSSEEnvelopeMediaType   = "application/vnd.dsse.envelope.v1+json"
+	PredicateTypeAnnotation = "predicateType"
+
+	// AttestationTagSuffix is the suffix of the tag cosign looks for the image attestations at (sha256-<image digest>.att).
+	AttestationTagSuffix = ".att"
+)
+
+var ErrUnsignedAttestation = errors.New("attestation is not signed")
+
+// Statement is the in-toto v1 statement with the SLSA provenance predicate.
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     *Provenance          `json:"predicate"`
+}
+
+func NewProvenanceStatement(subjectName, subjectDigest string, provenance *Provenance) *Statement {
+	return &Statement{
+		Type:          StatementType,
+		Subject:       []ResourceDescriptor{{Name: subjectName, Digest: NewDigestSet(subjectDigest)}},
+		PredicateType: SLSAProvenancePredicateType,
+		Predicate:     provenance,
+	}
+}
+
+func AttestationTag(imageDigest string) string {
+	return strings.Replace(imageDigest, ":", "-", 1) + AttestationTagSuffix
+}
+
+// CheckSubject checks that the statement is issued for the image manifest digest.
+func (s *Statement) CheckSubject(imageDigest string) error {
+	expected := NewDigestSet(imageDigest)
+	for _, subject := range s.Subject {
+		for algorithm, hex := range expected {
+			if subject.Digest[algorithm] == hex {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("attestation is not issued for %s", imageDigest)
+}
+
+// SignStatement wraps the statement into the DSSE envelope, the envelope is left unsigned if signer is nil.
+func SignStatement(ctx context.Context, statement *Statement, signer signature.Signer) (*dsse.Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal statement: %w", err)
+	}
+
+	if signer == nil {
+		return &dsse.Envelope{
+			PayloadType: InTotoPayloadType,
+			Payload:     base64.StdEncoding.EncodeToString(payload),
+			Signatures:  []dsse.Signature{},
+		}, nil
+	}
+
+	envelopeSigner, err := dsse.NewEnvelopeSigner(&dsseSigner{signer: signer})
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := envelopeSigner.SignPayload(ctx, InTotoPayloadType, payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign statement: %w", err)
+	}
+
+	return envelope, nil
+}
+
+func ParseEnvelope(data []byte) (*dsse.Envelope, error) {
+	var envelope dsse.Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal DSSE envelope: %w", err)
+	}
+
+	return &envelope, nil
+}
+
+// OpenEnvelope verifies the envelope signature with the public key and returns the provenance statement.
+// The signature is not checked if publicKey is nil.
+func OpenEnvelope(ctx context.Context, envelope *dsse.Envelope, publicKey crypto.PublicKey) (*Statement, error) {
+	if envelope.PayloadType != InTotoPayloadType {
+		return nil, fmt.Errorf("unexpected payload type %q", envelope.PayloadType)
+	}
+
+	if publicKey != nil {
+		if len(envelope.Signatures) == 0 {
+			return nil, ErrUnsignedAttestation
+		}
+
+		envelopeVerifier, err := dsse.NewEnvelopeVerifier(&dsseVerifier{publicKey: publicKey})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := envelopeVerifier.Verify(ctx, envelope); err != nil {
+			return nil, fmt.Errorf("%w: %s", signature.ErrInvalidSignature, err)
+		}
+	}
+
+	payload, err := envelope.DecodeB64Payload()
+	if err != nil {
+		return nil, err
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal statement: %w", err)
+	}
+
+	if statement.Type != StatementType {
+		return nil, fmt.Errorf("unexpected statement type %q", statement.Type)
+	}
+
+	if statement.PredicateType != SLSAProvenancePredicateType || statement.Predicate == nil {
+		return nil, fmt.Errorf("unexpected predicate type %q", statement.PredicateType)
+	}
+
+	return &statement, nil
+}
+
+type dsseSigner struct {
+	signer signature.Signer
+}
+
+func (s *dsseSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	return s.signer.Sign(ctx, data)
+}
+
+func (s *dsseSigner) KeyID() (string, error) {
+	return "", nil
+}
+
+type dsseVerifier struct {
+	publicKey crypto.PublicKey
+}
+
+func (v *dsseVerifier) Verify(_ context.Context, data, sig []byte) error {
+	return signature.Verify(v.publicKey, data, sig)
+}
+
+func (v *dsseVerifier) KeyID() (string, error) {
+	return "", nil
+}
+
+func (v *dsseVerifier) Public() crypto.PublicKey {
+	return v.publicKey
+}
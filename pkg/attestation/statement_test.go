@@ -0,0 +1,140 @@
+package attestation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/werf/werf/pkg/signature"
+)
+
+const testImageDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestSignStatement(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	statement := NewProvenanceStatement("registry.example.com/app", testImageDigest, newTestProvenance())
+
+	envelope, err := SignStatement(ctx, statement, signature.NewKeySigner(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := OpenEnvelope(ctx, envelope, key.Public())
+	if err != nil {
+		t.Fatalf("expected valid attestation, got %v", err)
+	}
+
+	if err := opened.CheckSubject(testImageDigest); err != nil {
+		t.Error(err)
+	}
+
+	if err := opened.CheckSubject("sha256:other"); err == nil {
+		t.Error("expected error for other image digest")
+	}
+
+	if err := opened.Predicate.Match(newTestProvenance()); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := OpenEnvelope(ctx, envelope, otherKey.Public()); !errors.Is(err, signature.ErrInvalidSignature) {
+		t.Errorf("expected invalid signature error, got %v", err)
+	}
+
+	unsignedEnvelope, err := SignStatement(ctx, statement, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenEnvelope(ctx, unsignedEnvelope, key.Public()); !errors.Is(err, ErrUnsignedAttestation) {
+		t.Errorf("expected unsigned attestation error, got %v", err)
+	}
+
+	if _, err := OpenEnvelope(ctx, unsignedEnvelope, nil); err != nil {
+		t.Errorf("expected unsigned attestation to be opened without key, got %v", err)
+	}
+}
+
+func TestProvenance_Match(t *testing.T) {
+	tests := []struct {
+		name        string
+		mutate      func(p *Provenance)
+		expectError bool
+	}{
+		{
+			name: "other commit",
+			mutate: func(p *Provenance) {
+				p.BuildDefinition.ResolvedDependencies[0].Digest[GitCommitDigestAlgorithm] = "other"
+			},
+		},
+		{
+			name:        "other stage digest",
+			mutate:      func(p *Provenance) { p.BuildDefinition.InternalParameters.Stages[1].Digest = "other" },
+			expectError: true,
+		},
+		{
+			name: "extra stage",
+			mutate: func(p *Provenance) {
+				p.BuildDefinition.InternalParameters.Stages = append(p.BuildDefinition.InternalParameters.Stages, Stage{Name: "setup"})
+			},
+			expectError: true,
+		},
+		{
+			name:        "other build args",
+			mutate:      func(p *Provenance) { p.BuildDefinition.ExternalParameters.Dockerfile.BuildArgs["VERSION"] = "2" },
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvenance()
+			tt.mutate(p)
+
+			err := p.Match(newTestProvenance())
+			if tt.expectError && err == nil {
+				t.Error("expected error")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func newTestProvenance() *Provenance {
+	return &Provenance{
+		BuildDefinition: BuildDefinition{
+			BuildType: BuildType,
+			ExternalParameters: ExternalParameters{
+				Project: "project",
+				Image:   "app",
+				Dockerfile: &DockerfileParameters{
+					Path:      "Dockerfile",
+					BuildArgs: map[string]string{"VERSION": "1"},
+				},
+			},
+			InternalParameters: InternalParameters{
+				Stages: []Stage{
+					{Name: "from", Digest: "a"},
+					{Name: "install", Digest: "b"},
+				},
+			},
+			ResolvedDependencies: []ResourceDescriptor{
+				{URI: "git+https://example.com/project.git", Digest: map[string]string{GitCommitDigestAlgorithm: "commit"}},
+			},
+		},
+		RunDetails: RunDetails{Builder: Builder{ID: BuilderID}},
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/werf/logboek"
 	"github.com/werf/logboek/pkg/style"
 	"github.com/werf/logboek/pkg/types"
+	"github.com/werf/werf/pkg/attestation"
 	"github.com/werf/werf/pkg/build/image"
 	"github.com/werf/werf/pkg/build/stage"
 	"github.com/werf/werf/pkg/build/stage/instruction"
@@ -28,6 +29,8 @@ import (
 	"github.com/werf/werf/pkg/git_repo"
 	imagePkg "github.com/werf/werf/pkg/image"
 	"github.com/werf/werf/pkg/logging"
+	"github.com/werf/werf/pkg/sbom"
+	"github.com/werf/werf/pkg/signature"
 	"github.com/werf/werf/pkg/stapel"
 	"github.com/werf/werf/pkg/storage"
 	"github.com/werf/werf/pkg/storage/manager"
@@ -49,6 +52,15 @@ type BuildOptions struct {
 
 	SkipImageMetadataPublication bool
 	CustomTagFuncList            []imagePkg.CustomTagFunc
+
+	SBOM       bool
+	SBOMFormat sbom.Format
+
+	Signer signature.Signer
+
+	Provenance bool
+
+	Output *OutputOptions
 }
 
 type IntrospectOptions struct {
@@ -72,9 +84,12 @@ func (opts *IntrospectOptions) ImageStageShouldBeIntrospected(imageName, stageNa
 
 func NewBuildPhase(c *Conveyor, opts BuildPhaseOptions) *BuildPhase {
 	return &BuildPhase{
-		BasePhase:         BasePhase{c},
-		BuildPhaseOptions: opts,
-		ImagesReport:      NewImagesReport(),
+		BasePhase:             BasePhase{c},
+		BuildPhaseOptions:     opts,
+		ImagesReport:          NewImagesReport(),
+		imagesSBOMDigests:     make(map[string]string),
+		imagesProvenance:      make(map[*image.Image]*attestation.Provenance),
+		imagesProvenanceMutex: &sync.Mutex{},
 	}
 }
 
@@ -86,6 +101,11 @@ type BuildPhase struct {
 	ImagesReport   *ImagesReport
 
 	buildContextArchive container_backend.BuildContextArchiver
+	imagesSBOMDigests   map[string]string
+
+	startedAt             time.Time
+	imagesProvenance      map[*image.Image]*attestation.Provenance
+	imagesProvenanceMutex *sync.Mutex
 }
 
 const (
@@ -174,6 +194,7 @@ type ReportImageRecord struct {
 	DockerImageDigest string
 	DockerImageName   string
 	Rebuilt           bool
+	SBOMDigest        string `json:",omitempty"`
 }
 
 func (phase *BuildPhase) Name() string {
@@ -184,6 +205,7 @@ func (phase *BuildPhase) BeforeImages(ctx context.Context) error {
 	if err := phase.Conveyor.StorageManager.InitCache(ctx); err != nil {
 		return fmt.Errorf("unable to init storage manager cache: %w", err)
 	}
+	phase.startedAt = time.Now()
 	return nil
 }
 
@@ -250,6 +272,32 @@ func (phase *BuildPhase) AfterImages(ctx context.Context) error {
 				}
 			}
 
+			if img.IsFinal() && phase.SBOM {
+				if err := phase.publishImageSBOM(ctx, img); err != nil {
+					return fmt.Errorf("unable to publish image %q SBOM: %w", name, err)
+				}
+			}
+
+			if img.IsFinal() && phase.Provenance {
+				if err := phase.publishImageProvenance(ctx, img); err != nil {
+					return fmt.Errorf("unable to publish image %q provenance: %w", name, err)
+				}
+			}
+
+			if img.IsFinal() && phase.Signer != nil {
+				stageImage := img.GetLastNonEmptyStage().GetStageImage().Image
+				if err := phase.publishImageSignature(ctx, img.GetLogName(), stageImage.GetFinalStageDescription(), stageImage.GetStageDescription()); err != nil {
+					return fmt.Errorf("unable to sign image %q: %w", name, err)
+				}
+			}
+
+			if img.IsFinal() && phase.Output != nil {
+				stageImage := img.GetLastNonEmptyStage().GetStageImage().Image
+				if err := phase.exportImage(ctx, name, img.GetLogName(), outputImagePlatform{finalDesc: stageImage.GetFinalStageDescription(), desc: stageImage.GetStageDescription()}, nil); err != nil {
+					return fmt.Errorf("unable to export image %q: %w", name, err)
+				}
+			}
+
 			// TODO: Separate LocalStagesStorage and RepoStagesStorage interfaces, local should not include metadata publishing methods at all
 			if _, isLocal := phase.Conveyor.StorageManager.GetStagesStorage().(*storage.LocalStagesStorage); !isLocal {
 				if err := phase.publishImageMetadata(ctx, name, img); err != nil {
@@ -288,6 +336,40 @@ func (phase *BuildPhase) AfterImages(ctx context.Context) error {
 					}
 				}
 			}
+
+			if img.IsFinal() && phase.SBOM {
+				for _, pImg := range img.Images {
+					if err := phase.publishImageSBOM(ctx, pImg); err != nil {
+						return fmt.Errorf("unable to publish image %q SBOM for platform %q: %w", name, pImg.TargetPlatform, err)
+					}
+				}
+			}
+
+			if img.IsFinal() && phase.Provenance {
+				for _, pImg := range img.Images {
+					if err := phase.publishImageProvenance(ctx, pImg); err != nil {
+						return fmt.Errorf("unable to publish image %q provenance for platform %q: %w", name, pImg.TargetPlatform, err)
+					}
+				}
+			}
+
+			if img.IsFinal() && phase.Signer != nil {
+				if err := phase.publishImageSignature(ctx, logging.ImageLogName(name, false), img.GetFinalStageDescription(), img.GetStageDescription()); err != nil {
+					return fmt.Errorf("unable to sign image %q: %w", name, err)
+				}
+			}
+
+			if img.IsFinal() && phase.Output != nil {
+				var platforms []outputImagePlatform
+				for _, pImg := range img.Images {
+					stageImage := pImg.GetLastNonEmptyStage().GetStageImage().Image
+					platforms = append(platforms, outputImagePlatform{platform: pImg.TargetPlatform, finalDesc: stageImage.GetFinalStageDescription(), desc: stageImage.GetStageDescription()})
+				}
+
+				if err := phase.exportImage(ctx, name, logging.ImageLogName(name, false), outputImagePlatform{finalDesc: img.GetFinalStageDescription(), desc: img.GetStageDescription()}, platforms); err != nil {
+					return fmt.Errorf("unable to export image %q: %w", name, err)
+				}
+			}
 		}
 	}
 
@@ -469,6 +551,7 @@ func (phase *BuildPhase) createReport(ctx context.Context) error {
 				DockerImageDigest: desc.Info.GetDigest(),
 				DockerImageName:   desc.Info.Name,
 				Rebuilt:           img.GetRebuilt(),
+				SBOMDigest:        phase.getImageSBOMDigest(desc),
 			}
 
 			if os.Getenv("WERF_ENABLE_REPORT_BY_PLATFORM") == "1" {
@@ -569,6 +652,21 @@ func (phase *BuildPhase) BeforeImageStages(ctx context.Context, img *image.Image
 func (phase *BuildPhase) AfterImageStages(ctx context.Context, img *image.Image) error {
 	img.SetLastNonEmptyStage(phase.StagesIterator.PrevNonEmptyStage)
 	img.SetContentDigest(phase.StagesIterator.PrevNonEmptyStage.GetContentDigest())
+
+	if phase.Provenance && img.IsFinal() && !phase.ShouldBeBuiltMode {
+		provenance, err := newImageProvenance(ctx, phase.Conveyor, img)
+		if err != nil {
+			return fmt.Errorf("unable to get image %s provenance: %w", img.GetLogName(), err)
+		}
+
+		startedOn, finishedOn := phase.startedAt, time.Now()
+		provenance.RunDetails.Metadata = &attestation.BuildMetadata{StartedOn: &startedOn, FinishedOn: &finishedOn}
+
+		phase.imagesProvenanceMutex.Lock()
+		phase.imagesProvenance[img] = provenance
+		phase.imagesProvenanceMutex.Unlock()
+	}
+
 	return nil
 }
 
@@ -707,7 +805,7 @@ func (phase *BuildPhase) OnImageStage(ctx context.Context, img *image.Image, stg
 	})
 }
 
-func (phase *BuildPhase) onImageStage(ctx context.Context, img *image.Image, stg stage.Interface) error {
+func (phase *BuildPhase) onImageStage(ctx context.Context, img *image.Image, stg stage.Interface) (err error) {
 	if err := stg.FetchDependencies(ctx, phase.Conveyor, phase.Conveyor.ContainerBackend, docker_registry.API()); err != nil {
 		return fmt.Errorf("unable to fetch dependencies for stage %s: %w", stg.LogDetailedName(), err)
 	}
@@ -724,9 +822,9 @@ func (phase *BuildPhase) onImageStage(ctx context.Context, img *image.Image, stg
 		}
 	}
 
-	foundSuitableStage, cleanupFunc, err := phase.calculateStage(ctx, img, stg)
-	if cleanupFunc != nil {
-		defer cleanupFunc()
+	foundSuitableStage, releaseFunc, err := phase.calculateStage(ctx, img, stg)
+	if releaseFunc != nil {
+		defer func() { releaseFunc(err) }()
 	}
 	if err != nil {
 		return err
@@ -891,7 +989,9 @@ func (phase *BuildPhase) fetchBaseImageForStage(ctx context.Context, img *image.
 	return nil
 }
 
-func (phase *BuildPhase) calculateStage(ctx context.Context, img *image.Image, stg stage.Interface) (bool, func(), error) {
+// calculateStage calculates the stage digest and finds the suitable stage either in the stages storage or among the stages of the images processed in parallel.
+// The returned release function must be called with the stage processing result if the image owns the stage build.
+func (phase *BuildPhase) calculateStage(ctx context.Context, img *image.Image, stg stage.Interface) (bool, func(error), error) {
 	// FIXME(stapel-to-buildah): store StageImage-s everywhere in stage and build pkgs
 	stageDependencies, err := stg.GetDependencies(ctx, phase.Conveyor, phase.Conveyor.ContainerBackend, phase.StagesIterator.GetPrevImage(img, stg), phase.StagesIterator.GetPrevBuiltImage(img, stg), phase.buildContextArchive)
 	if err != nil {
@@ -917,38 +1017,57 @@ func (phase *BuildPhase) calculateStage(ctx context.Context, img *image.Image, s
 	}
 	stg.SetDigest(stageDigest)
 
-	logboek.Context(ctx).Info().LogProcessInline("Lock parallel conveyor tasks by stage digest %s", stg.LogDetailedName()).
-		Options(func(options types.LogProcessInlineOptionsInterface) {
-			if !phase.Conveyor.Parallel {
-				options.Mute()
+	var releaseFunc func(error)
+	foundSuitableStage := false
+
+	if build, isOwner := phase.Conveyor.stagesBuilds.acquire(img.TargetPlatform, stageDigest, img.LogDetailedName()); isOwner {
+		releaseFunc = func(err error) {
+			var stageDesc *imagePkg.StageDescription
+			if stg.GetStageImage() != nil {
+				stageDesc = stg.GetStageImage().Image.GetStageDescription()
 			}
-		}).
-		Do(phase.Conveyor.GetStageDigestMutex(stg.GetDigest()).Lock)
+			phase.Conveyor.stagesBuilds.finish(img.TargetPlatform, build, stageDesc, err)
+		}
 
-	foundSuitableStage := false
-	storageManager := phase.Conveyor.StorageManager
-	if stages, err := storageManager.GetStagesByDigestWithCache(ctx, stg.LogDetailedName(), stageDigest); err != nil {
-		return false, phase.Conveyor.GetStageDigestMutex(stg.GetDigest()).Unlock, err
+		storageManager := phase.Conveyor.StorageManager
+		if stages, err := storageManager.GetStagesByDigestWithCache(ctx, stg.LogDetailedName(), stageDigest); err != nil {
+			return false, releaseFunc, err
+		} else {
+			if stageDesc, err := storageManager.SelectSuitableStage(ctx, phase.Conveyor, stg, stages); err != nil {
+				return false, releaseFunc, err
+			} else if stageDesc != nil {
+				i := phase.Conveyor.GetOrCreateStageImage(stageDesc.Info.Name, phase.StagesIterator.GetPrevImage(img, stg), stg, img)
+				i.Image.SetStageDescription(stageDesc)
+				stg.SetStageImage(i)
+				foundSuitableStage = true
+			}
+		}
 	} else {
-		if stageDesc, err := storageManager.SelectSuitableStage(ctx, phase.Conveyor, stg, stages); err != nil {
-			return false, phase.Conveyor.GetStageDigestMutex(stg.GetDigest()).Unlock, err
-		} else if stageDesc != nil {
-			i := phase.Conveyor.GetOrCreateStageImage(stageDesc.Info.Name, phase.StagesIterator.GetPrevImage(img, stg), stg, img)
-			i.Image.SetStageDescription(stageDesc)
-			stg.SetStageImage(i)
-			foundSuitableStage = true
+		var stageDesc *imagePkg.StageDescription
+		if err := logboek.Context(ctx).Default().LogProcessInline("Waiting for stage %s being processed by %s", stg.LogDetailedName(), build.imageName).
+			DoError(func() error {
+				var err error
+				stageDesc, err = build.wait(ctx)
+				return err
+			}); err != nil {
+			return false, nil, err
 		}
+
+		i := phase.Conveyor.GetOrCreateStageImage(stageDesc.Info.Name, phase.StagesIterator.GetPrevImage(img, stg), stg, img)
+		i.Image.SetStageDescription(stageDesc)
+		stg.SetStageImage(i)
+		foundSuitableStage = true
 	}
 
 	stageContentSig, err := calculateDigest(ctx, fmt.Sprintf("%s-content", stg.Name()), "", stg, phase.Conveyor, calculateDigestOptions{TargetPlatform: img.TargetPlatform})
 	if err != nil {
-		return false, phase.Conveyor.GetStageDigestMutex(stg.GetDigest()).Unlock, fmt.Errorf("unable to calculate stage %s content digest: %w", stg.Name(), err)
+		return false, releaseFunc, fmt.Errorf("unable to calculate stage %s content digest: %w", stg.Name(), err)
 	}
 	stg.SetContentDigest(stageContentSig)
 
 	logboek.Context(ctx).Info().LogF("Stage %s content digest: %s\n", stg.LogDetailedName(), stageContentSig)
 
-	return foundSuitableStage, phase.Conveyor.GetStageDigestMutex(stg.GetDigest()).Unlock, nil
+	return foundSuitableStage, releaseFunc, nil
 }
 
 func (phase *BuildPhase) prepareStageInstructions(ctx context.Context, img *image.Image, stg stage.Interface) error {
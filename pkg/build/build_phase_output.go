@@ -0,0 +1,257 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/docker_registry"
+	imagePkg "github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/storage"
+	"github.com/werf/werf/pkg/werf"
+)
+
+type OutputType string
+
+const (
+	OutputOCI           OutputType = "oci"
+	OutputDockerArchive OutputType = "docker-archive"
+)
+
+// OutputOptions describes where the final images are exported after the build.
+type OutputOptions struct {
+	Type OutputType
+	Dest string
+}
+
+// ParseOutputOptions parses the comma separated key=value pairs, e.g. type=oci,dest=./out.
+func ParseOutputOptions(value string) (*OutputOptions, error) {
+	opts := &OutputOptions{}
+
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || val == "" {
+			return nil, fmt.Errorf("invalid output parameter %q: expected key=value", pair)
+		}
+
+		switch key {
+		case "type":
+			switch t := OutputType(val); t {
+			case OutputOCI, OutputDockerArchive:
+				opts.Type = t
+			default:
+				return nil, fmt.Errorf("unknown output type %q: expected %q or %q", val, OutputOCI, OutputDockerArchive)
+			}
+		case "dest":
+			opts.Dest = val
+		default:
+			return nil, fmt.Errorf("unknown output parameter %q: expected type or dest", key)
+		}
+	}
+
+	if opts.Type == "" {
+		return nil, fmt.Errorf("output type is required")
+	}
+
+	if opts.Dest == "" {
+		return nil, fmt.Errorf("output dest is required")
+	}
+
+	return opts, nil
+}
+
+// outputImagePlatform is the published image of the target platform, an empty platform means the image or the image index as a whole.
+type outputImagePlatform struct {
+	platform  string
+	finalDesc *imagePkg.StageDescription
+	desc      *imagePkg.StageDescription
+}
+
+// exportImage writes the published final image into the output destination:
+// the oci type creates the OCI image layout directory DEST/IMAGE_NAME containing the image or the multi-platform image index,
+// the docker-archive type creates the tarball DEST/IMAGE_NAME.tar loadable by `docker load`, one per platform for the multi-platform image.
+// The images built with the local stages storage are exported from the container backend.
+func (phase *BuildPhase) exportImage(ctx context.Context, name, logName string, index outputImagePlatform, platforms []outputImagePlatform) error {
+	if name == "" {
+		name = phase.Conveyor.ProjectName()
+	}
+
+	_, isLocal := phase.Conveyor.StorageManager.GetStagesStorage().(*storage.LocalStagesStorage)
+
+	return logboek.Context(ctx).Default().LogProcess("Export image %s", logName).DoError(func() error {
+		switch phase.Output.Type {
+		case OutputOCI:
+			layoutDir := filepath.Join(phase.Output.Dest, name)
+
+			var err error
+			if isLocal {
+				err = phase.writeLocalImageLayout(ctx, layoutDir, index, platforms)
+			} else {
+				err = phase.exportImagePlatform(ctx, index, func(dockerRegistry docker_registry.Interface, desc *imagePkg.StageDescription) error {
+					return dockerRegistry.PullImageLayout(ctx, layoutDir, desc.Info.Name, desc.Info.Tag, docker_registry.TransferOptions{})
+				})
+			}
+			if err != nil {
+				return err
+			}
+
+			logboek.Context(ctx).Default().LogFDetails("layout: %s\n", layoutDir)
+			return nil
+		case OutputDockerArchive:
+			if len(platforms) == 0 {
+				platforms = []outputImagePlatform{index}
+			}
+
+			for _, p := range platforms {
+				archivePath := filepath.Join(phase.Output.Dest, name+".tar")
+				if p.platform != "" {
+					archivePath = filepath.Join(phase.Output.Dest, fmt.Sprintf("%s_%s.tar", name, strings.ReplaceAll(p.platform, "/", "-")))
+				}
+
+				var err error
+				if isLocal {
+					err = writeImageArchive(archivePath, func(w io.Writer) error {
+						return phase.saveLocalImage(ctx, w, p.desc.Info.Name)
+					})
+				} else {
+					err = phase.exportImagePlatform(ctx, p, func(dockerRegistry docker_registry.Interface, desc *imagePkg.StageDescription) error {
+						return writeImageArchive(archivePath, func(w io.Writer) error {
+							return dockerRegistry.PullImageArchive(ctx, w, desc.Info.Name)
+						})
+					})
+				}
+				if err != nil {
+					return err
+				}
+
+				logboek.Context(ctx).Default().LogFDetails("archive: %s\n", archivePath)
+			}
+
+			return nil
+		default:
+			panic(fmt.Sprintf("unexpected output type %q", phase.Output.Type))
+		}
+	})
+}
+
+func (phase *BuildPhase) exportImagePlatform(ctx context.Context, p outputImagePlatform, exportFunc func(dockerRegistry docker_registry.Interface, desc *imagePkg.StageDescription) error) error {
+	desc, dockerRegistry, err := phase.Conveyor.getPublishedImageRegistry(p.finalDesc, p.desc)
+	if err != nil {
+		return err
+	}
+
+	if err := exportFunc(dockerRegistry, desc); err != nil {
+		return fmt.Errorf("unable to export image %s: %w", desc.Info.Name, err)
+	}
+
+	return nil
+}
+
+// saveLocalImage writes the docker-archive of the image from the container backend.
+func (phase *BuildPhase) saveLocalImage(ctx context.Context, w io.Writer, ref string) error {
+	stream, err := phase.Conveyor.ContainerBackend.SaveImageToStream(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("unable to save image %q: %w", ref, err)
+	}
+	defer stream.Close()
+
+	if _, err := io.Copy(w, stream); err != nil {
+		return fmt.Errorf("unable to save image %q: %w", ref, err)
+	}
+
+	return nil
+}
+
+// writeLocalImageLayout saves the images of the container backend into the temporary archives and writes them into the layout.
+// The multi-platform image is written as the image index, which has no tag in the local stages storage and is referenced by the digest.
+func (phase *BuildPhase) writeLocalImageLayout(ctx context.Context, layoutDir string, index outputImagePlatform, platforms []outputImagePlatform) error {
+	isMultiplatform := len(platforms) > 0
+	if !isMultiplatform {
+		platforms = []outputImagePlatform{index}
+	}
+
+	tmpDir, err := os.MkdirTemp(werf.GetTmpDir(), "output-image-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var imageIndex v1.ImageIndex = mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+	var img v1.Image
+	for i, p := range platforms {
+		archivePath := filepath.Join(tmpDir, fmt.Sprintf("%d.tar", i))
+		if err := writeImageArchive(archivePath, func(w io.Writer) error {
+			return phase.saveLocalImage(ctx, w, p.desc.Info.Name)
+		}); err != nil {
+			return err
+		}
+
+		img, err = tarball.ImageFromPath(archivePath, nil)
+		if err != nil {
+			return fmt.Errorf("unable to read image %q archive: %w", p.desc.Info.Name, err)
+		}
+
+		if isMultiplatform {
+			platform, err := v1.ParsePlatform(p.platform)
+			if err != nil {
+				return fmt.Errorf("unable to parse platform %q: %w", p.platform, err)
+			}
+
+			imageIndex = mutate.AppendManifests(imageIndex, mutate.IndexAddendum{
+				Add:        img,
+				Descriptor: v1.Descriptor{Platform: platform},
+			})
+		}
+	}
+
+	if !isMultiplatform {
+		if err := docker_registry.WriteImageLayout(layoutDir, index.desc.Info.Tag, img, docker_registry.TransferOptions{}); err != nil {
+			return fmt.Errorf("unable to export image %s: %w", index.desc.Info.Name, err)
+		}
+		return nil
+	}
+
+	if err := docker_registry.WriteImageLayout(layoutDir, "", imageIndex, docker_registry.TransferOptions{}); err != nil {
+		return fmt.Errorf("unable to export image index: %w", err)
+	}
+
+	return nil
+}
+
+// writeImageArchive writes the archive into the temporary file first to not leave the broken archive on failure.
+func writeImageArchive(archivePath string, writeFunc func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(archivePath), os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create dir %q: %w", filepath.Dir(archivePath), err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(archivePath), filepath.Base(archivePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := writeFunc(tmpFile); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to close %q: %w", tmpFile.Name(), err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), archivePath); err != nil {
+		return fmt.Errorf("unable to rename %q to %q: %w", tmpFile.Name(), archivePath, err)
+	}
+
+	return nil
+}
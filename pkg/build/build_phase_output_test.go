@@ -0,0 +1,42 @@
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOutputOptions(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected *OutputOptions
+	}{
+		{value: "type=oci,dest=./out", expected: &OutputOptions{Type: OutputOCI, Dest: "./out"}},
+		{value: "dest=/tmp/out, type=docker-archive", expected: &OutputOptions{Type: OutputDockerArchive, Dest: "/tmp/out"}},
+		{value: "type=oci,dest=./a,dest=./b", expected: &OutputOptions{Type: OutputOCI, Dest: "./b"}},
+		{value: ""},
+		{value: "oci"},
+		{value: "type=oci,dest="},
+		{value: "type=oci,dest=./out,"},
+		{value: "type=tar,dest=./out"},
+		{value: "type=oci,dest=./out,compression=gzip"},
+		{value: "dest=./out"},
+		{value: "type=oci"},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			opts, err := ParseOutputOptions(tt.value)
+			if tt.expected == nil {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", opts)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(opts, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/attestation"
+	"github.com/werf/werf/pkg/build/image"
+	"github.com/werf/werf/pkg/docker_registry"
+	"github.com/werf/werf/pkg/signature"
+	"github.com/werf/werf/pkg/werf"
+)
+
+// publishImageProvenance pushes the SLSA provenance of the final image into the image repository as a cosign-compatible attestation.
+// The attestation is tagged as sha256-<image digest>.att, is signed if the signer is configured and is not recreated if already exists.
+func (phase *BuildPhase) publishImageProvenance(ctx context.Context, img *image.Image) error {
+	provenance, ok := phase.imagesProvenance[img]
+	if !ok {
+		return nil
+	}
+
+	stageImage := img.GetLastNonEmptyStage().GetStageImage().Image
+	desc, dockerRegistry, err := phase.Conveyor.getPublishedImageRegistry(stageImage.GetFinalStageDescription(), stageImage.GetStageDescription())
+	if err != nil {
+		return err
+	}
+
+	return logboek.Context(ctx).Default().LogProcess("Publish image %s provenance", img.GetLogName()).DoError(func() error {
+		imageDigest := desc.Info.GetDigest()
+		if imageDigest == "" {
+			return fmt.Errorf("unable to get image %s digest", desc.Info.Name)
+		}
+
+		imageReference := fmt.Sprintf("%s@%s", desc.Info.Repository, imageDigest)
+		attestationReference := fmt.Sprintf("%s:%s", desc.Info.Repository, attestation.AttestationTag(imageDigest))
+
+		attestationInfo, err := dockerRegistry.TryGetRepoImage(ctx, attestationReference)
+		if err != nil {
+			return fmt.Errorf("unable to get attestation %s: %w", attestationReference, err)
+		}
+		if attestationInfo != nil || phase.ShouldBeBuiltMode {
+			logboek.Context(ctx).Default().LogFDetails("name: %s\n", attestationReference)
+			return nil
+		}
+
+		statement := attestation.NewProvenanceStatement(desc.Info.Repository, imageDigest, provenance)
+		envelope, err := attestation.SignStatement(ctx, statement, phase.Signer)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("unable to marshal attestation: %w", err)
+		}
+
+		if _, err := dockerRegistry.PushReferrer(ctx, attestation.AttestationTag(imageDigest), docker_registry.PushArtifactOptions{
+			ArtifactType:     signature.CosignConfigMediaType,
+			SubjectReference: imageReference,
+			Layers: []docker_registry.ArtifactLayer{
+				{
+					MediaType:   attestation.DSSEEnvelopeMediaType,
+					Data:        data,
+					Annotations: map[string]string{attestation.PredicateTypeAnnotation: attestation.SLSAProvenancePredicateType},
+				},
+			},
+		}); err != nil {
+			return fmt.Errorf("unable to push attestation %s: %w", attestationReference, err)
+		}
+
+		logboek.Context(ctx).Default().LogFDetails("name: %s\n", attestationReference)
+		if phase.Signer == nil {
+			logboek.Context(ctx).Warn().LogF("WARNING: Provenance of image %s is not signed, specify --sign-key to sign it\n", img.GetLogName())
+		}
+
+		return nil
+	})
+}
+
+// newImageProvenance describes how the image is built: werf.yaml image parameters, stages, base image and git commits.
+// The same provenance is calculated by the verifier for the current repo state to be compared with the published one.
+func newImageProvenance(ctx context.Context, c *Conveyor, img *image.Image) (*attestation.Provenance, error) {
+	provenance := &attestation.Provenance{
+		BuildDefinition: attestation.BuildDefinition{
+			BuildType: attestation.BuildType,
+			ExternalParameters: attestation.ExternalParameters{
+				Project:  c.ProjectName(),
+				Image:    img.GetName(),
+				Platform: img.TargetPlatform,
+			},
+		},
+		RunDetails: attestation.RunDetails{
+			Builder: attestation.Builder{
+				ID:      attestation.BuilderID,
+				Version: map[string]string{"werf": werf.Version},
+			},
+		},
+	}
+
+	if cfg := img.DockerfileImageConfig; img.IsDockerfileImage && cfg != nil {
+		dockerfile := &attestation.DockerfileParameters{
+			Path:    cfg.Dockerfile,
+			Context: cfg.Context,
+			Target:  cfg.Target,
+		}
+
+		if len(cfg.Args) > 0 {
+			dockerfile.BuildArgs = make(map[string]string, len(cfg.Args))
+			for name, value := range cfg.Args {
+				dockerfile.BuildArgs[name] = fmt.Sprint(value)
+			}
+		}
+
+		provenance.BuildDefinition.ExternalParameters.Dockerfile = dockerfile
+	}
+
+	for _, stg := range img.GetStages() {
+		if stg.GetDigest() == "" || stg.GetStageImage() == nil || stg.GetStageImage().Image.GetStageDescription() == nil {
+			continue
+		}
+
+		provenance.BuildDefinition.InternalParameters.Stages = append(provenance.BuildDefinition.InternalParameters.Stages, attestation.Stage{
+			Name:   string(stg.Name()),
+			Digest: stg.GetDigest(),
+			Image:  stg.GetStageImage().Image.GetStageDescription().Info.Name,
+		})
+	}
+
+	if reference := img.GetBaseImageReference(); reference != "" {
+		dependency := attestation.ResourceDescriptor{Name: "base-image", URI: reference}
+		if _, digest, found := strings.Cut(img.GetBaseImageRepoDigest(), "@"); found {
+			dependency.Digest = attestation.NewDigestSet(digest)
+		}
+
+		provenance.BuildDefinition.ResolvedDependencies = append(provenance.BuildDefinition.ResolvedDependencies, dependency)
+	}
+
+	sources, err := getImageGitSources(ctx, c, img)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get image %s git sources: %w", img.GetName(), err)
+	}
+
+	for _, source := range sources {
+		provenance.BuildDefinition.ResolvedDependencies = append(provenance.BuildDefinition.ResolvedDependencies, attestation.ResourceDescriptor{
+			Name:   source.Name,
+			URI:    gitSourceURI(source.URL),
+			Digest: map[string]string{attestation.GitCommitDigestAlgorithm: source.Commit},
+		})
+	}
+
+	return provenance, nil
+}
+
+func gitSourceURI(url string) string {
+	if url == "" {
+		return ""
+	}
+
+	return "git+" + url
+}
@@ -0,0 +1,158 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/build/image"
+	"github.com/werf/werf/pkg/docker_registry"
+	imagePkg "github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/sbom"
+)
+
+// publishImageSBOM generates SBOM of the final image and pushes it into the image repository as an OCI artifact referring to the image.
+// The artifact is tagged as sha256-<image digest>.sbom and is not regenerated if already exists.
+func (phase *BuildPhase) publishImageSBOM(ctx context.Context, img *image.Image) error {
+	stageImage := img.GetLastNonEmptyStage().GetStageImage().Image
+	desc, dockerRegistry, err := phase.Conveyor.getPublishedImageRegistry(stageImage.GetFinalStageDescription(), stageImage.GetStageDescription())
+	if err != nil {
+		return err
+	}
+
+	return logboek.Context(ctx).Default().LogProcess("Publish image %s SBOM", img.GetLogName()).DoError(func() error {
+		imageDigest := desc.Info.GetDigest()
+		if imageDigest == "" {
+			return fmt.Errorf("unable to get image %s digest", desc.Info.Name)
+		}
+
+		imageReference := fmt.Sprintf("%s@%s", desc.Info.Repository, imageDigest)
+		sbomReference := fmt.Sprintf("%s:%s", desc.Info.Repository, sbom.ArtifactTag(imageDigest))
+
+		sbomInfo, err := dockerRegistry.TryGetRepoImage(ctx, sbomReference)
+		if err != nil {
+			return fmt.Errorf("unable to get SBOM %s: %w", sbomReference, err)
+		}
+		if sbomInfo != nil {
+			logboek.Context(ctx).Default().LogFDetails("name: %s\n", sbomReference)
+			phase.imagesSBOMDigests[imageDigest] = sbomInfo.GetDigest()
+			return nil
+		}
+
+		if phase.ShouldBeBuiltMode {
+			return nil
+		}
+
+		doc := &sbom.Document{
+			ImageName:   desc.Info.Name,
+			ImageDigest: imageDigest,
+			Created:     time.Now(),
+		}
+
+		if doc.Sources, err = getImageGitSources(ctx, phase.Conveyor, img); err != nil {
+			return fmt.Errorf("unable to get image %s git sources: %w", img.GetName(), err)
+		}
+
+		if err := scanImageFilesystem(ctx, dockerRegistry, imageReference, doc); err != nil {
+			return err
+		}
+
+		data, err := doc.Marshal(phase.SBOMFormat)
+		if err != nil {
+			return fmt.Errorf("unable to marshal SBOM: %w", err)
+		}
+
+		sbomDigest, err := dockerRegistry.PushReferrer(ctx, sbom.ArtifactTag(imageDigest), docker_registry.PushArtifactOptions{
+			ArtifactType:     phase.SBOMFormat.MediaType(),
+			SubjectReference: imageReference,
+			Layers: []docker_registry.ArtifactLayer{
+				{MediaType: phase.SBOMFormat.MediaType(), Data: data},
+			},
+			Annotations: map[string]string{
+				"org.opencontainers.image.created": doc.Created.UTC().Format(time.RFC3339),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to push SBOM %s: %w", sbomReference, err)
+		}
+
+		logboek.Context(ctx).Default().LogFDetails("name: %s\n", sbomReference)
+		logboek.Context(ctx).Default().LogFDetails("packages: %d\n", len(doc.Packages))
+		phase.imagesSBOMDigests[imageDigest] = sbomDigest
+
+		return nil
+	})
+}
+
+func (phase *BuildPhase) getImageSBOMDigest(desc *imagePkg.StageDescription) string {
+	return phase.imagesSBOMDigests[desc.Info.GetDigest()]
+}
+
+// getImageGitSources returns the project commit and the commits of remote git repositories used by the image.
+func getImageGitSources(ctx context.Context, c *Conveyor, img *image.Image) ([]sbom.Source, error) {
+	localGitRepo := c.giterminismManager.LocalGitRepo()
+
+	url, err := localGitRepo.RemoteOriginUrl(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []sbom.Source{
+		{
+			Name:   localGitRepo.GetName(),
+			URL:    url,
+			Commit: c.giterminismManager.HeadCommit(),
+		},
+	}
+
+	for _, gitMapping := range img.GetLastNonEmptyStage().GetGitMappings() {
+		if gitMapping.IsLocal() {
+			continue
+		}
+
+		url, err := gitMapping.GitRepo().RemoteOriginUrl(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		commitInfo, err := gitMapping.GetLatestCommitInfo(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, sbom.Source{
+			Name:   gitMapping.GetFullName(),
+			URL:    url,
+			Commit: commitInfo.Commit,
+		})
+	}
+
+	return sources, nil
+}
+
+func scanImageFilesystem(ctx context.Context, dockerRegistry docker_registry.Interface, reference string, doc *sbom.Document) error {
+	fsReader, fsWriter := io.Pipe()
+
+	pullErrCh := make(chan error, 1)
+	go func() {
+		err := dockerRegistry.PullImageFilesystem(ctx, fsWriter, reference)
+		fsWriter.CloseWithError(err)
+		pullErrCh <- err
+	}()
+
+	scanErr := sbom.ScanFilesystem(ctx, fsReader, doc)
+	fsReader.Close()
+
+	if err := <-pullErrCh; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		return fmt.Errorf("unable to pull image %s filesystem: %w", reference, err)
+	}
+
+	if scanErr != nil {
+		return fmt.Errorf("unable to scan image %s filesystem: %w", reference, scanErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package build
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/docker_registry"
+	imagePkg "github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/signature"
+)
+
+// publishImageSignature signs the final image manifest digest and pushes the cosign-compatible signature into the image repository.
+// The signature is tagged as sha256-<image digest>.sig, so it could be verified with `cosign verify --key`, and is not recreated if already exists.
+func (phase *BuildPhase) publishImageSignature(ctx context.Context, logName string, finalDesc, desc *imagePkg.StageDescription) error {
+	desc, dockerRegistry, err := phase.Conveyor.getPublishedImageRegistry(finalDesc, desc)
+	if err != nil {
+		return err
+	}
+
+	return logboek.Context(ctx).Default().LogProcess("Sign image %s", logName).DoError(func() error {
+		imageDigest := desc.Info.GetDigest()
+		if imageDigest == "" {
+			return fmt.Errorf("unable to get image %s digest", desc.Info.Name)
+		}
+
+		imageReference := fmt.Sprintf("%s@%s", desc.Info.Repository, imageDigest)
+		signatureReference := fmt.Sprintf("%s:%s", desc.Info.Repository, signature.CosignSignatureTag(imageDigest))
+
+		signatureInfo, err := dockerRegistry.TryGetRepoImage(ctx, signatureReference)
+		if err != nil {
+			return fmt.Errorf("unable to get signature %s: %w", signatureReference, err)
+		}
+		if signatureInfo != nil || phase.ShouldBeBuiltMode {
+			logboek.Context(ctx).Default().LogFDetails("name: %s\n", signatureReference)
+			return nil
+		}
+
+		sig, err := signature.SignImage(ctx, phase.Signer, desc.Info.Repository, imageDigest)
+		if err != nil {
+			return err
+		}
+
+		if _, err := dockerRegistry.PushReferrer(ctx, signature.CosignSignatureTag(imageDigest), docker_registry.PushArtifactOptions{
+			ArtifactType:     signature.CosignConfigMediaType,
+			SubjectReference: imageReference,
+			Layers: []docker_registry.ArtifactLayer{
+				{
+					MediaType:   signature.CosignSimpleSigningMediaType,
+					Data:        sig.Payload,
+					Annotations: sig.Annotations,
+				},
+			},
+		}); err != nil {
+			return fmt.Errorf("unable to push signature %s: %w", signatureReference, err)
+		}
+
+		logboek.Context(ctx).Default().LogFDetails("name: %s\n", signatureReference)
+
+		return nil
+	})
+}
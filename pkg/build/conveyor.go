@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/werf/logboek"
 	stylePkg "github.com/werf/logboek/pkg/style"
@@ -20,13 +19,13 @@ import (
 	"github.com/werf/werf/pkg/config"
 	"github.com/werf/werf/pkg/container_backend"
 	"github.com/werf/werf/pkg/container_backend/thirdparty/platformutil"
+	"github.com/werf/werf/pkg/docker_registry"
 	"github.com/werf/werf/pkg/git_repo"
 	"github.com/werf/werf/pkg/giterminism_manager"
 	imagePkg "github.com/werf/werf/pkg/image"
 	"github.com/werf/werf/pkg/storage"
 	"github.com/werf/werf/pkg/storage/manager"
 	"github.com/werf/werf/pkg/util"
-	"github.com/werf/werf/pkg/util/parallel"
 )
 
 type Conveyor struct {
@@ -61,9 +60,9 @@ type Conveyor struct {
 
 	ConveyorOptions
 
-	mutex            sync.Mutex
-	serviceRWMutex   map[string]*sync.RWMutex
-	stageDigestMutex map[string]*sync.Mutex
+	mutex          sync.Mutex
+	serviceRWMutex map[string]*sync.RWMutex
+	stagesBuilds   *stagesBuilds
 }
 
 type ConveyorOptions struct {
@@ -72,6 +71,7 @@ type ConveyorOptions struct {
 	LocalGitRepoVirtualMergeOptions stage.VirtualMergeOptions
 	TargetPlatforms                 []string
 	DeferBuildLog                   bool
+	KeepGoing                       bool
 
 	ImagesToProcess
 }
@@ -101,8 +101,16 @@ func NewConveyor(werfConfig *config.WerfConfig, giterminismManager giterminism_m
 
 		ConveyorOptions: opts,
 
-		serviceRWMutex:   map[string]*sync.RWMutex{},
-		stageDigestMutex: map[string]*sync.Mutex{},
+		serviceRWMutex: map[string]*sync.RWMutex{},
+		stagesBuilds:   newStagesBuilds(),
+	}
+
+	// werf.yaml mirrors are added after the host config mirrors, so the host settings have priority
+	for _, registryMirror := range werfConfig.Meta.Build.RegistryMirrors {
+		docker_registry.AddRegistryMirrors(docker_registry.RegistryMirror{
+			Registry: registryMirror.Registry,
+			Mirrors:  registryMirror.Mirrors,
+		})
 	}
 
 	c.imagesTree = image.NewImagesTree(werfConfig, image.ImagesTreeOptions{
@@ -224,19 +232,6 @@ func (c *Conveyor) SetBaseImagesRepoErrCache(key string, err error) {
 	c.baseImagesRepoErrCache[key] = err
 }
 
-func (c *Conveyor) GetStageDigestMutex(stage string) *sync.Mutex {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	m, ok := c.stageDigestMutex[stage]
-	if !ok {
-		m = &sync.Mutex{}
-		c.stageDigestMutex[stage] = m
-	}
-
-	return m
-}
-
 func (c *Conveyor) GetLocalGitRepoVirtualMergeOptions() stage.VirtualMergeOptions {
 	return c.ConveyorOptions.LocalGitRepoVirtualMergeOptions
 }
@@ -443,6 +438,24 @@ func (c *Conveyor) GetImageInfoGetters(opts imagePkg.InfoGetterOptions) ([]*imag
 	return imagesGetters, nil
 }
 
+// getPublishedImageRegistry returns the description of the image in the final repo if the image has been published there, otherwise in the primary repo, and the registry of that repo.
+func (c *Conveyor) getPublishedImageRegistry(finalDesc, desc *imagePkg.StageDescription) (*imagePkg.StageDescription, docker_registry.Interface, error) {
+	stagesStorage := c.StorageManager.GetFinalStagesStorage()
+	if finalDesc == nil {
+		finalDesc = desc
+		stagesStorage = c.StorageManager.GetStagesStorage()
+	}
+
+	switch typedStagesStorage := stagesStorage.(type) {
+	case *storage.RepoStagesStorage:
+		return finalDesc, typedStagesStorage.DockerRegistry, nil
+	case *storage.OCILayoutStagesStorage:
+		return finalDesc, typedStagesStorage.DockerRegistry, nil
+	default:
+		return nil, nil, fmt.Errorf("image artifacts can only be published into the container registry, got %s", stagesStorage.String())
+	}
+}
+
 func (c *Conveyor) GetExportedImages() (res []*image.Image) {
 	for _, img := range c.imagesTree.GetImages() {
 		if img.IsArtifact {
@@ -600,22 +613,54 @@ func (c *Conveyor) runPhases(ctx context.Context, phases []Phase, logImages bool
 func (c *Conveyor) doImages(ctx context.Context, phases []Phase, logImages bool) error {
 	if c.Parallel && len(c.imagesTree.GetImages()) > 1 {
 		return c.doImagesInParallel(ctx, phases, logImages)
-	} else {
-		for _, img := range c.imagesTree.GetImages() {
-			if err := c.doImage(ctx, img, phases); err != nil {
+	}
+
+	// images are ordered by dependencies, so the failed dependencies of the image are already known
+	failedImages := map[*image.Image]bool{}
+	var imageErrors []error
+
+imagesLoop:
+	for _, img := range c.imagesTree.GetImages() {
+		for _, dependencyImg := range c.imagesTree.GetImageDependencies(img) {
+			if failedImages[dependencyImg] {
+				logboek.Context(ctx).Warn().LogF("Skipping %s: depends on the failed %s\n", img.LogDetailedName(), dependencyImg.LogDetailedName())
+				failedImages[img] = true
+				continue imagesLoop
+			}
+		}
+
+		if err := c.doImage(ctx, img, phases); err != nil {
+			if !c.KeepGoing {
 				return err
 			}
+
+			failedImages[img] = true
+			imageErrors = append(imageErrors, err)
 		}
 	}
 
-	return nil
+	return errors.Join(imageErrors...)
 }
 
 func (c *Conveyor) doImagesInParallel(ctx context.Context, phases []Phase, logImages bool) error {
+	images := c.imagesTree.GetImages()
+
+	imageTaskIds := make(map[*image.Image]int, len(images))
+	for taskId, img := range images {
+		imageTaskIds[img] = taskId
+	}
+
+	dependencies := make([][]int, len(images))
+	for taskId, img := range images {
+		for _, dependencyImg := range c.imagesTree.GetImageDependencies(img) {
+			dependencies[taskId] = append(dependencies[taskId], imageTaskIds[dependencyImg])
+		}
+	}
+
 	if logImages {
 		blockMsg := "Concurrent build plan"
 		if c.ParallelTasksLimit > 0 {
-			blockMsg = fmt.Sprintf("%s (no more than %d images at the same time)", blockMsg, c.ParallelTasksLimit)
+			blockMsg = fmt.Sprintf("%s (no more than %d stages at the same time)", blockMsg, c.ParallelTasksLimit)
 		}
 
 		logboek.Context(ctx).LogBlock(blockMsg).
@@ -623,75 +668,31 @@ func (c *Conveyor) doImagesInParallel(ctx context.Context, phases []Phase, logIm
 				options.Style(stylePkg.Highlight())
 			}).
 			Do(func() {
-				for setId := range c.imagesTree.GetImagesSets() {
-					logboek.Context(ctx).LogFHighlight("Set #%d:\n", setId)
-					for _, img := range c.imagesTree.GetImagesSets()[setId] {
+				for _, img := range images {
+					var dependencyNames []string
+					for _, dependencyImg := range c.imagesTree.GetImageDependencies(img) {
+						dependencyNames = append(dependencyNames, dependencyImg.LogDetailedName())
+					}
+
+					if len(dependencyNames) > 0 {
+						logboek.Context(ctx).LogFHighlight("- %s (after %s)\n", img.LogDetailedName(), strings.Join(dependencyNames, ", "))
+					} else {
 						logboek.Context(ctx).LogLnHighlight("-", img.LogDetailedName())
 					}
-					logboek.Context(ctx).LogOptionalLn()
 				}
+				logboek.Context(ctx).LogOptionalLn()
 			})
 	}
 
-	var setImageExecutionTimesArray [][]string
-	for setId := range c.imagesTree.GetImagesSets() {
-		numberOfTasks := len(c.imagesTree.GetImagesSets()[setId])
-		numberOfWorkers := int(c.ParallelTasksLimit)
+	report := newImagesCriticalPathReport(images, dependencies)
 
-		var setImageExecutionTimes []string
-		if err := parallel.DoTasks(ctx, numberOfTasks, parallel.DoTasksOptions{
-			InitDockerCLIForEachWorker: true,
-			MaxNumberOfWorkers:         numberOfWorkers,
-			LiveOutput:                 true,
-		}, func(ctx context.Context, taskId int) error {
-			taskImage := c.imagesTree.GetImagesSets()[setId][taskId]
+	err := c.doImagesStagesInParallel(ctx, images, dependencies, phases, report)
 
-			var taskPhases []Phase
-			for _, phase := range phases {
-				taskPhases = append(taskPhases, phase.Clone())
-			}
-
-			// execution time calculation
-			taskStartTime := time.Now()
-			{
-				if err := c.doImage(ctx, taskImage, taskPhases); err != nil {
-					return err
-				}
-
-				taskEndTime := time.Now()
-				taskDuration := taskEndTime.Sub(taskStartTime)
-				setImageExecutionTimes = append(
-					setImageExecutionTimes,
-					fmt.Sprintf("%s (%.2f seconds)", taskImage.LogDetailedName(), taskDuration.Seconds()),
-				)
-			}
-
-			return nil
-		}); err != nil {
-			return err
-		}
-
-		setImageExecutionTimesArray = append(setImageExecutionTimesArray, setImageExecutionTimes)
-	}
-
-	if logImages {
-		blockMsg := "Build summary"
-		logboek.Context(ctx).LogBlock(blockMsg).
-			Options(func(options types.LogBlockOptionsInterface) {
-				options.Style(stylePkg.Highlight())
-			}).
-			Do(func() {
-				for setId, setImageExecutionTImes := range setImageExecutionTimesArray {
-					logboek.Context(ctx).LogFHighlight("Set #%d:\n", setId)
-					for _, msg := range setImageExecutionTImes {
-						logboek.Context(ctx).LogLnHighlight("-", msg)
-					}
-					logboek.Context(ctx).LogOptionalLn()
-				}
-			})
+	if logImages && (err == nil || c.KeepGoing) {
+		report.log(ctx)
 	}
 
-	return nil
+	return err
 }
 
 func (c *Conveyor) doImage(ctx context.Context, img *image.Image, phases []Phase) error {
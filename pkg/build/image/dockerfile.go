@@ -225,6 +225,7 @@ func mapDockerfileToImagesSets(ctx context.Context, cfg *dockerfile.Dockerfile,
 
 			for _, dep := range instr.GetDependenciesByStageRef() {
 				appendQueue(dep.GetWerfImageName(), dep, item.Level+1)
+				img.addDependencyImageName(dep.GetWerfImageName())
 			}
 
 			instrNum++
@@ -19,6 +19,7 @@ import (
 	"github.com/werf/werf/pkg/image"
 	"github.com/werf/werf/pkg/logging"
 	"github.com/werf/werf/pkg/storage/manager"
+	"github.com/werf/werf/pkg/util"
 	"github.com/werf/werf/pkg/werf"
 )
 
@@ -115,6 +116,9 @@ type Image struct {
 
 	baseStageImage   *stage.StageImage
 	stageAsBaseImage stage.Interface
+
+	// dependencyImagesNames are the images used by the image stages besides the base one (imports, dependencies, COPY --from)
+	dependencyImagesNames []string
 }
 
 func (i *Image) LogName() string {
@@ -270,7 +274,9 @@ func (i *Image) SetupBaseImage(ctx context.Context, storageManager manager.Stora
 								options.Style(style.Highlight())
 							}).
 							DoError(func() error {
-								return i.ContainerBackend.PullImageFromRegistry(ctx, i.baseStageImage.Image)
+								return stage.PullBaseImage(ctx, i.ContainerBackend, i.baseStageImage.Image.Name(), i.baseStageImage.Image.GetTargetPlatform(), func() error {
+									return i.ContainerBackend.PullImageFromRegistry(ctx, i.baseStageImage.Image)
+								})
 							}); err != nil {
 							return err
 						}
@@ -316,6 +322,24 @@ func (i *Image) SetupBaseImage(ctx context.Context, storageManager manager.Stora
 }
 
 // TODO(staged-dockerfile): this is only for compatibility with stapel-builder logic, and this should be unified with new staged-dockerfile logic
+// GetDependencyImagesNames returns names of the images that must be built before the image.
+func (i *Image) GetDependencyImagesNames() []string {
+	var names []string
+	if i.baseImageType == StageAsBaseImage && i.baseImageName != "" {
+		names = append(names, i.baseImageName)
+	}
+
+	for _, name := range i.dependencyImagesNames {
+		names = util.UniqAppendString(names, name)
+	}
+
+	return names
+}
+
+func (i *Image) addDependencyImageName(name string) {
+	i.dependencyImagesNames = util.UniqAppendString(i.dependencyImagesNames, name)
+}
+
 func (i *Image) GetBaseStageImage() *stage.StageImage {
 	return i.baseStageImage
 }
@@ -369,7 +393,9 @@ func (i *Image) FetchBaseImage(ctx context.Context) error {
 				options.Style(style.Highlight())
 			}).
 			DoError(func() error {
-				return i.ContainerBackend.PullImageFromRegistry(ctx, i.baseStageImage.Image)
+				return stage.PullBaseImage(ctx, i.ContainerBackend, i.baseStageImage.Image.Name(), i.baseStageImage.Image.GetTargetPlatform(), func() error {
+					return i.ContainerBackend.PullImageFromRegistry(ctx, i.baseStageImage.Image)
+				})
 			}); err != nil {
 			return err
 		}
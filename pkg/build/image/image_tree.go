@@ -25,8 +25,9 @@ type ImagesTree struct {
 
 	werfConfig *config.WerfConfig
 
-	allImages  []*Image
-	imagesSets ImagesSets
+	allImages          []*Image
+	imagesSets         ImagesSets
+	imagesDependencies map[*Image][]*Image
 
 	multiplatformImages []*MultiplatformImage
 }
@@ -117,6 +118,15 @@ func (tree *ImagesTree) Calculate(ctx context.Context) error {
 							}
 						}
 
+						relativesNames := tree.werfConfig.GetImageRelativesNames(imageConfigI)
+						for _, set := range newImagesSets {
+							for _, img := range set {
+								for _, name := range relativesNames {
+									img.addDependencyImageName(name)
+								}
+							}
+						}
+
 						builder.MergeImagesSets(newImagesSets)
 
 						return nil
@@ -132,10 +142,33 @@ func (tree *ImagesTree) Calculate(ctx context.Context) error {
 
 	tree.imagesSets = builder.GetImagesSets()
 	tree.allImages = builder.GetAllImages()
+	tree.imagesDependencies = calculateImagesDependencies(tree.allImages)
 
 	return nil
 }
 
+// calculateImagesDependencies links every image with the images of the same platform it depends on.
+func calculateImagesDependencies(images []*Image) map[*Image][]*Image {
+	imagesByPlatformAndName := make(map[string]map[string]*Image)
+	for _, img := range images {
+		if imagesByPlatformAndName[img.TargetPlatform] == nil {
+			imagesByPlatformAndName[img.TargetPlatform] = make(map[string]*Image)
+		}
+		imagesByPlatformAndName[img.TargetPlatform][img.Name] = img
+	}
+
+	res := make(map[*Image][]*Image)
+	for _, img := range images {
+		for _, name := range img.GetDependencyImagesNames() {
+			if dependencyImg, ok := imagesByPlatformAndName[img.TargetPlatform][name]; ok && dependencyImg != img {
+				res[img] = append(res[img], dependencyImg)
+			}
+		}
+	}
+
+	return res
+}
+
 func (tree *ImagesTree) GetImage(name string) *Image {
 	return nil
 }
@@ -212,6 +245,11 @@ func (tree *ImagesTree) GetImagesSets() ImagesSets {
 	return tree.imagesSets
 }
 
+// GetImageDependencies returns the images which must be built before the image.
+func (tree *ImagesTree) GetImageDependencies(img *Image) []*Image {
+	return tree.imagesDependencies[img]
+}
+
 func (tree *ImagesTree) GetMultiplatformImage(name string) *MultiplatformImage {
 	for _, img := range tree.multiplatformImages {
 		if img.Name == name {
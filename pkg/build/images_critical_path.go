@@ -0,0 +1,124 @@
+package build
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/werf/logboek"
+	stylePkg "github.com/werf/logboek/pkg/style"
+	"github.com/werf/logboek/pkg/types"
+	"github.com/werf/werf/pkg/build/image"
+)
+
+// imagesCriticalPathReport collects the images build times to show which chain of dependent images has determined the build duration.
+type imagesCriticalPathReport struct {
+	images       []*image.Image
+	dependencies [][]int
+
+	mutex      sync.Mutex
+	startedAt  []time.Time
+	finishedAt []time.Time
+}
+
+func newImagesCriticalPathReport(images []*image.Image, dependencies [][]int) *imagesCriticalPathReport {
+	return &imagesCriticalPathReport{
+		images:       images,
+		dependencies: dependencies,
+		startedAt:    make([]time.Time, len(images)),
+		finishedAt:   make([]time.Time, len(images)),
+	}
+}
+
+func (r *imagesCriticalPathReport) start(taskId int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.startedAt[taskId] = time.Now()
+}
+
+func (r *imagesCriticalPathReport) finish(taskId int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.finishedAt[taskId] = time.Now()
+}
+
+// criticalPath starts from the last finished image and goes through the latest finished dependencies, each of them has delayed the start of the next image.
+func (r *imagesCriticalPathReport) criticalPath() []int {
+	last := -1
+	for taskId, finishedAt := range r.finishedAt {
+		if !finishedAt.IsZero() && (last == -1 || finishedAt.After(r.finishedAt[last])) {
+			last = taskId
+		}
+	}
+
+	var path []int
+	for last != -1 {
+		path = append([]int{last}, path...)
+
+		prev := -1
+		for _, dependencyTaskId := range r.dependencies[last] {
+			if prev == -1 || r.finishedAt[dependencyTaskId].After(r.finishedAt[prev]) {
+				prev = dependencyTaskId
+			}
+		}
+		last = prev
+	}
+
+	return path
+}
+
+func (r *imagesCriticalPathReport) log(ctx context.Context) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path := r.criticalPath()
+	if len(path) == 0 {
+		return
+	}
+
+	var buildStartedAt time.Time
+	for _, startedAt := range r.startedAt {
+		if !startedAt.IsZero() && (buildStartedAt.IsZero() || startedAt.Before(buildStartedAt)) {
+			buildStartedAt = startedAt
+		}
+	}
+
+	logboek.Context(ctx).LogBlock("Critical path (%.2f seconds)", r.finishedAt[path[len(path)-1]].Sub(buildStartedAt).Seconds()).
+		Options(func(options types.LogBlockOptionsInterface) {
+			options.Style(stylePkg.Highlight())
+		}).
+		Do(func() {
+			readyAt := buildStartedAt
+			for _, taskId := range path {
+				duration := r.finishedAt[taskId].Sub(r.startedAt[taskId])
+
+				// the image could wait for a free worker if the number of parallel tasks is limited
+				if queued := r.startedAt[taskId].Sub(readyAt); queued >= time.Second {
+					logboek.Context(ctx).LogFHighlight("- %s (%.2f seconds, queued %.2f seconds)\n", r.images[taskId].LogDetailedName(), duration.Seconds(), queued.Seconds())
+				} else {
+					logboek.Context(ctx).LogFHighlight("- %s (%.2f seconds)\n", r.images[taskId].LogDetailedName(), duration.Seconds())
+				}
+
+				readyAt = r.finishedAt[taskId]
+			}
+			logboek.Context(ctx).LogOptionalLn()
+
+			var notBuilt []string
+			for taskId, img := range r.images {
+				switch {
+				case r.startedAt[taskId].IsZero():
+					notBuilt = append(notBuilt, img.LogDetailedName()+" (skipped)")
+				case r.finishedAt[taskId].IsZero():
+					notBuilt = append(notBuilt, img.LogDetailedName()+" (failed)")
+				}
+			}
+
+			if len(notBuilt) > 0 {
+				logboek.Context(ctx).LogLnHighlight("Not built:")
+				for _, name := range notBuilt {
+					logboek.Context(ctx).LogLnHighlight("-", name)
+				}
+				logboek.Context(ctx).LogOptionalLn()
+			}
+		})
+}
@@ -0,0 +1,195 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/werf/logboek"
+	"github.com/werf/logboek/pkg/types"
+	"github.com/werf/werf/pkg/build/image"
+	"github.com/werf/werf/pkg/util/parallel"
+)
+
+// imageStageTask is the unit of the parallel build: the stage of the image processed by the phase.
+// The first stage task of the phase runs the before image stages handler and the last one runs the after image stages handler.
+type imageStageTask struct {
+	imageTaskId int
+	phaseInd    int
+	// stageInd is -1 if the image has no stages, only the phase handlers are run then
+	stageInd int
+}
+
+// imageStagesState is shared by the stage tasks of the image, these tasks run one after another.
+type imageStagesState struct {
+	mutex    sync.Mutex
+	phases   []Phase
+	deferFns []func()
+	// done is set when the image is processed, failed or its processing is stopped by the phase
+	done bool
+}
+
+func (state *imageStagesState) cleanup() {
+	for i := len(state.deferFns) - 1; i >= 0; i-- {
+		state.deferFns[i]()
+	}
+	state.deferFns = nil
+	state.done = true
+}
+
+// newImagesStagesGraph splits every image into the stage tasks of all phases.
+// The stage task depends on the previous stage task of the image, the first stage task of the image depends on the last stage tasks of the images it depends on.
+func newImagesStagesGraph(images []*image.Image, imagesDependencies [][]int, numberOfPhases int) ([]imageStageTask, [][]int) {
+	var tasks []imageStageTask
+	var dependencies [][]int
+
+	lastTaskIds := make([]int, len(images))
+	firstTaskIds := make([]int, len(images))
+
+	for imageTaskId, img := range images {
+		firstTaskIds[imageTaskId] = len(tasks)
+
+		numberOfStages := len(img.GetStages())
+		for phaseInd := 0; phaseInd < numberOfPhases; phaseInd++ {
+			if numberOfStages == 0 {
+				tasks = append(tasks, imageStageTask{imageTaskId: imageTaskId, phaseInd: phaseInd, stageInd: -1})
+				continue
+			}
+
+			for stageInd := 0; stageInd < numberOfStages; stageInd++ {
+				tasks = append(tasks, imageStageTask{imageTaskId: imageTaskId, phaseInd: phaseInd, stageInd: stageInd})
+			}
+		}
+
+		lastTaskIds[imageTaskId] = len(tasks) - 1
+	}
+
+	dependencies = make([][]int, len(tasks))
+	for taskId, task := range tasks {
+		if taskId != firstTaskIds[task.imageTaskId] {
+			dependencies[taskId] = []int{taskId - 1}
+			continue
+		}
+
+		for _, dependencyImageTaskId := range imagesDependencies[task.imageTaskId] {
+			dependencies[taskId] = append(dependencies[taskId], lastTaskIds[dependencyImageTaskId])
+		}
+	}
+
+	return tasks, dependencies
+}
+
+// doImagesStagesInParallel builds the stages of different images in parallel, identical stages are built once thanks to the stages builds of the conveyor.
+func (c *Conveyor) doImagesStagesInParallel(ctx context.Context, images []*image.Image, imagesDependencies [][]int, phases []Phase, report *imagesCriticalPathReport) error {
+	tasks, dependencies := newImagesStagesGraph(images, imagesDependencies, len(phases))
+
+	states := make([]*imageStagesState, len(images))
+	for imageTaskId := range images {
+		var imagePhases []Phase
+		for _, phase := range phases {
+			imagePhases = append(imagePhases, phase.Clone())
+		}
+		states[imageTaskId] = &imageStagesState{phases: imagePhases}
+	}
+
+	err := parallel.DoGraphTasks(ctx, dependencies, parallel.DoGraphTasksOptions{
+		InitDockerCLIForEachWorker: true,
+		MaxNumberOfWorkers:         int(c.ParallelTasksLimit),
+		LiveOutput:                 true,
+		KeepGoing:                  c.KeepGoing,
+	}, func(ctx context.Context, taskId int) error {
+		task := tasks[taskId]
+		state := states[task.imageTaskId]
+
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if state.done {
+			return nil
+		}
+
+		isFirstTask := taskId == 0 || tasks[taskId-1].imageTaskId != task.imageTaskId
+		isLastTask := taskId == len(tasks)-1 || tasks[taskId+1].imageTaskId != task.imageTaskId
+
+		if isFirstTask {
+			report.start(task.imageTaskId)
+		}
+
+		stopped, err := c.doImageStageTask(ctx, images[task.imageTaskId], state, task)
+		if err != nil {
+			state.cleanup()
+			return err
+		}
+
+		if isLastTask || stopped {
+			state.cleanup()
+			report.finish(task.imageTaskId)
+		}
+
+		return nil
+	})
+
+	// the images interrupted by the failure of another image are not processed further
+	for _, state := range states {
+		state.mutex.Lock()
+		if !state.done {
+			state.cleanup()
+		}
+		state.mutex.Unlock()
+	}
+
+	return err
+}
+
+// doImageStageTask runs the phase handlers of the stage task and reports whether the image processing is stopped by the phase.
+func (c *Conveyor) doImageStageTask(ctx context.Context, img *image.Image, state *imageStagesState, task imageStageTask) (bool, error) {
+	phase := state.phases[task.phaseInd]
+	stages := img.GetStages()
+
+	isFirstPhaseTask := task.stageInd <= 0
+	isLastPhaseTask := task.stageInd == len(stages)-1
+
+	processName := img.LogDetailedName()
+	if task.stageInd >= 0 {
+		processName = fmt.Sprintf("%s stage %s (%d/%d)", img.LogDetailedName(), stages[task.stageInd].Name(), task.stageInd+1, len(stages))
+	}
+
+	var stopped bool
+	err := logboek.Context(ctx).LogProcess(processName).
+		Options(func(options types.LogProcessOptionsInterface) {
+			options.Style(img.LogProcessStyle())
+		}).
+		DoError(func() error {
+			if isFirstPhaseTask {
+				logboek.Context(ctx).Debug().LogF("Phase %s -- BeforeImageStages() %s\n", phase.Name(), img.GetLogName())
+				deferFn, err := phase.BeforeImageStages(ctx, img)
+				if deferFn != nil {
+					state.deferFns = append(state.deferFns, deferFn)
+				}
+				if err != nil {
+					return fmt.Errorf("phase %s before image %s stages handler failed: %w", phase.Name(), img.GetLogName(), err)
+				}
+			}
+
+			if task.stageInd >= 0 {
+				stg := stages[task.stageInd]
+				logboek.Context(ctx).Debug().LogF("Phase %s -- OnImageStage() %s %s\n", phase.Name(), img.GetLogName(), stg.LogDetailedName())
+				if err := phase.OnImageStage(ctx, img, stg); err != nil {
+					return fmt.Errorf("phase %s on image %s stage %s handler failed: %w", phase.Name(), img.GetLogName(), stg.Name(), err)
+				}
+			}
+
+			if isLastPhaseTask {
+				logboek.Context(ctx).Debug().LogF("Phase %s -- AfterImageStages() %s\n", phase.Name(), img.GetLogName())
+				if err := phase.AfterImageStages(ctx, img); err != nil {
+					return fmt.Errorf("phase %s after image %s stages handler failed: %w", phase.Name(), img.GetLogName(), err)
+				}
+
+				stopped = phase.ImageProcessingShouldBeStopped(ctx, img)
+			}
+
+			return nil
+		})
+
+	return stopped, err
+}
@@ -0,0 +1,107 @@
+package build
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/attestation"
+	"github.com/werf/werf/pkg/build/image"
+)
+
+type VerifyProvenanceOptions struct {
+	// PublicKey to verify attestations signature with, the signature is not checked if nil.
+	PublicKey crypto.PublicKey
+}
+
+// VerifyProvenance checks that each final image has the provenance attestation matching the current repo state.
+// Stages of the images should be calculated by the ShouldBeBuilt or Build run before.
+func (c *Conveyor) VerifyProvenance(ctx context.Context, opts VerifyProvenanceOptions) error {
+	if opts.PublicKey == nil {
+		logboek.Context(ctx).Warn().LogF("WARNING: Attestations signatures will not be verified, specify --key to verify them\n")
+	}
+
+	for _, img := range c.imagesTree.GetImages() {
+		if !img.IsFinal() {
+			continue
+		}
+
+		if err := logboek.Context(ctx).Default().LogProcess("Verify image %s provenance", img.GetLogName()).DoError(func() error {
+			return c.verifyImageProvenance(ctx, img, opts)
+		}); err != nil {
+			return fmt.Errorf("image %s provenance verification failed: %w", img.GetLogName(), err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Conveyor) verifyImageProvenance(ctx context.Context, img *image.Image, opts VerifyProvenanceOptions) error {
+	expected, err := newImageProvenance(ctx, c, img)
+	if err != nil {
+		return err
+	}
+
+	stageImage := img.GetLastNonEmptyStage().GetStageImage().Image
+	desc, dockerRegistry, err := c.getPublishedImageRegistry(stageImage.GetFinalStageDescription(), stageImage.GetStageDescription())
+	if err != nil {
+		return err
+	}
+
+	imageDigest := desc.Info.GetDigest()
+	if imageDigest == "" {
+		return fmt.Errorf("unable to get image %s digest", desc.Info.Name)
+	}
+
+	attestationReference := fmt.Sprintf("%s:%s", desc.Info.Repository, attestation.AttestationTag(imageDigest))
+
+	attestationInfo, err := dockerRegistry.TryGetRepoImage(ctx, attestationReference)
+	if err != nil {
+		return fmt.Errorf("unable to get attestation %s: %w", attestationReference, err)
+	}
+	if attestationInfo == nil {
+		return fmt.Errorf("attestation %s not found", attestationReference)
+	}
+
+	layers, err := dockerRegistry.PullArtifactLayers(ctx, attestationReference)
+	if err != nil {
+		return err
+	}
+
+	for _, layer := range layers {
+		if layer.MediaType != attestation.DSSEEnvelopeMediaType || layer.Annotations[attestation.PredicateTypeAnnotation] != attestation.SLSAProvenancePredicateType {
+			continue
+		}
+
+		envelope, err := attestation.ParseEnvelope(layer.Data)
+		if err != nil {
+			return err
+		}
+
+		statement, err := attestation.OpenEnvelope(ctx, envelope, opts.PublicKey)
+		if err != nil {
+			return err
+		}
+
+		if err := statement.CheckSubject(imageDigest); err != nil {
+			return err
+		}
+
+		if err := statement.Predicate.Match(expected); err != nil {
+			return fmt.Errorf("image has been built not from the current repo state: %w", err)
+		}
+
+		logboek.Context(ctx).Default().LogFDetails("name: %s@%s\n", desc.Info.Repository, imageDigest)
+		logboek.Context(ctx).Default().LogFDetails("attestation: %s\n", attestationReference)
+		for _, dep := range statement.Predicate.BuildDefinition.ResolvedDependencies {
+			if commit := dep.Digest[attestation.GitCommitDigestAlgorithm]; commit != "" {
+				logboek.Context(ctx).Default().LogFDetails("source: %s@%s\n", dep.Name, commit)
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("no SLSA provenance found in attestation %s", attestationReference)
+}
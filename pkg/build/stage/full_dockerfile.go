@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -360,7 +361,9 @@ outerLoop:
 					logboek.Context(ctx).Warn().LogF("WARNING: Could not get base image manifest from local docker and from docker registry: %s\n", getRemotelyErr)
 					logboek.Context(ctx).Warn().LogLn("WARNING: The base image pulling is necessary for calculating digest of image correctly\n")
 					if err := logboek.Context(ctx).Default().LogProcess("Pulling base image %s", resolvedBaseName).DoError(func() error {
-						return containerBackend.Pull(ctx, resolvedBaseName, container_backend.PullOpts{})
+						return PullBaseImage(ctx, containerBackend, resolvedBaseName, "", func() error {
+							return containerBackend.Pull(ctx, resolvedBaseName, container_backend.PullOpts{})
+						})
 					}); err != nil {
 						return err
 					}
@@ -678,6 +681,16 @@ func (s *FullDockerfileStage) dockerfileOnBuildInstructionDependencies(ctx conte
 }
 
 func (s *FullDockerfileStage) PrepareImage(ctx context.Context, c Conveyor, cb container_backend.ContainerBackend, prevBuiltImage, stageImage *StageImage, buildContextArchive container_backend.BuildContextArchiver) error {
+	var baseImages []string
+	for baseImage := range s.imageOnBuildInstructions {
+		baseImages = append(baseImages, baseImage)
+	}
+	sort.Strings(baseImages)
+
+	if err := pullMirroredBaseImages(ctx, cb, baseImages, s.targetPlatform); err != nil {
+		return err
+	}
+
 	if err := s.SetupDockerImageBuilder(stageImage.Builder.DockerfileBuilder(), c); err != nil {
 		return err
 	}
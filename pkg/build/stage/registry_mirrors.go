@@ -0,0 +1,71 @@
+package stage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/container_backend"
+	"github.com/werf/werf/pkg/docker_registry"
+)
+
+// PullBaseImage pulls the base image from the first available registry mirror and tags it with the original reference,
+// so the image is used by the original name regardless of the mirror. The pullFunc pulls the image from the original registry
+// if there are no mirrors for the reference or all of them have failed.
+func PullBaseImage(ctx context.Context, containerBackend container_backend.ContainerBackend, reference, targetPlatform string, pullFunc func() error) error {
+	mirrorReferences, err := docker_registry.RegistryMirrorReferences(reference)
+	if err != nil {
+		return fmt.Errorf("unable to get registry mirrors of %q: %w", reference, err)
+	}
+
+	for _, mirrorReference := range mirrorReferences {
+		if err := containerBackend.Pull(ctx, mirrorReference, container_backend.PullOpts{TargetPlatform: targetPlatform}); err != nil {
+			logboek.Context(ctx).Warn().LogF("WARNING: Unable to pull base image %s from mirror: %s\n", mirrorReference, err)
+			continue
+		}
+
+		if err := containerBackend.Tag(ctx, mirrorReference, reference, container_backend.TagOpts{TargetPlatform: targetPlatform}); err != nil {
+			return fmt.Errorf("unable to tag %s as %s: %w", mirrorReference, reference, err)
+		}
+
+		logboek.Context(ctx).Info().LogF("Base image %s pulled from mirror %s\n", reference, mirrorReference)
+
+		return nil
+	}
+
+	return pullFunc()
+}
+
+// pullMirroredBaseImages pulls the base images, which have registry mirrors, before the build,
+// otherwise the builder would pull missing images from the original registry.
+func pullMirroredBaseImages(ctx context.Context, containerBackend container_backend.ContainerBackend, references []string, targetPlatform string) error {
+	for _, reference := range references {
+		mirrorReferences, err := docker_registry.RegistryMirrorReferences(reference)
+		if err != nil {
+			return fmt.Errorf("unable to get registry mirrors of %q: %w", reference, err)
+		}
+
+		if len(mirrorReferences) == 0 {
+			continue
+		}
+
+		info, err := containerBackend.GetImageInfo(ctx, reference, container_backend.GetImageInfoOpts{TargetPlatform: targetPlatform})
+		if err != nil {
+			return fmt.Errorf("unable to inspect local image %s: %w", reference, err)
+		}
+
+		if info != nil {
+			continue
+		}
+
+		if err := logboek.Context(ctx).Default().LogProcess("Pulling base image %s", reference).DoError(func() error {
+			return PullBaseImage(ctx, containerBackend, reference, targetPlatform, func() error {
+				return containerBackend.Pull(ctx, reference, container_backend.PullOpts{TargetPlatform: targetPlatform})
+			})
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+// stagesBuilds deduplicates the stages shared by the images processed in parallel.
+// The first image reaching the stage digest looks for the suitable stage and builds it if needed,
+// the other images with the same stage wait for the result and continue from it.
+type stagesBuilds struct {
+	mutex  sync.Mutex
+	builds map[string]*stageBuild
+}
+
+type stageBuild struct {
+	digest    string
+	imageName string
+	done      chan struct{}
+
+	stageDesc *image.StageDescription
+	err       error
+}
+
+func newStagesBuilds() *stagesBuilds {
+	return &stagesBuilds{builds: make(map[string]*stageBuild)}
+}
+
+// acquire returns the build of the stage with the digest, the caller owns the build and must finish it if isOwner is true.
+func (b *stagesBuilds) acquire(targetPlatform, digest, imageName string) (build *stageBuild, isOwner bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := fmt.Sprintf("%s/%s", targetPlatform, digest)
+	if build, ok := b.builds[key]; ok {
+		return build, false
+	}
+
+	build = &stageBuild{digest: digest, imageName: imageName, done: make(chan struct{})}
+	b.builds[key] = build
+
+	return build, true
+}
+
+// finish passes the stage to the waiting images, the failed build is forgotten to be retried by the next phase.
+func (b *stagesBuilds) finish(targetPlatform string, build *stageBuild, stageDesc *image.StageDescription, err error) {
+	if err == nil && stageDesc == nil {
+		err = fmt.Errorf("stage %s has not been built", build.digest)
+	}
+
+	b.mutex.Lock()
+	if err != nil {
+		delete(b.builds, fmt.Sprintf("%s/%s", targetPlatform, build.digest))
+	}
+	b.mutex.Unlock()
+
+	build.stageDesc, build.err = stageDesc, err
+	close(build.done)
+}
+
+func (build *stageBuild) wait(ctx context.Context) (*image.StageDescription, error) {
+	select {
+	case <-build.done:
+		if build.err != nil {
+			return nil, fmt.Errorf("stage %s has not been built by %s: %w", build.digest, build.imageName, build.err)
+		}
+
+		return build.stageDesc, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
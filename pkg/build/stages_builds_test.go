@@ -0,0 +1,100 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+func newTestStageDescription(name string) *image.StageDescription {
+	return &image.StageDescription{Info: &image.Info{Name: name}}
+}
+
+func TestStagesBuildsWaitersGetOwnerStage(t *testing.T) {
+	builds := newStagesBuilds()
+
+	build, isOwner := builds.acquire("linux/amd64", "digest", "image-1")
+	if !isOwner {
+		t.Fatalf("expected the first image to own the build")
+	}
+
+	waitingBuild, isOwner := builds.acquire("linux/amd64", "digest", "image-2")
+	if isOwner || waitingBuild != build {
+		t.Fatalf("expected the second image to wait for the build of the first one")
+	}
+
+	if _, isOwner := builds.acquire("linux/arm64", "digest", "image-3"); !isOwner {
+		t.Errorf("expected the build of another platform to be separate")
+	}
+
+	type result struct {
+		stageDesc *image.StageDescription
+		err       error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		stageDesc, err := waitingBuild.wait(context.Background())
+		resultCh <- result{stageDesc, err}
+	}()
+
+	select {
+	case <-resultCh:
+		t.Fatalf("expected the waiter to be blocked until the build is finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stageDesc := newTestStageDescription("stage")
+	builds.finish("linux/amd64", build, stageDesc, nil)
+
+	res := <-resultCh
+	if res.err != nil || res.stageDesc != stageDesc {
+		t.Fatalf("expected the stage of the owner, got %v, %v", res.stageDesc, res.err)
+	}
+
+	if lateBuild, isOwner := builds.acquire("linux/amd64", "digest", "image-4"); isOwner || lateBuild != build {
+		t.Errorf("expected the finished build to be reused")
+	} else if stageDesc, err := lateBuild.wait(context.Background()); err != nil || stageDesc.Info.Name != "stage" {
+		t.Errorf("expected the stage of the finished build, got %v, %v", stageDesc, err)
+	}
+}
+
+func TestStagesBuildsFailedOwnerIsRetried(t *testing.T) {
+	builds := newStagesBuilds()
+
+	build, _ := builds.acquire("", "digest", "image-1")
+	waitingBuild, _ := builds.acquire("", "digest", "image-2")
+
+	buildErr := errors.New("build failed")
+	builds.finish("", build, nil, buildErr)
+
+	if _, err := waitingBuild.wait(context.Background()); !errors.Is(err, buildErr) {
+		t.Errorf("expected the waiter to get the owner error, got %v", err)
+	}
+
+	retriedBuild, isOwner := builds.acquire("", "digest", "image-2")
+	if !isOwner || retriedBuild == build {
+		t.Fatalf("expected the failed build to be retried by the next image")
+	}
+
+	builds.finish("", retriedBuild, nil, nil)
+	if _, err := retriedBuild.wait(context.Background()); err == nil {
+		t.Errorf("expected the error if the owner has not built the stage")
+	}
+}
+
+func TestStagesBuildsWaitIsCancelled(t *testing.T) {
+	builds := newStagesBuilds()
+
+	builds.acquire("", "digest", "image-1")
+	waitingBuild, _ := builds.acquire("", "digest", "image-2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := waitingBuild.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+}
@@ -128,6 +128,9 @@ type ImagesOptions struct {
 	CommitOpts
 	Names   []string
 	Filters []util.Pair[string, string]
+
+	// WithLabelsAndSize requests image labels and size, which require reading image config and layers.
+	WithLabelsAndSize bool
 }
 
 type ContainersOptions struct {
@@ -149,8 +152,10 @@ type (
 type Buildah interface {
 	GetDefaultPlatform() string
 	GetRuntimePlatform() string
+	GetStoragePath() string
 	Tag(ctx context.Context, ref, newRef string, opts TagOpts) error
 	Push(ctx context.Context, ref string, opts PushOpts) error
+	SaveImageArchive(ctx context.Context, ref, archivePath string, opts PushOpts) error
 	BuildFromDockerfile(ctx context.Context, dockerfile string, opts BuildFromDockerfileOpts) (string, error)
 	RunCommand(ctx context.Context, container string, command []string, opts RunCommandOpts) error
 	FromCommand(ctx context.Context, container, image string, opts FromCommandOpts) (string, error)
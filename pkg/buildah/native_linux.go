@@ -214,6 +214,10 @@ func (b *NativeBuildah) GetDefaultPlatform() string {
 	return b.defaultPlatform
 }
 
+func (b *NativeBuildah) GetStoragePath() string {
+	return b.Store.GraphRoot()
+}
+
 // Inspect returns nil, nil if image not found.
 func (b *NativeBuildah) Inspect(ctx context.Context, ref string) (*thirdparty.BuilderInfo, error) {
 	builder, err := b.getBuilderFromImage(ctx, ref, CommonOpts{})
@@ -272,6 +276,33 @@ func (b *NativeBuildah) Push(ctx context.Context, ref string, opts PushOpts) err
 	return nil
 }
 
+// SaveImageArchive writes the image into the docker-archive tarball loadable by Pull with the docker-archive transport.
+func (b *NativeBuildah) SaveImageArchive(ctx context.Context, ref, archivePath string, opts PushOpts) error {
+	sysCtx, err := b.getSystemContext("")
+	if err != nil {
+		return err
+	}
+
+	pushOpts := buildah.PushOptions{
+		SignaturePolicyPath: b.SignaturePolicyPath,
+		ReportWriter:        opts.LogWriter,
+		Store:               b.Store,
+		SystemContext:       sysCtx,
+		ManifestType:        manifest.DockerV2Schema2MediaType,
+	}
+
+	archiveRef, err := alltransports.ParseImageName(fmt.Sprintf("docker-archive:%s:%s", archivePath, ref))
+	if err != nil {
+		return fmt.Errorf("error parsing archive ref from %q: %w", archivePath, err)
+	}
+
+	if _, _, err = buildah.Push(ctx, ref, archiveRef, pushOpts); err != nil {
+		return fmt.Errorf("error saving image %q into archive %q: %w", ref, archivePath, err)
+	}
+
+	return nil
+}
+
 func (b *NativeBuildah) BuildFromDockerfile(ctx context.Context, dockerfile string, opts BuildFromDockerfileOpts) (string, error) {
 	var targetPlatform string
 	var targetPlatforms []struct{ OS, Arch, Variant string }
@@ -830,7 +861,25 @@ func (b *NativeBuildah) Images(ctx context.Context, opts ImagesOptions) (image.I
 		if err != nil {
 			return nil, fmt.Errorf("unable to get image %s repo digests: %w", img.ID(), err)
 		}
-		res = append(res, image.Summary{RepoTags: repoTags, RepoDigests: repoDigests})
+		summary := image.Summary{
+			ID:          img.ID(),
+			RepoTags:    repoTags,
+			RepoDigests: repoDigests,
+			Created:     img.Created(),
+		}
+
+		if opts.WithLabelsAndSize {
+			summary.Labels, err = img.Labels(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get image %s labels: %w", img.ID(), err)
+			}
+			summary.Size, err = img.Size()
+			if err != nil {
+				return nil, fmt.Errorf("unable to get image %s size: %w", img.ID(), err)
+			}
+		}
+
+		res = append(res, summary)
 	}
 
 	return res, nil
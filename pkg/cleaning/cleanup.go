@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/go-git/go-git/v5"
 	"github.com/gookit/color"
 	"github.com/rodaine/table"
@@ -34,15 +35,41 @@ type CleanupOptions struct {
 	ConfigMetaCleanup                       config.MetaCleanup
 	KeepStagesBuiltWithinLastNHours         uint64
 	DryRun                                  bool
+	PlanOutput                              string
 }
 
-func Cleanup(ctx context.Context, projectName string, storageManager *manager.StorageManager, options CleanupOptions) error {
-	return newCleanupManager(projectName, storageManager, options).run(ctx)
+func Cleanup(ctx context.Context, projectName string, storageManager *manager.StorageManager, options CleanupOptions) (err error) {
+	m := newCleanupManager(projectName, storageManager, options)
+
+	// the plan is saved on failure too to show the decisions made and the deletions applied before the failure
+	if options.PlanOutput != "" {
+		defer func() {
+			if err != nil {
+				m.plan.setError(err)
+			}
+
+			if saveErr := m.plan.Save(options.PlanOutput); saveErr != nil {
+				if err == nil {
+					err = saveErr
+				} else {
+					logboek.Context(ctx).Warn().LogF("WARNING: %s\n", saveErr)
+				}
+
+				return
+			}
+
+			logboek.Context(ctx).Default().LogF("Cleanup plan saved to %s\n", options.PlanOutput)
+		}()
+	}
+
+	return m.run(ctx)
 }
 
 func newCleanupManager(projectName string, storageManager *manager.StorageManager, options CleanupOptions) *cleanupManager {
 	return &cleanupManager{
 		stageManager:                            stage_manager.NewManager(),
+		plan:                                    newCleanupPlan(projectName, storageManager),
+		stageDeletionReasons:                    map[string]string{},
 		ProjectName:                             projectName,
 		StorageManager:                          storageManager,
 		ImageNameList:                           options.ImageNameList,
@@ -58,8 +85,10 @@ func newCleanupManager(projectName string, storageManager *manager.StorageManage
 
 type cleanupManager struct {
 	stageManager stage_manager.Manager
+	plan         *CleanupPlan
 
 	nonexistentImportMetadataIDs []string
+	stageDeletionReasons         map[string]string // stage tag -> reason of deletion of the stage unprotected by the age or the total size policy
 
 	ProjectName                             string
 	StorageManager                          manager.StorageManagerInterface
@@ -170,7 +199,25 @@ func (m *cleanupManager) run(ctx context.Context) error {
 }
 
 func (m *cleanupManager) purgeImageMetadata(ctx context.Context) error {
-	return purgeImageMetadata(ctx, m.ProjectName, m.StorageManager, m.DryRun)
+	_, imageMetadataByImageName, err := m.StorageManager.GetStagesStorage().GetAllAndGroupImageMetadataByImageName(ctx, m.ProjectName, []string{}, storage.WithCache())
+	if err != nil {
+		return err
+	}
+
+	var records []*CleanupPlanImageMetadata
+	for imageNameID, stageIDCommitList := range imageMetadataByImageName {
+		records = append(records, m.plan.addImageMetadata(imageNameID, stageIDCommitList, CleanupPlanActionDelete, cleanupPlanReasonGitHistoryBasedPolicyDisabled)...)
+	}
+
+	if err := purgeImageMetadata(ctx, m.ProjectName, m.StorageManager, m.DryRun); err != nil {
+		return err
+	}
+
+	if !m.DryRun {
+		m.plan.markImageMetadataDeleted(records)
+	}
+
+	return nil
 }
 
 func (m *cleanupManager) skipStageIDsThatAreUsedInKubernetes(ctx context.Context, deployedDockerImages []*DeployedDockerImage) error {
@@ -202,7 +249,7 @@ func (m *cleanupManager) skipStageIDsThatAreUsedInKubernetes(ctx context.Context
 
 	for _, stageID := range m.stageManager.GetStageIDList() {
 		handleTagFunc(stageID, stageID, func() {
-			m.stageManager.MarkStageAsProtected(stageID, "used in the Kubernetes")
+			m.stageManager.MarkStageAsProtected(stageID, stage_manager.ProtectionReasonKubernetes)
 		})
 	}
 
@@ -211,9 +258,10 @@ func (m *cleanupManager) skipStageIDsThatAreUsedInKubernetes(ctx context.Context
 			handleTagFunc(customTag, stageID, func() {
 				if m.stageManager.IsStageExist(stageID) {
 					// keep existent stage and associated custom tags
-					m.stageManager.MarkStageAsProtected(stageID, "used in the Kubernetes")
+					m.stageManager.MarkStageAsProtected(stageID, stage_manager.ProtectionReasonKubernetes)
 				} else {
 					// keep custom tags that do not have associated existent stage
+					m.plan.addCustomTags(stageID, m.stageManager.GetCustomTagsMetadata()[stageID], CleanupPlanActionKeep, stage_manager.ProtectionReasonKubernetes)
 					m.stageManager.ForgetCustomTagsByStageID(stageID)
 				}
 			})
@@ -232,7 +280,7 @@ Loop:
 		for _, deployedDockerImage := range deployedDockerImages {
 			if deployedDockerImage.Name == dockerImageName {
 				if !handledDeployedFinalStages[stageID] {
-					m.stageManager.MarkFinalStageAsProtected(stageID, "used in the Kubernetes")
+					m.stageManager.MarkFinalStageAsProtected(stageID, stage_manager.ProtectionReasonKubernetes)
 
 					logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", stageID)
 					logboek.Context(ctx).LogOptionalLn()
@@ -299,11 +347,15 @@ AppendNewImages:
 }
 
 func (m *cleanupManager) deployedDockerImages(ctx context.Context) ([]*DeployedDockerImage, error) {
+	return getDeployedDockerImages(ctx, m.KubernetesContextClients, m.KubernetesNamespaceRestrictionByContext)
+}
+
+func getDeployedDockerImages(ctx context.Context, kubernetesContextClients []*kube.ContextClient, kubernetesNamespaceRestrictionByContext map[string]string) ([]*DeployedDockerImage, error) {
 	var deployedDockerImages []*DeployedDockerImage
-	for _, contextClient := range m.KubernetesContextClients {
+	for _, contextClient := range kubernetesContextClients {
 		if err := logboek.Context(ctx).LogProcessInline("Getting deployed docker images (context %s)", contextClient.ContextName).
 			DoError(func() error {
-				contextDeployedImages, err := allow_list.DeployedDockerImages(ctx, contextClient.Client, m.KubernetesNamespaceRestrictionByContext[contextClient.ContextName])
+				contextDeployedImages, err := allow_list.DeployedDockerImages(ctx, contextClient.Client, kubernetesNamespaceRestrictionByContext[contextClient.ContextName])
 				if err != nil {
 					return fmt.Errorf("cannot get deployed imagesStageList: %w", err)
 				}
@@ -463,7 +515,7 @@ func (m *cleanupManager) prepareStageIDTableRows(ctx context.Context, stageIDCus
 func (m *cleanupManager) handleSavedStageIDs(ctx context.Context, savedStageIDs []string) {
 	logboek.Context(ctx).Default().LogBlock("Saved tags").Do(func() {
 		for _, stageID := range savedStageIDs {
-			m.stageManager.MarkStageAsProtected(stageID, "found in the git history")
+			m.stageManager.MarkStageAsProtected(stageID, stage_manager.ProtectionReasonGitHistory)
 			logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", stageID)
 			logboek.Context(ctx).LogOptionalLn()
 		}
@@ -482,10 +534,10 @@ func (m *cleanupManager) deleteStages(ctx context.Context, stages []*image.Stage
 		},
 	}
 
-	return deleteStages(ctx, m.StorageManager, m.DryRun, deleteStageOptions, stages, isFinal)
+	return deleteStages(ctx, m.StorageManager, m.DryRun, deleteStageOptions, stages, isFinal, m.plan)
 }
 
-func deleteStages(ctx context.Context, storageManager manager.StorageManagerInterface, dryRun bool, deleteStageOptions manager.ForEachDeleteStageOptions, stages []*image.StageDescription, isFinal bool) error {
+func deleteStages(ctx context.Context, storageManager manager.StorageManagerInterface, dryRun bool, deleteStageOptions manager.ForEachDeleteStageOptions, stages []*image.StageDescription, isFinal bool, plan *CleanupPlan) error {
 	if dryRun {
 		for _, stageDesc := range stages {
 			logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", stageDesc.Info.Tag)
@@ -505,6 +557,7 @@ func deleteStages(ctx context.Context, storageManager manager.StorageManagerInte
 			return nil
 		}
 
+		plan.markStageDeleted(stageDesc.Info.Tag, isFinal)
 		logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", stageDesc.Info.Tag)
 
 		return nil
@@ -518,6 +571,8 @@ func deleteStages(ctx context.Context, storageManager manager.StorageManagerInte
 }
 
 func (m *cleanupManager) cleanupImageMetadata(ctx context.Context, imageName string, hitStageIDCommitList map[string][]string, stageIDsToUnlink []string) error {
+	m.plan.addImageMetadata(imageName, hitStageIDCommitList, CleanupPlanActionKeep, stage_manager.ProtectionReasonGitHistory)
+
 	if countStageIDCommitList(hitStageIDCommitList) != 0 || len(stageIDsToUnlink) != 0 {
 		stageIDCommitListToDelete := map[string][]string{}
 		stageIDCommitListToCleanup := m.stageManager.GetStageIDCommitListToCleanup(imageName)
@@ -548,7 +603,7 @@ func (m *cleanupManager) cleanupImageMetadata(ctx context.Context, imageName str
 			}
 
 			if err := logProcessDoError(func() error {
-				return m.deleteImageMetadata(ctx, imageName, stageIDCommitListToDelete)
+				return m.deleteImageMetadata(ctx, imageName, stageIDCommitListToDelete, cleanupPlanReasonNotFoundInGitHistory)
 			}); err != nil {
 				return err
 			}
@@ -565,7 +620,7 @@ func (m *cleanupManager) cleanupImageMetadata(ctx context.Context, imageName str
 		}
 
 		if err := logProcessDoError(func() error {
-			return m.deleteImageMetadata(ctx, imageName, nonexistentStageIDCommitList)
+			return m.deleteImageMetadata(ctx, imageName, nonexistentStageIDCommitList, cleanupPlanReasonStageNotExist)
 		}); err != nil {
 			return err
 		}
@@ -581,7 +636,7 @@ func (m *cleanupManager) cleanupImageMetadata(ctx context.Context, imageName str
 		}
 
 		if err := logProcessDoError(func() error {
-			return m.deleteImageMetadata(ctx, imageName, stageIDNonexistentCommitList)
+			return m.deleteImageMetadata(ctx, imageName, stageIDNonexistentCommitList, cleanupPlanReasonCommitNotExist)
 		}); err != nil {
 			return err
 		}
@@ -614,7 +669,7 @@ func (m *cleanupManager) cleanupNonexistentImageMetadata(ctx context.Context) er
 
 	return logboek.Context(ctx).Default().LogProcess("Deleting metadata for nonexistent images (%d)", counter).DoError(func() error {
 		for imageName, stageIDCommitList := range stageIDCommitListByNonexistentImage {
-			if err := m.deleteImageMetadata(ctx, imageName, stageIDCommitList); err != nil {
+			if err := m.deleteImageMetadata(ctx, imageName, stageIDCommitList, cleanupPlanReasonImageNotExist); err != nil {
 				return err
 			}
 		}
@@ -623,11 +678,17 @@ func (m *cleanupManager) cleanupNonexistentImageMetadata(ctx context.Context) er
 	})
 }
 
-func (m *cleanupManager) deleteImageMetadata(ctx context.Context, imageName string, stageIDCommitList map[string][]string) error {
+func (m *cleanupManager) deleteImageMetadata(ctx context.Context, imageName string, stageIDCommitList map[string][]string, reason string) error {
+	records := m.plan.addImageMetadata(imageName, stageIDCommitList, CleanupPlanActionDelete, reason)
+
 	if err := deleteImageMetadata(ctx, m.ProjectName, m.StorageManager, imageName, stageIDCommitList, m.DryRun); err != nil {
 		return err
 	}
 
+	if !m.DryRun {
+		m.plan.markImageMetadataDeleted(records)
+	}
+
 	return nil
 }
 
@@ -692,6 +753,12 @@ func (m *cleanupManager) cleanupUnusedStages(ctx context.Context) error {
 		return fmt.Errorf("unable to init imports metadata: %w", err)
 	}
 
+	m.unprotectExpiredStages(ctx)
+
+	if m.ConfigMetaCleanup.KeepImagesWithinTotalSizeGB != 0 {
+		m.unprotectStagesExceedingTotalSize(ctx, stageDescriptionList)
+	}
+
 	// skip stages and their relatives covered by Kubernetes- or git history-based cleanup policies
 	stageDescriptionListToDelete := stageDescriptionList
 
@@ -706,9 +773,11 @@ func (m *cleanupManager) cleanupUnusedStages(ctx context.Context) error {
 
 				for _, exclSD := range excludedSDListBySD {
 					if sd.Info.Name == exclSD.Info.Name {
+						m.plan.addStages([]*image.StageDescription{exclSD}, CleanupPlanActionKeep, reason)
 						excludedSDListByReason[reason] = append(excludedSDListByReason[reason], exclSD)
 					} else {
 						ancestorReason := fmt.Sprintf("ancestors of images %s", reason)
+						m.plan.addStages([]*image.StageDescription{exclSD}, CleanupPlanActionKeep, ancestorReason)
 						excludedSDListByReason[ancestorReason] = append(excludedSDListByReason[ancestorReason], exclSD)
 					}
 				}
@@ -731,21 +800,18 @@ func (m *cleanupManager) cleanupUnusedStages(ctx context.Context) error {
 		})
 	}
 
-	keepImagesBuiltWithinLastNHours := m.ConfigMetaCleanup.KeepImagesBuiltWithinLastNHours
-	if m.KeepStagesBuiltWithinLastNHours != 0 {
-		keepImagesBuiltWithinLastNHours = m.KeepStagesBuiltWithinLastNHours
-	}
-
-	if !(m.ConfigMetaCleanup.DisableBuiltWithinLastNHoursPolicy || keepImagesBuiltWithinLastNHours == 0) {
+	if keepImagesBuiltWithinLastNHours := m.keepImagesBuiltWithinLastNHours(); keepImagesBuiltWithinLastNHours != 0 {
 		var excludedSDList []*image.StageDescription
 		for _, sd := range stageDescriptionListToDelete {
-			if (time.Since(sd.Info.GetCreatedAt()).Hours()) <= float64(keepImagesBuiltWithinLastNHours) {
+			if isStageBuiltWithinLastNHours(sd, keepImagesBuiltWithinLastNHours) {
 				var excludedRelativesSDList []*image.StageDescription
 				stageDescriptionListToDelete, excludedRelativesSDList = m.excludeStageAndRelativesByImage(stageDescriptionListToDelete, sd.Info)
 				excludedSDList = append(excludedSDList, excludedRelativesSDList...)
 			}
 		}
 
+		m.plan.addStages(excludedSDList, CleanupPlanActionKeep, fmt.Sprintf("built within last %d hours", keepImagesBuiltWithinLastNHours))
+
 		if len(excludedSDList) != 0 {
 			logboek.Context(ctx).Default().LogBlock("Saved stages that were built within last %d hours (%d/%d)", keepImagesBuiltWithinLastNHours, len(excludedSDList), len(stageDescriptionList)).Do(func() {
 				for _, stage := range excludedSDList {
@@ -756,9 +822,30 @@ func (m *cleanupManager) cleanupUnusedStages(ctx context.Context) error {
 		}
 	}
 
+	for _, sd := range stageDescriptionListToDelete {
+		reason, ok := m.stageDeletionReasons[sd.Info.Tag]
+		if !ok {
+			reason = cleanupPlanReasonNotCoveredByPolicies
+		}
+
+		m.plan.addStages([]*image.StageDescription{sd}, CleanupPlanActionDelete, reason)
+	}
+
 	if len(stageDescriptionListToDelete) != 0 {
 		if err := logboek.Context(ctx).Default().LogProcess("Deleting stages tags (%d/%d)", len(stageDescriptionListToDelete), stageDescriptionListCount).DoError(func() error {
-			return m.deleteStages(ctx, stageDescriptionListToDelete, false)
+			if err := m.deleteStages(ctx, stageDescriptionListToDelete, false); err != nil {
+				return err
+			}
+
+			keptStageDescriptionList := excludeStages(stageDescriptionList, stageDescriptionListToDelete...)
+			freedSize := stage_manager.StagesSize(stageDescriptionList) - stage_manager.StagesSize(keptStageDescriptionList)
+			if m.DryRun {
+				logboek.Context(ctx).Default().LogF("Estimated size to be freed: %s\n", humanize.Bytes(uint64(freedSize)))
+			} else {
+				logboek.Context(ctx).Default().LogF("Estimated freed size: %s\n", humanize.Bytes(uint64(freedSize)))
+			}
+
+			return nil
 		}); err != nil {
 			return err
 		}
@@ -770,9 +857,13 @@ func (m *cleanupManager) cleanupUnusedStages(ctx context.Context) error {
 		return fmt.Errorf("unable to cleanup custom tags metadata: %w", err)
 	}
 
+	if err := cleanupImageReferrers(ctx, m.StorageManager.GetStagesStorage(), m.stageManager.GetStageDescriptionList(stage_manager.StageDescriptionListOptions{}), stageDescriptionListToDelete, m.DryRun); err != nil {
+		return fmt.Errorf("unable to cleanup image referrers: %w", err)
+	}
+
 	if len(m.nonexistentImportMetadataIDs) != 0 {
 		if err := logboek.Context(ctx).Default().LogProcess("Cleaning imports metadata (%d)", len(m.nonexistentImportMetadataIDs)).DoError(func() error {
-			return m.deleteImportsMetadata(ctx, m.nonexistentImportMetadataIDs)
+			return m.deleteImportsMetadata(ctx, m.nonexistentImportMetadataIDs, cleanupPlanReasonImportSourceNotExist)
 		}); err != nil {
 			return err
 		}
@@ -781,6 +872,91 @@ func (m *cleanupManager) cleanupUnusedStages(ctx context.Context) error {
 	return nil
 }
 
+func (m *cleanupManager) keepImagesBuiltWithinLastNHours() uint64 {
+	if m.ConfigMetaCleanup.DisableBuiltWithinLastNHoursPolicy {
+		return 0
+	}
+
+	if m.KeepStagesBuiltWithinLastNHours != 0 {
+		return m.KeepStagesBuiltWithinLastNHours
+	}
+
+	return m.ConfigMetaCleanup.KeepImagesBuiltWithinLastNHours
+}
+
+func isStageBuiltWithinLastNHours(sd *image.StageDescription, hours uint64) bool {
+	return time.Since(sd.Info.GetCreatedAt()).Hours() <= float64(hours)
+}
+
+// unprotectExpiredStages deletes the stages found in the git history regardless of it when the stages are older than deleteImagesOlderThanNDays
+func (m *cleanupManager) unprotectExpiredStages(ctx context.Context) {
+	expiredSDList := m.stageManager.UnprotectExpiredStages(m.ConfigMetaCleanup.GetDeleteImagesOlderThanNDays)
+	if len(expiredSDList) == 0 {
+		return
+	}
+
+	for _, stage := range expiredSDList {
+		m.stageDeletionReasons[stage.Info.Tag] = cleanupPlanReasonExpired
+	}
+
+	logboek.Context(ctx).Default().LogBlock("Expired stages found in the git history (%d)", len(expiredSDList)).Do(func() {
+		for _, stage := range expiredSDList {
+			logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", stage.Info.Tag)
+			logboek.Context(ctx).LogOptionalLn()
+		}
+	})
+}
+
+// unprotectStagesExceedingTotalSize deletes the oldest stages found in the git history until the kept stages fit keepImagesWithinTotalSizeGB
+func (m *cleanupManager) unprotectStagesExceedingTotalSize(ctx context.Context, stageDescriptionList []*image.StageDescription) {
+	limit := int64(m.ConfigMetaCleanup.KeepImagesWithinTotalSizeGB * 1000 * 1000 * 1000)
+	keptStagesFunc := func() []*image.StageDescription {
+		return m.getKeptStageDescriptionList(stageDescriptionList)
+	}
+
+	exceedingSDList := m.stageManager.UnprotectStagesExceedingTotalSize(limit, m.ConfigMetaCleanup.IsTotalSizePolicyDisabled, keptStagesFunc)
+	for _, stage := range exceedingSDList {
+		m.stageDeletionReasons[stage.Info.Tag] = cleanupPlanReasonExceedingTotalSize
+	}
+
+	if len(exceedingSDList) != 0 {
+		logboek.Context(ctx).Default().LogBlock("Stages found in the git history exceeding the total size limit %s (%d)", humanize.Bytes(uint64(limit)), len(exceedingSDList)).Do(func() {
+			for _, stage := range exceedingSDList {
+				logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", stage.Info.Tag)
+				logboek.Context(ctx).LogOptionalLn()
+			}
+		})
+	}
+
+	if keptSize := stage_manager.StagesSize(keptStagesFunc()); keptSize > limit {
+		logboek.Context(ctx).Warn().LogF("WARNING: The size of the kept stages %s exceeds the total size limit %s due to the stages that cannot be deleted by other cleanup policies\n", humanize.Bytes(uint64(keptSize)), humanize.Bytes(uint64(limit)))
+	}
+}
+
+// getKeptStageDescriptionList returns the protected stages and the stages built within last N hours including their relatives
+func (m *cleanupManager) getKeptStageDescriptionList(stageDescriptionList []*image.StageDescription) []*image.StageDescription {
+	var keptSDList []*image.StageDescription
+	stageDescriptionListToDelete := stageDescriptionList
+
+	for _, sd := range m.stageManager.GetStageDescriptionList(stage_manager.StageDescriptionListOptions{OnlyProtected: true}) {
+		var excludedSDList []*image.StageDescription
+		stageDescriptionListToDelete, excludedSDList = m.excludeStageAndRelativesByImage(stageDescriptionListToDelete, sd.Info)
+		keptSDList = append(keptSDList, excludedSDList...)
+	}
+
+	if keepImagesBuiltWithinLastNHours := m.keepImagesBuiltWithinLastNHours(); keepImagesBuiltWithinLastNHours != 0 {
+		for _, sd := range stageDescriptionListToDelete {
+			if isStageBuiltWithinLastNHours(sd, keepImagesBuiltWithinLastNHours) {
+				var excludedSDList []*image.StageDescription
+				stageDescriptionListToDelete, excludedSDList = m.excludeStageAndRelativesByImage(stageDescriptionListToDelete, sd.Info)
+				keptSDList = append(keptSDList, excludedSDList...)
+			}
+		}
+	}
+
+	return keptSDList
+}
+
 func (m *cleanupManager) cleanupFinalStages(ctx context.Context) error {
 	finalStagesDescriptionListFull := m.stageManager.GetFinalStageDescriptionList(stage_manager.StageDescriptionListOptions{})
 	finalStageDescriptionListFullCount := len(finalStagesDescriptionListFull)
@@ -790,10 +966,13 @@ func (m *cleanupManager) cleanupFinalStages(ctx context.Context) error {
 
 	var finalStagesDescriptionListToDelete []*image.StageDescription
 
+	m.plan.addFinalStages(m.stageManager.GetFinalStageDescriptionList(stage_manager.StageDescriptionListOptions{OnlyProtected: true}), CleanupPlanActionKeep, stage_manager.ProtectionReasonKubernetes)
+
 FilterOutFinalStages:
 	for _, finalStg := range finalStagesDescriptionList {
 		for _, stg := range stagesDescriptionList {
 			if stg.StageID.IsEqual(*finalStg.StageID) {
+				m.plan.addFinalStages([]*image.StageDescription{finalStg}, CleanupPlanActionKeep, cleanupPlanReasonStageExists)
 				continue FilterOutFinalStages
 			}
 		}
@@ -801,6 +980,8 @@ FilterOutFinalStages:
 		finalStagesDescriptionListToDelete = append(finalStagesDescriptionListToDelete, finalStg)
 	}
 
+	m.plan.addFinalStages(finalStagesDescriptionListToDelete, CleanupPlanActionDelete, cleanupPlanReasonFinalStageNotExist)
+
 	if len(finalStagesDescriptionListToDelete) != 0 {
 		if err := logboek.Context(ctx).Default().LogProcess("Deleting final stages tags (%d/%d)", len(finalStagesDescriptionListToDelete), finalStageDescriptionListFullCount).DoError(func() error {
 			return m.deleteStages(ctx, finalStagesDescriptionListToDelete, true)
@@ -811,6 +992,10 @@ FilterOutFinalStages:
 		m.stageManager.ForgetDeletedFinalStages(finalStagesDescriptionListToDelete)
 	}
 
+	if err := cleanupImageReferrers(ctx, m.StorageManager.GetFinalStagesStorage(), m.stageManager.GetFinalStageDescriptionList(stage_manager.StageDescriptionListOptions{}), finalStagesDescriptionListToDelete, m.DryRun); err != nil {
+		return fmt.Errorf("unable to cleanup final image referrers: %w", err)
+	}
+
 	return nil
 }
 
@@ -829,7 +1014,7 @@ func (m *cleanupManager) initImportsMetadata(ctx context.Context, stageDescripti
 		if metadata == nil {
 			if err := logboek.Context(ctx).Warn().LogProcess("Deleting invalid import metadata %s", metadataID).
 				DoError(func() error {
-					return m.deleteImportsMetadata(ctx, []string{metadataID})
+					return m.deleteImportsMetadata(ctx, []string{metadataID}, cleanupPlanReasonInvalidImportMetadata)
 				}); err != nil {
 				return fmt.Errorf("unable to delete import metadata %s: %w", metadataID, err)
 			}
@@ -851,11 +1036,12 @@ func (m *cleanupManager) initImportsMetadata(ctx context.Context, stageDescripti
 	})
 }
 
-func (m *cleanupManager) deleteImportsMetadata(ctx context.Context, importMetadataIDs []string) error {
-	return deleteImportsMetadata(ctx, m.ProjectName, m.StorageManager, importMetadataIDs, m.DryRun)
+func (m *cleanupManager) deleteImportsMetadata(ctx context.Context, importMetadataIDs []string, reason string) error {
+	m.plan.addImportMetadata(importMetadataIDs, CleanupPlanActionDelete, reason)
+	return deleteImportsMetadata(ctx, m.ProjectName, m.StorageManager, importMetadataIDs, m.DryRun, m.plan)
 }
 
-func deleteImportsMetadata(ctx context.Context, projectName string, storageManager manager.StorageManagerInterface, importMetadataIDs []string, dryRun bool) error {
+func deleteImportsMetadata(ctx context.Context, projectName string, storageManager manager.StorageManagerInterface, importMetadataIDs []string, dryRun bool, plan *CleanupPlan) error {
 	if dryRun {
 		for _, importMetadataID := range importMetadataIDs {
 			logboek.Context(ctx).Info().LogFDetails("  importMetadataID: %s\n", importMetadataID)
@@ -875,6 +1061,7 @@ func deleteImportsMetadata(ctx context.Context, projectName string, storageManag
 			return nil
 		}
 
+		plan.markImportMetadataDeleted(importMetadataID)
 		logboek.Context(ctx).Info().LogFDetails("  importMetadataID: %s\n", importMetadataID)
 
 		return nil
@@ -987,8 +1174,10 @@ func (m *cleanupManager) deleteUnusedCustomTags(ctx context.Context) error {
 	for stageID, customTagList := range stageIDCustomTagList {
 		numberOfCustomTags += len(customTagList)
 		if !m.stageManager.IsStageExist(stageID) {
+			m.plan.addCustomTags(stageID, customTagList, CleanupPlanActionDelete, cleanupPlanReasonStageNotExist)
 			customTagListToDelete = append(customTagListToDelete, customTagList...)
 		} else {
+			m.plan.addCustomTags(stageID, customTagList, CleanupPlanActionKeep, cleanupPlanReasonStageExists)
 			customTagListToKeep = append(customTagListToKeep, customTagList...)
 		}
 	}
@@ -1006,7 +1195,7 @@ func (m *cleanupManager) deleteUnusedCustomTags(ctx context.Context) error {
 	if len(customTagListToDelete) != 0 {
 		header := fmt.Sprintf("Deleting unused custom tags (%d/%d)", len(customTagListToDelete), numberOfCustomTags)
 		if err := logboek.Context(ctx).LogProcess(header).DoError(func() error {
-			if err := deleteCustomTags(ctx, m.StorageManager, customTagListToDelete, m.DryRun); err != nil {
+			if err := deleteCustomTags(ctx, m.StorageManager, customTagListToDelete, m.DryRun, m.plan); err != nil {
 				return err
 			}
 
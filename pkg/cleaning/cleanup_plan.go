@@ -0,0 +1,562 @@
+package cleaning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/werf/kubedog/pkg/kube"
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/storage"
+	"github.com/werf/werf/pkg/storage/manager"
+	"github.com/werf/werf/pkg/util"
+)
+
+const CleanupPlanVersion = 1
+
+type CleanupPlanAction string
+
+const (
+	CleanupPlanActionKeep   CleanupPlanAction = "keep"
+	CleanupPlanActionDelete CleanupPlanAction = "delete"
+)
+
+const (
+	cleanupPlanReasonNotCoveredByPolicies          = "not covered by cleanup policies"
+	cleanupPlanReasonExpired                       = "found in the git history, but built more than deleteImagesOlderThanNDays ago"
+	cleanupPlanReasonExceedingTotalSize            = "found in the git history, but exceeds keepImagesWithinTotalSizeGB"
+	cleanupPlanReasonStageExists                   = "the stage exists"
+	cleanupPlanReasonStageNotExist                 = "the stage does not exist"
+	cleanupPlanReasonFinalStageNotExist            = "the stage does not exist in the repo"
+	cleanupPlanReasonCommitNotExist                = "the commit does not exist in the git repository"
+	cleanupPlanReasonImageNotExist                 = "the image does not exist in werf.yaml"
+	cleanupPlanReasonNotFoundInGitHistory          = "not found in the git history"
+	cleanupPlanReasonGitHistoryBasedPolicyDisabled = "git history-based policy is disabled"
+	cleanupPlanReasonInvalidImportMetadata         = "invalid import metadata"
+	cleanupPlanReasonImportSourceNotExist          = "the import source stage does not exist"
+)
+
+// CleanupPlan describes the decisions of the cleanup: which stages, custom tags and metadata are kept or deleted and why.
+// The plan saved with the dry run can be reviewed and applied later as is.
+// The records deleted by the cleanup are marked as deleted, and the error is set if the cleanup failed, such a plan cannot be applied.
+type CleanupPlan struct {
+	Version        int                          `json:"version"`
+	ProjectName    string                       `json:"projectName"`
+	Repo           string                       `json:"repo"`
+	FinalRepo      string                       `json:"finalRepo,omitempty"`
+	CreatedAt      time.Time                    `json:"createdAt"`
+	Error          string                       `json:"error,omitempty"`
+	Stages         []*CleanupPlanStage          `json:"stages"`
+	FinalStages    []*CleanupPlanStage          `json:"finalStages,omitempty"`
+	CustomTags     []*CleanupPlanCustomTag      `json:"customTags,omitempty"`
+	ImageMetadata  []*CleanupPlanImageMetadata  `json:"imageMetadata,omitempty"`
+	ImportMetadata []*CleanupPlanImportMetadata `json:"importMetadata,omitempty"`
+
+	mutex                  sync.Mutex
+	stagesToDelete         map[string]*CleanupPlanStage
+	finalStagesToDelete    map[string]*CleanupPlanStage
+	customTagsToDelete     map[string]*CleanupPlanCustomTag
+	importMetadataToDelete map[string]*CleanupPlanImportMetadata
+}
+
+type CleanupPlanStage struct {
+	Tag       string            `json:"tag"`
+	Digest    string            `json:"digest,omitempty"`
+	Size      int64             `json:"size"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Action    CleanupPlanAction `json:"action"`
+	Reason    string            `json:"reason"`
+	Deleted   bool              `json:"deleted,omitempty"`
+}
+
+type CleanupPlanCustomTag struct {
+	Tag     string            `json:"tag"`
+	StageID string            `json:"stageID"`
+	Action  CleanupPlanAction `json:"action"`
+	Reason  string            `json:"reason"`
+	Deleted bool              `json:"deleted,omitempty"`
+}
+
+type CleanupPlanImageMetadata struct {
+	ImageName string            `json:"imageName"`
+	StageID   string            `json:"stageID"`
+	Commits   []string          `json:"commits"`
+	Action    CleanupPlanAction `json:"action"`
+	Reason    string            `json:"reason"`
+	Deleted   bool              `json:"deleted,omitempty"`
+}
+
+type CleanupPlanImportMetadata struct {
+	ID      string            `json:"id"`
+	Action  CleanupPlanAction `json:"action"`
+	Reason  string            `json:"reason"`
+	Deleted bool              `json:"deleted,omitempty"`
+}
+
+func newCleanupPlan(projectName string, storageManager manager.StorageManagerInterface) *CleanupPlan {
+	plan := &CleanupPlan{
+		Version:     CleanupPlanVersion,
+		ProjectName: projectName,
+		Repo:        storageManager.GetStagesStorage().Address(),
+		CreatedAt:   time.Now().UTC(),
+
+		stagesToDelete:         map[string]*CleanupPlanStage{},
+		finalStagesToDelete:    map[string]*CleanupPlanStage{},
+		customTagsToDelete:     map[string]*CleanupPlanCustomTag{},
+		importMetadataToDelete: map[string]*CleanupPlanImportMetadata{},
+	}
+
+	if finalStagesStorage := storageManager.GetFinalStagesStorage(); finalStagesStorage != nil {
+		plan.FinalRepo = finalStagesStorage.Address()
+	}
+
+	return plan
+}
+
+func newCleanupPlanStage(stageDesc *image.StageDescription, action CleanupPlanAction, reason string) *CleanupPlanStage {
+	return &CleanupPlanStage{
+		Tag:       stageDesc.Info.Tag,
+		Digest:    stageDesc.Info.GetDigest(),
+		Size:      stageDesc.Info.Size,
+		CreatedAt: stageDesc.Info.GetCreatedAt().UTC(),
+		Action:    action,
+		Reason:    reason,
+	}
+}
+
+func (p *CleanupPlan) addStages(stages []*image.StageDescription, action CleanupPlanAction, reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, stageDesc := range stages {
+		record := newCleanupPlanStage(stageDesc, action, reason)
+		p.Stages = append(p.Stages, record)
+		if action == CleanupPlanActionDelete {
+			p.stagesToDelete[record.Tag] = record
+		}
+	}
+}
+
+func (p *CleanupPlan) addFinalStages(stages []*image.StageDescription, action CleanupPlanAction, reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, stageDesc := range stages {
+		record := newCleanupPlanStage(stageDesc, action, reason)
+		p.FinalStages = append(p.FinalStages, record)
+		if action == CleanupPlanActionDelete {
+			p.finalStagesToDelete[record.Tag] = record
+		}
+	}
+}
+
+func (p *CleanupPlan) addCustomTags(stageID string, customTagList []string, action CleanupPlanAction, reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, customTag := range customTagList {
+		record := &CleanupPlanCustomTag{Tag: customTag, StageID: stageID, Action: action, Reason: reason}
+		p.CustomTags = append(p.CustomTags, record)
+		if action == CleanupPlanActionDelete {
+			p.customTagsToDelete[record.Tag] = record
+		}
+	}
+}
+
+func (p *CleanupPlan) addImageMetadata(imageName string, stageIDCommitList map[string][]string, action CleanupPlanAction, reason string) []*CleanupPlanImageMetadata {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var records []*CleanupPlanImageMetadata
+	for stageID, commitList := range stageIDCommitList {
+		if len(commitList) == 0 {
+			continue
+		}
+
+		records = append(records, &CleanupPlanImageMetadata{ImageName: imageName, StageID: stageID, Commits: commitList, Action: action, Reason: reason})
+	}
+	p.ImageMetadata = append(p.ImageMetadata, records...)
+
+	return records
+}
+
+func (p *CleanupPlan) addImportMetadata(importMetadataIDs []string, action CleanupPlanAction, reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, importMetadataID := range importMetadataIDs {
+		record := &CleanupPlanImportMetadata{ID: importMetadataID, Action: action, Reason: reason}
+		p.ImportMetadata = append(p.ImportMetadata, record)
+		if action == CleanupPlanActionDelete {
+			p.importMetadataToDelete[record.ID] = record
+		}
+	}
+}
+
+func (p *CleanupPlan) setError(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.Error = err.Error()
+}
+
+// The mark methods record the applied deletions, so the plan saved after the failed cleanup shows what is already deleted.
+// The methods do nothing for the nil plan, e.g. when the plan is applied or the project is purged.
+
+func (p *CleanupPlan) markStageDeleted(tag string, isFinal bool) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	records := p.stagesToDelete
+	if isFinal {
+		records = p.finalStagesToDelete
+	}
+
+	if record, ok := records[tag]; ok {
+		record.Deleted = true
+	}
+}
+
+func (p *CleanupPlan) markCustomTagDeleted(tag string) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if record, ok := p.customTagsToDelete[tag]; ok {
+		record.Deleted = true
+	}
+}
+
+func (p *CleanupPlan) markImageMetadataDeleted(records []*CleanupPlanImageMetadata) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, record := range records {
+		record.Deleted = true
+	}
+}
+
+func (p *CleanupPlan) markImportMetadataDeleted(id string) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if record, ok := p.importMetadataToDelete[id]; ok {
+		record.Deleted = true
+	}
+}
+
+// Save writes the plan in JSON format, the records are sorted to make plans comparable.
+func (p *CleanupPlan) Save(path string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, stages := range [][]*CleanupPlanStage{p.Stages, p.FinalStages} {
+		sort.SliceStable(stages, func(i, j int) bool { return stages[i].Tag < stages[j].Tag })
+	}
+	sort.SliceStable(p.CustomTags, func(i, j int) bool { return p.CustomTags[i].Tag < p.CustomTags[j].Tag })
+	sort.SliceStable(p.ImageMetadata, func(i, j int) bool {
+		if p.ImageMetadata[i].ImageName != p.ImageMetadata[j].ImageName {
+			return p.ImageMetadata[i].ImageName < p.ImageMetadata[j].ImageName
+		}
+		return p.ImageMetadata[i].StageID < p.ImageMetadata[j].StageID
+	})
+	sort.SliceStable(p.ImportMetadata, func(i, j int) bool { return p.ImportMetadata[i].ID < p.ImportMetadata[j].ID })
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal cleanup plan: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("unable to write cleanup plan %q: %w", path, err)
+	}
+
+	return nil
+}
+
+func LoadCleanupPlan(path string) (*CleanupPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cleanup plan %q: %w", path, err)
+	}
+
+	plan := &CleanupPlan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal cleanup plan %q: %w", path, err)
+	}
+
+	if plan.Version != CleanupPlanVersion {
+		return nil, fmt.Errorf("unsupported cleanup plan %q version %d, expected %d", path, plan.Version, CleanupPlanVersion)
+	}
+
+	return plan, nil
+}
+
+type ApplyCleanupPlanOptions struct {
+	KubernetesContextClients                []*kube.ContextClient
+	KubernetesNamespaceRestrictionByContext map[string]string
+	WithoutKube                             bool
+	DryRun                                  bool
+}
+
+// ApplyCleanupPlan deletes exactly the stages, custom tags and metadata marked for deletion in the plan.
+// The cleanup policies are not evaluated again, records which no longer exist in the repo are skipped.
+// The images used in Kubernetes are checked again, since they could be deployed after the plan was created.
+func ApplyCleanupPlan(ctx context.Context, projectName string, storageManager *manager.StorageManager, plan *CleanupPlan, options ApplyCleanupPlanOptions) error {
+	if plan.Error != "" {
+		return fmt.Errorf("cleanup plan is incomplete, the cleanup failed: %s", plan.Error)
+	}
+
+	if plan.ProjectName != projectName {
+		return fmt.Errorf("cleanup plan is created for project %q, not for %q", plan.ProjectName, projectName)
+	}
+
+	if repo := storageManager.GetStagesStorage().Address(); plan.Repo != repo {
+		return fmt.Errorf("cleanup plan is created for repo %q, not for %q", plan.Repo, repo)
+	}
+
+	var finalRepo string
+	if finalStagesStorage := storageManager.GetFinalStagesStorage(); finalStagesStorage != nil {
+		finalRepo = finalStagesStorage.Address()
+	}
+
+	if plan.FinalRepo != finalRepo {
+		return fmt.Errorf("cleanup plan is created for final repo %q, not for %q", plan.FinalRepo, finalRepo)
+	}
+
+	// resolve stages before any deletion to fail on tags which point to other images than the planned ones
+	stages, err := storageManager.GetStageDescriptionListWithCache(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get stages: %w", err)
+	}
+
+	stagesToDelete, err := getCleanupPlanStagesToDelete(ctx, stages, plan.Stages)
+	if err != nil {
+		return err
+	}
+
+	var finalStages, finalStagesToDelete []*image.StageDescription
+	if storageManager.GetFinalStagesStorage() != nil {
+		finalStages, err = storageManager.GetFinalStageDescriptionList(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to get final stages: %w", err)
+		}
+
+		finalStagesToDelete, err = getCleanupPlanStagesToDelete(ctx, finalStages, plan.FinalStages)
+		if err != nil {
+			return err
+		}
+	}
+
+	var customTagListToDelete []string
+	for _, record := range plan.CustomTags {
+		if record.Action == CleanupPlanActionDelete {
+			customTagListToDelete = append(customTagListToDelete, record.Tag)
+		}
+	}
+
+	if !options.WithoutKube {
+		if len(options.KubernetesContextClients) == 0 {
+			return fmt.Errorf("no kubernetes configs found to skip images being used in the Kubernetes, pass --without-kube option (or WERF_WITHOUT_KUBE env var) to suppress this error")
+		}
+
+		deployedDockerImages, err := getDeployedDockerImages(ctx, options.KubernetesContextClients, options.KubernetesNamespaceRestrictionByContext)
+		if err != nil {
+			return fmt.Errorf("error getting deployed docker images names from Kubernetes: %w", err)
+		}
+
+		deployedImageNames := map[string]bool{}
+		for _, deployedDockerImage := range deployedDockerImages {
+			deployedImageNames[deployedDockerImage.Name] = true
+		}
+
+		usedStageIDs := getCleanupPlanStageIDsUsedInKubernetes(plan.Repo, stages, plan.CustomTags, deployedImageNames)
+		stagesToDelete = excludeCleanupPlanTagsUsedInKubernetes(ctx, stagesToDelete, usedStageIDs)
+
+		var customTagListToKeep []string
+		for _, record := range plan.CustomTags {
+			if record.Action == CleanupPlanActionDelete && (usedStageIDs[record.StageID] || deployedImageNames[fmt.Sprintf("%s:%s", plan.Repo, record.Tag)]) {
+				logboek.Context(ctx).Default().LogF("Skipping tag %s that is used in Kubernetes\n", record.Tag)
+				customTagListToKeep = append(customTagListToKeep, record.Tag)
+			}
+		}
+		customTagListToDelete = util.ExcludeFromStringArray(customTagListToDelete, customTagListToKeep...)
+
+		usedFinalStageIDs := map[string]bool{}
+		for _, stageDesc := range finalStages {
+			if deployedImageNames[fmt.Sprintf("%s:%s", plan.FinalRepo, stageDesc.Info.Tag)] {
+				usedFinalStageIDs[stageDesc.Info.Tag] = true
+			}
+		}
+		finalStagesToDelete = excludeCleanupPlanTagsUsedInKubernetes(ctx, finalStagesToDelete, usedFinalStageIDs)
+	}
+
+	imageMetadataByImageName := map[string]map[string][]string{}
+	for _, record := range plan.ImageMetadata {
+		if record.Action != CleanupPlanActionDelete {
+			continue
+		}
+
+		stageIDCommitList, ok := imageMetadataByImageName[record.ImageName]
+		if !ok {
+			stageIDCommitList = map[string][]string{}
+			imageMetadataByImageName[record.ImageName] = stageIDCommitList
+		}
+
+		stageIDCommitList[record.StageID] = append(stageIDCommitList[record.StageID], record.Commits...)
+	}
+
+	if len(imageMetadataByImageName) != 0 {
+		if err := logboek.Context(ctx).Default().LogProcess("Deleting images metadata").DoError(func() error {
+			for imageName, stageIDCommitList := range imageMetadataByImageName {
+				if err := deleteImageMetadata(ctx, projectName, storageManager, imageName, stageIDCommitList, options.DryRun); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteCleanupPlanStages(ctx, storageManager, storageManager.GetStagesStorage(), stages, stagesToDelete, false, options); err != nil {
+		return err
+	}
+
+	if len(customTagListToDelete) != 0 {
+		if err := logboek.Context(ctx).Default().LogProcess("Deleting custom tags (%d)", len(customTagListToDelete)).DoError(func() error {
+			return deleteCustomTags(ctx, storageManager, customTagListToDelete, options.DryRun, nil)
+		}); err != nil {
+			return err
+		}
+	}
+
+	var importMetadataIDsToDelete []string
+	for _, record := range plan.ImportMetadata {
+		if record.Action == CleanupPlanActionDelete {
+			importMetadataIDsToDelete = append(importMetadataIDsToDelete, record.ID)
+		}
+	}
+
+	if len(importMetadataIDsToDelete) != 0 {
+		if err := logboek.Context(ctx).Default().LogProcess("Cleaning imports metadata (%d)", len(importMetadataIDsToDelete)).DoError(func() error {
+			return deleteImportsMetadata(ctx, projectName, storageManager, importMetadataIDsToDelete, options.DryRun, nil)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if storageManager.GetFinalStagesStorage() != nil {
+		if err := deleteCleanupPlanStages(ctx, storageManager, storageManager.GetFinalStagesStorage(), finalStages, finalStagesToDelete, true, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getCleanupPlanStagesToDelete(ctx context.Context, stages []*image.StageDescription, records []*CleanupPlanStage) ([]*image.StageDescription, error) {
+	stageByTag := map[string]*image.StageDescription{}
+	for _, stageDesc := range stages {
+		stageByTag[stageDesc.Info.Tag] = stageDesc
+	}
+
+	var stagesToDelete []*image.StageDescription
+	for _, record := range records {
+		if record.Action != CleanupPlanActionDelete {
+			continue
+		}
+
+		stageDesc, ok := stageByTag[record.Tag]
+		if !ok {
+			logboek.Context(ctx).Info().LogF("Skipping tag %s that no longer exists\n", record.Tag)
+			continue
+		}
+
+		if record.Digest != "" && stageDesc.Info.GetDigest() != record.Digest {
+			return nil, fmt.Errorf("tag %s digest %s does not match the cleanup plan digest %s", record.Tag, stageDesc.Info.GetDigest(), record.Digest)
+		}
+
+		stagesToDelete = append(stagesToDelete, stageDesc)
+	}
+
+	return stagesToDelete, nil
+}
+
+// getCleanupPlanStageIDsUsedInKubernetes returns the stages used in Kubernetes by the stage tag or by one of the custom tags of the stage.
+func getCleanupPlanStageIDsUsedInKubernetes(repo string, stages []*image.StageDescription, customTags []*CleanupPlanCustomTag, deployedImageNames map[string]bool) map[string]bool {
+	usedStageIDs := map[string]bool{}
+	for _, stageDesc := range stages {
+		if deployedImageNames[fmt.Sprintf("%s:%s", repo, stageDesc.Info.Tag)] {
+			usedStageIDs[stageDesc.Info.Tag] = true
+		}
+	}
+
+	for _, record := range customTags {
+		if deployedImageNames[fmt.Sprintf("%s:%s", repo, record.Tag)] {
+			usedStageIDs[record.StageID] = true
+		}
+	}
+
+	return usedStageIDs
+}
+
+func excludeCleanupPlanTagsUsedInKubernetes(ctx context.Context, stagesToDelete []*image.StageDescription, usedStageIDs map[string]bool) []*image.StageDescription {
+	var res []*image.StageDescription
+	for _, stageDesc := range stagesToDelete {
+		if usedStageIDs[stageDesc.Info.Tag] {
+			logboek.Context(ctx).Default().LogF("Skipping tag %s that is used in Kubernetes\n", stageDesc.Info.Tag)
+			continue
+		}
+
+		res = append(res, stageDesc)
+	}
+
+	return res
+}
+
+func deleteCleanupPlanStages(ctx context.Context, storageManager manager.StorageManagerInterface, stagesStorage storage.StagesStorage, stages, stagesToDelete []*image.StageDescription, isFinal bool, options ApplyCleanupPlanOptions) error {
+	if len(stagesToDelete) == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("Deleting stages tags (%d/%d)", len(stagesToDelete), len(stages))
+	if isFinal {
+		header = fmt.Sprintf("Deleting final stages tags (%d/%d)", len(stagesToDelete), len(stages))
+	}
+
+	if err := logboek.Context(ctx).Default().LogProcess(header).DoError(func() error {
+		deleteStageOptions := manager.ForEachDeleteStageOptions{
+			FilterStagesAndProcessRelatedDataOptions: storage.FilterStagesAndProcessRelatedDataOptions{
+				SkipUsedImage: true,
+			},
+		}
+
+		return deleteStages(ctx, storageManager, options.DryRun, deleteStageOptions, stagesToDelete, isFinal, nil)
+	}); err != nil {
+		return err
+	}
+
+	return cleanupImageReferrers(ctx, stagesStorage, excludeStages(stages, stagesToDelete...), stagesToDelete, options.DryRun)
+}
@@ -0,0 +1,200 @@
+package cleaning
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/image"
+)
+
+const cleanupPlanTestRepository = "registry.werf.local/project"
+
+func newCleanupPlanTestPlan() *CleanupPlan {
+	return &CleanupPlan{
+		Version:     CleanupPlanVersion,
+		ProjectName: "project",
+		Repo:        cleanupPlanTestRepository,
+		CreatedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+
+		stagesToDelete:         map[string]*CleanupPlanStage{},
+		finalStagesToDelete:    map[string]*CleanupPlanStage{},
+		customTagsToDelete:     map[string]*CleanupPlanCustomTag{},
+		importMetadataToDelete: map[string]*CleanupPlanImportMetadata{},
+	}
+}
+
+func newCleanupPlanTestStage(tag, digest string) *image.StageDescription {
+	info := &image.Info{
+		Name:       cleanupPlanTestRepository + ":" + tag,
+		Repository: cleanupPlanTestRepository,
+		Tag:        tag,
+		RepoDigest: cleanupPlanTestRepository + "@" + digest,
+		Size:       100,
+	}
+	info.SetCreatedAtUnix(1611836746)
+
+	return &image.StageDescription{Info: info}
+}
+
+func TestCleanupPlanSaveLoad(t *testing.T) {
+	plan := newCleanupPlanTestPlan()
+	plan.addStages([]*image.StageDescription{newCleanupPlanTestStage("b", "sha256:b"), newCleanupPlanTestStage("a", "sha256:a")}, CleanupPlanActionDelete, cleanupPlanReasonNotCoveredByPolicies)
+	plan.addStages([]*image.StageDescription{newCleanupPlanTestStage("c", "sha256:c")}, CleanupPlanActionKeep, "used in the Kubernetes")
+	plan.addCustomTags("b", []string{"v2", "v1"}, CleanupPlanActionDelete, cleanupPlanReasonNotCoveredByPolicies)
+	metadataRecords := plan.addImageMetadata("backend", map[string][]string{"a": {"commit"}, "c": nil}, CleanupPlanActionDelete, cleanupPlanReasonNotCoveredByPolicies)
+	plan.addImportMetadata([]string{"import"}, CleanupPlanActionDelete, cleanupPlanReasonNotCoveredByPolicies)
+
+	plan.markStageDeleted("a", false)
+	plan.markStageDeleted("c", false)
+	plan.markStageDeleted("b", true)
+	plan.markCustomTagDeleted("v1")
+	plan.markImageMetadataDeleted(metadataRecords)
+	plan.markImportMetadataDeleted("import")
+	plan.setError(errors.New("unable to delete stage b"))
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := plan.Save(path); err != nil {
+		t.Fatalf("unable to save plan: %s", err)
+	}
+
+	loadedPlan, err := LoadCleanupPlan(path)
+	if err != nil {
+		t.Fatalf("unable to load plan: %s", err)
+	}
+
+	if loadedPlan.ProjectName != plan.ProjectName || loadedPlan.Repo != plan.Repo || !loadedPlan.CreatedAt.Equal(plan.CreatedAt) {
+		t.Errorf("expected plan %+v, got %+v", plan, loadedPlan)
+	}
+	if loadedPlan.Error != "unable to delete stage b" {
+		t.Errorf("expected the cleanup error to be saved, got %q", loadedPlan.Error)
+	}
+
+	// the records are sorted, only the applied deletions are marked
+	expectedStages := []*CleanupPlanStage{
+		{Tag: "a", Digest: "sha256:a", Size: 100, CreatedAt: time.Unix(1611836746, 0).UTC(), Action: CleanupPlanActionDelete, Reason: cleanupPlanReasonNotCoveredByPolicies, Deleted: true},
+		{Tag: "b", Digest: "sha256:b", Size: 100, CreatedAt: time.Unix(1611836746, 0).UTC(), Action: CleanupPlanActionDelete, Reason: cleanupPlanReasonNotCoveredByPolicies},
+		{Tag: "c", Digest: "sha256:c", Size: 100, CreatedAt: time.Unix(1611836746, 0).UTC(), Action: CleanupPlanActionKeep, Reason: "used in the Kubernetes"},
+	}
+	if !reflect.DeepEqual(loadedPlan.Stages, expectedStages) {
+		t.Errorf("expected stages %+v, got %+v", expectedStages, loadedPlan.Stages)
+	}
+
+	expectedCustomTags := []*CleanupPlanCustomTag{
+		{Tag: "v1", StageID: "b", Action: CleanupPlanActionDelete, Reason: cleanupPlanReasonNotCoveredByPolicies, Deleted: true},
+		{Tag: "v2", StageID: "b", Action: CleanupPlanActionDelete, Reason: cleanupPlanReasonNotCoveredByPolicies},
+	}
+	if !reflect.DeepEqual(loadedPlan.CustomTags, expectedCustomTags) {
+		t.Errorf("expected custom tags %+v, got %+v", expectedCustomTags, loadedPlan.CustomTags)
+	}
+
+	expectedImageMetadata := []*CleanupPlanImageMetadata{
+		{ImageName: "backend", StageID: "a", Commits: []string{"commit"}, Action: CleanupPlanActionDelete, Reason: cleanupPlanReasonNotCoveredByPolicies, Deleted: true},
+	}
+	if !reflect.DeepEqual(loadedPlan.ImageMetadata, expectedImageMetadata) {
+		t.Errorf("expected image metadata %+v, got %+v", expectedImageMetadata, loadedPlan.ImageMetadata)
+	}
+
+	expectedImportMetadata := []*CleanupPlanImportMetadata{
+		{ID: "import", Action: CleanupPlanActionDelete, Reason: cleanupPlanReasonNotCoveredByPolicies, Deleted: true},
+	}
+	if !reflect.DeepEqual(loadedPlan.ImportMetadata, expectedImportMetadata) {
+		t.Errorf("expected import metadata %+v, got %+v", expectedImportMetadata, loadedPlan.ImportMetadata)
+	}
+}
+
+func TestLoadCleanupPlanUnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(path, []byte(`{"version": 100, "projectName": "project"}`), 0o644); err != nil {
+		t.Fatalf("unable to write plan: %s", err)
+	}
+
+	if _, err := LoadCleanupPlan(path); err == nil || !strings.Contains(err.Error(), "unsupported cleanup plan") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
+
+func TestCleanupPlanMarkNilPlan(t *testing.T) {
+	var plan *CleanupPlan
+	plan.markStageDeleted("a", false)
+	plan.markCustomTagDeleted("v1")
+	plan.markImageMetadataDeleted([]*CleanupPlanImageMetadata{{}})
+	plan.markImportMetadataDeleted("import")
+}
+
+func TestGetCleanupPlanStagesToDelete(t *testing.T) {
+	ctx := logboek.NewContext(context.Background(), logboek.DefaultLogger())
+	stages := []*image.StageDescription{
+		newCleanupPlanTestStage("a", "sha256:a"),
+		newCleanupPlanTestStage("b", "sha256:b"),
+		newCleanupPlanTestStage("c", "sha256:c"),
+	}
+
+	t.Run("existing and missing tags", func(t *testing.T) {
+		records := []*CleanupPlanStage{
+			{Tag: "a", Digest: "sha256:a", Action: CleanupPlanActionDelete},
+			{Tag: "b", Digest: "sha256:b", Action: CleanupPlanActionKeep},
+			{Tag: "c", Action: CleanupPlanActionDelete},
+			{Tag: "missing", Digest: "sha256:missing", Action: CleanupPlanActionDelete},
+		}
+
+		stagesToDelete, err := getCleanupPlanStagesToDelete(ctx, stages, records)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expected := []*image.StageDescription{stages[0], stages[2]}
+		if !reflect.DeepEqual(stagesToDelete, expected) {
+			t.Errorf("expected stages %v, got %v", expected, stagesToDelete)
+		}
+	})
+
+	t.Run("digest mismatch", func(t *testing.T) {
+		records := []*CleanupPlanStage{
+			{Tag: "a", Digest: "sha256:a", Action: CleanupPlanActionDelete},
+			{Tag: "b", Digest: "sha256:other", Action: CleanupPlanActionDelete},
+		}
+
+		stagesToDelete, err := getCleanupPlanStagesToDelete(ctx, stages, records)
+		if err == nil || !strings.Contains(err.Error(), "does not match the cleanup plan digest") {
+			t.Fatalf("expected digest mismatch error, got %v", err)
+		}
+		if stagesToDelete != nil {
+			t.Errorf("expected no stages to delete, got %v", stagesToDelete)
+		}
+	})
+}
+
+func TestExcludeCleanupPlanTagsUsedInKubernetes(t *testing.T) {
+	ctx := logboek.NewContext(context.Background(), logboek.DefaultLogger())
+	stages := []*image.StageDescription{
+		newCleanupPlanTestStage("a", "sha256:a"),
+		newCleanupPlanTestStage("b", "sha256:b"),
+		newCleanupPlanTestStage("c", "sha256:c"),
+	}
+	customTags := []*CleanupPlanCustomTag{
+		{Tag: "v1", StageID: "b", Action: CleanupPlanActionDelete},
+		{Tag: "v2", StageID: "c", Action: CleanupPlanActionDelete},
+	}
+	deployedImageNames := map[string]bool{
+		cleanupPlanTestRepository + ":a":  true,
+		cleanupPlanTestRepository + ":v1": true,
+		"registry.werf.local/other:c":     true,
+	}
+
+	usedStageIDs := getCleanupPlanStageIDsUsedInKubernetes(cleanupPlanTestRepository, stages, customTags, deployedImageNames)
+	if expected := map[string]bool{"a": true, "b": true}; !reflect.DeepEqual(usedStageIDs, expected) {
+		t.Errorf("expected used stages %v, got %v", expected, usedStageIDs)
+	}
+
+	stagesToDelete := excludeCleanupPlanTagsUsedInKubernetes(ctx, stages, usedStageIDs)
+	if expected := stages[2:]; !reflect.DeepEqual(stagesToDelete, expected) {
+		t.Errorf("expected stages %v, got %v", expected, stagesToDelete)
+	}
+}
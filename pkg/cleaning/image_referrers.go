@@ -0,0 +1,99 @@
+package cleaning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/storage"
+)
+
+// cleanupImageReferrers deletes the referrers tags left after the stages deletion: the referrers tag schema indexes of the deleted images
+// and the signatures, SBOMs and other artifacts of the images deleted without their referrers (by other tools or earlier werf versions).
+// The referrers of the deleted stages are deleted with the stages by the stages storage.
+// Artifacts of the kept stages are skipped without checking the image existence.
+func cleanupImageReferrers(ctx context.Context, stagesStorage storage.StagesStorage, keptStages, deletedStages []*image.StageDescription, dryRun bool) error {
+	tags, err := stagesStorage.GetImageReferrerTags(ctx, storage.WithCache())
+	if err != nil {
+		return fmt.Errorf("unable to get image referrers tags: %w", err)
+	}
+
+	keptDigests := getStagesImageDigests(keptStages)
+	deletedDigests := getStagesImageDigests(deletedStages)
+
+	var tagsToDelete []string
+	for _, tag := range tags {
+		digest, _ := storage.GetImageDigestByReferrerTag(tag)
+		if keptDigests[digest] {
+			continue
+		}
+
+		if deletedDigests[digest] {
+			tagsToDelete = append(tagsToDelete, tag)
+			continue
+		}
+
+		exist, err := stagesStorage.IsImageDigestExist(ctx, digest)
+		if err != nil {
+			return err
+		}
+
+		if !exist {
+			tagsToDelete = append(tagsToDelete, tag)
+		}
+	}
+
+	if len(tagsToDelete) == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("Deleting orphaned image referrers tags (%d/%d)", len(tagsToDelete), len(tags))
+	return logboek.Context(ctx).Default().LogProcess(header).DoError(func() error {
+		return deleteImageReferrerTags(ctx, stagesStorage, tagsToDelete, dryRun)
+	})
+}
+
+func deleteImageReferrerTags(ctx context.Context, stagesStorage storage.StagesStorage, tags []string, dryRun bool) error {
+	for _, tag := range tags {
+		if !dryRun {
+			if err := stagesStorage.DeleteImageReferrerTag(ctx, tag); err != nil {
+				if err := handleDeletionError(err); err != nil {
+					return err
+				}
+
+				logboek.Context(ctx).Warn().LogF("WARNING: Image referrer tag %s deletion failed: %s\n", tag, err)
+				continue
+			}
+		}
+
+		logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", tag)
+		logboek.Context(ctx).LogOptionalLn()
+	}
+
+	return nil
+}
+
+// getStagesImageDigests returns manifest digests of the stages images including platform manifests of multiplatform images.
+func getStagesImageDigests(stages []*image.StageDescription) map[string]bool {
+	res := map[string]bool{}
+
+	var addInfo func(info *image.Info)
+	addInfo = func(info *image.Info) {
+		if digest := info.GetDigest(); digest != "" {
+			res[digest] = true
+		}
+
+		for _, platformInfo := range info.Index {
+			addInfo(platformInfo)
+		}
+	}
+
+	for _, stg := range stages {
+		if stg.Info != nil {
+			addInfo(stg.Info)
+		}
+	}
+
+	return res
+}
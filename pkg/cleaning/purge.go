@@ -43,7 +43,11 @@ func (m *purgeManager) run(ctx context.Context) error {
 			return err
 		}
 
-		return m.deleteStages(ctx, stages, false)
+		if err := m.deleteStages(ctx, stages, false); err != nil {
+			return err
+		}
+
+		return cleanupImageReferrers(ctx, m.StorageManager.GetStagesStorage(), nil, stages, m.DryRun)
 	}); err != nil {
 		return err
 	}
@@ -89,7 +93,11 @@ func (m *purgeManager) run(ctx context.Context) error {
 				return err
 			}
 
-			return m.deleteStages(ctx, finalStages, true)
+			if err := m.deleteStages(ctx, finalStages, true); err != nil {
+				return err
+			}
+
+			return cleanupImageReferrers(ctx, m.StorageManager.GetFinalStagesStorage(), nil, finalStages, m.DryRun)
 		}); err != nil {
 			return err
 		}
@@ -110,11 +118,11 @@ func (m *purgeManager) deleteStages(ctx context.Context, stages []*image.StageDe
 		},
 	}
 
-	return deleteStages(ctx, m.StorageManager, m.DryRun, deleteStageOptions, stages, isFinal)
+	return deleteStages(ctx, m.StorageManager, m.DryRun, deleteStageOptions, stages, isFinal, nil)
 }
 
 func (m *purgeManager) deleteImportsMetadata(ctx context.Context, importsMetadataIDs []string) error {
-	return deleteImportsMetadata(ctx, m.ProjectName, m.StorageManager, importsMetadataIDs, m.DryRun)
+	return deleteImportsMetadata(ctx, m.ProjectName, m.StorageManager, importsMetadataIDs, m.DryRun, nil)
 }
 
 func (m *purgeManager) deleteManagedImages(ctx context.Context, managedImages []string) error {
@@ -159,7 +167,7 @@ func (m *purgeManager) deleteCustomTags(ctx context.Context) error {
 			customTagList = append(customTagList, list...)
 		}
 
-		if err := deleteCustomTags(ctx, m.StorageManager, customTagList, m.DryRun); err != nil {
+		if err := deleteCustomTags(ctx, m.StorageManager, customTagList, m.DryRun, nil); err != nil {
 			return err
 		}
 
@@ -171,7 +179,7 @@ func (m *purgeManager) deleteCustomTags(ctx context.Context) error {
 	return nil
 }
 
-func deleteCustomTags(ctx context.Context, storageManager manager.StorageManagerInterface, customTagList []string, dryRun bool) error {
+func deleteCustomTags(ctx context.Context, storageManager manager.StorageManagerInterface, customTagList []string, dryRun bool, plan *CleanupPlan) error {
 	if dryRun {
 		for _, customTag := range customTagList {
 			logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", customTag)
@@ -186,6 +194,8 @@ func deleteCustomTags(ctx context.Context, storageManager manager.StorageManager
 			if err := handleDeletionError(err); err != nil {
 				return err
 			}
+		} else {
+			plan.markCustomTagDeleted(tag)
 		}
 
 		logboek.Context(ctx).Default().LogFDetails("  tag: %s\n", tag)
@@ -3,13 +3,20 @@ package stage_manager
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/werf/werf/pkg/image"
 	"github.com/werf/werf/pkg/storage"
 	"github.com/werf/werf/pkg/storage/manager"
 )
 
+const (
+	ProtectionReasonKubernetes = "used in the Kubernetes"
+	ProtectionReasonGitHistory = "found in the git history"
+)
+
 type Manager struct {
 	stages               map[string]*stage
 	stageIDCustomTagList map[string][]string
@@ -187,6 +194,11 @@ func (m *Manager) GetFinalStageIDList() []string {
 }
 
 func (m *Manager) MarkStageAsProtected(stageID, reason string) {
+	// keep the first reason, the stage used in the Kubernetes must not be unprotected by the git history-based policies
+	if m.stages[stageID].isProtected {
+		return
+	}
+
 	m.stages[stageID].isProtected = true
 	m.stages[stageID].protectionReason = reason
 }
@@ -345,6 +357,238 @@ func (m *Manager) GetProtectedStageDescriptionListByReason() map[string][]*image
 	return res
 }
 
+// UnprotectExpiredStages method removes the protection of the stages found in the git history, which were built more days ago than the daysFunc returns for the stage images (0 means never).
+// The stage is expired only if it is expired for all its images, the stages used in the Kubernetes remain protected.
+func (m *Manager) UnprotectExpiredStages(daysFunc func(imageName string) uint64) []*image.StageDescription {
+	var result []*image.StageDescription
+stagesLoop:
+	for _, stage := range m.stages {
+		if !stage.isProtected || stage.protectionReason != ProtectionReasonGitHistory {
+			continue
+		}
+
+		age := time.Since(stage.description.Info.GetCreatedAt())
+		for _, imageName := range m.getStageImageNameList(stage.stageID) {
+			days := daysFunc(imageName)
+			if days == 0 || age < time.Duration(days)*24*time.Hour {
+				continue stagesLoop
+			}
+		}
+
+		m.unprotectStage(stage)
+		result = append(result, stage.description)
+	}
+
+	return result
+}
+
+// UnprotectStagesExceedingTotalSize method removes the protection of the oldest stages found in the git history until the size of the kept stages fits the limit.
+// The keptStagesFunc returns the stages kept with the current protection including the relatives of the protected stages.
+// The stages of the images excluded by the isExcludedFunc and the stages used in the Kubernetes remain protected.
+func (m *Manager) UnprotectStagesExceedingTotalSize(limit int64, isExcludedFunc func(imageName string) bool, keptStagesFunc func() []*image.StageDescription) []*image.StageDescription {
+	keptStages := keptStagesFunc()
+	keptSize := newStagesSizeCounter(keptStages)
+	if keptSize.size <= limit {
+		return nil
+	}
+
+	var candidates []*stage
+candidatesLoop:
+	for _, stage := range m.stages {
+		if !stage.isProtected || stage.protectionReason != ProtectionReasonGitHistory {
+			continue
+		}
+
+		for _, imageName := range m.getStageImageNameList(stage.stageID) {
+			if isExcludedFunc(imageName) {
+				continue candidatesLoop
+			}
+		}
+
+		candidates = append(candidates, stage)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		iCreatedAt := candidates[i].description.Info.CreatedAtUnixNano
+		jCreatedAt := candidates[j].description.Info.CreatedAtUnixNano
+		if iCreatedAt == jCreatedAt {
+			return candidates[i].stageID < candidates[j].stageID
+		}
+
+		return iCreatedAt < jCreatedAt
+	})
+
+	releasedStagesByCandidate := m.getReleasedStagesByCandidate(candidates, keptStages, keptStagesFunc)
+
+	var result []*image.StageDescription
+	for i, stage := range candidates {
+		if keptSize.size <= limit {
+			break
+		}
+
+		m.unprotectStage(stage)
+		result = append(result, stage.description)
+
+		for _, stg := range releasedStagesByCandidate[i] {
+			keptSize.remove(stg)
+		}
+	}
+
+	return result
+}
+
+// getReleasedStagesByCandidate method returns the kept stages that are no longer kept after the protection of the candidates is removed in order.
+// The stage is released by the last candidate among the stage and the stages it is the relative of,
+// the stages kept without the candidates protection (by other protected stages or other policies) are never released.
+func (m *Manager) getReleasedStagesByCandidate(candidates []*stage, keptStages []*image.StageDescription, keptStagesFunc func() []*image.StageDescription) map[int][]*image.StageDescription {
+	for _, stage := range candidates {
+		stage.isProtected = false
+	}
+
+	keptWithoutCandidates := map[string]bool{}
+	for _, stg := range keptStagesFunc() {
+		keptWithoutCandidates[stg.StageID.String()] = true
+	}
+
+	for _, stage := range candidates {
+		stage.isProtected = true
+	}
+
+	keptStageByImageID := map[string]*image.StageDescription{}
+	for _, stg := range keptStages {
+		keptStageByImageID[stg.Info.ID] = stg
+	}
+
+	releasingCandidate := map[string]int{}
+	var markReleasingCandidate func(stg *image.StageDescription, i int)
+	markReleasingCandidate = func(stg *image.StageDescription, i int) {
+		if stg == nil || keptWithoutCandidates[stg.StageID.String()] || releasingCandidate[stg.StageID.String()] == i+1 {
+			return
+		}
+		releasingCandidate[stg.StageID.String()] = i + 1
+
+		// the relatives of the kept stage are its parents
+		if stg.Info.IsIndex {
+			for _, info := range stg.Info.Index {
+				markReleasingCandidate(keptStageByImageID[info.ParentID], i)
+			}
+		} else {
+			markReleasingCandidate(keptStageByImageID[stg.Info.ParentID], i)
+		}
+	}
+
+	// the later candidate overrides the earlier one, since the stage remains kept until all its candidates are unprotected
+	for i, stage := range candidates {
+		markReleasingCandidate(keptStageByImageID[stage.description.Info.ID], i)
+	}
+
+	res := map[int][]*image.StageDescription{}
+	for _, stg := range keptStages {
+		if i, ok := releasingCandidate[stg.StageID.String()]; ok {
+			res[i-1] = append(res[i-1], stg)
+		}
+	}
+
+	return res
+}
+
+func (m *Manager) unprotectStage(stage *stage) {
+	stage.isProtected = false
+	stage.protectionReason = ""
+}
+
+// getStageImageNameList method returns names of the images which metadata refers to the stage, or the empty name for the stage without metadata
+func (m *Manager) getStageImageNameList(stageID string) []string {
+	var result []string
+	for _, im := range m.imageMetadataList {
+		if im.stageID == stageID && !im.isNonexistentImage {
+			result = append(result, im.imageName)
+		}
+	}
+
+	if len(result) == 0 {
+		return []string{""}
+	}
+
+	return result
+}
+
+// StagesSize returns the approximate size of the stages in the container registry.
+// The stage image contains the layers of the parent stage, thus only the difference with the parent is counted if the parent is one of the stages.
+func StagesSize(stages []*image.StageDescription) int64 {
+	return newStagesSizeCounter(stages).size
+}
+
+// stagesSizeCounter keeps StagesSize of the stages while the stages are removed one by one.
+type stagesSizeCounter struct {
+	infoByID     map[string]*image.Info
+	childrenByID map[string][]*image.Info
+	size         int64
+}
+
+func newStagesSizeCounter(stages []*image.StageDescription) *stagesSizeCounter {
+	c := &stagesSizeCounter{
+		infoByID:     map[string]*image.Info{},
+		childrenByID: map[string][]*image.Info{},
+	}
+
+	for _, stg := range stages {
+		for _, info := range getStageSizeInfoList(stg) {
+			c.infoByID[info.ID] = info
+		}
+	}
+
+	for _, info := range c.infoByID {
+		c.childrenByID[info.ParentID] = append(c.childrenByID[info.ParentID], info)
+		c.size += c.infoSize(info)
+	}
+
+	return c
+}
+
+func (c *stagesSizeCounter) remove(stg *image.StageDescription) {
+	for _, info := range getStageSizeInfoList(stg) {
+		if _, ok := c.infoByID[info.ID]; !ok {
+			continue
+		}
+
+		// the children of the removed stage are counted in full
+		children := c.childrenByID[info.ID]
+		for _, child := range children {
+			if _, ok := c.infoByID[child.ID]; ok {
+				c.size -= c.infoSize(child)
+			}
+		}
+
+		c.size -= c.infoSize(info)
+		delete(c.infoByID, info.ID)
+
+		for _, child := range children {
+			if _, ok := c.infoByID[child.ID]; ok {
+				c.size += c.infoSize(child)
+			}
+		}
+	}
+}
+
+func (c *stagesSizeCounter) infoSize(info *image.Info) int64 {
+	size := info.Size
+	if parent, ok := c.infoByID[info.ParentID]; ok && parent.Size <= size {
+		size -= parent.Size
+	}
+
+	return size
+}
+
+// getStageSizeInfoList returns the platform images of the multiplatform stage or the stage image.
+func getStageSizeInfoList(stg *image.StageDescription) []*image.Info {
+	if stg.Info.IsIndex {
+		return stg.Info.Index
+	}
+
+	return []*image.Info{stg.Info}
+}
+
 func (m *Manager) IsStageExist(stageID string) bool {
 	_, exist := m.stages[stageID]
 	return exist
@@ -0,0 +1,347 @@
+package stage_manager
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+type testStage struct {
+	id       string
+	parentID string
+	size     int64
+	age      time.Duration
+	images   []string
+	reason   string
+}
+
+func newTestManager(stages []testStage) *Manager {
+	m := NewManager()
+	now := time.Now()
+
+	for _, stg := range stages {
+		m.stages[stg.id] = newStage(stg.id, &image.StageDescription{
+			StageID: image.NewStageID(stg.id, now.Add(-stg.age).UnixMilli()),
+			Info: &image.Info{
+				Name:              "registry.werf.local/project:" + stg.id,
+				Tag:               stg.id,
+				ID:                "id-" + stg.id,
+				ParentID:          "id-" + stg.parentID,
+				Size:              stg.size,
+				CreatedAtUnixNano: now.Add(-stg.age).UnixNano(),
+			},
+		})
+
+		if stg.reason != "" {
+			m.MarkStageAsProtected(stg.id, stg.reason)
+		}
+
+		for _, imageName := range stg.images {
+			m.getOrCreateImageMetadata(imageName, stg.id)
+		}
+	}
+
+	return &m
+}
+
+// keptStagesFunc returns the protected and the pinned stages with their parents as the cleanup does.
+func keptStagesFunc(m *Manager, pinned ...string) func() []*image.StageDescription {
+	return func() []*image.StageDescription {
+		var roots []*stage
+		for _, stg := range m.stages {
+			if stg.isProtected {
+				roots = append(roots, stg)
+			}
+		}
+		for _, id := range pinned {
+			roots = append(roots, m.stages[id])
+		}
+
+		stageByImageID := map[string]*stage{}
+		for _, stg := range m.stages {
+			stageByImageID[stg.description.Info.ID] = stg
+		}
+
+		kept := map[string]*image.StageDescription{}
+		for _, stg := range roots {
+			for ; stg != nil && kept[stg.stageID] == nil; stg = stageByImageID[stg.description.Info.ParentID] {
+				kept[stg.stageID] = stg.description
+			}
+		}
+
+		var res []*image.StageDescription
+		for _, desc := range kept {
+			res = append(res, desc)
+		}
+
+		return res
+	}
+}
+
+func stageTags(stages []*image.StageDescription) []string {
+	res := []string{}
+	for _, stg := range stages {
+		res = append(res, stg.Info.Tag)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func TestUnprotectExpiredStages(t *testing.T) {
+	day := 24 * time.Hour
+	m := newTestManager([]testStage{
+		{id: "expired", age: 10 * day, images: []string{"backend"}, reason: ProtectionReasonGitHistory},
+		{id: "fresh", age: 2 * day, images: []string{"backend"}, reason: ProtectionReasonGitHistory},
+		{id: "expired-for-one-image", age: 10 * day, images: []string{"backend", "frontend"}, reason: ProtectionReasonGitHistory},
+		{id: "never-expired", age: 100 * day, images: []string{"frontend"}, reason: ProtectionReasonGitHistory},
+		{id: "without-metadata", age: 10 * day, reason: ProtectionReasonGitHistory},
+		{id: "used-in-kubernetes", age: 10 * day, images: []string{"backend"}, reason: ProtectionReasonKubernetes},
+		{id: "not-protected", age: 10 * day, images: []string{"backend"}},
+	})
+
+	daysFunc := func(imageName string) uint64 {
+		switch imageName {
+		case "backend", "":
+			return 7
+		default:
+			return 0
+		}
+	}
+
+	expected := []string{"expired", "without-metadata"}
+	if unprotected := stageTags(m.UnprotectExpiredStages(daysFunc)); !reflect.DeepEqual(unprotected, expected) {
+		t.Errorf("expected unprotected %v, got %v", expected, unprotected)
+	}
+
+	expectedProtected := []string{"expired-for-one-image", "fresh", "never-expired", "used-in-kubernetes"}
+	if protected := stageTags(m.GetStageDescriptionList(StageDescriptionListOptions{OnlyProtected: true})); !reflect.DeepEqual(protected, expectedProtected) {
+		t.Errorf("expected protected %v, got %v", expectedProtected, protected)
+	}
+}
+
+func TestUnprotectStagesExceedingTotalSize(t *testing.T) {
+	hour := time.Hour
+
+	// base <- dependencies <- app-1 <- app-2
+	//                      <- app-3
+	// other-base <- other-app
+	stages := []testStage{
+		{id: "base", size: 100, age: 10 * hour, images: []string{"app"}},
+		{id: "dependencies", parentID: "base", size: 150, age: 9 * hour, images: []string{"app"}, reason: ProtectionReasonGitHistory},
+		{id: "app-1", parentID: "dependencies", size: 160, age: 8 * hour, images: []string{"app"}, reason: ProtectionReasonGitHistory},
+		{id: "app-2", parentID: "app-1", size: 170, age: 7 * hour, images: []string{"app"}, reason: ProtectionReasonGitHistory},
+		{id: "app-3", parentID: "dependencies", size: 180, age: 6 * hour, images: []string{"app"}, reason: ProtectionReasonGitHistory},
+		{id: "other-base", size: 1000, age: 20 * hour, images: []string{"other"}},
+		{id: "other-app", parentID: "other-base", size: 1010, age: 5 * hour, images: []string{"other"}, reason: ProtectionReasonGitHistory},
+	}
+
+	for _, tt := range []struct {
+		name                string
+		limit               int64
+		excluded            []string
+		usedInKubernetes    []string
+		pinned              []string
+		expectedUnprotected []string
+		expectedKeptSize    int64
+	}{
+		{
+			name:             "fits the limit",
+			limit:            1210,
+			expectedKeptSize: 1210,
+		},
+		{
+			// the dependencies and app-1 stages are kept as the parents of the other app stages, so they are unprotected without freeing the size
+			name:                "oldest stages",
+			limit:               1200,
+			expectedUnprotected: []string{"app-1", "app-2", "dependencies"},
+			expectedKeptSize:    1190,
+		},
+		{
+			name:                "all stages",
+			limit:               100,
+			expectedUnprotected: []string{"app-1", "app-2", "app-3", "dependencies", "other-app"},
+			expectedKeptSize:    0,
+		},
+		{
+			name:                "excluded image",
+			limit:               100,
+			excluded:            []string{"other"},
+			expectedUnprotected: []string{"app-1", "app-2", "app-3", "dependencies"},
+			expectedKeptSize:    1010,
+		},
+		{
+			name:                "used in kubernetes",
+			limit:               100,
+			usedInKubernetes:    []string{"app-2"},
+			expectedUnprotected: []string{"app-1", "app-3", "dependencies", "other-app"},
+			expectedKeptSize:    170,
+		},
+		{
+			name:                "kept by other policy",
+			limit:               100,
+			pinned:              []string{"other-app"},
+			expectedUnprotected: []string{"app-1", "app-2", "app-3", "dependencies", "other-app"},
+			expectedKeptSize:    1010,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(stages)
+			for _, id := range tt.usedInKubernetes {
+				m.stages[id].isProtected = true
+				m.stages[id].protectionReason = ProtectionReasonKubernetes
+			}
+
+			isExcludedFunc := func(imageName string) bool {
+				for _, excluded := range tt.excluded {
+					if imageName == excluded {
+						return true
+					}
+				}
+				return false
+			}
+
+			kept := keptStagesFunc(m, tt.pinned...)
+			unprotected := stageTags(m.UnprotectStagesExceedingTotalSize(tt.limit, isExcludedFunc, kept))
+			if tt.expectedUnprotected == nil {
+				tt.expectedUnprotected = []string{}
+			}
+			if !reflect.DeepEqual(unprotected, tt.expectedUnprotected) {
+				t.Errorf("expected unprotected %v, got %v", tt.expectedUnprotected, unprotected)
+			}
+
+			if keptSize := StagesSize(kept()); keptSize != tt.expectedKeptSize {
+				t.Errorf("expected kept size %d, got %d", tt.expectedKeptSize, keptSize)
+			}
+		})
+	}
+}
+
+// TestUnprotectStagesExceedingTotalSizeRandomTrees checks the running kept size against the kept stages size calculated after each unprotected stage.
+func TestUnprotectStagesExceedingTotalSizeRandomTrees(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 50; n++ {
+		var stages []testStage
+		var pinned []string
+		ages := random.Perm(40)
+		for i := 0; i < 40; i++ {
+			stg := testStage{
+				id:   fmt.Sprintf("stage-%d", i),
+				size: random.Int63n(1000),
+				age:  time.Duration(ages[i]) * time.Hour,
+			}
+			if i > 0 && random.Intn(4) != 0 {
+				stg.parentID = fmt.Sprintf("stage-%d", random.Intn(i))
+			}
+			if random.Intn(3) != 0 {
+				stg.reason = ProtectionReasonGitHistory
+			} else if random.Intn(5) == 0 {
+				pinned = append(pinned, stg.id)
+			}
+			stages = append(stages, stg)
+		}
+
+		m := newTestManager(stages)
+		kept := keptStagesFunc(m, pinned...)
+		limit := StagesSize(kept()) * int64(random.Intn(100)) / 100
+
+		unprotected := m.UnprotectStagesExceedingTotalSize(limit, func(string) bool { return false }, kept)
+
+		// the stages are unprotected one by one until the kept size fits the limit
+		expected := newTestManager(stages)
+		expectedKept := keptStagesFunc(expected, pinned...)
+		var candidates []*stage
+		for _, stg := range expected.stages {
+			if stg.isProtected {
+				candidates = append(candidates, stg)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].description.Info.CreatedAtUnixNano < candidates[j].description.Info.CreatedAtUnixNano
+		})
+
+		var expectedUnprotected []*image.StageDescription
+		for _, stg := range candidates {
+			if StagesSize(expectedKept()) <= limit {
+				break
+			}
+			expected.unprotectStage(stg)
+			expectedUnprotected = append(expectedUnprotected, stg.description)
+		}
+
+		if got, want := stageTags(unprotected), stageTags(expectedUnprotected); !reflect.DeepEqual(got, want) {
+			t.Fatalf("tree %d: expected unprotected %v, got %v", n, want, got)
+		}
+	}
+}
+
+func TestStagesSize(t *testing.T) {
+	info := func(id, parentID string, size int64) *image.Info {
+		return &image.Info{ID: id, ParentID: parentID, Size: size}
+	}
+	desc := func(info *image.Info) *image.StageDescription {
+		return &image.StageDescription{Info: info}
+	}
+	index := func(infos ...*image.Info) *image.StageDescription {
+		return &image.StageDescription{Info: &image.Info{ID: "index", IsIndex: true, Index: infos}}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		stages   []*image.StageDescription
+		expected int64
+	}{
+		{name: "empty", expected: 0},
+		{name: "parent difference", stages: []*image.StageDescription{desc(info("base", "", 100)), desc(info("app", "base", 150))}, expected: 150},
+		{name: "parent is not in the stages", stages: []*image.StageDescription{desc(info("app", "base", 150))}, expected: 150},
+		{name: "parent bigger than the child", stages: []*image.StageDescription{desc(info("base", "", 200)), desc(info("app", "base", 150))}, expected: 350},
+		{name: "duplicated stage", stages: []*image.StageDescription{desc(info("base", "", 100)), desc(info("base", "", 100))}, expected: 100},
+		{
+			name: "multiplatform",
+			stages: []*image.StageDescription{
+				index(info("base-amd64", "", 100), info("base-arm64", "", 90)),
+				index(info("app-amd64", "base-amd64", 130), info("app-arm64", "base-arm64", 110)),
+			},
+			expected: 240,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if size := StagesSize(tt.stages); size != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, size)
+			}
+		})
+	}
+}
+
+func TestStagesSizeCounterRemove(t *testing.T) {
+	var stages []*image.StageDescription
+	for i, parent := range []int{-1, 0, 1, 1, 0, -1, 5, 6} {
+		info := &image.Info{ID: fmt.Sprint(i), Size: int64(100 + 10*i)}
+		if parent >= 0 {
+			info.ParentID = fmt.Sprint(parent)
+		}
+		stages = append(stages, &image.StageDescription{Info: info})
+	}
+
+	c := newStagesSizeCounter(stages)
+	for _, i := range []int{1, 7, 0, 3, 5, 2, 6, 4} {
+		c.remove(stages[i])
+
+		var rest []*image.StageDescription
+		for _, stg := range stages {
+			if _, ok := c.infoByID[stg.Info.ID]; ok {
+				rest = append(rest, stg)
+			}
+		}
+
+		if expected := StagesSize(rest); c.size != expected {
+			t.Fatalf("after stage %d removal: expected %d, got %d", i, expected, c.size)
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package config
 
 type MetaBuild struct {
-	Platform []string
+	Platform        []string
+	RegistryMirrors []MetaBuildRegistryMirror
+}
+
+// MetaBuildRegistryMirror describes the mirrors used to resolve and pull base images of the Registry.
+type MetaBuildRegistryMirror struct {
+	Registry string
+	Mirrors  []string
 }
@@ -12,7 +12,59 @@ type MetaCleanup struct {
 	DisableGitHistoryBasedPolicy       bool
 	DisableBuiltWithinLastNHoursPolicy bool
 	KeepImagesBuiltWithinLastNHours    uint64
+	DeleteImagesOlderThanNDays         uint64
+	KeepImagesWithinTotalSizeGB        float64
 	KeepPolicies                       []*MetaCleanupKeepPolicy
+	ImagePolicies                      []*MetaCleanupImagePolicy
+}
+
+// GetDeleteImagesOlderThanNDays returns the number of days after which the image is deleted regardless of the git history (0 means never).
+// The first image policy matching the image name overrides the common value.
+func (c *MetaCleanup) GetDeleteImagesOlderThanNDays(imageName string) uint64 {
+	if policy := c.getImagePolicy(imageName); policy != nil && policy.DeleteImagesOlderThanNDays != nil {
+		return *policy.DeleteImagesOlderThanNDays
+	}
+
+	return c.DeleteImagesOlderThanNDays
+}
+
+// IsTotalSizePolicyDisabled returns true if the first image policy matching the image name excludes the image from the total size policy.
+func (c *MetaCleanup) IsTotalSizePolicyDisabled(imageName string) bool {
+	if policy := c.getImagePolicy(imageName); policy != nil {
+		return policy.DisableTotalSizePolicy
+	}
+
+	return false
+}
+
+func (c *MetaCleanup) getImagePolicy(imageName string) *MetaCleanupImagePolicy {
+	for _, policy := range c.ImagePolicies {
+		if policy.ImageRegexp.MatchString(imageName) {
+			return policy
+		}
+	}
+
+	return nil
+}
+
+type MetaCleanupImagePolicy struct {
+	ImageRegexp                *regexp.Regexp
+	DeleteImagesOlderThanNDays *uint64
+	DisableTotalSizePolicy     bool
+}
+
+func (p *MetaCleanupImagePolicy) String() string {
+	parts := []string{fmt.Sprintf("image=%s", p.ImageRegexp.String())}
+
+	if p.DeleteImagesOlderThanNDays != nil {
+		parts = append(parts, fmt.Sprintf("deleteImagesOlderThanNDays=%d", *p.DeleteImagesOlderThanNDays))
+	}
+
+	if p.DisableTotalSizePolicy {
+		parts = append(parts, "disableTotalSizePolicy=true")
+	}
+
+	return strings.Join(parts, " ")
 }
 
 type MetaCleanupKeepPolicy struct {
@@ -1,13 +1,23 @@
 package config
 
 type rawMetaBuild struct {
-	Platform []string `yaml:"platform,omitempty"`
+	Platform        []string                      `yaml:"platform,omitempty"`
+	RegistryMirrors []*rawMetaBuildRegistryMirror `yaml:"registryMirrors,omitempty"`
 
 	rawMeta *rawMeta
 
 	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
 }
 
+type rawMetaBuildRegistryMirror struct {
+	Registry string   `yaml:"registry,omitempty"`
+	Mirrors  []string `yaml:"mirrors,omitempty"`
+
+	rawMetaBuild *rawMetaBuild `yaml:"-"` // parent
+
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
 func (c *rawMetaBuild) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if parent, ok := parentStack.Peek().(*rawMeta); ok {
 		c.rawMeta = parent
@@ -28,8 +38,44 @@ func (c *rawMetaBuild) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+func (c *rawMetaBuildRegistryMirror) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawMetaBuild); ok {
+		c.rawMetaBuild = parent
+	}
+
+	parentStack.Push(c)
+	type plain rawMetaBuildRegistryMirror
+	err := unmarshal((*plain)(c))
+	parentStack.Pop()
+	if err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawMetaBuild.rawMeta.doc); err != nil {
+		return err
+	}
+
+	if c.Registry == "" {
+		return newDetailedConfigError("registry mirror 'registry' field cannot be empty!", c, c.rawMetaBuild.rawMeta.doc)
+	}
+
+	if len(c.Mirrors) == 0 {
+		return newDetailedConfigError("registry mirror 'mirrors' field cannot be empty!", c, c.rawMetaBuild.rawMeta.doc)
+	}
+
+	return nil
+}
+
 func (c *rawMetaBuild) toMetaBuild() MetaBuild {
 	metaBuild := MetaBuild{}
 	metaBuild.Platform = c.Platform
+
+	for _, rawRegistryMirror := range c.RegistryMirrors {
+		metaBuild.RegistryMirrors = append(metaBuild.RegistryMirrors, MetaBuildRegistryMirror{
+			Registry: rawRegistryMirror.Registry,
+			Mirrors:  rawRegistryMirror.Mirrors,
+		})
+	}
+
 	return metaBuild
 }
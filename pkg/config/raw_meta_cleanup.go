@@ -8,11 +8,14 @@ import (
 )
 
 type rawMetaCleanup struct {
-	DisableKubernetesBasedPolicy       bool                        `yaml:"disableKubernetesBasedPolicy,omitempty"`
-	DisableGitHistoryBasedPolicy       bool                        `yaml:"disableGitHistoryBasedPolicy,omitempty"`
-	DisableBuiltWithinLastNHoursPolicy bool                        `yaml:"disableBuiltWithinLastNHoursPolicy,omitempty"`
-	KeepPolicies                       []*rawMetaCleanupKeepPolicy `yaml:"keepPolicies,omitempty"`
-	KeepImagesBuiltWithinLastNHours    *uint64                     `yaml:"keepImagesBuiltWithinLastNHours,omitempty"`
+	DisableKubernetesBasedPolicy       bool                         `yaml:"disableKubernetesBasedPolicy,omitempty"`
+	DisableGitHistoryBasedPolicy       bool                         `yaml:"disableGitHistoryBasedPolicy,omitempty"`
+	DisableBuiltWithinLastNHoursPolicy bool                         `yaml:"disableBuiltWithinLastNHoursPolicy,omitempty"`
+	KeepPolicies                       []*rawMetaCleanupKeepPolicy  `yaml:"keepPolicies,omitempty"`
+	KeepImagesBuiltWithinLastNHours    *uint64                      `yaml:"keepImagesBuiltWithinLastNHours,omitempty"`
+	DeleteImagesOlderThanNDays         *uint64                      `yaml:"deleteImagesOlderThanNDays,omitempty"`
+	KeepImagesWithinTotalSizeGB        *float64                     `yaml:"keepImagesWithinTotalSizeGB,omitempty"`
+	ImagePolicies                      []*rawMetaCleanupImagePolicy `yaml:"imagePolicies,omitempty"`
 
 	rawMeta               *rawMeta
 	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
@@ -26,6 +29,17 @@ type rawMetaCleanupKeepPolicy struct {
 	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
 }
 
+type rawMetaCleanupImagePolicy struct {
+	Image                      string  `yaml:"image,omitempty"`
+	DeleteImagesOlderThanNDays *uint64 `yaml:"deleteImagesOlderThanNDays,omitempty"`
+	DisableTotalSizePolicy     bool    `yaml:"disableTotalSizePolicy,omitempty"`
+
+	ImageRegexp *regexp.Regexp `yaml:"-"`
+
+	rawMetaCleanup        *rawMetaCleanup
+	UnsupportedAttributes map[string]interface{} `yaml:",inline"`
+}
+
 type rawMetaCleanupKeepPolicyReferences struct {
 	Tag    string                                   `yaml:"tag,omitempty"`
 	Branch string                                   `yaml:"branch,omitempty"`
@@ -71,6 +85,41 @@ func (c *rawMetaCleanup) UnmarshalYAML(unmarshal func(interface{}) error) error
 		c.KeepImagesBuiltWithinLastNHours = &defaultKeepImagesBuiltWithinLastNHours
 	}
 
+	if c.KeepImagesWithinTotalSizeGB != nil && *c.KeepImagesWithinTotalSizeGB <= 0 {
+		return newDetailedConfigError(fmt.Sprintf("invalid value %v for `keepImagesWithinTotalSizeGB: float`: the value must be greater than zero!", *c.KeepImagesWithinTotalSizeGB), c, c.rawMeta.doc)
+	}
+
+	return nil
+}
+
+func (c *rawMetaCleanupImagePolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if parent, ok := parentStack.Peek().(*rawMetaCleanup); ok {
+		c.rawMetaCleanup = parent
+	}
+
+	parentStack.Push(c)
+	type plain rawMetaCleanupImagePolicy
+	err := unmarshal((*plain)(c))
+	parentStack.Pop()
+	if err != nil {
+		return err
+	}
+
+	if err := checkOverflow(c.UnsupportedAttributes, c, c.rawMetaCleanup.rawMeta.doc); err != nil {
+		return err
+	}
+
+	if c.Image == "" {
+		return newDetailedConfigError("image `image: string|REGEX` required for cleanup image policy!", c, c.rawMetaCleanup.rawMeta.doc)
+	}
+
+	regex, err := compileCleanupRegexpString(c.Image)
+	if err != nil {
+		return newDetailedConfigError(fmt.Sprintf("invalid value %q for `image: string|REGEX`!", c.Image), c, c.rawMetaCleanup.rawMeta.doc)
+	}
+
+	c.ImageRegexp = regex
+
 	return nil
 }
 
@@ -170,6 +219,15 @@ func (c *rawMetaCleanupKeepPolicyReferencesLimit) UnmarshalYAML(unmarshal func(i
 }
 
 func (c *rawMetaCleanupKeepPolicyReferences) processRegexpString(name, configValue string) (*regexp.Regexp, error) {
+	regex, err := compileCleanupRegexpString(configValue)
+	if err != nil {
+		return nil, newDetailedConfigError(fmt.Sprintf("invalid value %q for `%s: string|REGEX`!", configValue, name), c, c.rawMetaCleanup.rawMeta.doc)
+	}
+
+	return regex, nil
+}
+
+func compileCleanupRegexpString(configValue string) (*regexp.Regexp, error) {
 	var value string
 	if strings.HasPrefix(configValue, "/") && strings.HasSuffix(configValue, "/") {
 		value = strings.TrimPrefix(configValue, "/")
@@ -178,13 +236,7 @@ func (c *rawMetaCleanupKeepPolicyReferences) processRegexpString(name, configVal
 		value = regexp.QuoteMeta(configValue)
 	}
 
-	expr := fmt.Sprintf("^%s$", value)
-	regex, err := regexp.Compile(expr)
-	if err != nil {
-		return nil, newDetailedConfigError(fmt.Sprintf("invalid value %q for `%s: string|REGEX`!", configValue, name), c, c.rawMetaCleanup.rawMeta.doc)
-	}
-
-	return regex, nil
+	return regexp.Compile(fmt.Sprintf("^%s$", value))
 }
 
 func (c *rawMetaCleanup) toMetaCleanup() MetaCleanup {
@@ -200,9 +252,29 @@ func (c *rawMetaCleanup) toMetaCleanup() MetaCleanup {
 
 	metaCleanup.KeepImagesBuiltWithinLastNHours = *c.KeepImagesBuiltWithinLastNHours
 
+	if c.DeleteImagesOlderThanNDays != nil {
+		metaCleanup.DeleteImagesOlderThanNDays = *c.DeleteImagesOlderThanNDays
+	}
+
+	if c.KeepImagesWithinTotalSizeGB != nil {
+		metaCleanup.KeepImagesWithinTotalSizeGB = *c.KeepImagesWithinTotalSizeGB
+	}
+
+	for _, policy := range c.ImagePolicies {
+		metaCleanup.ImagePolicies = append(metaCleanup.ImagePolicies, policy.toMetaCleanupImagePolicy())
+	}
+
 	return metaCleanup
 }
 
+func (c *rawMetaCleanupImagePolicy) toMetaCleanupImagePolicy() *MetaCleanupImagePolicy {
+	return &MetaCleanupImagePolicy{
+		ImageRegexp:                c.ImageRegexp,
+		DeleteImagesOlderThanNDays: c.DeleteImagesOlderThanNDays,
+		DisableTotalSizePolicy:     c.DisableTotalSizePolicy,
+	}
+}
+
 func (c *rawMetaCleanupKeepPolicy) toMetaCleanupKeepPolicy() *MetaCleanupKeepPolicy {
 	policy := &MetaCleanupKeepPolicy{}
 
@@ -0,0 +1,62 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+
+	"github.com/werf/werf/pkg/util"
+)
+
+var _ = Describe("rawMetaCleanup", func() {
+	parseMetaCleanup := func(content string) (MetaCleanup, error) {
+		parentStack = util.NewStack()
+
+		d := &doc{Content: []byte(content)}
+		parentStack.Push(&rawMeta{doc: d})
+		defer parentStack.Pop()
+
+		rawCleanup := &rawMetaCleanup{}
+		if err := yaml.UnmarshalStrict(d.Content, rawCleanup); err != nil {
+			return MetaCleanup{}, err
+		}
+
+		return rawCleanup.toMetaCleanup(), nil
+	}
+
+	It("applies the first image policy matching the image name", func() {
+		metaCleanup, err := parseMetaCleanup(`
+deleteImagesOlderThanNDays: 30
+keepImagesWithinTotalSizeGB: 10.5
+imagePolicies:
+- image: backend
+  deleteImagesOlderThanNDays: 90
+- image: /^frontend-.*/
+  deleteImagesOlderThanNDays: 0
+  disableTotalSizePolicy: true
+- image: /.*/
+  disableTotalSizePolicy: true
+`)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(metaCleanup.KeepImagesWithinTotalSizeGB).Should(Equal(10.5))
+
+		Ω(metaCleanup.GetDeleteImagesOlderThanNDays("backend")).Should(Equal(uint64(90)))
+		Ω(metaCleanup.IsTotalSizePolicyDisabled("backend")).Should(BeFalse())
+
+		Ω(metaCleanup.GetDeleteImagesOlderThanNDays("frontend-app")).Should(Equal(uint64(0)))
+		Ω(metaCleanup.IsTotalSizePolicyDisabled("frontend-app")).Should(BeTrue())
+
+		Ω(metaCleanup.GetDeleteImagesOlderThanNDays("backend-worker")).Should(Equal(uint64(30)))
+		Ω(metaCleanup.IsTotalSizePolicyDisabled("backend-worker")).Should(BeTrue())
+	})
+
+	DescribeTable("fails on invalid configuration",
+		func(content, expectedErr string) {
+			_, err := parseMetaCleanup(content)
+			Ω(err).Should(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("non-positive total size", "keepImagesWithinTotalSizeGB: 0", "must be greater than zero"),
+		Entry("image policy without image", "imagePolicies:\n- deleteImagesOlderThanNDays: 1", "image `image: string|REGEX` required"),
+		Entry("invalid image regexp", "imagePolicies:\n- image: /(/", "invalid value"),
+	)
+})
@@ -424,6 +424,14 @@ func (c *WerfConfig) getImageRelativesInOrder(images []ImageInterface) (imageRel
 	return imageRelatives
 }
 
+// GetImageRelativesNames returns names of the images the image depends on: from, imports and dependencies.
+func (c *WerfConfig) GetImageRelativesNames(interf ImageInterface) (names []string) {
+	for _, relative := range c.getImageRelatives(interf) {
+		names = util.UniqAppendString(names, relative.GetName())
+	}
+	return names
+}
+
 func (c *WerfConfig) getImageRelatives(interf ImageInterface) (relatives []ImageInterface) {
 	if from := c.getImageFromRelative(interf); from != nil {
 		relatives = append(relatives, from)
@@ -21,12 +21,14 @@ import (
 	"github.com/opencontainers/runtime-spec/specs-go"
 
 	copyrec "github.com/werf/copy-recurse"
+	"github.com/werf/lockgate"
 	"github.com/werf/logboek"
 	"github.com/werf/werf/pkg/buildah"
 	"github.com/werf/werf/pkg/buildah/thirdparty"
 	"github.com/werf/werf/pkg/image"
 	"github.com/werf/werf/pkg/path_matcher"
 	"github.com/werf/werf/pkg/util"
+	"github.com/werf/werf/pkg/werf"
 )
 
 type BuildahBackend struct {
@@ -54,6 +56,11 @@ func (backend *BuildahBackend) GetRuntimePlatform() string {
 	return backend.buildah.GetRuntimePlatform()
 }
 
+// GetStoragePath returns the path to the buildah containers storage, which is used to check storage volume usage by the host cleanup.
+func (backend *BuildahBackend) GetStoragePath() string {
+	return backend.buildah.GetStoragePath()
+}
+
 func (backend *BuildahBackend) getBuildahCommonOpts(ctx context.Context, suppressLog bool, logWriterOverride io.Writer, targetPlatform string) (opts buildah.CommonOpts) {
 	if !suppressLog {
 		if logWriterOverride != nil {
@@ -71,6 +78,9 @@ type containerDesc struct {
 	ImageName string
 	Name      string
 	RootMount string
+
+	// HostLock protects working container from removal by the host cleanup while it is in use.
+	HostLock lockgate.LockHandle
 }
 
 func (backend *BuildahBackend) createContainers(ctx context.Context, images []string, opts CommonOpts) ([]*containerDesc, error) {
@@ -83,13 +93,19 @@ func (backend *BuildahBackend) createContainers(ctx context.Context, images []st
 			panic("cannot start container for an empty image param")
 		}
 
-		_, err := backend.buildah.FromCommand(ctx, containerID, img, buildah.FromCommandOpts(backend.getBuildahCommonOpts(ctx, true, nil, opts.TargetPlatform)))
+		_, lock, err := werf.AcquireHostLock(ctx, ContainerLockName(containerID), lockgate.AcquireOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to lock container %q: %w", containerID, err)
+		}
+
+		_, err = backend.buildah.FromCommand(ctx, containerID, img, buildah.FromCommandOpts(backend.getBuildahCommonOpts(ctx, true, nil, opts.TargetPlatform)))
 		if err != nil {
+			werf.ReleaseHostLock(lock)
 			return nil, fmt.Errorf("unable to create container using base image %q: %w", img, err)
 		}
 
 		logboek.Context(ctx).Debug().LogF("Started container %q for image %q\n", containerID, img)
-		res = append(res, &containerDesc{ImageName: img, Name: containerID})
+		res = append(res, &containerDesc{ImageName: img, Name: containerID, HostLock: lock})
 	}
 
 	return res, nil
@@ -100,6 +116,10 @@ func (backend *BuildahBackend) removeContainers(ctx context.Context, containers
 		if err := backend.buildah.Rm(ctx, cont.Name, buildah.RmOpts(backend.getBuildahCommonOpts(ctx, true, nil, opts.TargetPlatform))); err != nil {
 			return fmt.Errorf("unable to remove container %q: %w", cont.Name, err)
 		}
+
+		if err := werf.ReleaseHostLock(cont.HostLock); err != nil {
+			return fmt.Errorf("unable to release lock for container %q: %w", cont.Name, err)
+		}
 	}
 
 	return nil
@@ -452,7 +472,6 @@ func (backend *BuildahBackend) BuildDockerfileStage(ctx context.Context, baseIma
 			logboek.Context(ctx).Error().LogF("ERROR: unable to remove temporary build container: %s\n", err)
 		}
 	}()
-	// TODO: cleanup orphan build containers in werf-host-cleanup procedure
 
 	logboek.Context(ctx).Debug().LogF("Mounting build container %s\n", container.Name)
 	if err := backend.mountContainers(ctx, []*containerDesc{container}, opts.CommonOpts); err != nil {
@@ -498,7 +517,6 @@ func (backend *BuildahBackend) BuildStapelStage(ctx context.Context, baseImage s
 			logboek.Context(ctx).Error().LogF("ERROR: unable to remove temporal build container: %s\n", err)
 		}
 	}()
-	// TODO(stapel-to-buildah): cleanup orphan build containers in werf-host-cleanup procedure
 
 	if len(opts.DependencyImportSpecs)+len(opts.DataArchiveSpecs)+len(opts.RemoveDataSpecs) > 0 {
 		logboek.Context(ctx).Debug().LogF("Mounting build container %s\n", container.Name)
@@ -1008,7 +1026,7 @@ func newHealthConfigFromString(healthcheck string) (*thirdparty.BuildahHealthCon
 }
 
 func (backend *BuildahBackend) Images(ctx context.Context, opts ImagesOptions) (image.ImagesList, error) {
-	imagesOpts := buildah.ImagesOptions{Filters: opts.Filters}
+	imagesOpts := buildah.ImagesOptions{Filters: opts.Filters, WithLabelsAndSize: opts.WithLabelsAndSize}
 	return backend.buildah.Images(ctx, imagesOpts)
 }
 
@@ -1022,12 +1040,26 @@ func (backend *BuildahBackend) Rm(ctx context.Context, name string, opts RmOpts)
 }
 
 func (backend *BuildahBackend) PostManifest(ctx context.Context, ref string, opts PostManifestOpts) error {
-	containerID := uuid.New().String()
-	_, err := backend.buildah.FromCommand(ctx, containerID, "", buildah.FromCommandOpts(backend.getBuildahCommonOpts(ctx, true, nil, opts.TargetPlatform)))
+	containerID := fmt.Sprintf("werf-%s", uuid.New().String())
+
+	_, lock, err := werf.AcquireHostLock(ctx, ContainerLockName(containerID), lockgate.AcquireOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to lock container %q: %w", containerID, err)
+	}
+
+	_, err = backend.buildah.FromCommand(ctx, containerID, "", buildah.FromCommandOpts(backend.getBuildahCommonOpts(ctx, true, nil, opts.TargetPlatform)))
 	if err != nil {
+		werf.ReleaseHostLock(lock)
 		return fmt.Errorf("unable to create container using scratch base image: %w", err)
 	}
 
+	container := &containerDesc{Name: containerID, HostLock: lock}
+	defer func() {
+		if err := backend.removeContainers(ctx, []*containerDesc{container}, opts.CommonOpts); err != nil {
+			logboek.Context(ctx).Error().LogF("ERROR: unable to remove temporary manifest container: %s\n", err)
+		}
+	}()
+
 	if err := backend.buildah.Config(ctx, containerID, buildah.ConfigOpts{Labels: opts.Labels}); err != nil {
 		return fmt.Errorf("unable to configure container %q labels: %w", containerID, err)
 	}
@@ -1038,4 +1070,53 @@ func (backend *BuildahBackend) PostManifest(ctx context.Context, ref string, opt
 	return nil
 }
 
+// SaveImageToStream saves the image into the temporary docker-archive tarball, which is removed when the stream is closed.
+func (backend *BuildahBackend) SaveImageToStream(ctx context.Context, ref string) (io.ReadCloser, error) {
+	archivePath := filepath.Join(backend.TmpDir, fmt.Sprintf("image-archive-%s.tar", uuid.New().String()))
+
+	if err := backend.buildah.SaveImageArchive(ctx, ref, archivePath, buildah.PushOpts(backend.getBuildahCommonOpts(ctx, true, nil, ""))); err != nil {
+		os.Remove(archivePath)
+		return nil, err
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		os.Remove(archivePath)
+		return nil, fmt.Errorf("unable to open image archive %q: %w", archivePath, err)
+	}
+
+	return &removeOnCloseFile{File: f}, nil
+}
+
+// LoadImageFromStream writes the docker-archive tarball stream into the temporary file to pull it with the docker-archive transport.
+func (backend *BuildahBackend) LoadImageFromStream(ctx context.Context, input io.Reader) error {
+	archivePath := filepath.Join(backend.TmpDir, fmt.Sprintf("image-archive-%s.tar", uuid.New().String()))
+	defer os.Remove(archivePath)
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("unable to create image archive %q: %w", archivePath, err)
+	}
+
+	if _, err := io.Copy(f, input); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write image archive %q: %w", archivePath, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to close image archive %q: %w", archivePath, err)
+	}
+
+	return backend.Pull(ctx, fmt.Sprintf("docker-archive:%s", archivePath), PullOpts{})
+}
+
+type removeOnCloseFile struct {
+	*os.File
+}
+
+func (f *removeOnCloseFile) Close() error {
+	defer os.Remove(f.Name())
+	return f.File.Close()
+}
+
 func (backend *BuildahBackend) ClaimTargetPlatforms(ctx context.Context, targetPlatforms []string) {}
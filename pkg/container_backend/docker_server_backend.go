@@ -3,8 +3,10 @@ package container_backend
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
@@ -297,7 +299,12 @@ func (backend *DockerServerBackend) Images(ctx context.Context, opts ImagesOptio
 	var res image.ImagesList
 	for _, img := range images {
 		res = append(res, image.Summary{
-			RepoTags: img.RepoTags,
+			ID:          img.ID,
+			RepoTags:    img.RepoTags,
+			RepoDigests: img.RepoDigests,
+			Labels:      img.Labels,
+			Created:     time.Unix(img.Created, 0),
+			Size:        img.Size,
 		})
 	}
 	return res, nil
@@ -341,3 +348,11 @@ func (backend *DockerServerBackend) Containers(ctx context.Context, opts Contain
 func (backend *DockerServerBackend) PostManifest(ctx context.Context, ref string, opts PostManifestOpts) error {
 	return docker.CreateImage(ctx, ref, docker.CreateImageOptions{Labels: opts.Labels})
 }
+
+func (backend *DockerServerBackend) SaveImageToStream(ctx context.Context, ref string) (io.ReadCloser, error) {
+	return docker.ImageSave(ctx, ref)
+}
+
+func (backend *DockerServerBackend) LoadImageFromStream(ctx context.Context, input io.Reader) error {
+	return docker.ImageLoad(ctx, input)
+}
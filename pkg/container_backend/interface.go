@@ -2,6 +2,7 @@ package container_backend
 
 import (
 	"context"
+	"io"
 
 	"github.com/werf/werf/pkg/image"
 	"github.com/werf/werf/pkg/util"
@@ -57,6 +58,9 @@ type BuildOptions struct {
 type ImagesOptions struct {
 	CommonOpts
 	Filters []util.Pair[string, string]
+
+	// WithLabelsAndSize requests image labels and size, which are not always available without extra cost (e.g. for Buildah).
+	WithLabelsAndSize bool
 }
 
 type ContainersOptions struct {
@@ -77,6 +81,8 @@ type ContainerBackend interface {
 	Rmi(ctx context.Context, ref string, opts RmiOpts) error
 	Rm(ctx context.Context, name string, opts RmOpts) error
 	PostManifest(ctx context.Context, ref string, opts PostManifestOpts) error
+	SaveImageToStream(ctx context.Context, ref string) (io.ReadCloser, error)
+	LoadImageFromStream(ctx context.Context, input io.Reader) error
 
 	GetImageInfo(ctx context.Context, ref string, opts GetImageInfoOpts) (*image.Info, error)
 	BuildDockerfile(ctx context.Context, dockerfile []byte, opts BuildDockerfileOpts) (string, error)
@@ -2,6 +2,7 @@ package container_backend
 
 import (
 	"context"
+	"io"
 
 	"github.com/werf/logboek"
 	"github.com/werf/werf/pkg/image"
@@ -191,3 +192,19 @@ func (runtime *PerfCheckContainerBackend) ClaimTargetPlatforms(ctx context.Conte
 	logboek.Context(ctx).Default().LogProcess("ContainerBackend.ClaimTargetPlatforms %v", targetPlatforms).
 		Do(func() { runtime.ContainerBackend.ClaimTargetPlatforms(ctx, targetPlatforms) })
 }
+
+func (runtime *PerfCheckContainerBackend) SaveImageToStream(ctx context.Context, ref string) (resStream io.ReadCloser, resErr error) {
+	logboek.Context(ctx).Default().LogProcess("ContainerBackend.SaveImageToStream %q", ref).
+		Do(func() {
+			resStream, resErr = runtime.ContainerBackend.SaveImageToStream(ctx, ref)
+		})
+	return
+}
+
+func (runtime *PerfCheckContainerBackend) LoadImageFromStream(ctx context.Context, input io.Reader) (resErr error) {
+	logboek.Context(ctx).Default().LogProcess("ContainerBackend.LoadImageFromStream").
+		Do(func() {
+			resErr = runtime.ContainerBackend.LoadImageFromStream(ctx, input)
+		})
+	return
+}
@@ -17,6 +17,8 @@ type copyToOptions struct {
 type BundleAccessor interface {
 	ReadChart(ctx context.Context) (*chart.Chart, error)
 	WriteChart(ctx context.Context, ch *chart.Chart) error
+	// ReadImageDigests returns the digests of the images referred by .Values.werf.image of the bundle chart by the image name.
+	ReadImageDigests(ctx context.Context, ch *chart.Chart) (map[string]string, error)
 
 	CopyTo(ctx context.Context, to BundleAccessor, opts copyToOptions) error
 	CopyFromArchive(ctx context.Context, fromArchive *BundleArchive, opts copyToOptions) error
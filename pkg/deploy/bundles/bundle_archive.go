@@ -5,17 +5,34 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"helm.sh/helm/v3/pkg/chart"
 
 	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/docker_registry"
 	"github.com/werf/werf/pkg/image"
 )
 
 const (
-	chartArchiveFileName = "chart.tar.gz"
+	chartArchiveFileName    = "chart.tar.gz"
+	bundleMetadataFileName  = "bundle.json"
+	imageLayoutTmpDirPrefix = "werf-bundle-archive-layout-"
+
+	// BundleArchiveFormatVersion is the version of the bundle archive written by the BundleArchiveFileWriter.
+	// The archive of the first version has no metadata and stores the chart archive and the image archive per tag.
+	// Since the second version the archive stores the OCI image layout with the blobs shared by all images and the chart,
+	// the images are annotated with the tag and the chart blob is referred by the bundle.json metadata, which is the first entry of the archive.
+	BundleArchiveFormatVersion = 2
 )
 
+type bundleArchiveMetadata struct {
+	Version int           `json:"version"`
+	Chart   v1.Descriptor `json:"chart"`
+}
+
 type BundleArchive struct {
 	Reader BundleArchiveReader
 	Writer BundleArchiveWriter
@@ -56,6 +73,31 @@ func (bundle *BundleArchive) WriteChart(ctx context.Context, ch *chart.Chart) er
 	return nil
 }
 
+func (bundle *BundleArchive) ReadImageDigests(ctx context.Context, ch *chart.Chart) (map[string]string, error) {
+	layoutDir, err := bundle.extractImageLayout()
+	if err != nil {
+		return nil, fmt.Errorf("unable to extract image layout from the bundle archive %q: %w", bundle.Reader.String(), err)
+	}
+	if layoutDir != "" {
+		defer os.RemoveAll(layoutDir)
+	}
+
+	res := map[string]string{}
+
+	for imageName, imageRef := range bundleImageReferences(ch) {
+		_, tag := image.ParseRepositoryAndTag(imageRef)
+
+		digest, err := bundle.readImageDigest(layoutDir, tag)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get image %q digest from the bundle archive %q: %w", imageRef, bundle.Reader.String(), err)
+		}
+
+		res[imageName] = digest.String()
+	}
+
+	return res, nil
+}
+
 func (bundle *BundleArchive) CopyTo(ctx context.Context, to BundleAccessor, opts copyToOptions) error {
 	return to.CopyFromArchive(ctx, bundle, opts)
 }
@@ -66,9 +108,10 @@ func (bundle *BundleArchive) CopyFromArchive(ctx context.Context, fromArchive *B
 		return fmt.Errorf("unable to read chart from the bundle archive %q: %w", fromArchive.Reader.String(), err)
 	}
 
-	if err := bundle.Writer.Open(); err != nil {
+	if err := bundle.Writer.Open(fromArchive.Reader.String()); err != nil {
 		return fmt.Errorf("unable to open target bundle archive: %w", err)
 	}
+	defer bundle.Writer.Close()
 
 	if err := logboek.Context(ctx).LogProcess("Saving bundle %s into archive", fromArchive.Reader.String()).DoError(func() error {
 		return bundle.WriteChart(ctx, ch)
@@ -76,6 +119,16 @@ func (bundle *BundleArchive) CopyFromArchive(ctx context.Context, fromArchive *B
 		return err
 	}
 
+	fromLayoutDir, err := fromArchive.extractImageLayout()
+	if err != nil {
+		return fmt.Errorf("unable to extract image layout from the bundle archive %q: %w", fromArchive.Reader.String(), err)
+	}
+	if fromLayoutDir != "" {
+		defer os.RemoveAll(fromLayoutDir)
+	}
+
+	progress := &copyProgress{}
+
 	if err := logboek.Context(ctx).LogProcess("Copy images from bundle archive").DoError(func() error {
 		if werfVals, ok := ch.Values["werf"].(map[string]interface{}); ok {
 			if imageVals, ok := werfVals["image"].(map[string]interface{}); ok {
@@ -85,6 +138,33 @@ func (bundle *BundleArchive) CopyFromArchive(ctx context.Context, fromArchive *B
 
 						_, tag := image.ParseRepositoryAndTag(imageRef)
 
+						if toLayoutDir := bundle.Writer.ImageLayoutDir(); fromLayoutDir != "" && toLayoutDir != "" {
+							imageOrIndex, err := docker_registry.ReadImageLayout(fromLayoutDir, tag)
+							if err != nil {
+								return fmt.Errorf("error reading image by tag %q from the bundle archive %q: %w", tag, fromArchive.Reader.String(), err)
+							}
+
+							digest, err := imageOrIndexDigest(imageOrIndex)
+							if err != nil {
+								return fmt.Errorf("error reading image by tag %q from the bundle archive %q: %w", tag, fromArchive.Reader.String(), err)
+							}
+
+							if bundle.Writer.IsImageWritten(tag, digest.String()) {
+								logboek.Context(ctx).Default().LogF("Image %s is already saved, skipped\n", imageRef)
+								continue
+							}
+
+							if err := docker_registry.WriteImageLayout(toLayoutDir, tag, imageOrIndex, progress.TransferOptions(ctx)); err != nil {
+								return fmt.Errorf("error writing image %q into bundle archive: %w", imageRef, err)
+							}
+
+							if err := bundle.Writer.CheckpointImage(tag, digest.String()); err != nil {
+								return fmt.Errorf("error writing image %q into bundle archive: %w", imageRef, err)
+							}
+
+							continue
+						}
+
 						imageArchive, err := fromArchive.Reader.ReadImageArchive(tag)
 						if err != nil {
 							return fmt.Errorf("error reading image archive by tag %q from the bundle archive %q: %w", tag, fromArchive.Reader.String(), err)
@@ -115,6 +195,8 @@ func (bundle *BundleArchive) CopyFromArchive(ctx context.Context, fromArchive *B
 		return err
 	}
 
+	progress.LogSummary(ctx)
+
 	if err := bundle.Writer.Save(); err != nil {
 		return fmt.Errorf("error saving destination bundle archive: %w", err)
 	}
@@ -128,9 +210,10 @@ func (bundle *BundleArchive) CopyFromRemote(ctx context.Context, fromRemote *Rem
 		return fmt.Errorf("unable to read chart from remote bundle: %w", err)
 	}
 
-	if err := bundle.Writer.Open(); err != nil {
+	if err := bundle.Writer.Open(fromRemote.RegistryAddress.FullName()); err != nil {
 		return fmt.Errorf("unable to open target bundle archive: %w", err)
 	}
+	defer bundle.Writer.Close()
 
 	if err := logboek.Context(ctx).LogProcess("Saving bundle %s into archive", fromRemote.RegistryAddress.FullName()).DoError(func() error {
 		return bundle.WriteChart(ctx, ch)
@@ -138,6 +221,8 @@ func (bundle *BundleArchive) CopyFromRemote(ctx context.Context, fromRemote *Rem
 		return err
 	}
 
+	progress := &copyProgress{}
+
 	if werfVals, ok := ch.Values["werf"].(map[string]interface{}); ok {
 		if imageVals, ok := werfVals["image"].(map[string]interface{}); ok {
 			for imageName, v := range imageVals {
@@ -146,6 +231,28 @@ func (bundle *BundleArchive) CopyFromRemote(ctx context.Context, fromRemote *Rem
 
 					_, tag := image.ParseRepositoryAndTag(imageRef)
 
+					if layoutDir := bundle.Writer.ImageLayoutDir(); layoutDir != "" {
+						digest, err := getImageDigest(ctx, fromRemote.RegistryClient, imageRef)
+						if err != nil {
+							return err
+						}
+
+						if bundle.Writer.IsImageWritten(tag, digest) {
+							logboek.Context(ctx).Default().LogF("Image %s is already saved, skipped\n", imageRef)
+							continue
+						}
+
+						if err := fromRemote.RegistryClient.PullImageLayout(ctx, layoutDir, imageRef, tag, progress.TransferOptions(ctx)); err != nil {
+							return fmt.Errorf("error pulling image %q into bundle archive: %w", imageRef, err)
+						}
+
+						if err := bundle.Writer.CheckpointImage(tag, digest); err != nil {
+							return fmt.Errorf("error pulling image %q into bundle archive: %w", imageRef, err)
+						}
+
+						continue
+					}
+
 					// TODO: maybe save into tmp file archive OR read resulting image size from the registry before pulling
 					imageBytes := bytes.NewBuffer(nil)
 
@@ -163,6 +270,8 @@ func (bundle *BundleArchive) CopyFromRemote(ctx context.Context, fromRemote *Rem
 		}
 	}
 
+	progress.LogSummary(ctx)
+
 	if err := bundle.Writer.Save(); err != nil {
 		return fmt.Errorf("error saving destination bundle archive: %w", err)
 	}
@@ -170,6 +279,52 @@ func (bundle *BundleArchive) CopyFromRemote(ctx context.Context, fromRemote *Rem
 	return nil
 }
 
+// readImageDigest reads the image digest by tag from the extracted image layout or from the image archive if the layoutDir is empty.
+func (bundle *BundleArchive) readImageDigest(layoutDir, tag string) (v1.Hash, error) {
+	if layoutDir == "" {
+		img, err := tarball.Image(bundle.GetImageArchiveOpener(tag).Open, nil)
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("error reading image archive by tag %q: %w", tag, err)
+		}
+
+		return img.Digest()
+	}
+
+	imageOrIndex, err := docker_registry.ReadImageLayout(layoutDir, tag)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("error reading image by tag %q: %w", tag, err)
+	}
+
+	return imageOrIndexDigest(imageOrIndex)
+}
+
+func imageOrIndexDigest(imageOrIndex interface{}) (v1.Hash, error) {
+	switch i := imageOrIndex.(type) {
+	case v1.Image:
+		return i.Digest()
+	case v1.ImageIndex:
+		return i.Digest()
+	default:
+		panic(fmt.Sprintf("unexpected image layout object %T", imageOrIndex))
+	}
+}
+
+// extractImageLayout extracts the OCI image layout of the bundle archive into the tmp directory, which should be removed by the caller.
+// The empty directory is returned for the bundle archive without image layout.
+func (bundle *BundleArchive) extractImageLayout() (string, error) {
+	dir, err := os.MkdirTemp("", imageLayoutTmpDirPrefix)
+	if err != nil {
+		return "", fmt.Errorf("unable to create tmp dir: %w", err)
+	}
+
+	if extracted, err := bundle.Reader.ExtractImageLayout(dir); err != nil || !extracted {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
 type ImageArchiveOpener struct {
 	Archive  *BundleArchive
 	ImageTag string
@@ -4,15 +4,26 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"github.com/werf/werf/pkg/docker_registry"
 )
 
 type BundleArchiveReader interface {
 	String() string
 	ReadChartArchive() ([]byte, error)
 	ReadImageArchive(imageTag string) (*ImageArchiveReadCloser, error)
+	// ExtractImageLayout extracts the OCI image layout with the bundle images into the dir.
+	// False is returned for the bundle archive storing the image archive per tag.
+	ExtractImageLayout(dir string) (bool, error)
 }
 
 type BundleArchiveFileReader struct {
@@ -28,6 +39,175 @@ func (reader *BundleArchiveFileReader) String() string {
 }
 
 func (reader *BundleArchiveFileReader) ReadChartArchive() ([]byte, error) {
+	metadata, err := reader.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata == nil {
+		data, err := reader.readFile(chartArchiveFileName)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			return nil, fmt.Errorf("no chart archive found in the bundle archive %q", reader.Path)
+		}
+
+		return data, nil
+	}
+
+	blobFileName := imageLayoutBlobFileName(metadata.Chart.Digest)
+
+	data, err := reader.readFile(blobFileName)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("no chart blob %q found in the bundle archive %q", blobFileName, reader.Path)
+	}
+
+	if digest, _, err := v1.SHA256(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("unable to calculate chart blob %q digest: %w", blobFileName, err)
+	} else if digest != metadata.Chart.Digest {
+		return nil, fmt.Errorf("chart blob %q digest mismatch: got %s", blobFileName, digest)
+	}
+
+	return data, nil
+}
+
+func (reader *BundleArchiveFileReader) ReadImageArchive(imageTag string) (*ImageArchiveReadCloser, error) {
+	metadata, err := reader.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata == nil {
+		return reader.readImageArchive(imageTag)
+	}
+
+	dir, err := os.MkdirTemp("", imageLayoutTmpDirPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tmp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := reader.ExtractImageLayout(dir); err != nil {
+		return nil, fmt.Errorf("unable to extract image layout: %w", err)
+	}
+
+	imageOrIndex, err := docker_registry.ReadImageLayout(dir, imageTag)
+	if err != nil {
+		return nil, err
+	}
+
+	img, ok := imageOrIndex.(v1.Image)
+	if !ok {
+		return nil, fmt.Errorf("unable to read image archive by tag %q from the bundle archive %q: image expected, got image index", imageTag, reader.Path)
+	}
+
+	ref, err := name.NewTag(fmt.Sprintf("bundle:%s", imageTag))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse image tag %q: %w", imageTag, err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := tarball.Write(ref, img, buf); err != nil {
+		return nil, fmt.Errorf("unable to write image archive by tag %q: %w", imageTag, err)
+	}
+
+	return NewImageArchiveReadCloser(buf, func() error { return nil }), nil
+}
+
+func (reader *BundleArchiveFileReader) ExtractImageLayout(dir string) (bool, error) {
+	metadata, err := reader.readMetadata()
+	if err != nil {
+		return false, err
+	}
+
+	if metadata == nil {
+		return false, nil
+	}
+
+	treader, closer, err := reader.openForReading()
+	defer closer()
+
+	if err != nil {
+		return false, fmt.Errorf("unable to open bundle archive: %w", err)
+	}
+
+	for {
+		header, err := treader.Next()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("error reading tar archive: %w", err)
+		}
+
+		if header.Name == bundleMetadataFileName {
+			continue
+		}
+
+		if !filepath.IsLocal(header.Name) {
+			return false, fmt.Errorf("invalid path %q found in the bundle archive %q", header.Name, reader.Path)
+		}
+		p := filepath.Join(dir, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(p, os.ModePerm); err != nil {
+				return false, fmt.Errorf("unable to create dir %q: %w", p, err)
+			}
+		case tar.TypeReg:
+			if err := extractFile(treader, p); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// readMetadata returns nil for the bundle archive of the first version without metadata.
+func (reader *BundleArchiveFileReader) readMetadata() (*bundleArchiveMetadata, error) {
+	treader, closer, err := reader.openForReading()
+	defer closer()
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to open bundle archive: %w", err)
+	}
+
+	for {
+		header, err := treader.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading tar archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// the metadata is the first file of the bundle archive
+		if header.Name != bundleMetadataFileName {
+			return nil, nil
+		}
+
+		metadata := &bundleArchiveMetadata{}
+		if err := json.NewDecoder(treader).Decode(metadata); err != nil {
+			return nil, fmt.Errorf("unable to decode %q from the bundle archive %q: %w", bundleMetadataFileName, reader.Path, err)
+		}
+
+		if metadata.Version > BundleArchiveFormatVersion {
+			return nil, fmt.Errorf("unsupported bundle archive %q format version %d: the latest supported version is %d, werf upgrade required", reader.Path, metadata.Version, BundleArchiveFormatVersion)
+		}
+
+		return metadata, nil
+	}
+}
+
+// readFile returns nil if the file not found in the bundle archive.
+func (reader *BundleArchiveFileReader) readFile(fileName string) ([]byte, error) {
 	treader, closer, err := reader.openForReading()
 	defer closer()
 
@@ -40,7 +220,7 @@ func (reader *BundleArchiveFileReader) ReadChartArchive() ([]byte, error) {
 	for {
 		header, err := treader.Next()
 		if err == io.EOF {
-			return nil, fmt.Errorf("no chart archive found in the bundle archive %q", reader.Path)
+			return nil, nil
 		}
 		if err != nil {
 			return nil, fmt.Errorf("error reading tar archive: %w", err)
@@ -49,19 +229,19 @@ func (reader *BundleArchiveFileReader) ReadChartArchive() ([]byte, error) {
 		if header.Typeflag != tar.TypeReg {
 			continue
 		}
-		if header.Name != chartArchiveFileName {
+		if header.Name != fileName {
 			continue
 		}
 
 		if _, err := io.Copy(b, treader); err != nil {
-			return nil, fmt.Errorf("unable to read chart archive %q from the bundle archive %q: %w", chartArchiveFileName, reader.Path, err)
+			return nil, fmt.Errorf("unable to read %q from the bundle archive %q: %w", fileName, reader.Path, err)
 		}
 
 		return b.Bytes(), nil
 	}
 }
 
-func (reader *BundleArchiveFileReader) ReadImageArchive(imageTag string) (*ImageArchiveReadCloser, error) {
+func (reader *BundleArchiveFileReader) readImageArchive(imageTag string) (*ImageArchiveReadCloser, error) {
 	treader, closer, err := reader.openForReading()
 	if err != nil {
 		defer closer()
@@ -120,3 +300,25 @@ func (reader *BundleArchiveFileReader) openForReading() (*tar.Reader, func() err
 
 	return tar.NewReader(unzipper), closer, nil
 }
+
+func extractFile(r io.Reader, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create dir %q: %w", filepath.Dir(path), err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create %q: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("unable to write %q: %w", path, err)
+	}
+
+	return nil
+}
+
+func imageLayoutBlobFileName(digest v1.Hash) string {
+	return fmt.Sprintf("blobs/%s/%s", digest.Algorithm, digest.Hex)
+}
@@ -0,0 +1,178 @@
+package bundles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/werf/pkg/docker_registry"
+)
+
+var _ = Describe("Bundle archive file", func() {
+	It("should share the blobs of the images and preserve digests", func() {
+		archivePath := filepath.Join(GinkgoT().TempDir(), "bundle.tar.gz")
+
+		baseImage, err := random.Image(1024*1024, 3)
+		Expect(err).To(Succeed())
+
+		images := map[string]v1.Image{}
+		for _, tag := range []string{"tag-1", "tag-2", "tag-3"} {
+			layer, err := random.Layer(1024, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+			Expect(err).To(Succeed())
+
+			images[tag], err = mutate.AppendLayers(baseImage, layer)
+			Expect(err).To(Succeed())
+		}
+
+		writer := NewBundleArchiveFileWriter(archivePath)
+		Expect(writer.Open("source")).To(Succeed())
+		Expect(writer.WriteChartArchive([]byte("chart-bytes"))).To(Succeed())
+		for tag, img := range images {
+			Expect(docker_registry.WriteImageLayout(writer.ImageLayoutDir(), tag, img, docker_registry.TransferOptions{})).To(Succeed())
+		}
+		Expect(writer.Save()).To(Succeed())
+
+		// the lock is released by Save
+		nextWriter := NewBundleArchiveFileWriter(archivePath)
+		Expect(nextWriter.Open("source")).To(Succeed())
+		Expect(nextWriter.Close()).To(Succeed())
+
+		info, err := os.Stat(archivePath)
+		Expect(err).To(Succeed())
+		Expect(info.Size()).To(BeNumerically("<", 2*3*1024*1024))
+
+		reader := NewBundleArchiveFileReader(archivePath)
+
+		chartBytes, err := reader.ReadChartArchive()
+		Expect(err).To(Succeed())
+		Expect(string(chartBytes)).To(Equal("chart-bytes"))
+
+		layoutDir := GinkgoT().TempDir()
+		extracted, err := reader.ExtractImageLayout(layoutDir)
+		Expect(err).To(Succeed())
+		Expect(extracted).To(BeTrue())
+
+		for tag, img := range images {
+			imageOrIndex, err := docker_registry.ReadImageLayout(layoutDir, tag)
+			Expect(err).To(Succeed())
+
+			expectedDigest, err := img.Digest()
+			Expect(err).To(Succeed())
+			Expect(imageOrIndex.(v1.Image).Digest()).To(Equal(expectedDigest))
+		}
+	})
+
+	It("should resume the interrupted writing of the same source and skip the existing blobs", func() {
+		archivePath := filepath.Join(GinkgoT().TempDir(), "bundle.tar.gz")
+
+		baseImage, err := random.Image(1024, 3)
+		Expect(err).To(Succeed())
+		layer, err := random.Layer(1024, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+		Expect(err).To(Succeed())
+		img, err := mutate.AppendLayers(baseImage, layer)
+		Expect(err).To(Succeed())
+
+		baseDigest, err := baseImage.Digest()
+		Expect(err).To(Succeed())
+		imgDigest, err := img.Digest()
+		Expect(err).To(Succeed())
+
+		var transferred, skipped int
+		transferOptions := docker_registry.TransferOptions{OnBlob: func(blob docker_registry.BlobTransfer) {
+			if blob.Skipped {
+				skipped++
+			} else {
+				transferred++
+			}
+		}}
+
+		interruptedWriter := NewBundleArchiveFileWriter(archivePath)
+		Expect(interruptedWriter.Open("source")).To(Succeed())
+		Expect(interruptedWriter.WriteChartArchive([]byte("chart-bytes"))).To(Succeed())
+		Expect(docker_registry.WriteImageLayout(interruptedWriter.ImageLayoutDir(), "tag-1", baseImage, transferOptions)).To(Succeed())
+		Expect(interruptedWriter.CheckpointImage("tag-1", baseDigest.String())).To(Succeed())
+		Expect(interruptedWriter.Close()).To(Succeed())
+		Expect(transferred).To(Equal(4))
+		Expect(skipped).To(Equal(0))
+
+		writer := NewBundleArchiveFileWriter(archivePath)
+		Expect(writer.Open("source")).To(Succeed())
+		Expect(writer.IsImageWritten("tag-1", baseDigest.String())).To(BeTrue())
+		Expect(writer.IsImageWritten("tag-2", imgDigest.String())).To(BeFalse())
+		Expect(writer.WriteChartArchive([]byte("new-chart-bytes"))).To(Succeed())
+
+		transferred = 0
+		Expect(docker_registry.WriteImageLayout(writer.ImageLayoutDir(), "tag-2", img, transferOptions)).To(Succeed())
+		Expect(transferred).To(Equal(2))
+		Expect(skipped).To(Equal(3))
+
+		Expect(writer.Save()).To(Succeed())
+
+		_, err = os.Stat(writer.ImageLayoutDir())
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		_, err = os.Stat(writer.checkpointPath())
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		_, err = os.Stat(writer.locksDir())
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		reader := NewBundleArchiveFileReader(archivePath)
+
+		chartBytes, err := reader.ReadChartArchive()
+		Expect(err).To(Succeed())
+		Expect(string(chartBytes)).To(Equal("new-chart-bytes"))
+
+		layoutDir := GinkgoT().TempDir()
+		extracted, err := reader.ExtractImageLayout(layoutDir)
+		Expect(err).To(Succeed())
+		Expect(extracted).To(BeTrue())
+
+		staleChartDigest, _, err := v1.SHA256(strings.NewReader("chart-bytes"))
+		Expect(err).To(Succeed())
+		_, err = os.Stat(filepath.Join(layoutDir, "blobs", staleChartDigest.Algorithm, staleChartDigest.Hex))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		for tag, expectedDigest := range map[string]v1.Hash{"tag-1": baseDigest, "tag-2": imgDigest} {
+			imageOrIndex, err := docker_registry.ReadImageLayout(layoutDir, tag)
+			Expect(err).To(Succeed())
+			Expect(imageOrIndex.(v1.Image).Digest()).To(Equal(expectedDigest))
+		}
+	})
+
+	It("should start the writing from scratch if the interrupted writing is of another source", func() {
+		archivePath := filepath.Join(GinkgoT().TempDir(), "bundle.tar.gz")
+
+		interruptedWriter := NewBundleArchiveFileWriter(archivePath)
+		Expect(interruptedWriter.Open("source")).To(Succeed())
+		Expect(interruptedWriter.CheckpointImage("tag-1", "sha256:1111")).To(Succeed())
+		Expect(interruptedWriter.Close()).To(Succeed())
+
+		writer := NewBundleArchiveFileWriter(archivePath)
+		Expect(writer.Open("other-source")).To(Succeed())
+		Expect(writer.IsImageWritten("tag-1", "sha256:1111")).To(BeFalse())
+		Expect(writer.Close()).To(Succeed())
+	})
+
+	It("should not open the archive being written by another writer", func() {
+		archivePath := filepath.Join(GinkgoT().TempDir(), "bundle.tar.gz")
+
+		writer := NewBundleArchiveFileWriter(archivePath)
+		Expect(writer.Open("source")).To(Succeed())
+		Expect(writer.CheckpointImage("tag-1", "sha256:1111")).To(Succeed())
+
+		concurrentWriter := NewBundleArchiveFileWriter(archivePath)
+		Expect(concurrentWriter.Open("other-source")).To(MatchError(ContainSubstring("is being written by another process")))
+		Expect(writer.IsImageWritten("tag-1", "sha256:1111")).To(BeTrue())
+
+		Expect(writer.Close()).To(Succeed())
+
+		Expect(concurrentWriter.Open("source")).To(Succeed())
+		Expect(concurrentWriter.IsImageWritten("tag-1", "sha256:1111")).To(BeTrue())
+		Expect(concurrentWriter.Close()).To(Succeed())
+	})
+})
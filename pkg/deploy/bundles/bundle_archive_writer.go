@@ -4,148 +4,385 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/google/uuid"
+
+	"github.com/werf/lockgate"
+	"github.com/werf/lockgate/pkg/file_locker"
+	bundles_registry "github.com/werf/werf/pkg/deploy/bundles/registry"
+	"github.com/werf/werf/pkg/docker_registry"
 )
 
 type BundleArchiveWriter interface {
-	Open() error
+	// Open opens the writer to write the bundle from the source, the interrupted writing of the same source is resumed.
+	Open(source string) error
 	WriteChartArchive(data []byte) error
 	WriteImageArchive(imageTag string, data []byte) error
+	// ImageLayoutDir returns the OCI image layout directory to write the bundle images into annotated with the tag.
+	// The empty string is returned if the images should be written with WriteImageArchive.
+	ImageLayoutDir() string
+	// IsImageWritten returns true if the image with the digest has been written into the image layout by the interrupted writing.
+	IsImageWritten(imageTag, digest string) bool
+	// CheckpointImage records the image written into the image layout, so the image is skipped if the writing is resumed.
+	CheckpointImage(imageTag, digest string) error
 	Save() error
+	// Close releases the writer opened by Open without saving, the writing can be resumed later.
+	Close() error
 }
 
+// BundleArchiveFileWriter writes the bundle archive of the BundleArchiveFormatVersion.
+// The chart and the images are collected in the tmp OCI image layout directory, which is packed into the archive on Save.
+// The tmp image layout directory and the checkpoint file with the written images are kept next to the archive until the archive is saved,
+// so the interrupted writing of the same source is resumed by the next Open.
+// The writer holds the file lock next to the archive from Open until Save or Close, so the writers of the same archive do not share the tmp image layout.
 type BundleArchiveFileWriter struct {
 	Path string
 
-	tmpArchivePath   string
-	tmpArchiveWriter *tar.Writer
-	tmpArchiveCloser func() error
+	tmpLayoutDir    string
+	chartDescriptor *v1.Descriptor
+	checkpoint      *bundleArchiveCheckpoint
+
+	locker     lockgate.Locker
+	lockHandle *lockgate.LockHandle
+}
+
+const bundleArchiveWriterLockName = "bundle_archive_writer"
+
+type bundleArchiveCheckpoint struct {
+	Source string            `json:"source"`
+	Chart  string            `json:"chart,omitempty"`
+	Images map[string]string `json:"images"`
 }
 
 func NewBundleArchiveFileWriter(path string) *BundleArchiveFileWriter {
 	return &BundleArchiveFileWriter{Path: path}
 }
 
-func (writer *BundleArchiveFileWriter) Open() error {
-	p := fmt.Sprintf("%s.%s.tmp", writer.Path, uuid.New().String())
+func (writer *BundleArchiveFileWriter) Open(source string) error {
+	if err := writer.lock(); err != nil {
+		return err
+	}
+
+	writer.tmpLayoutDir = fmt.Sprintf("%s.layout.tmp", writer.Path)
+	writer.chartDescriptor = nil
 
-	f, err := os.Create(p)
+	checkpoint, err := writer.readCheckpoint()
 	if err != nil {
-		return fmt.Errorf("unable to open tmp archive file %q: %w", p, err)
+		return err
 	}
 
-	zipper := gzip.NewWriter(f)
-	zipper.Header.Comment = "bundle-archive"
-	twriter := tar.NewWriter(zipper)
-
-	writer.tmpArchivePath = p
-	writer.tmpArchiveWriter = twriter
-	writer.tmpArchiveCloser = func() error {
-		if err := twriter.Close(); err != nil {
-			return fmt.Errorf("unable to close tar writer for %q: %w", writer.tmpArchivePath, err)
-		}
-		if err := zipper.Close(); err != nil {
-			return fmt.Errorf("unable to close zipper for %q: %w", writer.tmpArchivePath, err)
-		}
-		if err := f.Close(); err != nil {
-			return fmt.Errorf("unable to close %q: %w", writer.tmpArchivePath, err)
+	if checkpoint != nil && checkpoint.Source == source {
+		if _, err := layout.FromPath(writer.tmpLayoutDir); err == nil {
+			writer.checkpoint = checkpoint
+			return nil
 		}
+	}
+
+	if err := os.RemoveAll(writer.tmpLayoutDir); err != nil {
+		return fmt.Errorf("unable to cleanup tmp image layout %q: %w", writer.tmpLayoutDir, err)
+	}
+
+	if _, err := layout.Write(writer.tmpLayoutDir, empty.Index); err != nil {
+		return fmt.Errorf("unable to create tmp image layout %q: %w", writer.tmpLayoutDir, err)
+	}
+
+	writer.checkpoint = &bundleArchiveCheckpoint{Source: source, Images: map[string]string{}}
+
+	return writer.writeCheckpoint()
+}
+
+func (writer *BundleArchiveFileWriter) Close() error {
+	if writer.lockHandle == nil {
 		return nil
 	}
 
-	now := time.Now()
-	header := &tar.Header{
-		Name:       "images",
-		Typeflag:   tar.TypeDir,
-		Mode:       0o777,
-		ModTime:    now,
-		AccessTime: now,
-		ChangeTime: now,
+	if err := writer.locker.Release(*writer.lockHandle); err != nil {
+		return fmt.Errorf("unable to unlock bundle archive %q: %w", writer.Path, err)
+	}
+	writer.lockHandle = nil
+
+	return nil
+}
+
+func (writer *BundleArchiveFileWriter) lock() error {
+	if writer.lockHandle != nil {
+		return nil
+	}
+
+	locker, err := file_locker.NewFileLocker(writer.locksDir())
+	if err != nil {
+		return fmt.Errorf("unable to create bundle archive %q locker: %w", writer.Path, err)
+	}
+
+	acquired, lockHandle, err := locker.Acquire(bundleArchiveWriterLockName, lockgate.AcquireOptions{NonBlocking: true})
+	if err != nil {
+		return fmt.Errorf("unable to lock bundle archive %q: %w", writer.Path, err)
+	}
+	if !acquired {
+		return fmt.Errorf("bundle archive %q is being written by another process", writer.Path)
+	}
+
+	writer.locker = locker
+	writer.lockHandle = &lockHandle
+
+	return nil
+}
+
+func (writer *BundleArchiveFileWriter) locksDir() string {
+	return fmt.Sprintf("%s.locks", writer.Path)
+}
+
+func (writer *BundleArchiveFileWriter) ImageLayoutDir() string {
+	return writer.tmpLayoutDir
+}
+
+func (writer *BundleArchiveFileWriter) IsImageWritten(imageTag, digest string) bool {
+	return writer.checkpoint.Images[imageTag] == digest
+}
+
+func (writer *BundleArchiveFileWriter) CheckpointImage(imageTag, digest string) error {
+	writer.checkpoint.Images[imageTag] = digest
+	return writer.writeCheckpoint()
+}
+
+func (writer *BundleArchiveFileWriter) checkpointPath() string {
+	return fmt.Sprintf("%s.checkpoint.json", writer.Path)
+}
+
+// readCheckpoint returns nil if there is no checkpoint or the checkpoint is broken, so the writing is started from scratch.
+func (writer *BundleArchiveFileWriter) readCheckpoint() (*bundleArchiveCheckpoint, error) {
+	data, err := os.ReadFile(writer.checkpointPath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read checkpoint %q: %w", writer.checkpointPath(), err)
+	}
+
+	var checkpoint *bundleArchiveCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil || checkpoint == nil || checkpoint.Images == nil {
+		return nil, nil
+	}
+
+	return checkpoint, nil
+}
+
+func (writer *BundleArchiveFileWriter) writeCheckpoint() error {
+	data, err := json.Marshal(writer.checkpoint)
+	if err != nil {
+		return fmt.Errorf("unable to encode checkpoint: %w", err)
+	}
+
+	tmpPath := fmt.Sprintf("%s.%s.tmp", writer.checkpointPath(), uuid.New().String())
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("unable to write checkpoint %q: %w", tmpPath, err)
 	}
-	if err := writer.tmpArchiveWriter.WriteHeader(header); err != nil {
-		return fmt.Errorf("unable to write images dir header: %w", err)
+
+	if err := os.Rename(tmpPath, writer.checkpointPath()); err != nil {
+		return fmt.Errorf("unable to rename checkpoint %q to %q: %w", tmpPath, writer.checkpointPath(), err)
 	}
 
 	return nil
 }
 
 func (writer *BundleArchiveFileWriter) Save() error {
-	if writer.tmpArchiveWriter == nil {
+	if writer.tmpLayoutDir == "" {
 		panic(fmt.Sprintf("bundle archive %q is not opened", writer.Path))
 	}
 
-	if err := writer.tmpArchiveCloser(); err != nil {
-		return fmt.Errorf("unable to close tmp archive %q: %w", writer.tmpArchivePath, err)
+	if writer.chartDescriptor == nil {
+		return fmt.Errorf("no chart archive written into the bundle archive %q", writer.Path)
+	}
+
+	tmpArchivePath := fmt.Sprintf("%s.%s.tmp", writer.Path, uuid.New().String())
+	if err := writer.writeArchive(tmpArchivePath); err != nil {
+		return fmt.Errorf("unable to write tmp archive %q: %w", tmpArchivePath, err)
 	}
 
 	if err := os.RemoveAll(writer.Path); err != nil {
 		return fmt.Errorf("unable to cleanup destination archive path %q: %w", writer.Path, err)
 	}
 
-	if err := os.Rename(writer.tmpArchivePath, writer.Path); err != nil {
-		return fmt.Errorf("unable to rename tmp bundle archive %q to %q: %w", writer.tmpArchivePath, writer.Path, err)
+	if err := os.Rename(tmpArchivePath, writer.Path); err != nil {
+		return fmt.Errorf("unable to rename tmp bundle archive %q to %q: %w", tmpArchivePath, writer.Path, err)
 	}
 
-	return nil
+	if err := os.RemoveAll(writer.tmpLayoutDir); err != nil {
+		return fmt.Errorf("unable to remove tmp image layout %q: %w", writer.tmpLayoutDir, err)
+	}
+
+	if err := os.RemoveAll(writer.checkpointPath()); err != nil {
+		return fmt.Errorf("unable to remove checkpoint %q: %w", writer.checkpointPath(), err)
+	}
+
+	// the locks are removed while the lock is held, the next writer starts from scratch with the new lock file anyway,
+	// the error is ignored as the held lock file cannot be removed on Windows
+	_ = os.RemoveAll(writer.locksDir())
+
+	return writer.Close()
 }
 
 func (writer *BundleArchiveFileWriter) WriteChartArchive(data []byte) error {
-	now := time.Now()
-	header := &tar.Header{
-		Name:       chartArchiveFileName,
-		Typeflag:   tar.TypeReg,
-		Mode:       0o777,
-		Size:       int64(len(data)),
-		ModTime:    now,
-		AccessTime: now,
-		ChangeTime: now,
+	digest, size, err := v1.SHA256(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unable to calculate %q digest: %w", chartArchiveFileName, err)
 	}
 
-	if err := writer.tmpArchiveWriter.WriteHeader(header); err != nil {
-		return fmt.Errorf("unable to write %q header: %w", chartArchiveFileName, err)
+	if err := layout.Path(writer.tmpLayoutDir).WriteBlob(digest, io.NopCloser(bytes.NewReader(data))); err != nil {
+		return fmt.Errorf("unable to write %q blob: %w", chartArchiveFileName, err)
 	}
 
-	if _, err := writer.tmpArchiveWriter.Write(data); err != nil {
-		return fmt.Errorf("unable to write %q data: %w", chartArchiveFileName, err)
+	// the chart archive is not reproducible, so the chart blob written by the interrupted writing is not needed anymore
+	if prevChart := writer.checkpoint.Chart; prevChart != "" && prevChart != digest.String() {
+		if prevDigest, err := v1.NewHash(prevChart); err == nil {
+			if err := layout.Path(writer.tmpLayoutDir).RemoveBlob(prevDigest); err != nil {
+				return fmt.Errorf("unable to remove previous %q blob: %w", chartArchiveFileName, err)
+			}
+		}
 	}
 
-	return nil
+	writer.chartDescriptor = &v1.Descriptor{
+		MediaType: types.MediaType(bundles_registry.HelmChartContentLayerMediaType),
+		Size:      size,
+		Digest:    digest,
+	}
+
+	writer.checkpoint.Chart = digest.String()
+
+	return writer.writeCheckpoint()
 }
 
 func (writer *BundleArchiveFileWriter) WriteImageArchive(imageTag string, data []byte) error {
+	img, err := tarball.Image(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("unable to open image %q archive: %w", imageTag, err)
+	}
+
+	if err := docker_registry.WriteImageLayout(writer.tmpLayoutDir, imageTag, img, docker_registry.TransferOptions{}); err != nil {
+		return fmt.Errorf("unable to write image %q: %w", imageTag, err)
+	}
+
+	return nil
+}
+
+func (writer *BundleArchiveFileWriter) writeArchive(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to open tmp archive file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	zipper := gzip.NewWriter(f)
+	zipper.Header.Comment = "bundle-archive"
+	twriter := tar.NewWriter(zipper)
 	now := time.Now()
-	buf := bytes.NewBuffer(nil)
-	zipper := gzip.NewWriter(buf)
 
-	if _, err := io.Copy(zipper, bytes.NewReader(data)); err != nil {
-		return fmt.Errorf("unable to gzip image archive data: %w", err)
+	metadata, err := json.Marshal(bundleArchiveMetadata{Version: BundleArchiveFormatVersion, Chart: *writer.chartDescriptor})
+	if err != nil {
+		return fmt.Errorf("unable to encode %q: %w", bundleMetadataFileName, err)
+	}
+
+	// the metadata should be the first file of the archive to detect the format version without reading the whole archive
+	if err := writeArchiveFile(twriter, bundleMetadataFileName, int64(len(metadata)), bytes.NewReader(metadata), now); err != nil {
+		return err
+	}
+
+	if err := filepath.WalkDir(writer.tmpLayoutDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(writer.tmpLayoutDir, p)
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		name = filepath.ToSlash(name)
+
+		// the blob left unfinished by the interrupted writing
+		if !d.IsDir() && strings.HasSuffix(name, ".tmp") {
+			return nil
+		}
+
+		if d.IsDir() {
+			if err := twriter.WriteHeader(&tar.Header{
+				Name:       name,
+				Typeflag:   tar.TypeDir,
+				Mode:       0o777,
+				ModTime:    now,
+				AccessTime: now,
+				ChangeTime: now,
+			}); err != nil {
+				return fmt.Errorf("unable to write %q dir header: %w", name, err)
+			}
+
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return writeArchiveFile(twriter, name, info.Size(), file, now)
+	}); err != nil {
+		return fmt.Errorf("unable to write image layout: %w", err)
 	}
 
+	if err := twriter.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer for %q: %w", path, err)
+	}
 	if err := zipper.Close(); err != nil {
-		return fmt.Errorf("unable to close gzip image archive: %w", err)
+		return fmt.Errorf("unable to close zipper for %q: %w", path, err)
 	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to close %q: %w", path, err)
+	}
+
+	return nil
+}
 
+func writeArchiveFile(twriter *tar.Writer, name string, size int64, r io.Reader, now time.Time) error {
 	header := &tar.Header{
-		Name:       fmt.Sprintf("images/%s.tar.gz", imageTag),
+		Name:       name,
 		Typeflag:   tar.TypeReg,
 		Mode:       0o777,
-		Size:       int64(len(buf.Bytes())),
+		Size:       size,
 		ModTime:    now,
 		AccessTime: now,
 		ChangeTime: now,
 	}
 
-	if err := writer.tmpArchiveWriter.WriteHeader(header); err != nil {
-		return fmt.Errorf("unable to write image %q header: %w", imageTag, err)
+	if err := twriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("unable to write %q header: %w", name, err)
 	}
 
-	if _, err := writer.tmpArchiveWriter.Write(buf.Bytes()); err != nil {
-		return fmt.Errorf("unable to write chart.tar.gz data: %w", err)
+	if _, err := io.Copy(twriter, r); err != nil {
+		return fmt.Errorf("unable to write %q data: %w", name, err)
 	}
 
 	return nil
@@ -0,0 +1,307 @@
+package bundles
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/signature"
+)
+
+// BundleSignatureFileName is the chart file with the bundle signature.
+// The signature travels with the chart when the bundle is copied between container registries and archives.
+const BundleSignatureFileName = "werf-bundle.sig"
+
+type VerifyMode string
+
+const (
+	VerifyModeEnforce VerifyMode = "enforce"
+	VerifyModeWarn    VerifyMode = "warn"
+)
+
+var ErrBundleNotSigned = errors.New("bundle is not signed")
+
+func ParseVerifyMode(mode string) (VerifyMode, error) {
+	switch VerifyMode(mode) {
+	case VerifyModeEnforce, VerifyModeWarn:
+		return VerifyMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown verify mode %q: %q or %q expected", mode, VerifyModeEnforce, VerifyModeWarn)
+	}
+}
+
+// RepoImageGetter resolves the digests of the bundle images, docker_registry.API() is used by werf commands.
+type RepoImageGetter interface {
+	GetRepoImage(ctx context.Context, reference string) (*image.Info, error)
+}
+
+// BundleSignaturePayload is the signed content of the bundle.
+// The chart digest does not depend on the values and the chart metadata changed by werf bundle copy (.Values.werf.repo, .Values.werf.image, the chart name and version),
+// the images are covered by the manifest digests instead of the references.
+type BundleSignaturePayload struct {
+	ChartDigest  string            `json:"chartDigest"`
+	ImageDigests map[string]string `json:"imageDigests"`
+}
+
+type BundleSignature struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// SignBundle signs the chart digest and the digests of all images referred by .Values.werf.image, the signature is added into the chart files.
+func SignBundle(ctx context.Context, ch *chart.Chart, signer signature.Signer, registry RepoImageGetter) error {
+	payload, err := newBundleSignaturePayload(ctx, ch, registry)
+	if err != nil {
+		return err
+	}
+
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("unable to marshal bundle signature payload: %w", err)
+	}
+
+	sig, err := signer.Sign(ctx, payloadData)
+	if err != nil {
+		return fmt.Errorf("unable to sign bundle: %w", err)
+	}
+
+	data, err := json.Marshal(BundleSignature{Payload: payloadData, Signature: sig})
+	if err != nil {
+		return fmt.Errorf("unable to marshal bundle signature: %w", err)
+	}
+
+	files := []*chart.File{{Name: BundleSignatureFileName, Data: data}}
+	for _, f := range ch.Files {
+		if f.Name != BundleSignatureFileName {
+			files = append(files, f)
+		}
+	}
+	ch.Files = files
+
+	return nil
+}
+
+type VerifyOptions struct {
+	PublicKey crypto.PublicKey
+	Mode      VerifyMode
+	Registry  RepoImageGetter
+}
+
+// VerifyBundle checks the bundle signature, the chart digest and the digests of the images the bundle refers to.
+// The returned values pin .Values.werf.image to the verified digests (repo@digest), so the images cannot be replaced by moving the tags after the verification.
+// The values should be set as the service values of the bundle to take precedence over the bundle and the user values.
+// The verification error is only logged as the warning in the VerifyModeWarn, no values are returned in this case.
+func VerifyBundle(ctx context.Context, ch *chart.Chart, opts VerifyOptions) (map[string]interface{}, error) {
+	imageVals, err := verifyBundle(ctx, ch, opts)
+	if err == nil {
+		logboek.Context(ctx).Default().LogFDetails("Bundle signature verified\n")
+		return map[string]interface{}{
+			"werf": map[string]interface{}{"image": imageVals},
+		}, nil
+	}
+
+	if opts.Mode == VerifyModeWarn {
+		logboek.Context(ctx).Warn().LogF("WARNING: Bundle verification failed: %s\n", err)
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("bundle verification failed: %w", err)
+}
+
+// VerifyBundleDir loads the chart of the bundle from the directory and verifies it, see VerifyBundle.
+func VerifyBundleDir(ctx context.Context, dir string, opts VerifyOptions) (map[string]interface{}, error) {
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	loader.GlobalLoadOptions = &loader.LoadOptions{}
+
+	ch, err := loader.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading chart %q: %w", path, err)
+	}
+
+	return VerifyBundle(ctx, ch, opts)
+}
+
+// verifyBundle returns the image references pinned to the signed digests by the image name.
+func verifyBundle(ctx context.Context, ch *chart.Chart, opts VerifyOptions) (map[string]interface{}, error) {
+	var data []byte
+	for _, f := range ch.Files {
+		if f.Name == BundleSignatureFileName {
+			data = f.Data
+			break
+		}
+	}
+	if data == nil {
+		return nil, ErrBundleNotSigned
+	}
+
+	var sig BundleSignature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal bundle signature: %w", err)
+	}
+
+	if err := signature.Verify(opts.PublicKey, sig.Payload, sig.Signature); err != nil {
+		return nil, err
+	}
+
+	var signedPayload BundleSignaturePayload
+	if err := json.Unmarshal(sig.Payload, &signedPayload); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal bundle signature payload: %w", err)
+	}
+
+	chartDigest, err := bundleChartDigest(ch)
+	if err != nil {
+		return nil, err
+	}
+	if chartDigest != signedPayload.ChartDigest {
+		return nil, fmt.Errorf("chart digest %s does not match the signed %s", chartDigest, signedPayload.ChartDigest)
+	}
+
+	imageRefs := bundleImageReferences(ch)
+	for imageName := range signedPayload.ImageDigests {
+		if _, ok := imageRefs[imageName]; !ok {
+			return nil, fmt.Errorf("signed image %q not found in the bundle", imageName)
+		}
+	}
+
+	imageVals := map[string]interface{}{}
+	for imageName, imageRef := range imageRefs {
+		signedDigest, ok := signedPayload.ImageDigests[imageName]
+		if !ok {
+			return nil, fmt.Errorf("image %q is not signed", imageName)
+		}
+
+		info, err := getRepoImageWithDigest(ctx, opts.Registry, imageRef)
+		if err != nil {
+			return nil, err
+		}
+
+		if digest := info.GetDigest(); digest != signedDigest {
+			return nil, fmt.Errorf("image %q digest %s does not match the signed %s", imageRef, digest, signedDigest)
+		}
+
+		imageVals[imageName] = fmt.Sprintf("%s@%s", info.Repository, signedDigest)
+	}
+
+	return imageVals, nil
+}
+
+func newBundleSignaturePayload(ctx context.Context, ch *chart.Chart, registry RepoImageGetter) (*BundleSignaturePayload, error) {
+	chartDigest, err := bundleChartDigest(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &BundleSignaturePayload{
+		ChartDigest:  chartDigest,
+		ImageDigests: map[string]string{},
+	}
+
+	for imageName, imageRef := range bundleImageReferences(ch) {
+		info, err := getRepoImageWithDigest(ctx, registry, imageRef)
+		if err != nil {
+			return nil, err
+		}
+
+		payload.ImageDigests[imageName] = info.GetDigest()
+	}
+
+	return payload, nil
+}
+
+// bundleImageReferences returns .Values.werf.image references by the image name.
+func bundleImageReferences(ch *chart.Chart) map[string]string {
+	res := map[string]string{}
+
+	if werfVals, ok := ch.Values["werf"].(map[string]interface{}); ok {
+		if imageVals, ok := werfVals["image"].(map[string]interface{}); ok {
+			for imageName, v := range imageVals {
+				if imageRef, ok := v.(string); ok {
+					res[imageName] = imageRef
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+func getRepoImageWithDigest(ctx context.Context, registry RepoImageGetter, imageRef string) (*image.Info, error) {
+	info, err := registry.GetRepoImage(ctx, imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get image %q: %w", imageRef, err)
+	}
+
+	if info.GetDigest() == "" {
+		return nil, fmt.Errorf("unable to get image %q digest", imageRef)
+	}
+
+	return info, nil
+}
+
+// bundleChartDigest calculates the digest of the chart content except the bundle signature file, .Values.werf.repo, .Values.werf.image, the chart name and version.
+func bundleChartDigest(ch *chart.Chart) (string, error) {
+	content := struct {
+		Metadata     *chart.Metadata        `json:"metadata"`
+		Values       map[string]interface{} `json:"values"`
+		Schema       []byte                 `json:"schema"`
+		Templates    []*chart.File          `json:"templates"`
+		Files        []*chart.File          `json:"files"`
+		Dependencies []string               `json:"dependencies"`
+	}{
+		Values:    bundleDefaultValues(ch),
+		Schema:    ch.Schema,
+		Templates: sortedChartFiles(ch.Templates),
+	}
+
+	if ch.Metadata != nil {
+		metadata := *ch.Metadata
+		metadata.Name = ""
+		metadata.Version = ""
+		content.Metadata = &metadata
+	}
+
+	for _, f := range ch.Files {
+		if f.Name != BundleSignatureFileName {
+			content.Files = append(content.Files, f)
+		}
+	}
+	content.Files = sortedChartFiles(content.Files)
+
+	for _, dep := range ch.Dependencies() {
+		digest, err := bundleChartDigest(dep)
+		if err != nil {
+			return "", err
+		}
+		content.Dependencies = append(content.Dependencies, digest)
+	}
+	sort.Strings(content.Dependencies)
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal chart content: %w", err)
+	}
+
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data)), nil
+}
+
+func sortedChartFiles(files []*chart.File) []*chart.File {
+	res := append([]*chart.File(nil), files...)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
@@ -0,0 +1,143 @@
+package bundles
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/signature"
+)
+
+var _ = Describe("Bundle signature", func() {
+	var ctx context.Context
+	var key *ecdsa.PrivateKey
+	var registry *RepoImageGetterStub
+	var ch *chart.Chart
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(Succeed())
+
+		registry = &RepoImageGetterStub{DigestsByReference: map[string]string{
+			"registry.example.com/group/project:tag-1": "sha256:1111",
+			"registry.example.com/group/project:tag-2": "sha256:2222",
+		}}
+
+		ch = &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: "v2", Name: "project", Version: "0.1.0"},
+			Values: map[string]interface{}{
+				"werf": map[string]interface{}{
+					"image": map[string]interface{}{
+						"image-1": "registry.example.com/group/project:tag-1",
+						"image-2": "registry.example.com/group/project:tag-2",
+					},
+					"repo": "registry.example.com/group/project",
+				},
+				"replicas": 1,
+			},
+			Templates: []*chart.File{{Name: "templates/deployment.yaml", Data: []byte("kind: Deployment")}},
+		}
+
+		Expect(SignBundle(ctx, ch, signature.NewKeySigner(key), registry)).To(Succeed())
+	})
+
+	verifyValues := func(mode VerifyMode) (map[string]interface{}, error) {
+		return VerifyBundle(ctx, ch, VerifyOptions{PublicKey: key.Public(), Mode: mode, Registry: registry})
+	}
+
+	verify := func(mode VerifyMode) error {
+		_, err := verifyValues(mode)
+		return err
+	}
+
+	pinnedImageValues := func(repo string) map[string]interface{} {
+		return map[string]interface{}{
+			"werf": map[string]interface{}{
+				"image": map[string]interface{}{
+					"image-1": repo + "@sha256:1111",
+					"image-2": repo + "@sha256:2222",
+				},
+			},
+		}
+	}
+
+	It("should pin the verified images by the signed digests", func() {
+		vals, err := verifyValues(VerifyModeEnforce)
+		Expect(err).To(Succeed())
+		Expect(vals).To(Equal(pinnedImageValues("registry.example.com/group/project")))
+	})
+
+	It("should verify the bundle copied into another repository", func() {
+		Expect(verify(VerifyModeEnforce)).To(Succeed())
+
+		registry.DigestsByReference["other.example.com/project:tag-1"] = "sha256:1111"
+		registry.DigestsByReference["other.example.com/project:tag-2"] = "sha256:2222"
+
+		werfVals := ch.Values["werf"].(map[string]interface{})
+		werfVals["image"] = map[string]interface{}{
+			"image-1": "other.example.com/project:tag-1",
+			"image-2": "other.example.com/project:tag-2",
+		}
+		werfVals["repo"] = "other.example.com/project"
+		ch.Metadata.Name = "renamed"
+		ch.Metadata.Version = "0.0.0-1700000000-tag"
+
+		vals, err := verifyValues(VerifyModeEnforce)
+		Expect(err).To(Succeed())
+		Expect(vals).To(Equal(pinnedImageValues("other.example.com/project")))
+	})
+
+	It("should fail if the chart is changed", func() {
+		ch.Templates[0].Data = []byte("kind: DaemonSet")
+
+		Expect(verify(VerifyModeEnforce)).To(MatchError(ContainSubstring("chart digest")))
+
+		vals, err := verifyValues(VerifyModeWarn)
+		Expect(err).To(Succeed())
+		Expect(vals).To(BeNil())
+	})
+
+	It("should fail if the image is changed", func() {
+		registry.DigestsByReference["registry.example.com/group/project:tag-2"] = "sha256:3333"
+
+		Expect(verify(VerifyModeEnforce)).To(MatchError(ContainSubstring("does not match the signed sha256:2222")))
+	})
+
+	It("should fail if the bundle is signed with another key", func() {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(Succeed())
+
+		_, err = VerifyBundle(ctx, ch, VerifyOptions{PublicKey: otherKey.Public(), Mode: VerifyModeEnforce, Registry: registry})
+		Expect(err).To(MatchError(signature.ErrInvalidSignature))
+	})
+
+	It("should fail if the bundle is not signed", func() {
+		ch.Files = nil
+
+		Expect(verify(VerifyModeEnforce)).To(MatchError(ErrBundleNotSigned))
+	})
+})
+
+type RepoImageGetterStub struct {
+	DigestsByReference map[string]string
+}
+
+func (registry *RepoImageGetterStub) GetRepoImage(_ context.Context, reference string) (*image.Info, error) {
+	digest, hasImage := registry.DigestsByReference[reference]
+	if !hasImage {
+		return nil, fmt.Errorf("image %q not found", reference)
+	}
+
+	repository, _ := image.ParseRepositoryAndTag(reference)
+	return &image.Info{Repository: repository, RepoDigest: fmt.Sprintf("%s@%s", repository, digest)}, nil
+}
@@ -0,0 +1,44 @@
+package bundles
+
+import (
+	"context"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/docker_registry"
+)
+
+// copyProgress logs the blobs of the bundle images transferred by the copy and counts the bytes saved by the blobs already present at the destination.
+type copyProgress struct {
+	transferredBlobs, skippedBlobs int
+	transferredBytes, skippedBytes int64
+}
+
+func (progress *copyProgress) TransferOptions(ctx context.Context) docker_registry.TransferOptions {
+	return docker_registry.TransferOptions{
+		OnBlob: func(blob docker_registry.BlobTransfer) {
+			if blob.Skipped {
+				progress.skippedBlobs++
+				progress.skippedBytes += blob.Size
+
+				logboek.Context(ctx).Info().LogF("(%d/%d) Blob %s (%s) already exists, skipped\n", blob.Index, blob.Total, blob.Digest, humanize.Bytes(uint64(blob.Size)))
+				return
+			}
+
+			progress.transferredBlobs++
+			progress.transferredBytes += blob.Size
+
+			logboek.Context(ctx).Default().LogF("(%d/%d) Blob %s (%s) transferred\n", blob.Index, blob.Total, blob.Digest, humanize.Bytes(uint64(blob.Size)))
+		},
+	}
+}
+
+func (progress *copyProgress) LogSummary(ctx context.Context) {
+	if progress.transferredBlobs+progress.skippedBlobs == 0 {
+		return
+	}
+
+	logboek.Context(ctx).Default().LogFDetails("Transferred: %d blobs, %s\n", progress.transferredBlobs, humanize.Bytes(uint64(progress.transferredBytes)))
+	logboek.Context(ctx).Default().LogFDetails("Skipped: %d blobs already present at the destination, %s saved\n", progress.skippedBlobs, humanize.Bytes(uint64(progress.skippedBytes)))
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 
-	v1 "github.com/google/go-containerregistry/pkg/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"helm.sh/helm/v3/pkg/chart"
@@ -474,6 +473,10 @@ func (reader *BundleArchiveStubReader) ReadImageArchive(imageTag string) (*Image
 	return NewImageArchiveReadCloser(bytes.NewReader(data), func() error { return nil }), nil
 }
 
+func (reader *BundleArchiveStubReader) ExtractImageLayout(dir string) (bool, error) {
+	return false, nil
+}
+
 type BundleArchiveStubWriter struct {
 	StubChart   *chart.Chart
 	ImagesByTag map[string][]byte
@@ -483,7 +486,7 @@ func NewBundleArchiveStubWriter() *BundleArchiveStubWriter {
 	return &BundleArchiveStubWriter{ImagesByTag: make(map[string][]byte)}
 }
 
-func (writer *BundleArchiveStubWriter) Open() error { return nil }
+func (writer *BundleArchiveStubWriter) Open(source string) error { return nil }
 
 func (writer *BundleArchiveStubWriter) WriteChartArchive(data []byte) error {
 	ch, err := BytesToChart(data)
@@ -501,8 +504,16 @@ func (writer *BundleArchiveStubWriter) WriteImageArchive(imageTag string, data [
 	return nil
 }
 
+func (writer *BundleArchiveStubWriter) ImageLayoutDir() string { return "" }
+
+func (writer *BundleArchiveStubWriter) IsImageWritten(imageTag, digest string) bool { return false }
+
+func (writer *BundleArchiveStubWriter) CheckpointImage(imageTag, digest string) error { return nil }
+
 func (writer *BundleArchiveStubWriter) Save() error { return nil }
 
+func (writer *BundleArchiveStubWriter) Close() error { return nil }
+
 type BundlesRegistryClientStub struct {
 	StubCharts map[string]*chart.Chart
 }
@@ -578,7 +589,7 @@ func (registry *DockerRegistryStub) PullImageArchive(ctx context.Context, archiv
 	return nil
 }
 
-func (registry *DockerRegistryStub) MutateAndPushImage(ctx context.Context, sourceReference, destinationReference string, mutateConfigFunc func(v1.Config) (v1.Config, error)) error {
+func (registry *DockerRegistryStub) CopyImage(ctx context.Context, sourceReference, destinationReference string, opts docker_registry.CopyImageOptions) error {
 	data, hasImage := registry.ImagesByReference[sourceReference]
 	if !hasImage {
 		return fmt.Errorf("source image not found")
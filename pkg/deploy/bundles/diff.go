@@ -0,0 +1,389 @@
+package bundles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/logboek"
+	"github.com/werf/nelm/pkg/utls"
+	"github.com/werf/werf/pkg/docker_registry"
+)
+
+// ErrBundlesDiffer is returned by werf bundle diff with --exit-code if any difference found.
+var ErrBundlesDiffer = errors.New("bundles differ")
+
+type DiffChange string
+
+const (
+	DiffChangeAdded    DiffChange = "added"
+	DiffChangeRemoved  DiffChange = "removed"
+	DiffChangeModified DiffChange = "modified"
+)
+
+// FileDiff is the change of the chart template or the rendered manifest.
+type FileDiff struct {
+	Name   string
+	Change DiffChange
+	From   string
+	To     string
+}
+
+// ValueDiff is the change of the default value by the dot-separated path, the lists are compared as a whole.
+type ValueDiff struct {
+	Path   string
+	Change DiffChange
+	From   interface{}
+	To     interface{}
+}
+
+// ImageDiff is the change of the image referred by .Values.werf.image.
+type ImageDiff struct {
+	Name       string
+	Change     DiffChange
+	FromDigest string
+	ToDigest   string
+}
+
+// BundleDiff is the difference between two bundles.
+// The bundle images are compared by the digests, so .Values.werf.image and .Values.werf.repo changed by werf bundle copy are excluded from the values.
+type BundleDiff struct {
+	Templates []FileDiff
+	Values    []ValueDiff
+	Manifests []FileDiff
+	Images    []ImageDiff
+}
+
+func (diff *BundleDiff) HasChanges() bool {
+	return len(diff.Templates) > 0 || len(diff.Values) > 0 || len(diff.Manifests) > 0 || len(diff.Images) > 0
+}
+
+type DiffOptions struct {
+	BundlesRegistryClient                BundlesRegistryClient
+	FromRegistryClient, ToRegistryClient docker_registry.Interface
+
+	// RenderManifests renders the bundle chart with the same values for both bundles, the manifests are not compared if not specified.
+	RenderManifests func(ctx context.Context, ch *chart.Chart) (string, error)
+}
+
+func Diff(ctx context.Context, fromAddr, toAddr *Addr, opts DiffOptions) (*BundleDiff, error) {
+	fromBundle := NewBundleAccessor(fromAddr, BundleAccessorOptions{
+		BundlesRegistryClient: opts.BundlesRegistryClient,
+		RegistryClient:        opts.FromRegistryClient,
+	})
+	toBundle := NewBundleAccessor(toAddr, BundleAccessorOptions{
+		BundlesRegistryClient: opts.BundlesRegistryClient,
+		RegistryClient:        opts.ToRegistryClient,
+	})
+
+	return DiffBundles(ctx, fromBundle, toBundle, opts)
+}
+
+func DiffBundles(ctx context.Context, fromBundle, toBundle BundleAccessor, opts DiffOptions) (*BundleDiff, error) {
+	fromChart, err := fromBundle.ReadChart(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read source bundle chart: %w", err)
+	}
+
+	toChart, err := toBundle.ReadChart(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read destination bundle chart: %w", err)
+	}
+
+	diff := &BundleDiff{
+		Templates: diffFiles(chartTemplates(fromChart, ""), chartTemplates(toChart, "")),
+		Values:    diffValues("", bundleDefaultValues(fromChart), bundleDefaultValues(toChart)),
+	}
+
+	if err := logboek.Context(ctx).LogProcess("Comparing bundle images").DoError(func() error {
+		fromDigests, err := fromBundle.ReadImageDigests(ctx, fromChart)
+		if err != nil {
+			return fmt.Errorf("unable to read source bundle images: %w", err)
+		}
+
+		toDigests, err := toBundle.ReadImageDigests(ctx, toChart)
+		if err != nil {
+			return fmt.Errorf("unable to read destination bundle images: %w", err)
+		}
+
+		diff.Images = diffImages(fromDigests, toDigests)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if opts.RenderManifests != nil {
+		if err := logboek.Context(ctx).LogProcess("Comparing rendered manifests").DoError(func() error {
+			fromManifests, err := opts.RenderManifests(ctx, fromChart)
+			if err != nil {
+				return fmt.Errorf("unable to render source bundle: %w", err)
+			}
+
+			toManifests, err := opts.RenderManifests(ctx, toChart)
+			if err != nil {
+				return fmt.Errorf("unable to render destination bundle: %w", err)
+			}
+
+			diff.Manifests = diffFiles(splitManifests(fromManifests), splitManifests(toManifests))
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return diff, nil
+}
+
+// PrintDiff prints the changes grouped by the kind, the templates and the manifests are printed as the unified diff.
+func PrintDiff(ctx context.Context, diff *BundleDiff) {
+	if !diff.HasChanges() {
+		logboek.Context(ctx).Default().LogLnHighlight("No changes found between bundles")
+		return
+	}
+
+	printFileDiffs(ctx, "Templates", diff.Templates)
+
+	if len(diff.Values) > 0 {
+		logboek.Context(ctx).Default().LogBlock("Values").Do(func() {
+			for _, d := range diff.Values {
+				switch d.Change {
+				case DiffChangeAdded:
+					logboek.Context(ctx).Default().LogF("+ %s: %s\n", d.Path, formatValue(d.To))
+				case DiffChangeRemoved:
+					logboek.Context(ctx).Default().LogF("- %s: %s\n", d.Path, formatValue(d.From))
+				default:
+					logboek.Context(ctx).Default().LogF("~ %s: %s -> %s\n", d.Path, formatValue(d.From), formatValue(d.To))
+				}
+			}
+		})
+	}
+
+	printFileDiffs(ctx, "Manifests", diff.Manifests)
+
+	if len(diff.Images) > 0 {
+		logboek.Context(ctx).Default().LogBlock("Images").Do(func() {
+			for _, d := range diff.Images {
+				switch d.Change {
+				case DiffChangeAdded:
+					logboek.Context(ctx).Default().LogF("+ %s: %s\n", d.Name, d.ToDigest)
+				case DiffChangeRemoved:
+					logboek.Context(ctx).Default().LogF("- %s: %s\n", d.Name, d.FromDigest)
+				default:
+					logboek.Context(ctx).Default().LogF("~ %s: %s -> %s\n", d.Name, d.FromDigest, d.ToDigest)
+				}
+			}
+		})
+	}
+}
+
+func printFileDiffs(ctx context.Context, title string, diffs []FileDiff) {
+	if len(diffs) == 0 {
+		return
+	}
+
+	logboek.Context(ctx).Default().LogBlock(title).Do(func() {
+		for _, d := range diffs {
+			logboek.Context(ctx).Default().LogFHighlight("%s %s\n", d.Change, d.Name)
+
+			if uDiff, present := utls.ColoredUnifiedDiff(d.From, d.To); present {
+				logboek.Context(ctx).Default().LogLn(uDiff)
+			}
+		}
+	})
+}
+
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
+
+func diffFiles(from, to map[string]string) []FileDiff {
+	var res []FileDiff
+
+	for _, name := range unionKeys(from, to) {
+		fromData, inFrom := from[name]
+		toData, inTo := to[name]
+
+		switch {
+		case !inFrom:
+			res = append(res, FileDiff{Name: name, Change: DiffChangeAdded, To: toData})
+		case !inTo:
+			res = append(res, FileDiff{Name: name, Change: DiffChangeRemoved, From: fromData})
+		case fromData != toData:
+			res = append(res, FileDiff{Name: name, Change: DiffChangeModified, From: fromData, To: toData})
+		}
+	}
+
+	return res
+}
+
+func diffValues(prefix string, from, to map[string]interface{}) []ValueDiff {
+	var res []ValueDiff
+
+	for _, key := range unionKeys(from, to) {
+		p := key
+		if prefix != "" {
+			p = prefix + "." + key
+		}
+
+		fromVal, inFrom := from[key]
+		toVal, inTo := to[key]
+
+		fromMap, fromIsMap := fromVal.(map[string]interface{})
+		toMap, toIsMap := toVal.(map[string]interface{})
+
+		switch {
+		case fromIsMap && toIsMap:
+			res = append(res, diffValues(p, fromMap, toMap)...)
+		case !inFrom:
+			res = append(res, ValueDiff{Path: p, Change: DiffChangeAdded, To: toVal})
+		case !inTo:
+			res = append(res, ValueDiff{Path: p, Change: DiffChangeRemoved, From: fromVal})
+		case !reflect.DeepEqual(fromVal, toVal):
+			res = append(res, ValueDiff{Path: p, Change: DiffChangeModified, From: fromVal, To: toVal})
+		}
+	}
+
+	return res
+}
+
+func diffImages(from, to map[string]string) []ImageDiff {
+	var res []ImageDiff
+
+	for _, name := range unionKeys(from, to) {
+		fromDigest, inFrom := from[name]
+		toDigest, inTo := to[name]
+
+		switch {
+		case !inFrom:
+			res = append(res, ImageDiff{Name: name, Change: DiffChangeAdded, ToDigest: toDigest})
+		case !inTo:
+			res = append(res, ImageDiff{Name: name, Change: DiffChangeRemoved, FromDigest: fromDigest})
+		case fromDigest != toDigest:
+			res = append(res, ImageDiff{Name: name, Change: DiffChangeModified, FromDigest: fromDigest, ToDigest: toDigest})
+		}
+	}
+
+	return res
+}
+
+// chartTemplates returns the templates of the chart and its dependencies by the path relative to the chart.
+func chartTemplates(ch *chart.Chart, prefix string) map[string]string {
+	res := map[string]string{}
+
+	for _, f := range ch.Templates {
+		res[path.Join(prefix, f.Name)] = string(f.Data)
+	}
+
+	for _, dep := range ch.Dependencies() {
+		for name, data := range chartTemplates(dep, path.Join(prefix, "charts", dep.Name())) {
+			res[name] = data
+		}
+	}
+
+	return res
+}
+
+// bundleDefaultValues returns the chart values except .Values.werf.image and .Values.werf.repo.
+func bundleDefaultValues(ch *chart.Chart) map[string]interface{} {
+	res := make(map[string]interface{}, len(ch.Values))
+	for k, v := range ch.Values {
+		res[k] = v
+	}
+
+	if werfVals, ok := ch.Values["werf"].(map[string]interface{}); ok {
+		newWerfVals := make(map[string]interface{}, len(werfVals))
+		for k, v := range werfVals {
+			if k != "repo" && k != "image" {
+				newWerfVals[k] = v
+			}
+		}
+
+		if len(newWerfVals) > 0 {
+			res["werf"] = newWerfVals
+		} else {
+			delete(res, "werf")
+		}
+	}
+
+	return res
+}
+
+// splitManifests splits the rendered manifests into the resources by Kind/name with the namespace if specified.
+// The "# Source:" comments are dropped, because they contain the chart name, which is changed by werf bundle copy.
+func splitManifests(manifests string) map[string]string {
+	res := map[string]string{}
+
+	for _, doc := range strings.Split(manifests, "\n---") {
+		var lines []string
+		var source string
+		for _, line := range strings.Split(strings.TrimPrefix(doc, "---"), "\n") {
+			if strings.HasPrefix(line, "# Source: ") {
+				source = strings.TrimPrefix(line, "# Source: ")
+				continue
+			}
+			lines = append(lines, line)
+		}
+
+		data := strings.TrimSpace(strings.Join(lines, "\n"))
+		if data == "" {
+			continue
+		}
+
+		var meta struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+
+		name := source
+		if err := yaml.Unmarshal([]byte(data), &meta); err == nil && meta.Kind != "" {
+			name = fmt.Sprintf("%s/%s", meta.Kind, meta.Metadata.Name)
+			if meta.Metadata.Namespace != "" {
+				name = fmt.Sprintf("%s (namespace: %s)", name, meta.Metadata.Namespace)
+			}
+		}
+
+		if _, exists := res[name]; exists {
+			res[name] += "\n---\n" + data + "\n"
+		} else {
+			res[name] = data + "\n"
+		}
+	}
+
+	return res
+}
+
+func unionKeys[V any](from, to map[string]V) []string {
+	keys := make(map[string]struct{}, len(from)+len(to))
+	for k := range from {
+		keys[k] = struct{}{}
+	}
+	for k := range to {
+		keys[k] = struct{}{}
+	}
+
+	res := make([]string, 0, len(keys))
+	for k := range keys {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+
+	return res
+}
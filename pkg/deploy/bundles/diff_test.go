@@ -0,0 +1,158 @@
+package bundles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/werf/werf/pkg/docker_registry"
+	"github.com/werf/werf/pkg/image"
+)
+
+var _ = Describe("Bundle diff", func() {
+	var ctx context.Context
+	var bundlesRegistryClient *BundlesRegistryClientStub
+	var registryClient *DockerRegistryDigestsStub
+
+	newChart := func(name, repo string, templates map[string]string, values map[string]interface{}) *chart.Chart {
+		ch := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: "v2", Name: name, Version: "0.1.0"},
+			Values: map[string]interface{}{
+				"werf": map[string]interface{}{
+					"image": map[string]interface{}{
+						"image-1": fmt.Sprintf("%s:tag-1", repo),
+						"image-2": fmt.Sprintf("%s:tag-2", repo),
+					},
+					"repo": repo,
+				},
+			},
+		}
+
+		for k, v := range values {
+			ch.Values[k] = v
+		}
+
+		for name, data := range templates {
+			ch.Templates = append(ch.Templates, &chart.File{Name: name, Data: []byte(data)})
+		}
+
+		return ch
+	}
+
+	renderManifests := func(_ context.Context, ch *chart.Chart) (string, error) {
+		var docs []string
+		for _, f := range sortedChartFiles(ch.Templates) {
+			docs = append(docs, fmt.Sprintf("---\n# Source: %s/%s\n%s", ch.Name(), f.Name, f.Data))
+		}
+		return strings.Join(docs, "\n"), nil
+	}
+
+	diff := func(from, to string) *BundleDiff {
+		fromAddr, err := ParseAddr(from)
+		Expect(err).To(Succeed())
+		toAddr, err := ParseAddr(to)
+		Expect(err).To(Succeed())
+
+		diff, err := Diff(ctx, fromAddr, toAddr, DiffOptions{
+			BundlesRegistryClient: bundlesRegistryClient,
+			FromRegistryClient:    registryClient,
+			ToRegistryClient:      registryClient,
+			RenderManifests:       renderManifests,
+		})
+		Expect(err).To(Succeed())
+
+		return diff
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		bundlesRegistryClient = NewBundlesRegistryClientStub()
+		registryClient = &DockerRegistryDigestsStub{DigestsByReference: map[string]string{
+			"registry.example.com/project:tag-1": "sha256:1111",
+			"registry.example.com/project:tag-2": "sha256:2222",
+			"other.example.com/project:tag-1":    "sha256:1111",
+			"other.example.com/project:tag-2":    "sha256:2222",
+			"registry.example.com/project:tag-3": "sha256:3333",
+		}}
+	})
+
+	It("should find no changes in the bundle copied into another repository", func() {
+		templates := map[string]string{"templates/deployment.yaml": "kind: Deployment\nmetadata:\n  name: app\n"}
+		values := map[string]interface{}{"replicas": 1}
+
+		bundlesRegistryClient.StubCharts["registry.example.com/project:1.0.0"] = newChart("project", "registry.example.com/project", templates, values)
+		bundlesRegistryClient.StubCharts["other.example.com/project:1.0.0"] = newChart("renamed", "other.example.com/project", templates, values)
+
+		d := diff("registry.example.com/project:1.0.0", "other.example.com/project:1.0.0")
+		Expect(d.HasChanges()).To(BeFalse())
+	})
+
+	It("should find changes of the templates, the values, the manifests and the images", func() {
+		bundlesRegistryClient.StubCharts["registry.example.com/project:1.0.0"] = newChart("project", "registry.example.com/project", map[string]string{
+			"templates/deployment.yaml": "kind: Deployment\nmetadata:\n  name: app\n",
+			"templates/job.yaml":        "kind: Job\nmetadata:\n  name: migrate\n",
+		}, map[string]interface{}{
+			"replicas": 1,
+			"app":      map[string]interface{}{"port": 80, "debug": true},
+		})
+
+		toChart := newChart("project", "registry.example.com/project", map[string]string{
+			"templates/deployment.yaml": "kind: Deployment\nmetadata:\n  name: app\nspec:\n  replicas: 2\n",
+			"templates/service.yaml":    "kind: Service\nmetadata:\n  name: app\n  namespace: prod\n",
+		}, map[string]interface{}{
+			"replicas": 2,
+			"app":      map[string]interface{}{"port": 80, "host": "example.com"},
+		})
+		toChart.Values["werf"].(map[string]interface{})["image"].(map[string]interface{})["image-2"] = "registry.example.com/project:tag-3"
+		toChart.Values["werf"].(map[string]interface{})["image"].(map[string]interface{})["image-3"] = "registry.example.com/project:tag-3"
+		bundlesRegistryClient.StubCharts["registry.example.com/project:2.0.0"] = toChart
+
+		d := diff("registry.example.com/project:1.0.0", "docker://registry.example.com/project:2.0.0")
+		Expect(d.HasChanges()).To(BeTrue())
+
+		Expect(fileDiffNames(d.Templates)).To(Equal([]string{
+			"modified templates/deployment.yaml",
+			"removed templates/job.yaml",
+			"added templates/service.yaml",
+		}))
+
+		Expect(d.Values).To(Equal([]ValueDiff{
+			{Path: "app.debug", Change: DiffChangeRemoved, From: true},
+			{Path: "app.host", Change: DiffChangeAdded, To: "example.com"},
+			{Path: "replicas", Change: DiffChangeModified, From: 1, To: 2},
+		}))
+
+		Expect(fileDiffNames(d.Manifests)).To(Equal([]string{
+			"modified Deployment/app",
+			"removed Job/migrate",
+			"added Service/app (namespace: prod)",
+		}))
+
+		Expect(d.Images).To(Equal([]ImageDiff{
+			{Name: "image-2", Change: DiffChangeModified, FromDigest: "sha256:2222", ToDigest: "sha256:3333"},
+			{Name: "image-3", Change: DiffChangeAdded, ToDigest: "sha256:3333"},
+		}))
+	})
+})
+
+func fileDiffNames(diffs []FileDiff) []string {
+	var res []string
+	for _, d := range diffs {
+		res = append(res, fmt.Sprintf("%s %s", d.Change, d.Name))
+	}
+	return res
+}
+
+type DockerRegistryDigestsStub struct {
+	docker_registry.Interface
+
+	DigestsByReference map[string]string
+}
+
+func (registry *DockerRegistryDigestsStub) GetRepoImage(ctx context.Context, reference string) (*image.Info, error) {
+	return (&RepoImageGetterStub{DigestsByReference: registry.DigestsByReference}).GetRepoImage(ctx, reference)
+}
@@ -10,11 +10,16 @@ import (
 	"github.com/werf/logboek"
 	"github.com/werf/werf/pkg/deploy/bundles/registry"
 	"github.com/werf/werf/pkg/deploy/helm/chart_extender"
+	"github.com/werf/werf/pkg/signature"
 )
 
 type PublishOptions struct {
 	HelmCompatibleChart bool
 	RenameChart         string
+
+	// Signer signs the bundle if specified, the image digests are resolved with the Registry.
+	Signer   signature.Signer
+	Registry RepoImageGetter
 }
 
 func Publish(ctx context.Context, bundle *chart_extender.Bundle, bundleRef string, bundlesRegistryClient *registry.Client, opts PublishOptions) error {
@@ -40,6 +45,12 @@ func Publish(ctx context.Context, bundle *chart_extender.Bundle, bundleRef strin
 			ch.Metadata.Name = *nameOverwrite
 		}
 
+		if opts.Signer != nil {
+			if err := SignBundle(ctx, ch, opts.Signer, opts.Registry); err != nil {
+				return err
+			}
+		}
+
 		if err := bundlesRegistryClient.SaveChart(ch, r); err != nil {
 			return fmt.Errorf("unable to save bundle to the local chart helm cache: %w", err)
 		}
@@ -17,7 +17,12 @@ limitations under the License.
 package registry // import "helm.sh/helm/v3/internal/experimental/registry"
 
 import (
+	"net/http"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
 	"github.com/deislabs/oras/pkg/auth"
+	"github.com/google/go-containerregistry/pkg/authn"
 )
 
 type (
@@ -25,4 +30,75 @@ type (
 	Authorizer struct {
 		auth.Client
 	}
+
+	// AuthConfigFunc returns the credentials of the registry host or nil if there are no credentials for the host
+	AuthConfigFunc func(hostname string) (*authn.AuthConfig, error)
 )
+
+// authConfigResolver returns a resolver, which takes the credentials from the authConfigFunc
+// and falls back to the credentials of the authorizer
+func (a *Authorizer) authConfigResolver(authConfigFunc AuthConfigFunc, client *http.Client, plainHTTP bool) remotes.Resolver {
+	fallback, _ := a.Client.(interface {
+		Credential(hostname string) (string, string, error)
+	})
+
+	return docker.NewResolver(docker.ResolverOptions{
+		Credentials: func(hostname string) (string, string, error) {
+			authConfig, err := authConfigFunc(hostname)
+			if err != nil {
+				return "", "", err
+			}
+
+			switch {
+			case authConfig == nil:
+				if fallback != nil {
+					return fallback.Credential(hostname)
+				}
+				return "", "", nil
+			case authConfig.IdentityToken != "":
+				return "", authConfig.IdentityToken, nil
+			default:
+				// the registry token is set by the transport
+				return authConfig.Username, authConfig.Password, nil
+			}
+		},
+		Client: &http.Client{
+			Transport: &registryTokenTransport{
+				authConfigFunc: authConfigFunc,
+				base:           client.Transport,
+			},
+		},
+		PlainHTTP: plainHTTP,
+	})
+}
+
+// registryTokenTransport sets the registry token as the bearer token for the hosts, which have it
+type registryTokenTransport struct {
+	authConfigFunc AuthConfigFunc
+	base           http.RoundTripper
+}
+
+func (t *registryTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.Header.Get("Authorization") != "" {
+		return base.RoundTrip(req)
+	}
+
+	authConfig, err := t.authConfigFunc(req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if authConfig == nil || authConfig.RegistryToken == "" {
+		return base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+authConfig.RegistryToken)
+
+	return base.RoundTrip(req)
+}
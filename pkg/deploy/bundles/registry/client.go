@@ -51,6 +51,7 @@ type (
 		credentialsFile string
 		out             io.Writer
 		authorizer      *Authorizer
+		authConfigFunc  AuthConfigFunc
 		resolver        *Resolver
 		cache           *Cache
 	}
@@ -87,12 +88,18 @@ func NewClient(opts ...ClientOption) (*Client, error) {
 			}
 		}
 
-		resolver, err := client.authorizer.Resolver(context.Background(), httpClient, client.insecure)
-		if err != nil {
-			return nil, err
-		}
-		client.resolver = &Resolver{
-			Resolver: resolver,
+		if client.authConfigFunc != nil {
+			client.resolver = &Resolver{
+				Resolver: client.authorizer.authConfigResolver(client.authConfigFunc, httpClient, client.insecure),
+			}
+		} else {
+			resolver, err := client.authorizer.Resolver(context.Background(), httpClient, client.insecure)
+			if err != nil {
+				return nil, err
+			}
+			client.resolver = &Resolver{
+				Resolver: resolver,
+			}
 		}
 	}
 	if client.cache == nil {
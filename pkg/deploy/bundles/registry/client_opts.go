@@ -79,3 +79,10 @@ func ClientOptSkipTlsVerify(skipTlsVerify bool) ClientOption {
 		client.skipTlsVerify = skipTlsVerify
 	}
 }
+
+// ClientOptAuthConfigFunc returns a function that sets the registry credentials source, which has priority over the credentials file
+func ClientOptAuthConfigFunc(authConfigFunc AuthConfigFunc) ClientOption {
+	return func(client *Client) {
+		client.authConfigFunc = authConfigFunc
+	}
+}
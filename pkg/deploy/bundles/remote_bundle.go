@@ -3,10 +3,10 @@ package bundles
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
-	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"helm.sh/helm/v3/pkg/chart"
 	"sigs.k8s.io/yaml"
 
@@ -77,6 +77,21 @@ func (bundle *RemoteBundle) WriteChart(ctx context.Context, ch *chart.Chart) err
 	return nil
 }
 
+func (bundle *RemoteBundle) ReadImageDigests(ctx context.Context, ch *chart.Chart) (map[string]string, error) {
+	res := map[string]string{}
+
+	for imageName, imageRef := range bundleImageReferences(ch) {
+		digest, err := getImageDigest(ctx, bundle.RegistryClient, imageRef)
+		if err != nil {
+			return nil, err
+		}
+
+		res[imageName] = digest
+	}
+
+	return res, nil
+}
+
 func (bundle *RemoteBundle) CopyTo(ctx context.Context, to BundleAccessor, opts copyToOptions) error {
 	return to.CopyFromRemote(ctx, bundle, opts)
 }
@@ -87,6 +102,16 @@ func (bundle *RemoteBundle) CopyFromArchive(ctx context.Context, fromArchive *Bu
 		return fmt.Errorf("unable to read chart from the bundle archive %q: %w", fromArchive.Reader.String(), err)
 	}
 
+	layoutDir, err := fromArchive.extractImageLayout()
+	if err != nil {
+		return fmt.Errorf("unable to extract image layout from the bundle archive %q: %w", fromArchive.Reader.String(), err)
+	}
+	if layoutDir != "" {
+		defer os.RemoveAll(layoutDir)
+	}
+
+	progress := &copyProgress{}
+
 	if err := logboek.Context(ctx).LogProcess("Copy images from bundle archive").DoError(func() error {
 		if werfVals, ok := ch.Values["werf"].(map[string]interface{}); ok {
 			if imageVals, ok := werfVals["image"].(map[string]interface{}); ok {
@@ -103,10 +128,16 @@ func (bundle *RemoteBundle) CopyFromArchive(ctx context.Context, fromArchive *Bu
 						if imageRef != ref.FullName() {
 							logboek.Context(ctx).Default().LogFDetails("Image: %s\n", ref.FullName())
 
-							imageArchiveOpener := fromArchive.GetImageArchiveOpener(ref.Tag)
+							if layoutDir != "" {
+								if err := bundle.RegistryClient.PushImageLayout(ctx, layoutDir, ref.FullName(), ref.Tag, progress.TransferOptions(ctx)); err != nil {
+									return fmt.Errorf("error copying image from bundle archive %q into %q: %w", fromArchive.Reader.String(), ref.FullName(), err)
+								}
+							} else {
+								imageArchiveOpener := fromArchive.GetImageArchiveOpener(ref.Tag)
 
-							if err := bundle.RegistryClient.PushImageArchive(ctx, imageArchiveOpener, ref.FullName()); err != nil {
-								return fmt.Errorf("error copying image from bundle archive %q into %q: %w", fromArchive.Reader.String(), ref.FullName(), err)
+								if err := bundle.RegistryClient.PushImageArchive(ctx, imageArchiveOpener, ref.FullName()); err != nil {
+									return fmt.Errorf("error copying image from bundle archive %q into %q: %w", fromArchive.Reader.String(), ref.FullName(), err)
+								}
 							}
 						}
 
@@ -127,6 +158,8 @@ func (bundle *RemoteBundle) CopyFromArchive(ctx context.Context, fromArchive *Bu
 		return err
 	}
 
+	progress.LogSummary(ctx)
+
 	if err := SaveChartValues(ctx, ch); err != nil {
 		return err
 	}
@@ -159,6 +192,8 @@ func (bundle *RemoteBundle) CopyFromRemote(ctx context.Context, fromRemote *Remo
 		logboek.Context(ctx).Debug().LogF("Values before change (%v):\n%s\n---\n", err, d)
 	}
 
+	progress := &copyProgress{}
+
 	if err := logboek.Context(ctx).LogProcess("Copy images from remote bundle").DoError(func() error {
 		if werfVals, ok := ch.Values["werf"].(map[string]interface{}); ok {
 			if imageVals, ok := werfVals["image"].(map[string]interface{}); ok {
@@ -178,7 +213,7 @@ func (bundle *RemoteBundle) CopyFromRemote(ctx context.Context, fromRemote *Remo
 							logboek.Context(ctx).Default().LogFDetails("Source: %s\n", image)
 							logboek.Context(ctx).Default().LogFDetails("Destination: %s\n", ref.FullName())
 
-							if err := fromRemote.RegistryClient.MutateAndPushImage(ctx, image, ref.FullName(), func(cfg v1.Config) (v1.Config, error) { return cfg, nil }); err != nil {
+							if err := fromRemote.RegistryClient.CopyImage(ctx, image, ref.FullName(), docker_registry.CopyImageOptions{TransferOptions: progress.TransferOptions(ctx)}); err != nil {
 								return fmt.Errorf("error copying image %s into %s: %w", image, ref.FullName(), err)
 							}
 						}
@@ -199,6 +234,8 @@ func (bundle *RemoteBundle) CopyFromRemote(ctx context.Context, fromRemote *Remo
 		return err
 	}
 
+	progress.LogSummary(ctx)
+
 	if err := SaveChartValues(ctx, ch); err != nil {
 		return err
 	}
@@ -13,6 +13,9 @@ const (
 
 	NoActivityTimeoutName = "werf.io/no-activity-timeout"
 
+	ReadyConditionAnnoName  = "werf.io/ready-condition"
+	FailedConditionAnnoName = "werf.io/failed-condition"
+
 	SkipLogsAnnoName              = "werf.io/skip-logs"
 	SkipLogsForContainersAnnoName = "werf.io/skip-logs-for-containers"
 	ShowLogsOnlyForContainers     = "werf.io/show-logs-only-for-containers"
@@ -41,6 +41,7 @@ type InitActionConfigOptions struct {
 	KubeConfigOptions         kube.KubeConfigOptions
 	ReleasesHistoryMax        int
 	RegistryClient            *registry.Client
+	ReadinessRules            []*ReadinessRule
 }
 
 func InitActionConfig(ctx context.Context, kubeInitializer KubeInitializer, namespace string, envSettings *cli.EnvSettings, actionConfig *action.Configuration, opts InitActionConfigOptions) error {
@@ -74,7 +75,9 @@ func InitActionConfig(ctx context.Context, kubeInitializer KubeInitializer, name
 
 	kubeClient := actionConfig.KubeClient.(*helm_kube.Client)
 	kubeClient.Namespace = namespace
-	kubeClient.ResourcesWaiter = NewResourcesWaiter(kubeInitializer, kubeClient, time.Now(), opts.StatusProgressPeriod, opts.HooksStatusProgressPeriod)
+	resourcesWaiter := NewResourcesWaiter(kubeInitializer, kubeClient, time.Now(), opts.StatusProgressPeriod, opts.HooksStatusProgressPeriod)
+	resourcesWaiter.ReadinessRules = opts.ReadinessRules
+	kubeClient.ResourcesWaiter = resourcesWaiter
 	kubeClient.Extender = NewHelmKubeClientExtender()
 
 	actionConfig.Log = func(f string, a ...interface{}) {
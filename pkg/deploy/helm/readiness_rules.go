@@ -0,0 +1,261 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// ReadinessCondition is the condition over the live object in the format of kubectl wait --for:
+// condition=TYPE[=STATUS] is met if the status condition of the TYPE has the STATUS (True by default),
+// jsonpath={JSONPATH}[=VALUE[,VALUE...]] is met if the JSONPath result equals one of the values or is not empty if no values specified,
+// cel=EXPRESSION is met if the CEL expression over the object as self evaluates to true.
+type ReadinessCondition struct {
+	Expression string
+
+	template string
+	values   []string
+	program  cel.Program
+}
+
+var readinessCELEnv *cel.Env
+
+func init() {
+	var err error
+	if readinessCELEnv, err = cel.NewEnv(cel.Variable("self", cel.DynType), ext.Strings()); err != nil {
+		panic(fmt.Sprintf("unable to create CEL environment: %s", err))
+	}
+}
+
+func ParseReadinessCondition(expression string) (*ReadinessCondition, error) {
+	var template string
+	var values []string
+
+	switch {
+	case strings.HasPrefix(expression, "condition="):
+		conditionType, status, hasStatus := strings.Cut(strings.TrimPrefix(expression, "condition="), "=")
+		if conditionType == "" {
+			return nil, fmt.Errorf("condition type expected")
+		}
+		if !hasStatus {
+			status = "True"
+		}
+
+		template = fmt.Sprintf("{.status.conditions[?(@.type==%q)].status}", conditionType)
+		values = []string{status}
+	case strings.HasPrefix(expression, "jsonpath="):
+		jsonPathWithValues := strings.TrimPrefix(expression, "jsonpath=")
+
+		templateEnd := strings.LastIndex(jsonPathWithValues, "}")
+		if !strings.HasPrefix(jsonPathWithValues, "{") || templateEnd < 0 {
+			return nil, fmt.Errorf("jsonpath template in curly braces expected")
+		}
+		template = jsonPathWithValues[:templateEnd+1]
+
+		if rest := jsonPathWithValues[templateEnd+1:]; rest != "" {
+			if !strings.HasPrefix(rest, "=") || strings.TrimSpace(rest[1:]) == "" {
+				return nil, fmt.Errorf("=VALUE expected after the jsonpath template, got %q", rest)
+			}
+
+			for _, value := range strings.Split(rest[1:], ",") {
+				values = append(values, strings.TrimSpace(value))
+			}
+		}
+	case strings.HasPrefix(expression, "cel="):
+		program, err := compileReadinessCELExpression(strings.TrimPrefix(expression, "cel="))
+		if err != nil {
+			return nil, err
+		}
+
+		return &ReadinessCondition{Expression: expression, program: program}, nil
+	default:
+		return nil, fmt.Errorf("condition=TYPE[=STATUS], jsonpath={JSONPATH}[=VALUE[,VALUE...]] or cel=EXPRESSION expected")
+	}
+
+	if err := jsonpath.New("").Parse(template); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: %w", template, err)
+	}
+
+	return &ReadinessCondition{Expression: expression, template: template, values: values}, nil
+}
+
+func compileReadinessCELExpression(expression string) (cel.Program, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, fmt.Errorf("cel expression expected")
+	}
+
+	ast, issues := readinessCELEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid cel expression %q: %w", expression, issues.Err())
+	}
+
+	if outputType := ast.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("invalid cel expression %q: bool result expected, got %s", expression, outputType)
+	}
+
+	// the program is safe for concurrent use, so it is created once
+	program, err := readinessCELEnv.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cel expression %q: %w", expression, err)
+	}
+
+	return program, nil
+}
+
+// Match returns true if the condition is met by the object along with the current values found by the JSONPath or the CEL expression result.
+func (condition *ReadinessCondition) Match(object map[string]interface{}) (bool, []string, error) {
+	if condition.program != nil {
+		return condition.matchCEL(object)
+	}
+
+	// the JSONPath is not safe for concurrent use, so it is parsed on each match
+	jsonPath := jsonpath.New("").AllowMissingKeys(true)
+	if err := jsonPath.Parse(condition.template); err != nil {
+		return false, nil, fmt.Errorf("invalid jsonpath %q: %w", condition.template, err)
+	}
+
+	results, err := jsonPath.FindResults(object)
+	if err != nil {
+		return false, nil, fmt.Errorf("unable to evaluate %q: %w", condition.Expression, err)
+	}
+
+	var current []string
+	for _, result := range results {
+		for _, value := range result {
+			if value.Kind() == reflect.Interface {
+				value = value.Elem()
+			}
+			if !value.IsValid() {
+				continue
+			}
+
+			current = append(current, fmt.Sprint(value.Interface()))
+		}
+	}
+
+	if len(condition.values) == 0 {
+		return len(current) > 0, current, nil
+	}
+
+	for _, currentValue := range current {
+		for _, value := range condition.values {
+			if currentValue == value {
+				return true, current, nil
+			}
+		}
+	}
+
+	return false, current, nil
+}
+
+func (condition *ReadinessCondition) matchCEL(object map[string]interface{}) (bool, []string, error) {
+	result, _, err := condition.program.Eval(map[string]interface{}{"self": object})
+	if err != nil {
+		// the fields used by the expression may be missing until the object is reconciled, e.g. no such key: status
+		return false, []string{err.Error()}, nil
+	}
+
+	met, ok := result.Value().(bool)
+	if !ok {
+		return false, nil, fmt.Errorf("unable to evaluate %q: bool result expected, got %s", condition.Expression, result.Type().TypeName())
+	}
+
+	return met, []string{fmt.Sprint(met)}, nil
+}
+
+// ReadinessRule sets the readiness conditions of the resources by the group, the kind and optionally the version.
+// The werf.io/ready-condition and werf.io/failed-condition annotations of the resource take precedence over the rule.
+type ReadinessRule struct {
+	Group           string `json:"group"`
+	Version         string `json:"version,omitempty"`
+	Kind            string `json:"kind"`
+	ReadyCondition  string `json:"readyCondition"`
+	FailedCondition string `json:"failedCondition,omitempty"`
+
+	ready, failed *ReadinessCondition
+}
+
+type readinessRulesFile struct {
+	Rules []*ReadinessRule `json:"rules"`
+}
+
+// LoadReadinessRules loads the project readiness rules file:
+//
+//	rules:
+//	- group: example.com
+//	  kind: Database
+//	  readyCondition: jsonpath={.status.phase}=Provisioned
+//	  failedCondition: jsonpath={.status.phase}=Failed
+func LoadReadinessRules(path string) ([]*ReadinessRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read readiness rules file %q: %w", path, err)
+	}
+
+	var rulesFile readinessRulesFile
+	if err := yaml.UnmarshalStrict(data, &rulesFile); err != nil {
+		return nil, fmt.Errorf("unable to parse readiness rules file %q: %w", path, err)
+	}
+
+	for ind, rule := range rulesFile.Rules {
+		if rule.Kind == "" {
+			return nil, fmt.Errorf("readiness rules file %q: rule %d: kind required", path, ind+1)
+		}
+
+		if rule.ReadyCondition == "" {
+			return nil, fmt.Errorf("readiness rules file %q: rule %d: readyCondition required", path, ind+1)
+		}
+
+		if rule.ready, err = ParseReadinessCondition(rule.ReadyCondition); err != nil {
+			return nil, fmt.Errorf("readiness rules file %q: rule %d: invalid readyCondition %q: %w", path, ind+1, rule.ReadyCondition, err)
+		}
+
+		if rule.FailedCondition != "" {
+			if rule.failed, err = ParseReadinessCondition(rule.FailedCondition); err != nil {
+				return nil, fmt.Errorf("readiness rules file %q: rule %d: invalid failedCondition %q: %w", path, ind+1, rule.FailedCondition, err)
+			}
+		}
+	}
+
+	return rulesFile.Rules, nil
+}
+
+func (rule *ReadinessRule) matches(gvk schema.GroupVersionKind) bool {
+	return rule.Group == gvk.Group && rule.Kind == gvk.Kind && (rule.Version == "" || rule.Version == gvk.Version)
+}
+
+// getReadinessConditions returns the readiness conditions of the resource set by the annotations or by the first matched rule.
+// The nil ready condition is returned if the conditions are not set, so the resource is tracked by the generic tracker.
+func getReadinessConditions(gvk schema.GroupVersionKind, annotations map[string]string, rules []*ReadinessRule) (ready, failed *ReadinessCondition, err error) {
+	for _, rule := range rules {
+		if rule.matches(gvk) {
+			ready, failed = rule.ready, rule.failed
+			break
+		}
+	}
+
+	if annoValue, ok := annotations[ReadyConditionAnnoName]; ok {
+		if ready, err = ParseReadinessCondition(annoValue); err != nil {
+			return nil, nil, fmt.Errorf("annotation %s with invalid value %s: %w", ReadyConditionAnnoName, annoValue, err)
+		}
+	}
+
+	if annoValue, ok := annotations[FailedConditionAnnoName]; ok {
+		if failed, err = ParseReadinessCondition(annoValue); err != nil {
+			return nil, nil, fmt.Errorf("annotation %s with invalid value %s: %w", FailedConditionAnnoName, annoValue, err)
+		}
+	}
+
+	if ready == nil && failed != nil {
+		return nil, nil, fmt.Errorf("annotation %s requires annotation %s", FailedConditionAnnoName, ReadyConditionAnnoName)
+	}
+
+	return ready, failed, nil
+}
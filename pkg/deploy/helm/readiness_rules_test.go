@@ -0,0 +1,149 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var databaseObject = map[string]interface{}{
+	"apiVersion": "example.com/v1",
+	"kind":       "Database",
+	"status": map[string]interface{}{
+		"phase": "Provisioned",
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+			map[string]interface{}{"type": "Degraded", "status": "False"},
+		},
+	},
+}
+
+var _ = Describe("ReadinessCondition", func() {
+	DescribeTable("matching the object",
+		func(expression string, expectedMatch bool, expectedCurrent []string) {
+			condition, err := ParseReadinessCondition(expression)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			match, current, err := condition.Match(databaseObject)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(match).To(Equal(expectedMatch))
+			Expect(current).To(Equal(expectedCurrent))
+		},
+		Entry("condition with the default status", "condition=Ready", true, []string{"True"}),
+		Entry("condition with the status", "condition=Degraded=True", false, []string{"False"}),
+		Entry("missing condition", "condition=Synced", false, nil),
+		Entry("jsonpath with the value", "jsonpath={.status.phase}=Provisioned", true, []string{"Provisioned"}),
+		Entry("jsonpath with one of the values", "jsonpath={.status.phase}=Failed,Provisioned", true, []string{"Provisioned"}),
+		Entry("jsonpath with the other value", "jsonpath={.status.phase}=Failed", false, []string{"Provisioned"}),
+		Entry("jsonpath without the value", "jsonpath={.status.phase}", true, []string{"Provisioned"}),
+		Entry("jsonpath to the missing field", "jsonpath={.status.endpoint}", false, nil),
+		Entry("cel", `cel=self.status.phase == "Provisioned"`, true, []string{"true"}),
+		Entry("cel over the conditions", `cel=self.status.conditions.exists(c, c.type == "Degraded" && c.status == "True")`, false, []string{"false"}),
+		Entry("cel with the string functions", `cel=self.status.phase.lowerAscii() == "provisioned"`, true, []string{"true"}),
+		Entry("cel checking the missing field", "cel=has(self.status.endpoint)", false, []string{"false"}),
+	)
+
+	It("should not match the cel expression over the missing field", func() {
+		condition, err := ParseReadinessCondition(`cel=self.status.endpoint != ""`)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		match, current, err := condition.Match(databaseObject)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(match).To(BeFalse())
+		Expect(current).To(HaveLen(1))
+	})
+
+	It("should fail if the cel expression does not evaluate to bool", func() {
+		condition, err := ParseReadinessCondition("cel=self.status.phase")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, _, err = condition.Match(databaseObject)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	DescribeTable("parsing the invalid expression",
+		func(expression string) {
+			_, err := ParseReadinessCondition(expression)
+			Expect(err).Should(HaveOccurred())
+		},
+		Entry("unknown format", "phase=Provisioned"),
+		Entry("condition without the type", "condition="),
+		Entry("jsonpath without the curly braces", "jsonpath=.status.phase"),
+		Entry("jsonpath with the empty value", "jsonpath={.status.phase}="),
+		Entry("jsonpath with the invalid template", "jsonpath={.status[}"),
+		Entry("cel without the expression", "cel="),
+		Entry("cel with the syntax error", "cel=self.status.phase =="),
+		Entry("cel with the non-bool result", `cel="Provisioned"`),
+	)
+})
+
+var _ = Describe("ReadinessRule", func() {
+	databaseGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"}
+
+	writeRulesFile := func(content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "readiness-rules.yaml")
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+		return path
+	}
+
+	It("should load the rules and select the conditions by the group and the kind", func() {
+		rules, err := LoadReadinessRules(writeRulesFile(`
+rules:
+- group: example.com
+  version: v1alpha1
+  kind: Database
+  readyCondition: condition=Ready
+- group: example.com
+  kind: Database
+  readyCondition: jsonpath={.status.phase}=Provisioned
+  failedCondition: jsonpath={.status.phase}=Failed
+`))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rules).To(HaveLen(2))
+
+		ready, failed, err := getReadinessConditions(databaseGVK, nil, rules)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ready.Expression).To(Equal("jsonpath={.status.phase}=Provisioned"))
+		Expect(failed.Expression).To(Equal("jsonpath={.status.phase}=Failed"))
+
+		ready, failed, err = getReadinessConditions(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Cache"}, nil, rules)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ready).To(BeNil())
+		Expect(failed).To(BeNil())
+	})
+
+	It("should prefer the annotations over the rule", func() {
+		rules, err := LoadReadinessRules(writeRulesFile(`
+rules:
+- group: example.com
+  kind: Database
+  readyCondition: jsonpath={.status.phase}=Provisioned
+  failedCondition: jsonpath={.status.phase}=Failed
+`))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		ready, failed, err := getReadinessConditions(databaseGVK, map[string]string{ReadyConditionAnnoName: "condition=Ready"}, rules)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ready.Expression).To(Equal("condition=Ready"))
+		Expect(failed.Expression).To(Equal("jsonpath={.status.phase}=Failed"))
+	})
+
+	It("should fail if the failed condition annotation is set without the ready condition", func() {
+		_, _, err := getReadinessConditions(databaseGVK, map[string]string{FailedConditionAnnoName: "condition=Degraded"}, nil)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	DescribeTable("loading the invalid rules file",
+		func(content string) {
+			_, err := LoadReadinessRules(writeRulesFile(content))
+			Expect(err).Should(HaveOccurred())
+		},
+		Entry("unknown field", "rules:\n- group: example.com\n  kind: Database\n  readyCondition: condition=Ready\n  timeout: 10m\n"),
+		Entry("rule without the kind", "rules:\n- group: example.com\n  readyCondition: condition=Ready\n"),
+		Entry("rule without the ready condition", "rules:\n- group: example.com\n  kind: Database\n"),
+		Entry("invalid ready condition", "rules:\n- group: example.com\n  kind: Database\n  readyCondition: phase=Provisioned\n"),
+	)
+})
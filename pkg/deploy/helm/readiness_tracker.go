@@ -0,0 +1,151 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/werf/kubedog/pkg/kube"
+	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack/generic"
+	"github.com/werf/logboek"
+)
+
+const readinessPollPeriod = 2 * time.Second
+
+// readinessSpec tracks the resource by the readiness conditions instead of the generic tracker.
+// The timeout, the fail mode and the track termination mode of the generic spec are respected.
+type readinessSpec struct {
+	*generic.Spec
+
+	Ready, Failed *ReadinessCondition
+}
+
+type readinessState struct {
+	isReady, isFailed bool
+	current           []string
+}
+
+func (state readinessState) currentString() string {
+	if len(state.current) == 0 {
+		return "<none>"
+	}
+	return strings.Join(state.current, ", ")
+}
+
+// trackReadiness polls the resources until the ready conditions are met.
+// The error is returned once the failed condition is met or the timeout is reached, unless the fail mode allows to continue.
+func trackReadiness(ctx context.Context, specs []*readinessSpec, statusProgressPeriod time.Duration) error {
+	var pending []*readinessSpec
+	for _, spec := range specs {
+		if spec.TrackTerminationMode != generic.NonBlocking {
+			pending = append(pending, spec)
+		}
+	}
+
+	startTime := time.Now()
+	lastStatusTime := startTime
+
+	ticker := time.NewTicker(readinessPollPeriod)
+	defer ticker.Stop()
+
+	for len(pending) > 0 {
+		var stillPending []*readinessSpec
+
+		logStatus := statusProgressPeriod >= 0 && time.Since(lastStatusTime) >= statusProgressPeriod
+		if logStatus {
+			lastStatusTime = time.Now()
+		}
+
+		for _, spec := range pending {
+			state, err := getReadinessState(ctx, spec)
+			if err != nil {
+				return fmt.Errorf("unable to get %s readiness: %w", spec.ResourceID, err)
+			}
+
+			switch {
+			case state.isReady:
+				logboek.Context(ctx).Default().LogF("%s is ready: %s\n", spec.ResourceID.KindNameString(), spec.Ready.Expression)
+				continue
+			case state.isFailed && spec.FailMode != generic.HopeUntilEndOfDeployProcess:
+				err := fmt.Errorf("%s failed: %s is met, current value: %s", spec.ResourceID, spec.Failed.Expression, state.currentString())
+				if spec.FailMode == generic.IgnoreAndContinueDeployProcess {
+					logboek.Context(ctx).Warn().LogF("WARNING %s\n", err)
+					continue
+				}
+				return err
+			case spec.Timeout > 0 && time.Since(startTime) > spec.Timeout:
+				err := fmt.Errorf("%s is not ready after %s: %s is not met, current value: %s", spec.ResourceID, spec.Timeout, spec.Ready.Expression, state.currentString())
+				if spec.FailMode == generic.IgnoreAndContinueDeployProcess {
+					logboek.Context(ctx).Warn().LogF("WARNING %s\n", err)
+					continue
+				}
+				return err
+			}
+
+			if logStatus {
+				logboek.Context(ctx).Default().LogF("Waiting for %s: %s, current value: %s\n", spec.ResourceID.KindNameString(), spec.Ready.Expression, state.currentString())
+			}
+
+			stillPending = append(stillPending, spec)
+		}
+
+		if pending = stillPending; len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+func getReadinessState(ctx context.Context, spec *readinessSpec) (readinessState, error) {
+	gvr, err := spec.ResourceID.GroupVersionResource(kube.Mapper)
+	if err != nil {
+		return readinessState{}, err
+	}
+
+	namespaced, err := spec.ResourceID.Namespaced(kube.Mapper)
+	if err != nil {
+		return readinessState{}, err
+	}
+
+	var client dynamic.ResourceInterface = kube.DynamicClient.Resource(*gvr)
+	if namespaced {
+		client = kube.DynamicClient.Resource(*gvr).Namespace(spec.Namespace)
+	}
+
+	obj, err := client.Get(ctx, spec.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return readinessState{}, nil
+	} else if err != nil {
+		return readinessState{}, err
+	}
+
+	var state readinessState
+
+	if state.isReady, state.current, err = spec.Ready.Match(obj.Object); err != nil {
+		return readinessState{}, err
+	}
+
+	if spec.Failed != nil && !state.isReady {
+		var failedCurrent []string
+		if state.isFailed, failedCurrent, err = spec.Failed.Match(obj.Object); err != nil {
+			return readinessState{}, err
+		}
+		if state.isFailed {
+			state.current = failedCurrent
+		}
+	}
+
+	return state, nil
+}
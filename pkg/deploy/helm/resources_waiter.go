@@ -12,6 +12,7 @@ import (
 
 	flaggerv1beta1 "github.com/fluxcd/flagger/pkg/apis/flagger/v1beta1"
 	flaggerscheme "github.com/fluxcd/flagger/pkg/client/clientset/versioned/scheme"
+	"golang.org/x/sync/errgroup"
 	helm_kube "helm.sh/helm/v3/pkg/kube"
 	appsv1 "k8s.io/api/apps/v1"
 	appsv1beta1 "k8s.io/api/apps/v1beta1"
@@ -44,6 +45,8 @@ type ResourcesWaiter struct {
 	LogsFromTime              time.Time
 	StatusProgressPeriod      time.Duration
 	HooksStatusProgressPeriod time.Duration
+	// ReadinessRules set the readiness conditions of the custom resources tracked by the generic tracker otherwise.
+	ReadinessRules []*ReadinessRule
 }
 
 func NewResourcesWaiter(kubeInitializer KubeInitializer, client *helm_kube.Client, logsFromTime time.Time, statusProgressPeriod, hooksStatusProgressPeriod time.Duration) *ResourcesWaiter {
@@ -74,7 +77,7 @@ func (waiter *ResourcesWaiter) Wait(ctx context.Context, resources helm_kube.Res
 		}
 	}
 
-	specs, err := makeMultitrackSpecsFromResList(ctx, resources, timeout, waiter.StatusProgressPeriod)
+	specs, readinessSpecs, err := makeMultitrackSpecsFromResList(ctx, resources, timeout, waiter.StatusProgressPeriod, waiter.ReadinessRules)
 	if err != nil {
 		return fmt.Errorf("error making multitrack specs: %w", err)
 	}
@@ -83,16 +86,7 @@ func (waiter *ResourcesWaiter) Wait(ctx context.Context, resources helm_kube.Res
 	logboek.Context(ctx).LogOptionalLn()
 	return logboek.Context(ctx).LogProcess("Waiting for resources to become ready").
 		DoError(func() error {
-			return multitrack.Multitrack(kube.Client, *specs, multitrack.MultitrackOptions{
-				StatusProgressPeriod: waiter.StatusProgressPeriod,
-				Options: tracker.Options{
-					Timeout:      timeout,
-					LogsFromTime: waiter.LogsFromTime,
-				},
-				DynamicClient:   kube.DynamicClient,
-				DiscoveryClient: kube.CachedDiscoveryClient,
-				Mapper:          kube.Mapper,
-			})
+			return waiter.track(ctx, specs, readinessSpecs, timeout, waiter.StatusProgressPeriod)
 		})
 }
 
@@ -323,7 +317,7 @@ func (waiter *ResourcesWaiter) WatchUntilReady(ctx context.Context, resources he
 		}
 	}
 
-	specs, err := makeMultitrackSpecsFromResList(ctx, resources, timeout, waiter.HooksStatusProgressPeriod)
+	specs, readinessSpecs, err := makeMultitrackSpecsFromResList(ctx, resources, timeout, waiter.HooksStatusProgressPeriod, waiter.ReadinessRules)
 	if err != nil {
 		return fmt.Errorf("error making multitrack specs: %w", err)
 	}
@@ -332,17 +326,34 @@ func (waiter *ResourcesWaiter) WatchUntilReady(ctx context.Context, resources he
 	logboek.Context(ctx).LogOptionalLn()
 	return logboek.Context(ctx).LogProcess("Waiting for helm hooks termination").
 		DoError(func() error {
-			return multitrack.Multitrack(kube.Client, *specs, multitrack.MultitrackOptions{
-				StatusProgressPeriod: waiter.HooksStatusProgressPeriod,
-				Options: tracker.Options{
-					Timeout:      timeout,
-					LogsFromTime: waiter.LogsFromTime,
-				},
-				DynamicClient:   kube.DynamicClient,
-				DiscoveryClient: kube.CachedDiscoveryClient,
-				Mapper:          kube.Mapper,
-			})
+			return waiter.track(ctx, specs, readinessSpecs, timeout, waiter.HooksStatusProgressPeriod)
+		})
+}
+
+// track runs the multitrack and tracks the resources with the readiness conditions at the same time.
+// The first error stops both trackers and is returned.
+func (waiter *ResourcesWaiter) track(ctx context.Context, specs *multitrack.MultitrackSpecs, readinessSpecs []*readinessSpec, timeout, statusProgressPeriod time.Duration) error {
+	g, gctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		return multitrack.Multitrack(kube.Client, *specs, multitrack.MultitrackOptions{
+			StatusProgressPeriod: statusProgressPeriod,
+			Options: tracker.Options{
+				ParentContext: gctx,
+				Timeout:       timeout,
+				LogsFromTime:  waiter.LogsFromTime,
+			},
+			DynamicClient:   kube.DynamicClient,
+			DiscoveryClient: kube.CachedDiscoveryClient,
+			Mapper:          kube.Mapper,
 		})
+	})
+
+	g.Go(func() error {
+		return trackReadiness(gctx, readinessSpecs, statusProgressPeriod)
+	})
+
+	return g.Wait()
 }
 
 func asVersioned(info *resource.Info) runtime.Object {
@@ -387,15 +398,16 @@ func (waiter *ResourcesWaiter) WaitUntilDeleted(ctx context.Context, specs []*he
 	})
 }
 
-func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.ResourceList, timeout, statusProgressPeriod time.Duration) (*multitrack.MultitrackSpecs, error) {
+func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.ResourceList, timeout, statusProgressPeriod time.Duration, readinessRules []*ReadinessRule) (*multitrack.MultitrackSpecs, []*readinessSpec, error) {
 	specs := &multitrack.MultitrackSpecs{}
+	var readinessSpecs []*readinessSpec
 
 	for _, v := range resources {
 		switch value := asVersioned(v).(type) {
 		case *appsv1.Deployment:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: extractSpecReplicas(value.Spec.Replicas), defaultPerReplica: 1}, "deploy")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.Deployments = append(specs.Deployments, *spec)
@@ -403,7 +415,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *appsv1beta1.Deployment:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: extractSpecReplicas(value.Spec.Replicas), defaultPerReplica: 1}, "deploy")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.Deployments = append(specs.Deployments, *spec)
@@ -411,7 +423,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *appsv1beta2.Deployment:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: extractSpecReplicas(value.Spec.Replicas), defaultPerReplica: 1}, "deploy")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.Deployments = append(specs.Deployments, *spec)
@@ -419,7 +431,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *extensions.Deployment:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: extractSpecReplicas(value.Spec.Replicas), defaultPerReplica: 1}, "deploy")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.Deployments = append(specs.Deployments, *spec)
@@ -429,7 +441,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 			// TODO: It is better to fetch number of nodes dynamically, but in the most cases multiplier=3 will work ok.
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: 3, defaultPerReplica: 1}, "ds")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.DaemonSets = append(specs.DaemonSets, *spec)
@@ -439,7 +451,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 			// TODO: It is better to fetch number of nodes dynamically, but in the most cases multiplier=3 will work ok.
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: 3, defaultPerReplica: 1}, "ds")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.DaemonSets = append(specs.DaemonSets, *spec)
@@ -449,7 +461,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 			// TODO: It is better to fetch number of nodes dynamically, but in the most cases multiplier=3 will work ok.
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: 3, defaultPerReplica: 1}, "ds")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.DaemonSets = append(specs.DaemonSets, *spec)
@@ -457,7 +469,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *appsv1.StatefulSet:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: extractSpecReplicas(value.Spec.Replicas), defaultPerReplica: 1}, "sts")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.StatefulSets = append(specs.StatefulSets, *spec)
@@ -465,7 +477,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *appsv1beta1.StatefulSet:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: extractSpecReplicas(value.Spec.Replicas), defaultPerReplica: 1}, "sts")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.StatefulSets = append(specs.StatefulSets, *spec)
@@ -473,7 +485,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *appsv1beta2.StatefulSet:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: extractSpecReplicas(value.Spec.Replicas), defaultPerReplica: 1}, "sts")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.StatefulSets = append(specs.StatefulSets, *spec)
@@ -481,7 +493,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *batchv1.Job:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: 1, defaultPerReplica: 0}, "job")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.Jobs = append(specs.Jobs, *spec)
@@ -489,7 +501,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		case *flaggerv1beta1.Canary:
 			spec, err := makeMultitrackSpec(ctx, &value.ObjectMeta, allowedFailuresCountOptions{multiplier: 1, defaultPerReplica: 0}, "canary")
 			if err != nil {
-				return nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
+				return nil, nil, fmt.Errorf("cannot track %s %s: %w", value.Kind, value.Name, err)
 			}
 			if spec != nil {
 				specs.Canaries = append(specs.Canaries, *spec)
@@ -497,7 +509,7 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 		default:
 			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(v.Object)
 			if err != nil {
-				return nil, fmt.Errorf("error converting object to unstructured: %w", err)
+				return nil, nil, fmt.Errorf("error converting object to unstructured: %w", err)
 			}
 
 			object := unstructured.Unstructured{
@@ -508,14 +520,29 @@ func makeMultitrackSpecsFromResList(ctx context.Context, resources helm_kube.Res
 				Namespace: object.GetNamespace(),
 			})
 
-			if spec, err := makeGenericSpec(ctx, resourceID, statusProgressPeriod, timeout, object.GetAnnotations()); err != nil {
+			spec, err := makeGenericSpec(ctx, resourceID, statusProgressPeriod, timeout, object.GetAnnotations())
+			if err != nil {
 				logboek.Context(ctx).Warn().LogLn()
 				logboek.Context(ctx).Warn().LogF("WARNING %s\n", err)
-			} else if spec != nil {
+				continue
+			}
+
+			ready, failed, err := getReadinessConditions(object.GroupVersionKind(), object.GetAnnotations(), readinessRules)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot track %s: %w", resourceID, err)
+			}
+
+			if ready != nil {
+				if spec.Namespace == "" {
+					spec.Namespace = v.Namespace
+				}
+
+				readinessSpecs = append(readinessSpecs, &readinessSpec{Spec: spec, Ready: ready, Failed: failed})
+			} else {
 				specs.Generics = append(specs.Generics, spec)
 			}
 		}
 	}
 
-	return specs, nil
+	return specs, readinessSpecs, nil
 }
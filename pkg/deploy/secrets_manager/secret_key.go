@@ -2,20 +2,21 @@ package secrets_manager
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/werf/werf/pkg/secret"
-	"github.com/werf/werf/pkg/util"
-	"github.com/werf/werf/pkg/werf"
 )
 
 func GenerateSecretKey() ([]byte, error) {
 	return secret.GenerateAesSecretKey()
 }
 
+// GenerateAgeIdentity returns a new age identity, which is used as the secret key, and the corresponding recipient.
+func GenerateAgeIdentity() (string, string, error) {
+	return secret.GenerateAgeIdentity()
+}
+
 func GetRequiredOldSecretKey() ([]byte, error) {
 	secretKey := []byte(os.Getenv("WERF_OLD_SECRET_KEY"))
 	if len(secretKey) == 0 {
@@ -24,56 +25,14 @@ func GetRequiredOldSecretKey() ([]byte, error) {
 	return secretKey, nil
 }
 
+// GetRequiredSecretKey loads the secret key with the provider configured by WERF_SECRET_KEY_PROVIDER.
 func GetRequiredSecretKey(workingDir string) ([]byte, error) {
-	var secretKey []byte
-	var werfSecretKeyPaths []string
-	var notFoundIn []string
-
-	secretKey = []byte(os.Getenv("WERF_SECRET_KEY"))
-	if len(secretKey) == 0 {
-		notFoundIn = append(notFoundIn, "$WERF_SECRET_KEY")
-
-		var werfSecretKeyPath string
-
-		if workingDir != "" {
-			if defaultWerfSecretKeyPath, err := filepath.Abs(filepath.Join(workingDir, ".werf_secret_key")); err != nil {
-				return nil, err
-			} else {
-				werfSecretKeyPaths = append(werfSecretKeyPaths, defaultWerfSecretKeyPath)
-			}
-		}
-
-		werfSecretKeyPaths = append(werfSecretKeyPaths, filepath.Join(werf.GetHomeDir(), "global_secret_key"))
-
-		for _, path := range werfSecretKeyPaths {
-			exist, err := util.FileExists(path)
-			if err != nil {
-				return nil, err
-			}
-
-			if exist {
-				werfSecretKeyPath = path
-				break
-			} else {
-				notFoundIn = append(notFoundIn, path)
-			}
-		}
-
-		if werfSecretKeyPath != "" {
-			data, err := ioutil.ReadFile(werfSecretKeyPath)
-			if err != nil {
-				return nil, err
-			}
-
-			secretKey = []byte(strings.TrimSpace(string(data)))
-		}
+	provider, err := GetSecretKeyProvider()
+	if err != nil {
+		return nil, err
 	}
 
-	if len(secretKey) == 0 {
-		return nil, NewEncryptionKeyRequiredError(notFoundIn)
-	}
-
-	return secretKey, nil
+	return provider.GetSecretKey(workingDir)
 }
 
 type EncryptionKeyRequiredError struct {
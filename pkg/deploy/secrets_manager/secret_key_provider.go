@@ -0,0 +1,133 @@
+package secrets_manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/werf/werf/pkg/util"
+	"github.com/werf/werf/pkg/werf"
+)
+
+const (
+	DefaultSecretKeyProviderName    = "default"
+	ExecSecretKeyProviderName       = "exec"
+	KubernetesSecretKeyProviderName = "kubernetes"
+	VaultSecretKeyProviderName      = "vault"
+)
+
+// SecretKeyProvider loads the secret key from some source.
+// Provider should return EncryptionKeyRequiredError when the key is not found,
+// any other error means that the source is unavailable or misconfigured.
+type SecretKeyProvider interface {
+	GetSecretKey(workingDir string) ([]byte, error)
+	String() string
+}
+
+// GetSecretKeyProvider returns the provider configured with WERF_SECRET_KEY_PROVIDER.
+func GetSecretKeyProvider() (SecretKeyProvider, error) {
+	return NewSecretKeyProvider(os.Getenv("WERF_SECRET_KEY_PROVIDER"))
+}
+
+// NewSecretKeyProvider creates the provider by the spec of the following form:
+//   - "" or "default": $WERF_SECRET_KEY, .werf_secret_key or ~/.werf/global_secret_key;
+//   - "exec:HELPER": credential helper, HELPER is a path, a name of werf-secret-key-HELPER program from $PATH or a shell command prefixed with "!";
+//   - "kubernetes:NAMESPACE/SECRET[#KEY]": key of the Kubernetes Secret ("secret_key" by default);
+//   - "vault:URL[#FIELD]": field of the Vault-compatible KV secret ("secret_key" by default).
+func NewSecretKeyProvider(spec string) (SecretKeyProvider, error) {
+	name, params := spec, ""
+	if i := strings.Index(spec, ":"); i != -1 {
+		name, params = spec[:i], spec[i+1:]
+	}
+
+	switch name {
+	case "", DefaultSecretKeyProviderName:
+		if params != "" {
+			return nil, fmt.Errorf("unexpected %s secret key provider parameters %q", DefaultSecretKeyProviderName, params)
+		}
+		return NewDefaultSecretKeyProvider(), nil
+	case ExecSecretKeyProviderName:
+		if params == "" {
+			return nil, fmt.Errorf("%s secret key provider requires helper: expected %s:HELPER", ExecSecretKeyProviderName, ExecSecretKeyProviderName)
+		}
+		return NewExecSecretKeyProvider(params), nil
+	case KubernetesSecretKeyProviderName:
+		return parseKubernetesSecretKeyProvider(params)
+	case VaultSecretKeyProviderName:
+		return parseVaultSecretKeyProvider(params)
+	default:
+		return nil, fmt.Errorf("unknown secret key provider %q: expected one of %q, %q, %q or %q", name, DefaultSecretKeyProviderName, ExecSecretKeyProviderName, KubernetesSecretKeyProviderName, VaultSecretKeyProviderName)
+	}
+}
+
+func splitSecretKeyProviderSpecFragment(params, defaultFragment string) (string, string) {
+	if i := strings.LastIndex(params, "#"); i != -1 {
+		return params[:i], params[i+1:]
+	}
+	return params, defaultFragment
+}
+
+// DefaultSecretKeyProvider reads the key from $WERF_SECRET_KEY, project .werf_secret_key or ~/.werf/global_secret_key file.
+type DefaultSecretKeyProvider struct{}
+
+func NewDefaultSecretKeyProvider() *DefaultSecretKeyProvider {
+	return &DefaultSecretKeyProvider{}
+}
+
+func (provider *DefaultSecretKeyProvider) String() string {
+	return DefaultSecretKeyProviderName
+}
+
+func (provider *DefaultSecretKeyProvider) GetSecretKey(workingDir string) ([]byte, error) {
+	var secretKey []byte
+	var werfSecretKeyPaths []string
+	var notFoundIn []string
+
+	secretKey = []byte(os.Getenv("WERF_SECRET_KEY"))
+	if len(secretKey) == 0 {
+		notFoundIn = append(notFoundIn, "$WERF_SECRET_KEY")
+
+		var werfSecretKeyPath string
+
+		if workingDir != "" {
+			if defaultWerfSecretKeyPath, err := filepath.Abs(filepath.Join(workingDir, ".werf_secret_key")); err != nil {
+				return nil, err
+			} else {
+				werfSecretKeyPaths = append(werfSecretKeyPaths, defaultWerfSecretKeyPath)
+			}
+		}
+
+		werfSecretKeyPaths = append(werfSecretKeyPaths, filepath.Join(werf.GetHomeDir(), "global_secret_key"))
+
+		for _, path := range werfSecretKeyPaths {
+			exist, err := util.FileExists(path)
+			if err != nil {
+				return nil, err
+			}
+
+			if exist {
+				werfSecretKeyPath = path
+				break
+			} else {
+				notFoundIn = append(notFoundIn, path)
+			}
+		}
+
+		if werfSecretKeyPath != "" {
+			data, err := ioutil.ReadFile(werfSecretKeyPath)
+			if err != nil {
+				return nil, err
+			}
+
+			secretKey = []byte(strings.TrimSpace(string(data)))
+		}
+	}
+
+	if len(secretKey) == 0 {
+		return nil, NewEncryptionKeyRequiredError(notFoundIn)
+	}
+
+	return secretKey, nil
+}
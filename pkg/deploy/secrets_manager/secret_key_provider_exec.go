@@ -0,0 +1,60 @@
+package secrets_manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const execSecretKeyProviderHelperPrefix = "werf-secret-key-"
+
+// ExecSecretKeyProvider gets the key from the credential helper program similarly to git credential helpers.
+//
+// The helper is run with the "get" argument and receives "workingDir=DIR" line on stdin.
+// The helper should print the key to stdout, empty output means that the key is not found.
+// Non-zero exit code is treated as an error, stderr is passed through to the user.
+type ExecSecretKeyProvider struct {
+	Helper string
+}
+
+func NewExecSecretKeyProvider(helper string) *ExecSecretKeyProvider {
+	return &ExecSecretKeyProvider{Helper: helper}
+}
+
+func (provider *ExecSecretKeyProvider) String() string {
+	return fmt.Sprintf("%s:%s", ExecSecretKeyProviderName, provider.Helper)
+}
+
+func (provider *ExecSecretKeyProvider) command() *exec.Cmd {
+	switch {
+	case strings.HasPrefix(provider.Helper, "!"):
+		return exec.Command("sh", "-c", fmt.Sprintf("%s \"$@\"", strings.TrimPrefix(provider.Helper, "!")), provider.Helper, "get")
+	case filepath.IsAbs(provider.Helper) || strings.ContainsRune(provider.Helper, filepath.Separator):
+		return exec.Command(provider.Helper, "get")
+	default:
+		return exec.Command(execSecretKeyProviderHelperPrefix+provider.Helper, "get")
+	}
+}
+
+func (provider *ExecSecretKeyProvider) GetSecretKey(workingDir string) ([]byte, error) {
+	var stdout bytes.Buffer
+
+	cmd := provider.command()
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("workingDir=%s\n", workingDir))
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("secret key helper %q failed: %w", provider.Helper, err)
+	}
+
+	secretKey := bytes.TrimSpace(stdout.Bytes())
+	if len(secretKey) == 0 {
+		return nil, NewEncryptionKeyRequiredError([]string{provider.String()})
+	}
+
+	return secretKey, nil
+}
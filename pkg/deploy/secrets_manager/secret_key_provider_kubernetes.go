@@ -0,0 +1,94 @@
+package secrets_manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/werf/kubedog/pkg/kube"
+	"github.com/werf/werf/pkg/util"
+)
+
+const defaultKubernetesSecretKeyProviderKey = "secret_key"
+
+// KubernetesSecretKeyProvider reads the key from the Kubernetes Secret.
+// Kubernetes connection is configured with $WERF_KUBE_CONFIG, $WERF_KUBE_CONFIG_BASE64 and $WERF_KUBE_CONTEXT
+// (or their standard alternatives), the same way as for other werf commands.
+type KubernetesSecretKeyProvider struct {
+	Namespace  string
+	SecretName string
+	Key        string
+
+	// Client is created from the environment on the first use when not set.
+	Client kubernetes.Interface
+}
+
+func NewKubernetesSecretKeyProvider(namespace, secretName, key string) *KubernetesSecretKeyProvider {
+	return &KubernetesSecretKeyProvider{Namespace: namespace, SecretName: secretName, Key: key}
+}
+
+func parseKubernetesSecretKeyProvider(params string) (*KubernetesSecretKeyProvider, error) {
+	ref, key := splitSecretKeyProviderSpecFragment(params, defaultKubernetesSecretKeyProviderKey)
+
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || key == "" {
+		return nil, fmt.Errorf("bad %s secret key provider parameters %q: expected %s:NAMESPACE/SECRET[#KEY]", KubernetesSecretKeyProviderName, params, KubernetesSecretKeyProviderName)
+	}
+
+	return NewKubernetesSecretKeyProvider(parts[0], parts[1], key), nil
+}
+
+func (provider *KubernetesSecretKeyProvider) String() string {
+	return fmt.Sprintf("%s:%s/%s#%s", KubernetesSecretKeyProviderName, provider.Namespace, provider.SecretName, provider.Key)
+}
+
+func (provider *KubernetesSecretKeyProvider) getClient() (kubernetes.Interface, error) {
+	if provider.Client != nil {
+		return provider.Client, nil
+	}
+
+	config, err := kube.GetKubeConfig(kube.KubeConfigOptions{
+		ConfigPath:       util.GetFirstExistingEnvVarAsString("WERF_KUBE_CONFIG", "WERF_KUBECONFIG", "KUBECONFIG"),
+		ConfigDataBase64: util.GetFirstExistingEnvVarAsString("WERF_KUBE_CONFIG_BASE64", "WERF_KUBECONFIG_BASE64", "KUBECONFIG_BASE64"),
+		Context:          os.Getenv("WERF_KUBE_CONTEXT"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kube config: %w", err)
+	} else if config == nil {
+		return nil, fmt.Errorf("kube config not found")
+	}
+
+	client, err := kubernetes.NewForConfig(config.Config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create kubernetes client: %w", err)
+	}
+	provider.Client = client
+
+	return client, nil
+}
+
+func (provider *KubernetesSecretKeyProvider) GetSecretKey(_ string) ([]byte, error) {
+	client, err := provider.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.CoreV1().Secrets(provider.Namespace).Get(context.Background(), provider.SecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, NewEncryptionKeyRequiredError([]string{provider.String()})
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to get secret %s/%s: %w", provider.Namespace, provider.SecretName, err)
+	}
+
+	secretKey := []byte(strings.TrimSpace(string(secret.Data[provider.Key])))
+	if len(secretKey) == 0 {
+		return nil, NewEncryptionKeyRequiredError([]string{provider.String()})
+	}
+
+	return secretKey, nil
+}
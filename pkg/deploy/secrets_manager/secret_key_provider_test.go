@@ -0,0 +1,271 @@
+package secrets_manager
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/werf/werf/pkg/werf"
+)
+
+func assertEncryptionKeyRequiredError(t *testing.T, err error) {
+	t.Helper()
+
+	var keyRequiredErr *EncryptionKeyRequiredError
+	if !errors.As(err, &keyRequiredErr) {
+		t.Fatalf("expected EncryptionKeyRequiredError, got %v", err)
+	}
+}
+
+func TestNewSecretKeyProvider(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "", want: "default"},
+		{spec: "default", want: "default"},
+		{spec: "default:params", wantErr: true},
+		{spec: "exec:pass", want: "exec:pass"},
+		{spec: "exec:!echo key", want: "exec:!echo key"},
+		{spec: "exec:", wantErr: true},
+		{spec: "kubernetes:ns/werf", want: "kubernetes:ns/werf#secret_key"},
+		{spec: "kubernetes:ns/werf#key", want: "kubernetes:ns/werf#key"},
+		{spec: "kubernetes:werf", wantErr: true},
+		{spec: "kubernetes:ns/werf/extra", wantErr: true},
+		{spec: "kubernetes:ns/werf#", wantErr: true},
+		{spec: "vault:https://vault.example.com/v1/secret/data/werf", want: "vault:https://vault.example.com/v1/secret/data/werf#secret_key"},
+		{spec: "vault:http://127.0.0.1:8200/v1/kv/werf#key", want: "vault:http://127.0.0.1:8200/v1/kv/werf#key"},
+		{spec: "vault:vault.example.com/v1/secret/werf", wantErr: true},
+		{spec: "vault:", wantErr: true},
+		{spec: "unknown:params", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			provider, err := NewSecretKeyProvider(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got provider %s", provider)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if provider.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, provider.String())
+			}
+		})
+	}
+}
+
+func TestDefaultSecretKeyProvider(t *testing.T) {
+	workingDir := t.TempDir()
+	t.Setenv("WERF_HOME", t.TempDir())
+	t.Setenv("WERF_SECRET_KEY", "")
+	if err := werf.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewDefaultSecretKeyProvider()
+
+	_, err := provider.GetSecretKey(workingDir)
+	assertEncryptionKeyRequiredError(t, err)
+
+	if err := os.WriteFile(filepath.Join(workingDir, ".werf_secret_key"), []byte("file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := provider.GetSecretKey(workingDir); err != nil || string(key) != "file-key" {
+		t.Errorf("unexpected key %q (err %v)", key, err)
+	}
+
+	t.Setenv("WERF_SECRET_KEY", "env-key")
+	if key, err := provider.GetSecretKey(workingDir); err != nil || string(key) != "env-key" {
+		t.Errorf("unexpected key %q (err %v)", key, err)
+	}
+}
+
+func TestExecSecretKeyProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		helper   string
+		want     string
+		notFound bool
+		wantErr  bool
+	}{
+		{name: "key", helper: `!printf " exec-key\n"`, want: "exec-key"},
+		{name: "args and stdin", helper: `!f() { test "$1" = get && read line && test "$line" = "workingDir=/project" && echo ok; }; f`, want: "ok"},
+		{name: "empty output", helper: "!true", notFound: true},
+		{name: "failure", helper: "!exit 1", wantErr: true},
+		{name: "missing helper", helper: "werf-test-missing-helper", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewExecSecretKeyProvider(tt.helper).GetSecretKey("/project")
+			switch {
+			case tt.notFound:
+				assertEncryptionKeyRequiredError(t, err)
+			case tt.wantErr:
+				var keyRequiredErr *EncryptionKeyRequiredError
+				if err == nil || errors.As(err, &keyRequiredErr) {
+					t.Fatalf("expected helper error, got %v", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case string(key) != tt.want:
+				t.Errorf("expected %q, got %q", tt.want, key)
+			}
+		})
+	}
+}
+
+func TestKubernetesSecretKeyProvider(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "werf"},
+		Data: map[string][]byte{
+			"secret_key": []byte("k8s-key\n"),
+			"empty":      {},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		namespace string
+		secret    string
+		key       string
+		want      string
+	}{
+		{name: "key", namespace: "ns", secret: "werf", key: "secret_key", want: "k8s-key"},
+		{name: "empty key", namespace: "ns", secret: "werf", key: "empty"},
+		{name: "missing key", namespace: "ns", secret: "werf", key: "missing"},
+		{name: "missing secret", namespace: "ns", secret: "missing", key: "secret_key"},
+		{name: "missing namespace", namespace: "missing", secret: "werf", key: "secret_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewKubernetesSecretKeyProvider(tt.namespace, tt.secret, tt.key)
+			provider.Client = client
+
+			key, err := provider.GetSecretKey("")
+			if tt.want == "" {
+				assertEncryptionKeyRequiredError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(key) != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, key)
+			}
+		})
+	}
+}
+
+func TestVaultSecretKeyProvider(t *testing.T) {
+	responses := map[string]string{
+		"/v1/kv/werf":             `{"data":{"secret_key":"kv1-key"}}`,
+		"/v1/secret/data/werf":    `{"data":{"data":{"secret_key":"kv2-key"},"metadata":{"version":1}}}`,
+		"/v1/secret/data/empty":   `{"data":{"data":{},"metadata":{"version":1}}}`,
+		"/v1/kv/nested":           `{"data":{"data":{"secret_key":"nested"}}}`,
+		"/v1/kv/not-string-field": `{"data":{"secret_key":1}}`,
+		"/v1/kv/bad-json":         `{`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if r.URL.Path == "/v1/kv/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	defer server.Close()
+
+	t.Setenv("WERF_SECRET_KEY_VAULT_TOKEN", "token")
+	t.Setenv("VAULT_NAMESPACE", "team")
+
+	tests := []struct {
+		name     string
+		path     string
+		field    string
+		want     string
+		notFound bool
+		wantErr  bool
+	}{
+		{name: "kv1", path: "/v1/kv/werf", field: "secret_key", want: "kv1-key"},
+		{name: "kv2", path: "/v1/secret/data/werf", field: "secret_key", want: "kv2-key"},
+		{name: "kv1 field named data", path: "/v1/kv/nested", field: "secret_key", notFound: true},
+		{name: "kv2 missing field", path: "/v1/secret/data/empty", field: "secret_key", notFound: true},
+		{name: "kv1 missing field", path: "/v1/kv/werf", field: "other", notFound: true},
+		{name: "not string field", path: "/v1/kv/not-string-field", field: "secret_key", notFound: true},
+		{name: "missing secret", path: "/v1/kv/missing", field: "secret_key", notFound: true},
+		{name: "server error", path: "/v1/kv/error", field: "secret_key", wantErr: true},
+		{name: "bad json", path: "/v1/kv/bad-json", field: "secret_key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewSecretKeyProvider("vault:" + server.URL + tt.path + "#" + tt.field)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			key, err := provider.GetSecretKey("")
+			switch {
+			case tt.notFound:
+				assertEncryptionKeyRequiredError(t, err)
+			case tt.wantErr:
+				var keyRequiredErr *EncryptionKeyRequiredError
+				if err == nil || errors.As(err, &keyRequiredErr) {
+					t.Fatalf("expected request error, got %v", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case string(key) != tt.want:
+				t.Errorf("expected %q, got %q", tt.want, key)
+			}
+		})
+	}
+}
+
+func TestAllowMissedSecretKeyModeWithProvider(t *testing.T) {
+	t.Setenv("WERF_SECRET_KEY_PROVIDER", "exec:!true")
+
+	manager := NewSecretsManager(SecretsManagerOptions{})
+	if err := manager.AllowMissedSecretKeyMode(""); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !manager.IsMissedSecretKeyModeEnabled() {
+		t.Errorf("expected missed secret key mode to be enabled")
+	}
+
+	t.Setenv("WERF_SECRET_KEY_PROVIDER", "exec:!exit 1")
+
+	manager = NewSecretsManager(SecretsManagerOptions{})
+	if err := manager.AllowMissedSecretKeyMode(""); err == nil {
+		t.Errorf("expected helper error")
+	}
+	if manager.IsMissedSecretKeyModeEnabled() {
+		t.Errorf("expected missed secret key mode to be disabled on helper error")
+	}
+}
@@ -0,0 +1,113 @@
+package secrets_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/werf/werf/pkg/util"
+)
+
+const (
+	defaultVaultSecretKeyProviderField = "secret_key"
+	vaultSecretKeyProviderTimeout      = 30 * time.Second
+)
+
+// VaultSecretKeyProvider reads the key from the field of the Vault-compatible KV secret.
+// Both KV v1 (https://vault/v1/secret/werf) and KV v2 (https://vault/v1/secret/data/werf) endpoints are supported.
+// Token is taken from $WERF_SECRET_KEY_VAULT_TOKEN or $VAULT_TOKEN, namespace from $VAULT_NAMESPACE.
+type VaultSecretKeyProvider struct {
+	URL   string
+	Field string
+
+	Token     string
+	Namespace string
+
+	Client *http.Client
+}
+
+func NewVaultSecretKeyProvider(rawURL, field string) *VaultSecretKeyProvider {
+	return &VaultSecretKeyProvider{
+		URL:       rawURL,
+		Field:     field,
+		Token:     util.GetFirstExistingEnvVarAsString("WERF_SECRET_KEY_VAULT_TOKEN", "VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Client:    &http.Client{Timeout: vaultSecretKeyProviderTimeout},
+	}
+}
+
+func parseVaultSecretKeyProvider(params string) (*VaultSecretKeyProvider, error) {
+	rawURL, field := splitSecretKeyProviderSpecFragment(params, defaultVaultSecretKeyProviderField)
+
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || field == "" {
+		return nil, fmt.Errorf("bad %s secret key provider parameters %q: expected %s:http(s)://ADDRESS/v1/PATH[#FIELD]", VaultSecretKeyProviderName, params, VaultSecretKeyProviderName)
+	}
+
+	return NewVaultSecretKeyProvider(rawURL, field), nil
+}
+
+func (provider *VaultSecretKeyProvider) String() string {
+	return fmt.Sprintf("%s:%s#%s", VaultSecretKeyProviderName, provider.URL, provider.Field)
+}
+
+type vaultSecretResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+func (provider *VaultSecretKeyProvider) GetSecretKey(_ string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, provider.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	if provider.Token != "" {
+		req.Header.Set("X-Vault-Token", provider.Token)
+	}
+	if provider.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", provider.Namespace)
+	}
+
+	resp, err := provider.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get secret %s: %w", provider.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret %s: %w", provider.URL, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, NewEncryptionKeyRequiredError([]string{provider.String()})
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unable to get secret %s: unexpected status %s: %s", provider.URL, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var secret vaultSecretResponse
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("unable to parse secret %s: %w", provider.URL, err)
+	}
+
+	data := secret.Data
+	// KV v2 wraps secret data and metadata into additional data object.
+	if kv2Data, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = kv2Data
+		}
+	}
+
+	value, _ := data[provider.Field].(string)
+	secretKey := []byte(strings.TrimSpace(value))
+	if len(secretKey) == 0 {
+		return nil, NewEncryptionKeyRequiredError([]string{provider.String()})
+	}
+
+	return secretKey, nil
+}
@@ -12,6 +12,7 @@ type SecretsManager struct {
 	DisableSecretsDecryption bool
 
 	missedSecretKeyModeEnabled bool
+	missedSecretKeyProvider    string
 }
 
 type SecretsManagerOptions struct {
@@ -29,10 +30,15 @@ func (manager *SecretsManager) IsMissedSecretKeyModeEnabled() bool {
 }
 
 func (manager *SecretsManager) AllowMissedSecretKeyMode(workingDir string) error {
-	_, err := GetRequiredSecretKey(workingDir)
+	provider, err := GetSecretKeyProvider()
 	if err != nil {
+		return fmt.Errorf("unable to load secret key: %w", err)
+	}
+
+	if _, err := provider.GetSecretKey(workingDir); err != nil {
 		if _, missedKey := err.(*EncryptionKeyRequiredError); missedKey {
 			manager.missedSecretKeyModeEnabled = true
+			manager.missedSecretKeyProvider = provider.String()
 			return nil
 		}
 		return fmt.Errorf("unable to load secret key: %w", err)
@@ -46,13 +52,39 @@ func (manager *SecretsManager) GetYamlEncoder(ctx context.Context, workingDir st
 		return secret.NewYamlEncoder(nil), nil
 	}
 	if manager.missedSecretKeyModeEnabled {
-		logboek.Context(ctx).Error().LogLn("Secrets decryption disabled due to missed key (no WERF_SECRET_KEY is set)")
+		if manager.missedSecretKeyProvider == DefaultSecretKeyProviderName {
+			logboek.Context(ctx).Error().LogLn("Secrets decryption disabled due to missed key (no WERF_SECRET_KEY is set)")
+		} else {
+			logboek.Context(ctx).Error().LogF("Secrets decryption disabled due to missed key (not found by %s secret key provider)\n", manager.missedSecretKeyProvider)
+		}
 		return secret.NewYamlEncoder(nil), nil
 	}
 
-	if key, err := GetRequiredSecretKey(workingDir); err != nil {
+	recipients, err := ReadSecretRecipients(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.GetYamlEncoderForRecipients(workingDir, recipients)
+}
+
+// GetYamlEncoderForRecipients returns the age encoder, which encrypts secrets for the specified recipients
+// and decrypts secrets with the age identity from the secret key. Without recipients the secret key is used as the AES key.
+func (manager *SecretsManager) GetYamlEncoderForRecipients(workingDir string, recipients []string) (*secret.YamlEncoder, error) {
+	key, err := GetRequiredSecretKey(workingDir)
+	if err != nil {
 		return nil, fmt.Errorf("unable to load secret key: %w", err)
-	} else if enc, err := secret.NewAesEncoder(key); err != nil {
+	}
+
+	if len(recipients) > 0 {
+		if enc, err := secret.NewAgeEncoder(recipients, key); err != nil {
+			return nil, fmt.Errorf("check age identity in the secret key and %s file: %w", SecretRecipientsFileName, err)
+		} else {
+			return secret.NewYamlEncoder(enc), nil
+		}
+	}
+
+	if enc, err := secret.NewAesEncoder(key); err != nil {
 		return nil, fmt.Errorf("check encryption key: %w", err)
 	} else {
 		return secret.NewYamlEncoder(enc), nil
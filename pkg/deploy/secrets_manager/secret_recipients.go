@@ -0,0 +1,133 @@
+package secrets_manager
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/werf/werf/pkg/secret"
+	"github.com/werf/werf/pkg/util"
+)
+
+// SecretRecipientsFileName is the project file with age recipients, one per line.
+// When the file exists, secrets are encrypted for all listed recipients,
+// and the secret key is treated as the age identity of one of them.
+const SecretRecipientsFileName = ".werf_secret_recipients"
+
+// ReadSecretRecipients returns nil if the project does not use recipients.
+func ReadSecretRecipients(workingDir string) ([]string, error) {
+	recipientsFile, err := LoadSecretRecipientsFile(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !recipientsFile.Exists() {
+		return nil, nil
+	}
+
+	recipients := recipientsFile.Recipients()
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients found in the %s file", recipientsFile.Path)
+	}
+
+	return recipients, nil
+}
+
+// SecretRecipientsFile keeps all lines of the recipients file, so that comments are preserved on save.
+type SecretRecipientsFile struct {
+	Path string
+
+	exists bool
+	lines  []string
+}
+
+func LoadSecretRecipientsFile(workingDir string) (*SecretRecipientsFile, error) {
+	recipientsFile := &SecretRecipientsFile{Path: filepath.Join(workingDir, SecretRecipientsFileName)}
+
+	data, err := os.ReadFile(recipientsFile.Path)
+	if os.IsNotExist(err) {
+		return recipientsFile, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read secret recipients file: %w", err)
+	}
+	recipientsFile.exists = true
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		recipientsFile.lines = append(recipientsFile.lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read secret recipients file: %w", err)
+	}
+
+	return recipientsFile, nil
+}
+
+func (recipientsFile *SecretRecipientsFile) Exists() bool {
+	return recipientsFile.exists
+}
+
+// Recipients returns recipients skipping empty lines and #-comments.
+func (recipientsFile *SecretRecipientsFile) Recipients() []string {
+	var recipients []string
+	for _, line := range recipientsFile.lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		recipients = append(recipients, line)
+	}
+
+	return recipients
+}
+
+// Add appends recipients, which are not in the file yet.
+func (recipientsFile *SecretRecipientsFile) Add(recipients ...string) {
+	for _, recipient := range recipients {
+		if !util.IsStringsContainValue(recipientsFile.Recipients(), recipient) {
+			recipientsFile.lines = append(recipientsFile.lines, recipient)
+		}
+	}
+}
+
+func (recipientsFile *SecretRecipientsFile) Remove(recipients ...string) error {
+	for _, recipient := range recipients {
+		if !util.IsStringsContainValue(recipientsFile.Recipients(), recipient) {
+			return fmt.Errorf("recipient %q not found in the %s file", recipient, recipientsFile.Path)
+		}
+	}
+
+	var lines []string
+	for _, line := range recipientsFile.lines {
+		if !util.IsStringsContainValue(recipients, strings.TrimSpace(line)) {
+			lines = append(lines, line)
+		}
+	}
+	recipientsFile.lines = lines
+
+	return nil
+}
+
+// Save validates recipients and writes the file.
+func (recipientsFile *SecretRecipientsFile) Save() error {
+	recipients := recipientsFile.Recipients()
+	if len(recipients) == 0 {
+		return fmt.Errorf("at least one recipient required")
+	}
+
+	if _, err := secret.NewAgeEncoder(recipients, nil); err != nil {
+		return err
+	}
+
+	data := []byte(strings.Join(recipientsFile.lines, "\n") + "\n")
+	if err := os.WriteFile(recipientsFile.Path, data, 0o644); err != nil {
+		return fmt.Errorf("unable to write secret recipients file: %w", err)
+	}
+	recipientsFile.exists = true
+
+	return nil
+}
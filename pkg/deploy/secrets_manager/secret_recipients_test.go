@@ -0,0 +1,112 @@
+package secrets_manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/werf/werf/pkg/secret"
+	"github.com/werf/werf/pkg/werf"
+)
+
+func TestSecretRecipientsFile(t *testing.T) {
+	_, recipient1, _ := secret.GenerateAgeIdentity()
+	_, recipient2, _ := secret.GenerateAgeIdentity()
+	_, recipient3, _ := secret.GenerateAgeIdentity()
+
+	workingDir := t.TempDir()
+
+	recipients, err := ReadSecretRecipients(workingDir)
+	if err != nil || recipients != nil {
+		t.Fatalf("expected no recipients without the file, got %v and error %v", recipients, err)
+	}
+
+	content := "# team\n" + recipient1 + "\n\n" + recipient2 + "\n"
+	if err := os.WriteFile(filepath.Join(workingDir, SecretRecipientsFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	recipientsFile, err := LoadSecretRecipientsFile(workingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recipientsFile.Exists() {
+		t.Fatalf("expected recipients file to exist")
+	}
+
+	recipientsFile.Add(recipient2, recipient3)
+	if err := recipientsFile.Remove(recipient1); err != nil {
+		t.Fatal(err)
+	}
+	if err := recipientsFile.Remove(recipient1); err == nil {
+		t.Errorf("expected error for unknown recipient")
+	}
+	if err := recipientsFile.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(recipientsFile.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "# team\n\n" + recipient2 + "\n" + recipient3 + "\n"; string(data) != expected {
+		t.Errorf("expected file content %q, got %q", expected, data)
+	}
+
+	recipients, err = ReadSecretRecipients(workingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{recipient2, recipient3}; !reflect.DeepEqual(recipients, expected) {
+		t.Errorf("expected recipients %v, got %v", expected, recipients)
+	}
+
+	if err := recipientsFile.Remove(recipient2, recipient3); err != nil {
+		t.Fatal(err)
+	}
+	if err := recipientsFile.Save(); err == nil {
+		t.Errorf("expected error on saving file without recipients")
+	}
+}
+
+func TestGetYamlEncoderWithRecipients(t *testing.T) {
+	identity, recipient, err := secret.GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("WERF_HOME", t.TempDir())
+	t.Setenv("WERF_SECRET_KEY_PROVIDER", "")
+	t.Setenv("WERF_SECRET_KEY", identity)
+	if err := werf.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	workingDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workingDir, SecretRecipientsFileName), []byte(recipient+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := NewSecretsManager(SecretsManagerOptions{}).GetYamlEncoder(context.Background(), workingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedData, err := enc.Encrypt([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !secret.IsAgeEncryptedData(encryptedData) {
+		t.Errorf("expected age encrypted data, got %q", encryptedData)
+	}
+
+	data, err := enc.Decrypt(encryptedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "data" {
+		t.Errorf("expected %q, got %q", "data", data)
+	}
+}
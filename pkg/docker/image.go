@@ -13,6 +13,7 @@ import (
 	"github.com/docker/cli/cli/streams"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 
@@ -65,6 +66,27 @@ func ImageInspect(ctx context.Context, ref string) (*types.ImageInspect, error)
 	return &inspect, nil
 }
 
+// ImageSave returns the docker-archive tarball stream of the image, the caller must close the stream.
+func ImageSave(ctx context.Context, ref string) (io.ReadCloser, error) {
+	return apiCli(ctx).ImageSave(ctx, []string{ref})
+}
+
+// ImageLoad loads images from the docker-archive tarball stream.
+func ImageLoad(ctx context.Context, input io.Reader) error {
+	resp, err := apiCli(ctx).ImageLoad(ctx, input, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !resp.JSON {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	return jsonmessage.DisplayJSONMessagesStream(resp.Body, io.Discard, 0, false, nil)
+}
+
 func doCliPull(c command.Cli, args ...string) error {
 	return prepareCliCmd(image.NewPullCommand(c), args...).Execute()
 }
@@ -11,7 +11,6 @@ import (
 	"time"
 
 	dockerReference "github.com/docker/distribution/reference"
-	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
@@ -19,6 +18,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/partial"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 
@@ -171,56 +171,65 @@ func (api *api) getRepoImageByDesc(ctx context.Context, originalTag string, desc
 			repoImage.Index = append(repoImage.Index, subInfo)
 		}
 	} else {
-		imageInfo, err := desc.Image()
+		img, err := desc.Image()
 		if err != nil {
 			return nil, fmt.Errorf("error getting image manifest: %w", err)
 		}
 
-		digest, err := imageInfo.Digest()
-		if err != nil {
+		if err := setRepoImageInfo(repoImage, img); err != nil {
 			return nil, err
 		}
-		repoImage.RepoDigest = fmt.Sprintf("%s@%s", image.NormalizeRepository(repoImage.Repository), digest.String())
+	}
 
-		manifest, err := imageInfo.Manifest()
-		if err != nil {
-			return nil, err
-		}
-		repoImage.ID = manifest.Config.Digest.String()
+	return repoImage, nil
+}
 
-		configFile, err := imageInfo.ConfigFile()
-		if err != nil {
-			return nil, err
-		}
-		repoImage.Labels = configFile.Config.Labels
-		repoImage.OnBuild = configFile.Config.OnBuild
-		repoImage.Env = configFile.Config.Env
-		repoImage.SetCreatedAtUnix(configFile.Created.Unix())
+// setRepoImageInfo fills the image digest, config and size fields of the repo image info.
+func setRepoImageInfo(repoImage *image.Info, img v1.Image) error {
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	repoImage.RepoDigest = fmt.Sprintf("%s@%s", image.NormalizeRepository(repoImage.Repository), digest.String())
 
-		var totalSize int64
-		if layers, err := imageInfo.Layers(); err != nil {
-			return nil, err
-		} else {
-			for _, l := range layers {
-				if lSize, err := l.Size(); err != nil {
-					return nil, err
-				} else {
-					totalSize += lSize
-				}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+	repoImage.ID = manifest.Config.Digest.String()
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return err
+	}
+	repoImage.Labels = configFile.Config.Labels
+	repoImage.OnBuild = configFile.Config.OnBuild
+	repoImage.Env = configFile.Config.Env
+	repoImage.SetCreatedAtUnix(configFile.Created.Unix())
+
+	var totalSize int64
+	if layers, err := img.Layers(); err != nil {
+		return err
+	} else {
+		for _, l := range layers {
+			if lSize, err := l.Size(); err != nil {
+				return err
+			} else {
+				totalSize += lSize
 			}
 		}
-		repoImage.Size = totalSize
+	}
+	repoImage.Size = totalSize
 
-		parentID := configFile.Config.Image
-		if parentID == "" {
-			if id, ok := configFile.Config.Labels[image.WerfBaseImageIDLabel]; ok { // built with werf and buildah backend
-				parentID = id
-			}
+	parentID := configFile.Config.Image
+	if parentID == "" {
+		if id, ok := configFile.Config.Labels[image.WerfBaseImageIDLabel]; ok { // built with werf and buildah backend
+			parentID = id
 		}
-		repoImage.ParentID = parentID
 	}
+	repoImage.ParentID = parentID
 
-	return repoImage, nil
+	return nil
 }
 
 func (api *api) list(ctx context.Context, reference string, extraListOptions ...remote.Option) ([]string, error) {
@@ -418,7 +427,7 @@ func (api *api) CopyImage(ctx context.Context, sourceReference, destinationRefer
 			return fmt.Errorf("getting image index %s digest: %w", sourceReference, err)
 		}
 		logboek.Context(ctx).Debug().LogF("-- CopyImage writing index ref:%q digest:%q\n", dstRef, digest)
-		if err := api.writeToRemote(ctx, dstRef, ii); err != nil {
+		if err := api.copyToRemote(ctx, dstRef, ii, opts.TransferOptions); err != nil {
 			return fmt.Errorf("unable to write %s: %w", dstRef, err)
 		}
 	case desc.MediaType.IsImage():
@@ -431,7 +440,7 @@ func (api *api) CopyImage(ctx context.Context, sourceReference, destinationRefer
 			return fmt.Errorf("getting image %s digest: %w", sourceReference, err)
 		}
 		logboek.Context(ctx).Debug().LogF("-- CopyImage writing image ref:%q digest:%q\n", dstRef, digest)
-		if err := api.writeToRemote(ctx, dstRef, img); err != nil {
+		if err := api.copyToRemote(ctx, dstRef, img, opts.TransferOptions); err != nil {
 			return fmt.Errorf("unable to write %s: %w", dstRef, err)
 		}
 	default:
@@ -440,6 +449,17 @@ func (api *api) CopyImage(ctx context.Context, sourceReference, destinationRefer
 	return nil
 }
 
+// copyToRemote writes the missing blobs and then the manifests of the image or the image index, the whole copying is retried on the transient errors.
+func (api *api) copyToRemote(ctx context.Context, ref name.Reference, imageOrIndex interface{}, opts TransferOptions) error {
+	return api.pushWithRetry(ctx, func() error {
+		if err := api.writeBlobsToRemote(ctx, ref.Context(), imageOrIndex, opts); err != nil {
+			return err
+		}
+
+		return api.writeToRemote(ctx, ref, imageOrIndex)
+	})
+}
+
 type PushImageOptions struct {
 	Labels map[string]string
 }
@@ -500,7 +520,7 @@ func (api *api) parseReferenceOptions() []name.Option {
 func (api *api) defaultRemoteOptions(ctx context.Context) []remote.Option {
 	return []remote.Option{
 		remote.WithContext(ctx),
-		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithAuthFromKeychain(Keychain()),
 		remote.WithTransport(getHttpTransport(api.SkipTlsVerifyRegistry)),
 	}
 }
@@ -571,6 +591,10 @@ attemptLoop:
 				"REDACTED: UNKNOWN",
 				"http2: server sent GOAWAY and closed the connection",
 				"http2: Transport received Server's graceful shutdown GOAWAY",
+				"connection reset by peer",
+				"broken pipe",
+				"unexpected EOF",
+				"i/o timeout",
 			} {
 				if strings.Contains(err.Error(), substr) {
 					seconds := rand.Intn(5) + 1
@@ -599,7 +623,7 @@ func (api *api) writeToRemote(ctx context.Context, ref name.Reference, imageOrIn
 	case v1.Image:
 		go remote.Write(
 			ref, i,
-			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+			remote.WithAuthFromKeychain(Keychain()),
 			remote.WithProgress(c),
 			remote.WithTransport(getHttpTransport(api.SkipTlsVerifyRegistry)),
 			remote.WithContext(ctx),
@@ -607,7 +631,7 @@ func (api *api) writeToRemote(ctx context.Context, ref name.Reference, imageOrIn
 	case v1.ImageIndex:
 		go remote.WriteIndex(
 			ref, i,
-			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+			remote.WithAuthFromKeychain(Keychain()),
 			remote.WithProgress(c),
 			remote.WithTransport(getHttpTransport(api.SkipTlsVerifyRegistry)),
 			remote.WithContext(ctx),
@@ -666,6 +690,300 @@ func (api *api) PullImageArchive(ctx context.Context, archiveWriter io.Writer, r
 	return nil
 }
 
+func (api *api) PullImageFilesystem(ctx context.Context, fsWriter io.Writer, reference string) error {
+	ref, err := name.ParseReference(reference, api.parseReferenceOptions()...)
+	if err != nil {
+		return fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	img, err := remote.Image(ref, api.defaultRemoteOptions(ctx)...)
+	if err != nil {
+		return fmt.Errorf("unable to get image %q: %w", reference, err)
+	}
+
+	fsReader := mutate.Extract(img)
+	defer fsReader.Close()
+
+	if _, err := io.Copy(fsWriter, fsReader); err != nil {
+		return fmt.Errorf("unable to extract image %q filesystem: %w", reference, err)
+	}
+
+	return nil
+}
+
+// PullImageLayout writes the image or the image index into the OCI image layout directory, the directory is created if not exists.
+// The manifest is annotated with the refName, the manifest previously written with the same refName is replaced.
+func (api *api) PullImageLayout(ctx context.Context, layoutDir, reference, refName string, opts TransferOptions) error {
+	desc, _, err := api.getImageDesc(ctx, reference)
+	if err != nil {
+		return err
+	}
+
+	imageOrIndex, err := getDescImageOrIndex(desc)
+	if err != nil {
+		return fmt.Errorf("unable to resolve %q: %w", reference, err)
+	}
+
+	layoutPath, err := openImageLayout(layoutDir)
+	if err != nil {
+		return err
+	}
+
+	if err := writeBlobsToLayout(layoutPath, imageOrIndex, opts); err != nil {
+		return fmt.Errorf("unable to write %q blobs into layout %q: %w", reference, layoutDir, err)
+	}
+
+	if err := writeImageLayout(layoutPath, refName, imageOrIndex); err != nil {
+		return fmt.Errorf("unable to write %q into layout %q: %w", reference, layoutDir, err)
+	}
+
+	return nil
+}
+
+// PushImageLayout pushes the image or the image index annotated with the refName in the OCI image layout directory.
+// The manifests are pushed as is, so the digests are preserved.
+func (api *api) PushImageLayout(ctx context.Context, layoutDir, reference, refName string, opts TransferOptions) error {
+	ref, err := name.ParseReference(reference, api.parseReferenceOptions()...)
+	if err != nil {
+		return fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	imageOrIndex, err := ReadImageLayout(layoutDir, refName)
+	if err != nil {
+		return err
+	}
+
+	if err := api.copyToRemote(ctx, ref, imageOrIndex, opts); err != nil {
+		return fmt.Errorf("write to the remote %s have failed: %w", ref.String(), err)
+	}
+
+	return nil
+}
+
+func getDescImageOrIndex(desc *remote.Descriptor) (interface{}, error) {
+	switch {
+	case desc.MediaType.IsIndex():
+		return desc.ImageIndex()
+	case desc.MediaType.IsImage():
+		return desc.Image()
+	default:
+		return nil, fmt.Errorf("unsupported media type %q", desc.MediaType)
+	}
+}
+
+func (api *api) PushArtifact(ctx context.Context, reference string, opts PushArtifactOptions) (string, error) {
+	ref, err := name.ParseReference(reference, api.parseReferenceOptions()...)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	var subject *v1.Descriptor
+	if opts.SubjectReference != "" {
+		subjectRef, err := name.ParseReference(opts.SubjectReference, api.parseReferenceOptions()...)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse reference %q: %w", opts.SubjectReference, err)
+		}
+
+		if subject, err = api.getSubjectDescriptor(ctx, subjectRef); err != nil {
+			return "", err
+		}
+	}
+
+	img, err := newArtifactImage(opts, subject)
+	if err != nil {
+		return "", err
+	}
+
+	return api.pushArtifactImage(ctx, ref, img)
+}
+
+// PushReferrer pushes the artifact referring to the SubjectReference image into the image repository with the tag
+// (e.g. the cosign sha256-<digest>.sig tag) or by digest if the tag is not specified.
+// The referrers tag schema index of the subject is updated for the registries without the OCI referrers API.
+func (api *api) PushReferrer(ctx context.Context, tag string, opts PushArtifactOptions) (string, error) {
+	if opts.SubjectReference == "" {
+		return "", fmt.Errorf("unable to push referrer: subject reference is not specified")
+	}
+
+	subjectRef, err := name.ParseReference(opts.SubjectReference, api.parseReferenceOptions()...)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse reference %q: %w", opts.SubjectReference, err)
+	}
+
+	subject, err := api.getSubjectDescriptor(ctx, subjectRef)
+	if err != nil {
+		return "", err
+	}
+
+	img, err := newArtifactImage(opts, subject)
+	if err != nil {
+		return "", err
+	}
+
+	var ref name.Reference
+	if tag != "" {
+		ref = subjectRef.Context().Tag(tag)
+	} else {
+		digest, err := img.Digest()
+		if err != nil {
+			return "", fmt.Errorf("unable to calculate artifact digest: %w", err)
+		}
+
+		ref = subjectRef.Context().Digest(digest.String())
+	}
+
+	return api.pushArtifactImage(ctx, ref, img)
+}
+
+func (api *api) getSubjectDescriptor(ctx context.Context, subjectRef name.Reference) (*v1.Descriptor, error) {
+	subjectDesc, err := remote.Head(subjectRef, api.defaultRemoteOptions(ctx)...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get subject %q descriptor: %w", subjectRef.String(), err)
+	}
+
+	return &v1.Descriptor{
+		MediaType: subjectDesc.MediaType,
+		Size:      subjectDesc.Size,
+		Digest:    subjectDesc.Digest,
+	}, nil
+}
+
+func (api *api) pushArtifactImage(ctx context.Context, ref name.Reference, img v1.Image) (string, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return "", fmt.Errorf("unable to calculate artifact digest: %w", err)
+	}
+
+	if err := api.pushWithRetry(ctx, func() error {
+		if err := api.writeToRemote(ctx, ref, img); err != nil {
+			return fmt.Errorf("write to the remote %s have failed: %w", ref.String(), err)
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return digest.String(), nil
+}
+
+// Referrers returns the descriptors of the artifacts referring to the image (signatures, SBOMs, attestations etc.).
+// The referrers tag schema index is used for the registries without the OCI referrers API.
+func (api *api) Referrers(ctx context.Context, reference string, opts ReferrersOptions) ([]v1.Descriptor, error) {
+	ref, err := name.ParseReference(reference, api.parseReferenceOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	digestRef, ok := ref.(name.Digest)
+	if !ok {
+		desc, err := remote.Head(ref, api.defaultRemoteOptions(ctx)...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %q descriptor: %w", reference, err)
+		}
+
+		digestRef = ref.Context().Digest(desc.Digest.String())
+	}
+
+	remoteOptions := api.defaultRemoteOptions(ctx)
+	if opts.ArtifactType != "" {
+		remoteOptions = append(remoteOptions, remote.WithFilter("artifactType", opts.ArtifactType))
+	}
+
+	ii, err := remote.Referrers(digestRef, remoteOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get referrers of %q: %w", reference, err)
+	}
+
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read referrers of %q: %w", reference, err)
+	}
+
+	return im.Manifests, nil
+}
+
+// newArtifactImage creates the OCI artifact manifest with the layers, the artifact refers to the subject if specified.
+func newArtifactImage(opts PushArtifactOptions, subject *v1.Descriptor) (v1.Image, error) {
+	var img v1.Image
+	img = empty.Image
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, types.MediaType(opts.ArtifactType))
+
+	for _, layer := range opts.Layers {
+		var err error
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer:       static.NewLayer(layer.Data, types.MediaType(layer.MediaType)),
+			Annotations: layer.Annotations,
+			MediaType:   types.MediaType(layer.MediaType),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to append artifact layer: %w", err)
+		}
+	}
+
+	if len(opts.Annotations) > 0 {
+		img = mutate.Annotations(img, opts.Annotations).(v1.Image)
+	}
+
+	if subject != nil {
+		img = mutate.Subject(img, *subject).(v1.Image)
+	}
+
+	return img, nil
+}
+
+func (api *api) PullArtifactLayers(ctx context.Context, reference string) ([]ArtifactLayer, error) {
+	ref, err := name.ParseReference(reference, api.parseReferenceOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	img, err := remote.Image(ref, api.defaultRemoteOptions(ctx)...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get artifact %q: %w", reference, err)
+	}
+
+	return getArtifactLayers(reference, img)
+}
+
+func getArtifactLayers(reference string, img v1.Image) ([]ArtifactLayer, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get artifact %q manifest: %w", reference, err)
+	}
+
+	var layers []ArtifactLayer
+	for _, desc := range manifest.Layers {
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get artifact %q layer %s: %w", reference, desc.Digest, err)
+		}
+
+		data, err := readLayerBlob(layer)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read artifact %q layer %s: %w", reference, desc.Digest, err)
+		}
+
+		layers = append(layers, ArtifactLayer{
+			MediaType:   string(desc.MediaType),
+			Data:        data,
+			Annotations: desc.Annotations,
+		})
+	}
+
+	return layers, nil
+}
+
+func readLayerBlob(layer v1.Layer) ([]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
 func (api *api) PushManifestList(ctx context.Context, reference string, opts ManifestListOptions) error {
 	if len(opts.Manifests) == 0 {
 		panic("unexpected empty manifests list")
@@ -701,20 +1019,12 @@ func (api *api) PushManifestList(ctx context.Context, reference string, opts Man
 		if err != nil {
 			return fmt.Errorf("unable to get image descriptor of %q: %w", info.Name, err)
 		}
-		cf, err := img.ConfigFile()
-		if err != nil {
-			return fmt.Errorf("unable to get config file of %q: %w", info.Name, err)
-		}
-		newdesc, err := partial.Descriptor(img)
+
+		add, err := newManifestListAddendum(info.Name, img)
 		if err != nil {
-			return fmt.Errorf("unable to create image descriptor of %q: %w", info.Name, err)
+			return err
 		}
-		newdesc.Platform = cf.Platform()
-
-		adds = append(adds, mutate.IndexAddendum{
-			Add:        img,
-			Descriptor: *newdesc,
-		})
+		adds = append(adds, add)
 	}
 
 	// TODO(multiarch): research whether we could use digest-only manifest writing mode
@@ -740,6 +1050,24 @@ func (api *api) PushManifestList(ctx context.Context, reference string, opts Man
 	return nil
 }
 
+// newManifestListAddendum describes the platform image of the manifest list.
+func newManifestListAddendum(reference string, img v1.Image) (mutate.IndexAddendum, error) {
+	cf, err := img.ConfigFile()
+	if err != nil {
+		return mutate.IndexAddendum{}, fmt.Errorf("unable to get config file of %q: %w", reference, err)
+	}
+	newdesc, err := partial.Descriptor(img)
+	if err != nil {
+		return mutate.IndexAddendum{}, fmt.Errorf("unable to create image descriptor of %q: %w", reference, err)
+	}
+	newdesc.Platform = cf.Platform()
+
+	return mutate.IndexAddendum{
+		Add:        img,
+		Descriptor: *newdesc,
+	}, nil
+}
+
 func ValidateRepositoryReference(reference string) error {
 	reg := regexp.MustCompile(`^` + dockerReference.NameRegexp.String() + `$`)
 	if !reg.MatchString(reference) {
@@ -0,0 +1,129 @@
+package docker_registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+const (
+	ArtifactoryImplementationName          = "artifactory"
+	artifactoryRepositoryNotFoundErrPrefix = "artifactory repository not found: "
+)
+
+var artifactoryPatterns = []string{"^.*\\.jfrog\\.io", "^artifactory\\..*"}
+
+type ArtifactoryRepositoryNotFoundErr apiError
+
+func NewArtifactoryRepositoryNotFoundErr(err error) ArtifactoryRepositoryNotFoundErr {
+	return ArtifactoryRepositoryNotFoundErr{
+		error: errors.New(artifactoryRepositoryNotFoundErrPrefix + err.Error()),
+	}
+}
+
+func IsArtifactoryRepositoryNotFoundErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), artifactoryRepositoryNotFoundErrPrefix)
+}
+
+// artifactory works with the docker repositories accessed by the repository path method: HOST/REPOSITORY_KEY/IMAGE.
+// The tags and images are deleted as the artifactory folders, because deleting the manifest by digest through
+// the Docker Registry API removes all tags of the manifest or is not allowed at all depending on the repository settings.
+type artifactory struct {
+	*defaultImplementation
+	artifactoryApi
+}
+
+type artifactoryOptions struct {
+	defaultImplementationOptions
+}
+
+func newArtifactory(options artifactoryOptions) (*artifactory, error) {
+	d, err := newDefaultAPIForImplementation(ArtifactoryImplementationName, options.defaultImplementationOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	artifactory := &artifactory{
+		defaultImplementation: d,
+		artifactoryApi:        newArtifactoryApi(),
+	}
+
+	return artifactory, nil
+}
+
+func (r *artifactory) DeleteRepo(ctx context.Context, reference string) error {
+	hostname, repositoryKey, imagePath, err := r.parseReference(reference)
+	if err != nil {
+		return err
+	}
+
+	username, password, err := resolveBasicAuth(hostname)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.artifactoryApi.DeleteRepository(ctx, hostname, repositoryKey, imagePath, username, password)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return NewArtifactoryRepositoryNotFoundErr(err)
+		}
+
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (r *artifactory) DeleteRepoImage(ctx context.Context, repoImage *image.Info) error {
+	if repoImage.Tag == "" {
+		return r.defaultImplementation.DeleteRepoImage(ctx, repoImage)
+	}
+
+	hostname, repositoryKey, imagePath, err := r.parseReference(repoImage.Repository)
+	if err != nil {
+		return err
+	}
+
+	username, password, err := resolveBasicAuth(hostname)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.artifactoryApi.DeleteTag(ctx, hostname, repositoryKey, imagePath, repoImage.Tag, username, password)
+	if err != nil {
+		// The tag has already been deleted.
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+func (r *artifactory) String() string {
+	return ArtifactoryImplementationName
+}
+
+func (r *artifactory) parseReference(reference string) (string, string, string, error) {
+	parsedReference, err := name.NewRepository(reference)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	parts := strings.SplitN(parsedReference.RepositoryStr(), "/", 2)
+	if len(parts) != 2 {
+		return "", "", "", fmt.Errorf("unexpected reference %s: expected HOST/REPOSITORY_KEY/IMAGE", reference)
+	}
+
+	return parsedReference.RegistryStr(), parts[0], parts[1], nil
+}
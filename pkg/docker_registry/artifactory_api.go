@@ -0,0 +1,44 @@
+package docker_registry
+
+import (
+	"context"
+	"net/http"
+	neturl "net/url"
+	"path"
+)
+
+type artifactoryApi struct{}
+
+func newArtifactoryApi() artifactoryApi {
+	return artifactoryApi{}
+}
+
+// DeleteRepository deletes the image folder with all tags from the docker repository.
+func (api *artifactoryApi) DeleteRepository(ctx context.Context, hostname, repositoryKey, imagePath, username, password string) (*http.Response, error) {
+	return api.deleteItem(ctx, hostname, path.Join(repositoryKey, imagePath), username, password)
+}
+
+// DeleteTag deletes the tag folder of the image, the blobs are removed by the artifactory garbage collector.
+func (api *artifactoryApi) DeleteTag(ctx context.Context, hostname, repositoryKey, imagePath, tag, username, password string) (*http.Response, error) {
+	return api.deleteItem(ctx, hostname, path.Join(repositoryKey, imagePath, tag), username, password)
+}
+
+func (api *artifactoryApi) deleteItem(ctx context.Context, hostname, itemPath, username, password string) (*http.Response, error) {
+	u, err := neturl.Parse("https://" + hostname + "/artifactory")
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = path.Join(u.Path, itemPath)
+	url := u.String()
+
+	resp, _, err := doRequest(ctx, http.MethodDelete, url, nil, doRequestOptions{
+		BasicAuth: doRequestBasicAuth{
+			username: username,
+			password: password,
+		},
+		AcceptedCodes: []int{http.StatusOK, http.StatusAccepted, http.StatusNoContent},
+	})
+
+	return resp, err
+}
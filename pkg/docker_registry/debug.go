@@ -121,6 +121,27 @@ func (r *DockerRegistryTracer) PullImageArchive(ctx context.Context, archiveWrit
 	return
 }
 
+func (r *DockerRegistryTracer) PullImageFilesystem(ctx context.Context, fsWriter io.Writer, reference string) (err error) {
+	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.PullImageFilesystem %q", reference).Do(func() {
+		err = r.DockerRegistry.PullImageFilesystem(ctx, fsWriter, reference)
+	})
+	return
+}
+
+func (r *DockerRegistryTracer) PullImageLayout(ctx context.Context, layoutDir, reference, refName string, opts TransferOptions) (err error) {
+	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.PullImageLayout %q %q", layoutDir, reference).Do(func() {
+		err = r.DockerRegistry.PullImageLayout(ctx, layoutDir, reference, refName, opts)
+	})
+	return
+}
+
+func (r *DockerRegistryTracer) PushImageLayout(ctx context.Context, layoutDir, reference, refName string, opts TransferOptions) (err error) {
+	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.PushImageLayout %q %q", layoutDir, reference).Do(func() {
+		err = r.DockerRegistry.PushImageLayout(ctx, layoutDir, reference, refName, opts)
+	})
+	return
+}
+
 func (r *DockerRegistryTracer) PushManifestList(ctx context.Context, reference string, opts ManifestListOptions) (err error) {
 	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.PushManifestList %q", reference).Do(func() {
 		err = r.DockerRegistry.PushManifestList(ctx, reference, opts)
@@ -128,6 +149,34 @@ func (r *DockerRegistryTracer) PushManifestList(ctx context.Context, reference s
 	return
 }
 
+func (r *DockerRegistryTracer) PushArtifact(ctx context.Context, reference string, opts PushArtifactOptions) (digest string, err error) {
+	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.PushArtifact %q", reference).Do(func() {
+		digest, err = r.DockerRegistry.PushArtifact(ctx, reference, opts)
+	})
+	return
+}
+
+func (r *DockerRegistryTracer) PullArtifactLayers(ctx context.Context, reference string) (layers []ArtifactLayer, err error) {
+	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.PullArtifactLayers %q", reference).Do(func() {
+		layers, err = r.DockerRegistry.PullArtifactLayers(ctx, reference)
+	})
+	return
+}
+
+func (r *DockerRegistryTracer) Referrers(ctx context.Context, reference string, opts ReferrersOptions) (referrers []v1.Descriptor, err error) {
+	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.Referrers %q", reference).Do(func() {
+		referrers, err = r.DockerRegistry.Referrers(ctx, reference, opts)
+	})
+	return
+}
+
+func (r *DockerRegistryTracer) PushReferrer(ctx context.Context, tag string, opts PushArtifactOptions) (digest string, err error) {
+	logboek.Context(ctx).Default().LogProcess("DockerRegistryTracer.PushReferrer %q %q", opts.SubjectReference, tag).Do(func() {
+		digest, err = r.DockerRegistry.PushReferrer(ctx, tag, opts)
+	})
+	return
+}
+
 func (r *DockerRegistryTracer) String() (res string) {
 	return r.DockerRegistry.String()
 }
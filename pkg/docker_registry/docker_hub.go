@@ -131,7 +131,16 @@ func (r *dockerHub) getToken(ctx context.Context) (string, error) {
 
 	//nolint:bodyclose
 	// TODO: close response body
-	token, resp, err := r.dockerHubApi.getToken(ctx, r.dockerHubCredentials.username, r.dockerHubCredentials.password)
+	username, password := r.dockerHubCredentials.username, r.dockerHubCredentials.password
+	if username == "" || password == "" {
+		var err error
+		username, password, err = resolveBasicAuth(name.DefaultRegistry)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	token, resp, err := r.dockerHubApi.getToken(ctx, username, password)
 	if err != nil {
 		return "", r.handleFailedApiResponse(resp, err)
 	}
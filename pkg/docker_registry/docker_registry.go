@@ -26,6 +26,12 @@ type DockerRegistryOptions struct {
 	SelectelPassword      string
 }
 
+func (o *DockerRegistryOptions) artifactoryOptions() artifactoryOptions {
+	return artifactoryOptions{
+		defaultImplementationOptions: o.defaultOptions(),
+	}
+}
+
 func (o *DockerRegistryOptions) awsEcrOptions() awsEcrOptions {
 	return awsEcrOptions{
 		defaultImplementationOptions: o.defaultOptions(),
@@ -80,6 +86,12 @@ func (o *DockerRegistryOptions) harborOptions() harborOptions {
 	}
 }
 
+func (o *DockerRegistryOptions) nexusOptions() nexusOptions {
+	return nexusOptions{
+		defaultImplementationOptions: o.defaultOptions(),
+	}
+}
+
 func (o *DockerRegistryOptions) quayOptions() quayOptions {
 	return quayOptions{
 		defaultImplementationOptions: o.defaultOptions(),
@@ -131,6 +143,8 @@ func newDockerRegistry(repositoryAddress, implementation string, options DockerR
 	}
 
 	switch implementation {
+	case ArtifactoryImplementationName:
+		return newArtifactory(options.artifactoryOptions())
 	case AwsEcrImplementationName:
 		return newAwsEcr(options.awsEcrOptions())
 	case AzureCrImplementationName:
@@ -145,6 +159,8 @@ func newDockerRegistry(repositoryAddress, implementation string, options DockerR
 		return newGitLabRegistry(options.gitLabRegistryOptions())
 	case HarborImplementationName:
 		return newHarbor(options.harborOptions())
+	case NexusImplementationName:
+		return newNexus(options.nexusOptions())
 	case QuayImplementationName:
 		return newQuay(options.quayOptions())
 	case SelectelImplementationName:
@@ -232,6 +248,14 @@ func detectImplementation(accountOrRepositoryAddress string) (string, error) {
 			name:     HarborImplementationName,
 			patterns: harborPatterns,
 		},
+		{
+			name:     ArtifactoryImplementationName,
+			patterns: artifactoryPatterns,
+		},
+		{
+			name:     NexusImplementationName,
+			patterns: nexusPatterns,
+		},
 		{
 			name:     QuayImplementationName,
 			patterns: quayPatterns,
@@ -258,6 +282,7 @@ func detectImplementation(accountOrRepositoryAddress string) (string, error) {
 
 func ImplementationList() []string {
 	return []string{
+		ArtifactoryImplementationName,
 		AwsEcrImplementationName,
 		AzureCrImplementationName,
 		DefaultImplementationName,
@@ -266,6 +291,7 @@ func ImplementationList() []string {
 		GitHubPackagesImplementationName,
 		GitLabRegistryImplementationName,
 		HarborImplementationName,
+		NexusImplementationName,
 		QuayImplementationName,
 		SelectelImplementationName,
 	}
@@ -16,6 +16,14 @@ var _ = DescribeTable("ResolveImplementation", func(entry ResolveImplementationE
 
 	Ω(resolvedImplementation).Should(Equal(entry.expectation))
 },
+	Entry("artifactory", ResolveImplementationEntry{
+		imagesRepoAddress: "company.jfrog.io/docker-local/repo",
+		expectation:       "artifactory",
+	}),
+	Entry("self-hosted artifactory", ResolveImplementationEntry{
+		imagesRepoAddress: "artifactory.company.com/docker-local/repo",
+		expectation:       "artifactory",
+	}),
 	Entry("ecr", ResolveImplementationEntry{
 		imagesRepoAddress: "123456789012.dkr.ecr.test.amazonaws.com/repo",
 		expectation:       "ecr",
@@ -44,6 +52,10 @@ var _ = DescribeTable("ResolveImplementation", func(entry ResolveImplementationE
 		imagesRepoAddress: "harbor.company.com/project/repo",
 		expectation:       "harbor",
 	}),
+	Entry("nexus", ResolveImplementationEntry{
+		imagesRepoAddress: "nexus.company.com:8083/repo",
+		expectation:       "nexus",
+	}),
 	Entry("quay", ResolveImplementationEntry{
 		imagesRepoAddress: "quay.io/account/repo",
 		expectation:       "quay",
@@ -78,6 +78,21 @@ func (r *DockerRegistryWithCache) MutateAndPushImage(ctx context.Context, source
 	return r.Interface.MutateAndPushImage(ctx, sourceReference, destinationReference, mutateConfigFunc)
 }
 
+func (r *DockerRegistryWithCache) PushArtifact(ctx context.Context, reference string, opts PushArtifactOptions) (string, error) {
+	defer r.mustAddTagToCachedTags(reference)
+	return r.Interface.PushArtifact(ctx, reference, opts)
+}
+
+func (r *DockerRegistryWithCache) PushReferrer(ctx context.Context, tag string, opts PushArtifactOptions) (string, error) {
+	if tag != "" {
+		if subjectParts, err := r.parseReferenceParts(opts.SubjectReference); err == nil {
+			defer r.mustAddTagToCachedTags(fmt.Sprintf("%s:%s", subjectParts.repository, tag))
+		}
+	}
+
+	return r.Interface.PushReferrer(ctx, tag, opts)
+}
+
 func (r *DockerRegistryWithCache) DeleteRepoImage(ctx context.Context, repoImage *image.Info) error {
 	defer r.mustDeleteTagFromCachedTags(repoImage.Name)
 	return r.Interface.DeleteRepoImage(ctx, repoImage)
@@ -109,8 +124,9 @@ func (r *DockerRegistryWithCache) mustDeleteTagFromCachedTags(reference string)
 			return nil, fmt.Errorf("unable to parse reference parts %q: %w", reference, err)
 		}
 
-		if !isExist {
-			return nil, nil
+		// the image referred by digest has no tag to delete
+		if !isExist || referenceParts.digest != "" {
+			return tags, nil
 		}
 
 		tags = util.ExcludeFromStringArray(tags, referenceParts.tag)
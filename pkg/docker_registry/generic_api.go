@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -77,6 +78,9 @@ func (api *genericApi) GetRepoImage(ctx context.Context, reference string) (*ima
 			return nil, fmt.Errorf("unable to try getting mirror repo image %q: %w", mirrorReference, err)
 		}
 		if info != nil {
+			if err := api.setOriginalRepoImageReference(info, reference); err != nil {
+				return nil, err
+			}
 			return info, nil
 		}
 	}
@@ -84,6 +88,34 @@ func (api *genericApi) GetRepoImage(ctx context.Context, reference string) (*ima
 	return api.commonApi.GetRepoImage(ctx, reference)
 }
 
+// setOriginalRepoImageReference replaces the mirror repository with the original one,
+// so that the image info (and the digests of the stages based on it) does not depend on the mirror used.
+func (api *genericApi) setOriginalRepoImageReference(info *image.Info, reference string) error {
+	referenceParts, err := api.commonApi.parseReferenceParts(reference)
+	if err != nil {
+		return fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	repository := strings.Join([]string{referenceParts.registry, referenceParts.repository}, "/")
+
+	var setFunc func(info *image.Info, name string)
+	setFunc = func(info *image.Info, name string) {
+		if _, digest, found := strings.Cut(info.RepoDigest, "@"); found {
+			info.RepoDigest = fmt.Sprintf("%s@%s", image.NormalizeRepository(repository), digest)
+		}
+		info.Name = name
+		info.Repository = repository
+
+		for _, subInfo := range info.Index {
+			_, digest, _ := strings.Cut(subInfo.Name, "@")
+			setFunc(subInfo, fmt.Sprintf("%s@%s", repository, digest))
+		}
+	}
+	setFunc(info, reference)
+
+	return nil
+}
+
 func (api *genericApi) mirrorReferenceList(ctx context.Context, reference string) ([]string, error) {
 	var referenceList []string
 
@@ -92,9 +124,15 @@ func (api *genericApi) mirrorReferenceList(ctx context.Context, reference string
 		return nil, fmt.Errorf("unable to parse reference %q: %w", reference, err)
 	}
 
-	// nothing if container registry is not Docker Hub
+	// werf mirrors have priority over docker daemon mirrors
+	referenceList, err = RegistryMirrorReferences(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	// docker daemon mirrors are only used for Docker Hub
 	if referenceParts.registry != name.DefaultRegistry {
-		return nil, nil
+		return referenceList, nil
 	}
 
 	mirrors, err := api.getOrCreateRegistryMirrors(ctx)
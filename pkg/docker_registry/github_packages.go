@@ -73,9 +73,21 @@ func newGitHubPackages(options gitHubPackagesOptions) (*gitHubPackages, error) {
 		return nil, err
 	}
 
+	credentials := options.gitHubCredentials
+	if credentials.token == "" {
+		authConfig, err := RegistryAuthConfig(GitHubPackagesRegistryAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		if authConfig != nil {
+			credentials.token = authConfig.Password
+		}
+	}
+
 	gitHub := &gitHubPackages{
 		defaultImplementation:      d,
-		gitHubCredentials:          options.gitHubCredentials,
+		gitHubCredentials:          credentials,
 		gitHubApi:                  newGitHubApi(),
 		isUserCache:                sync.Map{},
 		tagIDPackageVersionIDCache: map[string]string{},
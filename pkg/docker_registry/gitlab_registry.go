@@ -8,7 +8,6 @@ import (
 	"net/url"
 	"strings"
 
-	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 
@@ -137,7 +136,7 @@ func (r *gitLabRegistry) customDeleteRepoImage(endpointFormat, reference string,
 		return fmt.Errorf("parsing reference %q: %w", reference, err)
 	}
 
-	auth, authErr := authn.DefaultKeychain.Resolve(ref.Context().Registry)
+	auth, authErr := Keychain().Resolve(ref.Context().Registry)
 	if authErr != nil {
 		return fmt.Errorf("getting creds for %q: %w", ref, authErr)
 	}
@@ -69,7 +69,12 @@ func (r *harbor) deleteRepo(ctx context.Context, reference string) error {
 		return err
 	}
 
-	resp, err := r.harborApi.DeleteRepository(ctx, hostname, repository, r.harborCredentials.username, r.harborCredentials.password)
+	username, password, err := r.getCredentials(hostname)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.harborApi.DeleteRepository(ctx, hostname, repository, username, password)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			return NewHarborRepositoryNotFoundErr(err)
@@ -82,6 +87,15 @@ func (r *harbor) deleteRepo(ctx context.Context, reference string) error {
 	return nil
 }
 
+// getCredentials returns the Harbor credentials from the options or the registry credentials of the host.
+func (r *harbor) getCredentials(hostname string) (string, string, error) {
+	if r.harborCredentials.username != "" && r.harborCredentials.password != "" {
+		return r.harborCredentials.username, r.harborCredentials.password, nil
+	}
+
+	return resolveBasicAuth(hostname)
+}
+
 func (r *harbor) String() string {
 	return HarborImplementationName
 }
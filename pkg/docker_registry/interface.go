@@ -29,7 +29,14 @@ type Interface interface {
 
 	PushImageArchive(ctx context.Context, archiveOpener ArchiveOpener, reference string) error
 	PullImageArchive(ctx context.Context, archiveWriter io.Writer, reference string) error
+	PullImageFilesystem(ctx context.Context, fsWriter io.Writer, reference string) error
+	PullImageLayout(ctx context.Context, layoutDir, reference, refName string, opts TransferOptions) error
+	PushImageLayout(ctx context.Context, layoutDir, reference, refName string, opts TransferOptions) error
 	PushManifestList(ctx context.Context, reference string, opts ManifestListOptions) error
+	PushArtifact(ctx context.Context, reference string, opts PushArtifactOptions) (string, error)
+	PullArtifactLayers(ctx context.Context, reference string) ([]ArtifactLayer, error)
+	Referrers(ctx context.Context, reference string, opts ReferrersOptions) ([]v1.Descriptor, error)
+	PushReferrer(ctx context.Context, tag string, opts PushArtifactOptions) (string, error)
 
 	String() string
 
@@ -50,8 +57,29 @@ type ManifestListOptions struct {
 	Manifests []*image.Info
 }
 
+// PushArtifactOptions describes an OCI artifact manifest, the artifact refers to the SubjectReference image if specified.
+type PushArtifactOptions struct {
+	ArtifactType     string
+	SubjectReference string
+	Layers           []ArtifactLayer
+	Annotations      map[string]string
+}
+
+// ReferrersOptions filters the referrers by the artifact type if specified.
+type ReferrersOptions struct {
+	ArtifactType string
+}
+
+type ArtifactLayer struct {
+	MediaType   string
+	Data        []byte
+	Annotations map[string]string
+}
+
 type GetRepoImageOptions struct {
 	IsImageIndex bool
 }
 
-type CopyImageOptions struct{}
+type CopyImageOptions struct {
+	TransferOptions
+}
@@ -0,0 +1,259 @@
+package docker_registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+const (
+	NexusImplementationName          = "nexus"
+	nexusRepositoryNotFoundErrPrefix = "nexus repository not found: "
+)
+
+var nexusPatterns = []string{"^nexus\\..*"}
+
+type NexusRepositoryNotFoundErr apiError
+
+func NewNexusRepositoryNotFoundErr(err error) NexusRepositoryNotFoundErr {
+	return NexusRepositoryNotFoundErr{
+		error: errors.New(nexusRepositoryNotFoundErrPrefix + err.Error()),
+	}
+}
+
+func IsNexusRepositoryNotFoundErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), nexusRepositoryNotFoundErrPrefix)
+}
+
+// nexus deletes the image tags as the Nexus components through the REST API, because the Docker Registry API
+// deletes the manifest with all its tags. The REST API is served on the registry host without the connector port.
+// The components are always searched within the nexus repository serving the image, which is resolved by the connector port,
+// the subdomain or the first path element of the image name (path based routing), so the same images of other repositories are never deleted.
+type nexus struct {
+	*defaultImplementation
+	nexusApi
+
+	// apiURL is the REST API address, https://REGISTRY_HOST if empty
+	apiURL string
+
+	repositoriesMutex sync.Mutex
+	repositories      map[string][]nexusRepository
+}
+
+type nexusOptions struct {
+	defaultImplementationOptions
+}
+
+func newNexus(options nexusOptions) (*nexus, error) {
+	d, err := newDefaultAPIForImplementation(NexusImplementationName, options.defaultImplementationOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	nexus := &nexus{
+		defaultImplementation: d,
+		nexusApi:              newNexusApi(),
+	}
+
+	return nexus, nil
+}
+
+func (r *nexus) DeleteRepo(ctx context.Context, reference string) error {
+	registry, imageName, err := r.parseReference(reference)
+	if err != nil {
+		return err
+	}
+
+	components, err := r.searchComponents(ctx, registry, imageName, "")
+	if err != nil {
+		return err
+	}
+
+	if len(components) == 0 {
+		return NewNexusRepositoryNotFoundErr(fmt.Errorf("no components found for %s", reference))
+	}
+
+	return r.deleteComponents(ctx, registry, components)
+}
+
+func (r *nexus) DeleteRepoImage(ctx context.Context, repoImage *image.Info) error {
+	if repoImage.Tag == "" {
+		return r.defaultImplementation.DeleteRepoImage(ctx, repoImage)
+	}
+
+	registry, imageName, err := r.parseReference(repoImage.Repository)
+	if err != nil {
+		return err
+	}
+
+	components, err := r.searchComponents(ctx, registry, imageName, repoImage.Tag)
+	if err != nil {
+		return err
+	}
+
+	// The tag has already been deleted if there are no components.
+	return r.deleteComponents(ctx, registry, components)
+}
+
+// searchComponents finds the components of the image in the nexus repository serving the image.
+func (r *nexus) searchComponents(ctx context.Context, registry, imageName, tag string) ([]nexusComponent, error) {
+	repository, componentName, err := r.resolveRepository(ctx, registry, imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	username, password, err := resolveBasicAuth(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	query := neturl.Values{}
+	query.Set("repository", repository)
+	query.Set("name", componentName)
+	if tag != "" {
+		query.Set("version", tag)
+	}
+
+	components, _, err := r.nexusApi.SearchComponents(ctx, r.getApiURL(registry), query, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// the search matches the name and the version loosely (case-insensitive, wildcards)
+	var res []nexusComponent
+	for _, component := range components {
+		if component.Repository != repository || component.Name != componentName || (tag != "" && component.Version != tag) {
+			continue
+		}
+		res = append(res, component)
+	}
+
+	return res, nil
+}
+
+// resolveRepository returns the name of the nexus docker repository serving the image and the image name within the repository.
+// The repository is selected by the connector port of the registry, the subdomain or the first path element of the image name.
+func (r *nexus) resolveRepository(ctx context.Context, registry, imageName string) (string, string, error) {
+	repositories, err := r.getDockerRepositories(ctx, registry)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get nexus repositories: %w", err)
+	}
+
+	host, port, err := net.SplitHostPort(registry)
+	if err != nil {
+		host = registry
+	}
+
+	if port != "" {
+		for _, repository := range repositories {
+			if strconv.Itoa(repository.Docker.HttpPort) == port || strconv.Itoa(repository.Docker.HttpsPort) == port {
+				return repository.Name, imageName, nil
+			}
+		}
+	}
+
+	for _, repository := range repositories {
+		if repository.Docker.Subdomain != "" && strings.HasPrefix(host, repository.Docker.Subdomain+".") {
+			return repository.Name, imageName, nil
+		}
+	}
+
+	if parts := strings.SplitN(imageName, "/", 2); len(parts) == 2 {
+		for _, repository := range repositories {
+			if repository.Name == parts[0] {
+				return repository.Name, parts[1], nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("unable to find the nexus docker repository serving %s/%s: no repository with the connector port, the subdomain or the name of the first path element", registry, imageName)
+}
+
+func (r *nexus) getDockerRepositories(ctx context.Context, registry string) ([]nexusRepository, error) {
+	r.repositoriesMutex.Lock()
+	defer r.repositoriesMutex.Unlock()
+
+	if repositories, ok := r.repositories[registry]; ok {
+		return repositories, nil
+	}
+
+	username, password, err := resolveBasicAuth(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	repositories, _, err := r.nexusApi.GetRepositories(ctx, r.getApiURL(registry), username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var dockerRepositories []nexusRepository
+	for _, repository := range repositories {
+		if repository.Format == "docker" && repository.Docker != nil {
+			dockerRepositories = append(dockerRepositories, repository)
+		}
+	}
+
+	if r.repositories == nil {
+		r.repositories = map[string][]nexusRepository{}
+	}
+	r.repositories[registry] = dockerRepositories
+
+	return dockerRepositories, nil
+}
+
+func (r *nexus) deleteComponents(ctx context.Context, registry string, components []nexusComponent) error {
+	username, password, err := resolveBasicAuth(registry)
+	if err != nil {
+		return err
+	}
+
+	for _, component := range components {
+		resp, err := r.nexusApi.DeleteComponent(ctx, r.getApiURL(registry), component.ID, username, password)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue
+			}
+
+			return fmt.Errorf("unable to delete nexus component %s:%s (%s): %w", component.Name, component.Version, component.Repository, err)
+		}
+	}
+
+	return nil
+}
+
+// getApiURL drops the port of the docker connector, the REST API is served on the main nexus port.
+func (r *nexus) getApiURL(registry string) string {
+	if r.apiURL != "" {
+		return r.apiURL
+	}
+
+	if host, _, err := net.SplitHostPort(registry); err == nil {
+		return "https://" + host
+	}
+
+	return "https://" + registry
+}
+
+func (r *nexus) String() string {
+	return NexusImplementationName
+}
+
+func (r *nexus) parseReference(reference string) (string, string, error) {
+	parsedReference, err := name.NewRepository(reference)
+	if err != nil {
+		return "", "", err
+	}
+
+	return parsedReference.RegistryStr(), parsedReference.RepositoryStr(), nil
+}
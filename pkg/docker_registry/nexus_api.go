@@ -0,0 +1,120 @@
+package docker_registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"path"
+)
+
+type nexusApi struct{}
+
+type nexusComponent struct {
+	ID         string `json:"id"`
+	Repository string `json:"repository"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+}
+
+type nexusRepository struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Docker *struct {
+		HttpPort  int    `json:"httpPort"`
+		HttpsPort int    `json:"httpsPort"`
+		Subdomain string `json:"subdomain"`
+	} `json:"docker"`
+}
+
+func newNexusApi() nexusApi {
+	return nexusApi{}
+}
+
+// GetRepositories returns the repositories with their settings, the docker repositories include the connector ports and the subdomain.
+func (api *nexusApi) GetRepositories(ctx context.Context, apiURL, username, password string) ([]nexusRepository, *http.Response, error) {
+	resp, respBody, err := doRequest(ctx, http.MethodGet, apiURL+"/service/rest/v1/repositorySettings", nil, doRequestOptions{
+		Headers: map[string]string{
+			"Accept": "application/json",
+		},
+		BasicAuth: doRequestBasicAuth{
+			username: username,
+			password: password,
+		},
+		AcceptedCodes: []int{http.StatusOK},
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+
+	var repositories []nexusRepository
+	if err := json.Unmarshal(respBody, &repositories); err != nil {
+		return nil, resp, fmt.Errorf("unexpected nexus api response body: %w", err)
+	}
+
+	return repositories, resp, nil
+}
+
+// SearchComponents returns the docker components (image tags) matching the query, the name is the image name and the version is the tag.
+func (api *nexusApi) SearchComponents(ctx context.Context, apiURL string, query neturl.Values, username, password string) ([]nexusComponent, *http.Response, error) {
+	query.Set("format", "docker")
+
+	var components []nexusComponent
+	for {
+		u, err := neturl.Parse(apiURL + "/service/rest/v1/search")
+		if err != nil {
+			return nil, nil, err
+		}
+		u.RawQuery = query.Encode()
+
+		resp, respBody, err := doRequest(ctx, http.MethodGet, u.String(), nil, doRequestOptions{
+			Headers: map[string]string{
+				"Accept": "application/json",
+			},
+			BasicAuth: doRequestBasicAuth{
+				username: username,
+				password: password,
+			},
+			AcceptedCodes: []int{http.StatusOK},
+		})
+		if err != nil {
+			return nil, resp, err
+		}
+
+		var page struct {
+			Items             []nexusComponent `json:"items"`
+			ContinuationToken string           `json:"continuationToken"`
+		}
+		if err := json.Unmarshal(respBody, &page); err != nil {
+			return nil, resp, fmt.Errorf("unexpected nexus api response body: %w", err)
+		}
+
+		components = append(components, page.Items...)
+
+		if page.ContinuationToken == "" {
+			return components, resp, nil
+		}
+		query.Set("continuationToken", page.ContinuationToken)
+	}
+}
+
+func (api *nexusApi) DeleteComponent(ctx context.Context, apiURL, id, username, password string) (*http.Response, error) {
+	u, err := neturl.Parse(apiURL + "/service/rest/v1/components")
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = path.Join(u.Path, id)
+	url := u.String()
+
+	resp, _, err := doRequest(ctx, http.MethodDelete, url, nil, doRequestOptions{
+		BasicAuth: doRequestBasicAuth{
+			username: username,
+			password: password,
+		},
+		AcceptedCodes: []int{http.StatusOK, http.StatusNoContent},
+	})
+
+	return resp, err
+}
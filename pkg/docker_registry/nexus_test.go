@@ -0,0 +1,167 @@
+package docker_registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+// nexusTestServer serves the repositories, the search with pagination and the components deletion of the Nexus REST API.
+// The search without the repository filter returns the components of all repositories as Nexus does.
+type nexusTestServer struct {
+	*httptest.Server
+
+	mutex      sync.Mutex
+	components []nexusComponent
+	queries    []neturl.Values
+	deleted    []string
+}
+
+func newNexusTestServer(components []nexusComponent) *nexusTestServer {
+	s := &nexusTestServer{components: components}
+
+	repositories := `[
+		{"name": "docker-hosted", "format": "docker", "docker": {"httpPort": 8082, "httpsPort": 8083}},
+		{"name": "docker-other", "format": "docker", "docker": {"httpsPort": 8084}},
+		{"name": "docker-sub", "format": "docker", "docker": {"subdomain": "sub"}},
+		{"name": "maven-releases", "format": "maven2"}
+	]`
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/service/rest/v1/repositorySettings", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(repositories))
+	})
+	mux.HandleFunc("/service/rest/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		query := r.URL.Query()
+		s.queries = append(s.queries, query)
+
+		var items []nexusComponent
+		for _, component := range s.components {
+			if repository := query.Get("repository"); repository != "" && component.Repository != repository {
+				continue
+			}
+			if component.Name != query.Get("name") {
+				continue
+			}
+			if version := query.Get("version"); version != "" && component.Version != version {
+				continue
+			}
+			items = append(items, component)
+		}
+
+		// one component per page
+		page := map[string]interface{}{"items": []nexusComponent{}}
+		start := 0
+		if token := query.Get("continuationToken"); token != "" {
+			start = len(token)
+		}
+		if start < len(items) {
+			page["items"] = items[start : start+1]
+			if start+1 < len(items) {
+				page["continuationToken"] = strings.Repeat("t", start+1)
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(page)
+	})
+	mux.HandleFunc("/service/rest/v1/components/", func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.deleted = append(s.deleted, strings.TrimPrefix(r.URL.Path, "/service/rest/v1/components/"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+var _ = Describe("Nexus", func() {
+	var server *nexusTestServer
+	var registry *nexus
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		server = newNexusTestServer([]nexusComponent{
+			{ID: "hosted-v1", Repository: "docker-hosted", Name: "project", Version: "v1"},
+			{ID: "hosted-v2", Repository: "docker-hosted", Name: "project", Version: "v2"},
+			{ID: "hosted-other-v1", Repository: "docker-hosted", Name: "project-other", Version: "v1"},
+			{ID: "other-v1", Repository: "docker-other", Name: "project", Version: "v1"},
+			{ID: "other-path-v1", Repository: "docker-other", Name: "docker-hosted/project", Version: "v1"},
+			{ID: "sub-v1", Repository: "docker-sub", Name: "project", Version: "v1"},
+		})
+		DeferCleanup(server.Close)
+
+		registry = &nexus{nexusApi: newNexusApi(), apiURL: server.URL}
+	})
+
+	expectSearchedOnlyIn := func(repository string) {
+		Expect(server.queries).NotTo(BeEmpty())
+		for _, query := range server.queries {
+			Expect(query.Get("repository")).To(Equal(repository))
+		}
+	}
+
+	DescribeTable("deleting the tag in the repository serving the image",
+		func(repository, expectedNexusRepository string, expectedDeleted []string) {
+			Expect(registry.DeleteRepoImage(ctx, &image.Info{Repository: repository, Tag: "v1"})).To(Succeed())
+
+			expectSearchedOnlyIn(expectedNexusRepository)
+			Expect(server.deleted).To(Equal(expectedDeleted))
+		},
+		Entry("by the http connector port", "nexus.example.com:8082/project", "docker-hosted", []string{"hosted-v1"}),
+		Entry("by the https connector port", "nexus.example.com:8084/project", "docker-other", []string{"other-v1"}),
+		Entry("by the subdomain", "sub.nexus.example.com/project", "docker-sub", []string{"sub-v1"}),
+		Entry("by the path", "nexus.example.com/docker-hosted/project", "docker-hosted", []string{"hosted-v1"}),
+	)
+
+	It("should delete all tags of the image in the repository", func() {
+		Expect(registry.DeleteRepo(ctx, "nexus.example.com:8082/project")).To(Succeed())
+
+		expectSearchedOnlyIn("docker-hosted")
+		Expect(server.deleted).To(Equal([]string{"hosted-v1", "hosted-v2"}))
+	})
+
+	It("should not fail if the tag is already deleted", func() {
+		Expect(registry.DeleteRepoImage(ctx, &image.Info{Repository: "nexus.example.com:8082/project", Tag: "v3"})).To(Succeed())
+		Expect(server.deleted).To(BeEmpty())
+	})
+
+	It("should fail if the repository of the image is unknown", func() {
+		for _, repository := range []string{"nexus.example.com:5000/project", "nexus.example.com/unknown/project", "nexus.example.com/project"} {
+			err := registry.DeleteRepoImage(ctx, &image.Info{Repository: repository, Tag: "v1"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unable to find the nexus docker repository"))
+
+			err = registry.DeleteRepo(ctx, repository)
+			Expect(err).To(HaveOccurred())
+			Expect(IsNexusRepositoryNotFoundErr(err)).To(BeFalse())
+		}
+
+		Expect(server.queries).To(BeEmpty())
+		Expect(server.deleted).To(BeEmpty())
+	})
+
+	It("should report the missing image repository", func() {
+		err := registry.DeleteRepo(ctx, "nexus.example.com:8082/missing")
+		Expect(IsNexusRepositoryNotFoundErr(err)).To(BeTrue())
+		Expect(server.deleted).To(BeEmpty())
+	})
+})
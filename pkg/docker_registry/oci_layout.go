@@ -0,0 +1,887 @@
+package docker_registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/werf/lockgate"
+	"github.com/werf/lockgate/pkg/file_locker"
+	"github.com/werf/werf/pkg/docker_registry/container_registry_extensions"
+	"github.com/werf/werf/pkg/image"
+)
+
+const (
+	OCILayoutImplementationName = "oci-layout"
+
+	ociLayoutLocksDir       = ".werf-locks"
+	ociLayoutBlobsLockName  = "blobs"
+	ociLayoutIndexLockName  = "index.json"
+	ociLayoutIndexFileName  = "index.json"
+	ociLayoutLayoutFileName = "oci-layout"
+)
+
+// ociLayout stores the images in the OCI image layout directory instead of the container registry.
+// The images of the layout are addressed as repository:tag (the tag is kept in the org.opencontainers.image.ref.name annotation) or repository@digest,
+// where the repository is an arbitrary name of the layout, so the images could be used by the container runtime with the same names.
+// The references to other repositories are accessed in the container registry when copying images into or out of the layout.
+//
+// The layout could be shared by several werf processes, e.g. on NFS: blobs are written under the shared lock and removed under the exclusive one,
+// the index.json is updated under the exclusive lock.
+type ociLayout struct {
+	dir        string
+	repository string
+	locker     lockgate.Locker
+	api        *api
+}
+
+func NewOCILayout(dir, repository string, options DockerRegistryOptions) (Interface, error) {
+	if err := ValidateRepositoryReference(repository); err != nil {
+		return nil, err
+	}
+
+	locker, err := file_locker.NewFileLocker(filepath.Join(dir, ociLayoutLocksDir))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create OCI layout %q locker: %w", dir, err)
+	}
+
+	var res Interface = &ociLayout{
+		dir:        dir,
+		repository: repository,
+		locker:     locker,
+		api:        newAPI(options.defaultOptions().apiOptions),
+	}
+
+	if debugDockerRegistry() {
+		res = NewDockerRegistryTracer(res, nil)
+	}
+
+	return newDockerRegistryWithCache(res), nil
+}
+
+func (l *ociLayout) CreateRepo(_ context.Context, _ string) error {
+	return l.withIndexLock(true, func(_ layout.Path) error {
+		return nil
+	})
+}
+
+func (l *ociLayout) DeleteRepo(_ context.Context, _ string) error {
+	return l.withBlobsLock(true, func() error {
+		return l.withIndexLock(true, func(_ layout.Path) error {
+			for _, name := range []string{"blobs", ociLayoutIndexFileName, ociLayoutLayoutFileName} {
+				if err := os.RemoveAll(filepath.Join(l.dir, name)); err != nil {
+					return fmt.Errorf("unable to remove %q: %w", filepath.Join(l.dir, name), err)
+				}
+			}
+
+			return nil
+		})
+	})
+}
+
+func (l *ociLayout) Tags(_ context.Context, reference string, _ ...Option) ([]string, error) {
+	if _, err := l.parseLayoutReference(reference); err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	if err := l.withIndexLock(false, func(layoutPath layout.Path) error {
+		manifests, err := l.getManifests(layoutPath)
+		if err != nil {
+			return err
+		}
+
+		for _, desc := range manifests {
+			if tag := desc.Annotations[ocispec.AnnotationRefName]; tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (l *ociLayout) IsTagExist(_ context.Context, reference string, _ ...Option) (bool, error) {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return false, err
+	}
+
+	var exist bool
+	err = l.withIndexLock(false, func(layoutPath layout.Path) error {
+		desc, err := l.findDescriptor(layoutPath, parts)
+		exist = desc != nil
+		return err
+	})
+
+	return exist, err
+}
+
+func (l *ociLayout) TagRepoImage(_ context.Context, repoImage *image.Info, tag string) error {
+	parts, err := l.parseLayoutReference(repoImage.Name)
+	if err != nil {
+		return err
+	}
+
+	return l.withIndexLock(true, func(layoutPath layout.Path) error {
+		desc, err := l.findDescriptor(layoutPath, parts)
+		if err != nil {
+			return err
+		}
+		if desc == nil {
+			return newOCILayoutImageNotFoundError(repoImage.Name)
+		}
+
+		if err := layoutPath.RemoveDescriptors(match.Annotation(ocispec.AnnotationRefName, tag)); err != nil {
+			return fmt.Errorf("unable to untag %q: %w", tag, err)
+		}
+
+		newDesc := *desc
+		newDesc.Annotations = map[string]string{ocispec.AnnotationRefName: tag}
+		if err := layoutPath.AppendDescriptor(newDesc); err != nil {
+			return fmt.Errorf("unable to tag %q: %w", tag, err)
+		}
+
+		return nil
+	})
+}
+
+func (l *ociLayout) TryGetRepoImage(ctx context.Context, reference string) (*image.Info, error) {
+	info, err := l.GetRepoImage(ctx, reference)
+	if IsImageNotFoundError(err) {
+		return nil, nil
+	}
+
+	return info, err
+}
+
+func (l *ociLayout) GetRepoImage(_ context.Context, reference string) (*image.Info, error) {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var repoImage *image.Info
+	err = l.withImageOrIndex(reference, func(imageOrIndex interface{}) error {
+		repoImage = &image.Info{
+			Name:       reference,
+			Repository: l.repository,
+			Tag:        parts.tag,
+		}
+
+		switch i := imageOrIndex.(type) {
+		case v1.Image:
+			return setRepoImageInfo(repoImage, i)
+		case v1.ImageIndex:
+			repoImage.IsIndex = true
+
+			digest, err := i.Digest()
+			if err != nil {
+				return fmt.Errorf("error getting image index digest: %w", err)
+			}
+			repoImage.RepoDigest = fmt.Sprintf("%s@%s", image.NormalizeRepository(l.repository), digest)
+
+			im, err := i.IndexManifest()
+			if err != nil {
+				return fmt.Errorf("error getting image manifest: %w", err)
+			}
+
+			for _, desc := range im.Manifests {
+				img, err := i.Image(desc.Digest)
+				if err != nil {
+					return fmt.Errorf("error getting image %s: %w", desc.Digest, err)
+				}
+
+				subInfo := &image.Info{
+					Name:       fmt.Sprintf("%s@%s", l.repository, desc.Digest),
+					Repository: l.repository,
+					Tag:        parts.tag,
+				}
+				if err := setRepoImageInfo(subInfo, img); err != nil {
+					return fmt.Errorf("error getting image %s info: %w", desc.Digest, err)
+				}
+				repoImage.Index = append(repoImage.Index, subInfo)
+			}
+
+			return nil
+		default:
+			panic(fmt.Sprintf("unexpected object type %#v", i))
+		}
+	})
+
+	return repoImage, err
+}
+
+// DeleteRepoImage removes all manifests with the image digest from the index and the blobs not used by other images.
+func (l *ociLayout) DeleteRepoImage(_ context.Context, repoImage *image.Info) error {
+	_, digest, found := strings.Cut(repoImage.RepoDigest, "@")
+	if !found {
+		return fmt.Errorf("unable to get image %q digest", repoImage.Name)
+	}
+
+	hash, err := v1.NewHash(digest)
+	if err != nil {
+		return fmt.Errorf("invalid image %q digest %q: %w", repoImage.Name, digest, err)
+	}
+
+	return l.withBlobsLock(true, func() error {
+		return l.withIndexLock(true, func(layoutPath layout.Path) error {
+			if err := layoutPath.RemoveDescriptors(match.Digests(hash)); err != nil {
+				return fmt.Errorf("unable to remove image %q: %w", repoImage.Name, err)
+			}
+
+			//nolint:staticcheck
+			unusedBlobs, err := layoutPath.GarbageCollect()
+			if err != nil {
+				return fmt.Errorf("unable to get unused blobs: %w", err)
+			}
+
+			for _, blob := range unusedBlobs {
+				if err := layoutPath.RemoveBlob(blob); err != nil {
+					return fmt.Errorf("unable to remove blob %s: %w", blob, err)
+				}
+			}
+
+			return nil
+		})
+	})
+}
+
+func (l *ociLayout) PushImage(_ context.Context, reference string, opts *PushImageOptions) error {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]string{}
+	if opts != nil {
+		labels = opts.Labels
+	}
+
+	return l.writeImageOrIndex(layoutRefName(parts), container_registry_extensions.NewManifestOnlyImage(labels))
+}
+
+// CopyImage copies the image within the layout, from the container registry into the layout and from the layout into the container registry.
+func (l *ociLayout) CopyImage(ctx context.Context, sourceReference, destinationReference string, opts CopyImageOptions) error {
+	copyFunc := func(imageOrIndex interface{}) error {
+		if dstParts, ok := l.getLayoutReference(destinationReference); ok {
+			return l.writeImageOrIndex(layoutRefName(dstParts), imageOrIndex)
+		}
+
+		dstRef, err := name.ParseReference(destinationReference, l.api.parseReferenceOptions()...)
+		if err != nil {
+			return fmt.Errorf("parsing reference %q: %w", destinationReference, err)
+		}
+
+		return l.api.copyToRemote(ctx, dstRef, imageOrIndex, opts.TransferOptions)
+	}
+
+	if _, ok := l.getLayoutReference(sourceReference); ok {
+		return l.withImageOrIndex(sourceReference, copyFunc)
+	}
+
+	desc, _, err := l.api.getImageDesc(ctx, sourceReference)
+	if err != nil {
+		return fmt.Errorf("unable to get image %s: %w", sourceReference, err)
+	}
+
+	imageOrIndex, err := getDescImageOrIndex(desc)
+	if err != nil {
+		return fmt.Errorf("unable to resolve %s: %w", sourceReference, err)
+	}
+
+	return copyFunc(imageOrIndex)
+}
+
+// MutateAndPushImage mutates the layout image and pushes it into the container registry.
+func (l *ociLayout) MutateAndPushImage(ctx context.Context, sourceReference, destinationReference string, mutateConfigFunc func(v1.Config) (v1.Config, error)) error {
+	if _, ok := l.getLayoutReference(destinationReference); ok {
+		return fmt.Errorf("unable to push mutated image %q: mutating images within the OCI layout is not supported", destinationReference)
+	}
+
+	dstRef, err := name.ParseReference(destinationReference, l.api.parseReferenceOptions()...)
+	if err != nil {
+		return fmt.Errorf("parsing reference %q: %w", destinationReference, err)
+	}
+	_, isDstDigest := dstRef.(name.Digest)
+
+	return l.withImageOrIndex(sourceReference, func(imageOrIndex interface{}) error {
+		_, err := l.api.mutateImageOrIndex(ctx, imageOrIndex, mutateConfigFunc, dstRef, isDstDigest)
+		return err
+	})
+}
+
+func (l *ociLayout) PushImageArchive(_ context.Context, archiveOpener ArchiveOpener, reference string) error {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return err
+	}
+
+	img, err := tarball.Image(archiveOpener.Open, nil)
+	if err != nil {
+		return fmt.Errorf("unable to open tarball image: %w", err)
+	}
+
+	return l.writeImageOrIndex(layoutRefName(parts), img)
+}
+
+func (l *ociLayout) PullImageArchive(_ context.Context, archiveWriter io.Writer, reference string) error {
+	ref, err := name.ParseReference(reference, l.api.parseReferenceOptions()...)
+	if err != nil {
+		return fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	return l.withImage(reference, func(img v1.Image) error {
+		return tarball.Write(ref, img, archiveWriter)
+	})
+}
+
+func (l *ociLayout) PullImageFilesystem(_ context.Context, fsWriter io.Writer, reference string) error {
+	return l.withImage(reference, func(img v1.Image) error {
+		fsReader := mutate.Extract(img)
+		defer fsReader.Close()
+
+		if _, err := io.Copy(fsWriter, fsReader); err != nil {
+			return fmt.Errorf("unable to extract image %q filesystem: %w", reference, err)
+		}
+
+		return nil
+	})
+}
+
+func (l *ociLayout) PullImageLayout(_ context.Context, layoutDir, reference, refName string, opts TransferOptions) error {
+	return l.withImageOrIndex(reference, func(imageOrIndex interface{}) error {
+		layoutPath, err := openImageLayout(layoutDir)
+		if err != nil {
+			return err
+		}
+
+		if err := writeBlobsToLayout(layoutPath, imageOrIndex, opts); err != nil {
+			return fmt.Errorf("unable to write %q blobs into layout %q: %w", reference, layoutDir, err)
+		}
+
+		if err := writeImageLayout(layoutPath, refName, imageOrIndex); err != nil {
+			return fmt.Errorf("unable to write %q into layout %q: %w", reference, layoutDir, err)
+		}
+
+		return nil
+	})
+}
+
+func (l *ociLayout) PushImageLayout(_ context.Context, layoutDir, reference, refName string, _ TransferOptions) error {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return err
+	}
+
+	imageOrIndex, err := ReadImageLayout(layoutDir, refName)
+	if err != nil {
+		return err
+	}
+
+	return l.writeImageOrIndex(layoutRefName(parts), imageOrIndex)
+}
+
+func (l *ociLayout) PushManifestList(_ context.Context, reference string, opts ManifestListOptions) error {
+	if len(opts.Manifests) == 0 {
+		panic("unexpected empty manifests list")
+	}
+
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return err
+	}
+
+	return l.withBlobsLock(false, func() error {
+		var ii v1.ImageIndex
+		ii = empty.Index
+		ii = mutate.IndexMediaType(ii, types.DockerManifestList)
+
+		adds := make([]mutate.IndexAddendum, 0, len(opts.Manifests))
+		for _, info := range opts.Manifests {
+			imageOrIndex, err := l.getImageOrIndex(info.Name)
+			if err != nil {
+				return err
+			}
+
+			img, ok := imageOrIndex.(v1.Image)
+			if !ok {
+				return fmt.Errorf("unable to add %q into manifest list: image expected", info.Name)
+			}
+
+			add, err := newManifestListAddendum(info.Name, img)
+			if err != nil {
+				return err
+			}
+			adds = append(adds, add)
+		}
+
+		ii = mutate.AppendManifests(ii, adds...)
+
+		if err := l.writeImageOrIndexWithoutBlobsLock(layoutRefName(parts), ii); err != nil {
+			return fmt.Errorf("unable to write manifest %q: %w", reference, err)
+		}
+
+		return nil
+	})
+}
+
+func (l *ociLayout) PushArtifact(_ context.Context, reference string, opts PushArtifactOptions) (string, error) {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return "", err
+	}
+
+	return l.pushArtifact(layoutRefName(parts), opts)
+}
+
+// PushReferrer writes the artifact referring to the SubjectReference image with the tag or without tag if not specified.
+func (l *ociLayout) PushReferrer(_ context.Context, tag string, opts PushArtifactOptions) (string, error) {
+	if opts.SubjectReference == "" {
+		return "", fmt.Errorf("unable to push referrer: subject reference is not specified")
+	}
+
+	return l.pushArtifact(tag, opts)
+}
+
+func (l *ociLayout) pushArtifact(refName string, opts PushArtifactOptions) (string, error) {
+	var digest string
+	err := l.withBlobsLock(false, func() error {
+		var subject *v1.Descriptor
+		if opts.SubjectReference != "" {
+			subjectParts, err := l.parseLayoutReference(opts.SubjectReference)
+			if err != nil {
+				return err
+			}
+
+			if err := l.withIndexLock(false, func(layoutPath layout.Path) error {
+				subject, err = l.findDescriptor(layoutPath, subjectParts)
+				return err
+			}); err != nil {
+				return err
+			}
+			if subject == nil {
+				return newOCILayoutImageNotFoundError(opts.SubjectReference)
+			}
+
+			subject = &v1.Descriptor{
+				MediaType: subject.MediaType,
+				Size:      subject.Size,
+				Digest:    subject.Digest,
+			}
+		}
+
+		img, err := newArtifactImage(opts, subject)
+		if err != nil {
+			return err
+		}
+
+		imgDigest, err := img.Digest()
+		if err != nil {
+			return fmt.Errorf("unable to calculate artifact digest: %w", err)
+		}
+		digest = imgDigest.String()
+
+		return l.writeImageOrIndexWithoutBlobsLock(refName, img)
+	})
+
+	return digest, err
+}
+
+// Referrers finds the layout manifests with the subject referring to the image.
+func (l *ociLayout) Referrers(_ context.Context, reference string, opts ReferrersOptions) ([]v1.Descriptor, error) {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var referrers []v1.Descriptor
+	err = l.withBlobsLock(false, func() error {
+		return l.withIndexLock(false, func(layoutPath layout.Path) error {
+			subjectDigest := parts.digest
+			if subjectDigest == "" {
+				desc, err := l.findDescriptor(layoutPath, parts)
+				if err != nil {
+					return err
+				}
+				if desc == nil {
+					return newOCILayoutImageNotFoundError(reference)
+				}
+
+				subjectDigest = desc.Digest.String()
+			}
+
+			manifests, err := l.getManifests(layoutPath)
+			if err != nil {
+				return err
+			}
+
+			processed := map[v1.Hash]bool{}
+			for _, desc := range manifests {
+				if desc.MediaType.IsIndex() || processed[desc.Digest] {
+					continue
+				}
+				processed[desc.Digest] = true
+
+				img, err := layoutPath.Image(desc.Digest)
+				if err != nil {
+					return fmt.Errorf("unable to read manifest %s: %w", desc.Digest, err)
+				}
+
+				manifest, err := img.Manifest()
+				if err != nil {
+					return fmt.Errorf("unable to read manifest %s: %w", desc.Digest, err)
+				}
+
+				if manifest.Subject == nil || manifest.Subject.Digest.String() != subjectDigest {
+					continue
+				}
+
+				artifactType := string(manifest.Config.MediaType)
+				if opts.ArtifactType != "" && artifactType != opts.ArtifactType {
+					continue
+				}
+
+				referrers = append(referrers, v1.Descriptor{
+					MediaType:    desc.MediaType,
+					Size:         desc.Size,
+					Digest:       desc.Digest,
+					ArtifactType: artifactType,
+					Annotations:  manifest.Annotations,
+				})
+			}
+
+			return nil
+		})
+	})
+
+	return referrers, err
+}
+
+func (l *ociLayout) PullArtifactLayers(_ context.Context, reference string) ([]ArtifactLayer, error) {
+	var layers []ArtifactLayer
+	err := l.withImage(reference, func(img v1.Image) error {
+		var err error
+		layers, err = getArtifactLayers(reference, img)
+		return err
+	})
+
+	return layers, err
+}
+
+func (l *ociLayout) String() string {
+	return OCILayoutImplementationName
+}
+
+func (l *ociLayout) parseReferenceParts(reference string) (referenceParts, error) {
+	return l.api.parseReferenceParts(reference)
+}
+
+// getLayoutReference parses the reference and checks whether it refers to the layout repository.
+func (l *ociLayout) getLayoutReference(reference string) (referenceParts, bool) {
+	parts, err := l.parseReferenceParts(reference)
+	if err != nil {
+		return referenceParts{}, false
+	}
+
+	return parts, strings.Join([]string{parts.registry, parts.repository}, "/") == l.repository
+}
+
+// layoutRefName returns the tag to annotate the written manifest, the manifest referred by digest is written without tag.
+func layoutRefName(parts referenceParts) string {
+	if parts.digest != "" {
+		return ""
+	}
+
+	return parts.tag
+}
+
+func (l *ociLayout) parseLayoutReference(reference string) (referenceParts, error) {
+	parts, ok := l.getLayoutReference(reference)
+	if !ok {
+		return referenceParts{}, fmt.Errorf("reference %q does not belong to the OCI layout %s repository %s", reference, l.dir, l.repository)
+	}
+
+	return parts, nil
+}
+
+func (l *ociLayout) withBlobsLock(exclusive bool, f func() error) error {
+	return lockgate.WithAcquire(l.locker, ociLayoutBlobsLockName, lockgate.AcquireOptions{Shared: !exclusive}, func(_ bool) error {
+		return f()
+	})
+}
+
+// withIndexLock runs f holding the index.json lock, the empty layout is created if not exists when the lock is exclusive.
+func (l *ociLayout) withIndexLock(exclusive bool, f func(layoutPath layout.Path) error) error {
+	return lockgate.WithAcquire(l.locker, ociLayoutIndexLockName, lockgate.AcquireOptions{Shared: !exclusive}, func(_ bool) error {
+		layoutPath := layout.Path(l.dir)
+
+		if exclusive {
+			var err error
+			if layoutPath, err = openImageLayout(l.dir); err != nil {
+				return err
+			}
+		}
+
+		return f(layoutPath)
+	})
+}
+
+func (l *ociLayout) getManifests(layoutPath layout.Path) ([]v1.Descriptor, error) {
+	ii, err := layoutPath.ImageIndex()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read OCI layout %q index: %w", l.dir, err)
+	}
+
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read OCI layout %q index: %w", l.dir, err)
+	}
+
+	return im.Manifests, nil
+}
+
+// findDescriptor returns the index.json manifest descriptor by the reference digest or tag, nil if not found.
+func (l *ociLayout) findDescriptor(layoutPath layout.Path, parts referenceParts) (*v1.Descriptor, error) {
+	manifests, err := l.getManifests(layoutPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range manifests {
+		if parts.digest != "" {
+			if desc.Digest.String() == parts.digest {
+				return &desc, nil
+			}
+		} else if desc.Annotations[ocispec.AnnotationRefName] == parts.tag {
+			return &desc, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// getImageOrIndex returns the layout image or image index, the caller must hold the blobs lock while using the result.
+// The image referred only by the image index is found by digest as well.
+func (l *ociLayout) getImageOrIndex(reference string) (interface{}, error) {
+	parts, err := l.parseLayoutReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var imageOrIndex interface{}
+	if err := l.withIndexLock(false, func(layoutPath layout.Path) error {
+		desc, err := l.findDescriptor(layoutPath, parts)
+		if err != nil {
+			return err
+		}
+
+		if desc == nil {
+			if parts.digest == "" {
+				return newOCILayoutImageNotFoundError(reference)
+			}
+
+			hash, err := v1.NewHash(parts.digest)
+			if err != nil {
+				return fmt.Errorf("invalid digest %q: %w", parts.digest, err)
+			}
+
+			if _, err := os.Stat(filepath.Join(l.dir, "blobs", hash.Algorithm, hash.Hex)); errors.Is(err, fs.ErrNotExist) {
+				return newOCILayoutImageNotFoundError(reference)
+			}
+
+			imageOrIndex, err = layoutPath.Image(hash)
+			return err
+		}
+
+		if desc.MediaType.IsIndex() {
+			ii, err := layoutPath.ImageIndex()
+			if err != nil {
+				return err
+			}
+
+			imageOrIndex, err = ii.ImageIndex(desc.Digest)
+			return err
+		}
+
+		imageOrIndex, err = layoutPath.Image(desc.Digest)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return imageOrIndex, nil
+}
+
+func (l *ociLayout) withImageOrIndex(reference string, f func(imageOrIndex interface{}) error) error {
+	return l.withBlobsLock(false, func() error {
+		imageOrIndex, err := l.getImageOrIndex(reference)
+		if err != nil {
+			return err
+		}
+
+		return f(imageOrIndex)
+	})
+}
+
+func (l *ociLayout) withImage(reference string, f func(img v1.Image) error) error {
+	return l.withImageOrIndex(reference, func(imageOrIndex interface{}) error {
+		img, ok := imageOrIndex.(v1.Image)
+		if !ok {
+			return fmt.Errorf("unable to use %q: image expected, got image index", reference)
+		}
+
+		return f(img)
+	})
+}
+
+// writeImageOrIndex writes the blobs and tags the image or the image index, the image previously tagged with the same tag is untagged.
+// The image without tag is only accessible by digest.
+func (l *ociLayout) writeImageOrIndex(tag string, imageOrIndex interface{}) error {
+	return l.withBlobsLock(false, func() error {
+		return l.writeImageOrIndexWithoutBlobsLock(tag, imageOrIndex)
+	})
+}
+
+func (l *ociLayout) writeImageOrIndexWithoutBlobsLock(tag string, imageOrIndex interface{}) error {
+	layoutPath := layout.Path(l.dir)
+
+	// blobs are written before the index lock to not block other processes
+	switch i := imageOrIndex.(type) {
+	case v1.Image:
+		if err := layoutPath.WriteImage(i); err != nil {
+			return fmt.Errorf("unable to write image: %w", err)
+		}
+	case v1.ImageIndex:
+		if err := layoutPath.WriteIndex(i); err != nil {
+			return fmt.Errorf("unable to write image index: %w", err)
+		}
+	default:
+		panic(fmt.Sprintf("unexpected object type %#v", i))
+	}
+
+	return l.withIndexLock(true, func(layoutPath layout.Path) error {
+		return writeImageLayout(layoutPath, tag, imageOrIndex)
+	})
+}
+
+// openImageLayout opens the OCI image layout directory, the empty layout is created if not exists.
+func openImageLayout(layoutDir string) (layout.Path, error) {
+	layoutPath, err := layout.FromPath(layoutDir)
+	if err == nil {
+		return layoutPath, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("unable to open image layout %q: %w", layoutDir, err)
+	}
+
+	if layoutPath, err = layout.Write(layoutDir, empty.Index); err != nil {
+		return "", fmt.Errorf("unable to create image layout %q: %w", layoutDir, err)
+	}
+
+	return layoutPath, nil
+}
+
+// ReadImageLayout returns the image or the image index annotated with the refName in the OCI image layout directory.
+func ReadImageLayout(layoutDir, refName string) (interface{}, error) {
+	layoutPath, err := layout.FromPath(layoutDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open image layout %q: %w", layoutDir, err)
+	}
+
+	ii, err := layoutPath.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read image layout %q index: %w", layoutDir, err)
+	}
+
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read image layout %q index: %w", layoutDir, err)
+	}
+
+	for _, desc := range im.Manifests {
+		if desc.Annotations[ocispec.AnnotationRefName] != refName {
+			continue
+		}
+
+		if desc.MediaType.IsIndex() {
+			return ii.ImageIndex(desc.Digest)
+		}
+		return ii.Image(desc.Digest)
+	}
+
+	return nil, fmt.Errorf("no manifest %q found in the image layout %q", refName, layoutDir)
+}
+
+// WriteImageLayout writes the image or the image index into the OCI image layout directory, the directory is created if not exists.
+// The manifest previously written with the same refName is replaced.
+func WriteImageLayout(layoutDir, refName string, imageOrIndex interface{}, opts TransferOptions) error {
+	layoutPath, err := openImageLayout(layoutDir)
+	if err != nil {
+		return err
+	}
+
+	if err := writeBlobsToLayout(layoutPath, imageOrIndex, opts); err != nil {
+		return err
+	}
+
+	return writeImageLayout(layoutPath, refName, imageOrIndex)
+}
+
+// writeImageLayout writes the image or the image index into the layout annotated with the refName, the manifest previously written with the same refName is replaced.
+// The manifest without refName replaces only the same manifest.
+func writeImageLayout(layoutPath layout.Path, refName string, imageOrIndex interface{}) error {
+	var matcher match.Matcher
+	var options []layout.Option
+
+	if refName != "" {
+		matcher = match.Annotation(ocispec.AnnotationRefName, refName)
+		options = append(options, layout.WithAnnotations(map[string]string{ocispec.AnnotationRefName: refName}))
+	} else {
+		digest, err := imageOrIndex.(interface{ Digest() (v1.Hash, error) }).Digest()
+		if err != nil {
+			return fmt.Errorf("unable to get digest: %w", err)
+		}
+
+		matcher = func(desc v1.Descriptor) bool {
+			return desc.Digest == digest && desc.Annotations[ocispec.AnnotationRefName] == ""
+		}
+	}
+
+	switch i := imageOrIndex.(type) {
+	case v1.Image:
+		return layoutPath.ReplaceImage(i, matcher, options...)
+	case v1.ImageIndex:
+		return layoutPath.ReplaceIndex(i, matcher, options...)
+	default:
+		panic(fmt.Sprintf("unexpected object type %#v", i))
+	}
+}
+
+// newOCILayoutImageNotFoundError is recognized by IsImageNotFoundError as the container registry manifest unknown error.
+func newOCILayoutImageNotFoundError(reference string) error {
+	return fmt.Errorf("%s: image %q not found in the OCI layout", transport.ManifestUnknownErrorCode, reference)
+}
@@ -0,0 +1,189 @@
+package docker_registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const ociLayoutTestRepository = "oci-layout.werf.local/test"
+
+var _ = Describe("OCI layout", func() {
+	var dir string
+	var ctx context.Context
+
+	newLayout := func() Interface {
+		l, err := NewOCILayout(dir, ociLayoutTestRepository, DockerRegistryOptions{})
+		Expect(err).ShouldNot(HaveOccurred())
+		return l
+	}
+
+	// pushImage writes the random image into the source layout and pushes it into the tested layout
+	pushImage := func(l Interface, tag string) v1.Image {
+		img, err := random.Image(256, 2)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		srcDir := filepath.Join(GinkgoT().TempDir(), "src")
+		Expect(WriteImageLayout(srcDir, "src", img, TransferOptions{})).To(Succeed())
+		Expect(l.PushImageLayout(ctx, srcDir, fmt.Sprintf("%s:%s", ociLayoutTestRepository, tag), "src", TransferOptions{})).To(Succeed())
+
+		return img
+	}
+
+	blobExists := func(digest v1.Hash) bool {
+		_, err := os.Stat(filepath.Join(dir, "blobs", digest.Algorithm, digest.Hex))
+		if os.IsNotExist(err) {
+			return false
+		}
+		Expect(err).ShouldNot(HaveOccurred())
+		return true
+	}
+
+	imageBlobs := func(img v1.Image) []v1.Hash {
+		digest, err := img.Digest()
+		Expect(err).ShouldNot(HaveOccurred())
+		configDigest, err := img.ConfigName()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		blobs := []v1.Hash{digest, configDigest}
+
+		layers, err := img.Layers()
+		Expect(err).ShouldNot(HaveOccurred())
+		for _, layer := range layers {
+			layerDigest, err := layer.Digest()
+			Expect(err).ShouldNot(HaveOccurred())
+			blobs = append(blobs, layerDigest)
+		}
+
+		return blobs
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		ctx = context.Background()
+	})
+
+	It("should tag, get and delete images removing unused blobs", func() {
+		l := newLayout()
+
+		img := pushImage(l, "v1")
+		otherImg := pushImage(l, "other")
+
+		info, err := l.GetRepoImage(ctx, ociLayoutTestRepository+":v1")
+		Expect(err).ShouldNot(HaveOccurred())
+		digest, err := img.Digest()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Tag).To(Equal("v1"))
+		Expect(info.RepoDigest).To(HaveSuffix("@" + digest.String()))
+
+		Expect(l.TagRepoImage(ctx, info, "v2")).To(Succeed())
+		Expect(l.Tags(ctx, ociLayoutTestRepository)).To(ConsistOf("v1", "v2", "other"))
+
+		byDigest, err := l.GetRepoImage(ctx, fmt.Sprintf("%s@%s", ociLayoutTestRepository, digest))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(byDigest.RepoDigest).To(Equal(info.RepoDigest))
+
+		Expect(l.DeleteRepoImage(ctx, info)).To(Succeed())
+
+		// all tags of the image are removed, the fresh accessor has no cached tags
+		l = newLayout()
+		Expect(l.Tags(ctx, ociLayoutTestRepository)).To(ConsistOf("other"))
+		Expect(l.TryGetRepoImage(ctx, ociLayoutTestRepository+":v2")).To(BeNil())
+
+		_, err = l.GetRepoImage(ctx, ociLayoutTestRepository+":v1")
+		Expect(IsImageNotFoundError(err)).To(BeTrue(), fmt.Sprintf("unexpected error: %v", err))
+
+		for _, blob := range imageBlobs(img) {
+			Expect(blobExists(blob)).To(BeFalse(), fmt.Sprintf("blob %s is not removed", blob))
+		}
+		for _, blob := range imageBlobs(otherImg) {
+			Expect(blobExists(blob)).To(BeTrue(), fmt.Sprintf("blob %s of other image is removed", blob))
+		}
+	})
+
+	It("should find the referrers of the image", func() {
+		l := newLayout()
+		pushImage(l, "v1")
+		pushImage(l, "other")
+
+		subject := ociLayoutTestRepository + ":v1"
+		signatureDigest, err := l.PushReferrer(ctx, "sha256-abc.sig", PushArtifactOptions{
+			ArtifactType:     "application/vnd.dev.cosign.artifact.sig.v1+json",
+			SubjectReference: subject,
+			Layers:           []ArtifactLayer{{MediaType: "application/vnd.dev.cosign.simplesigning.v1+json", Data: []byte("{}")}},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = l.PushReferrer(ctx, "", PushArtifactOptions{
+			ArtifactType:     "application/spdx+json",
+			SubjectReference: subject,
+			Layers:           []ArtifactLayer{{MediaType: "application/spdx+json", Data: []byte("{}")}},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		referrers, err := l.Referrers(ctx, subject, ReferrersOptions{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(referrers).To(HaveLen(2))
+
+		referrers, err = l.Referrers(ctx, subject, ReferrersOptions{ArtifactType: "application/vnd.dev.cosign.artifact.sig.v1+json"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(referrers).To(HaveLen(1))
+		Expect(referrers[0].Digest.String()).To(Equal(signatureDigest))
+
+		Expect(l.Referrers(ctx, ociLayoutTestRepository+":other", ReferrersOptions{})).To(BeEmpty())
+
+		// the tagged referrer is available by the tag, the untagged one only by digest
+		signatureInfo, err := l.GetRepoImage(ctx, ociLayoutTestRepository+":sha256-abc.sig")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(signatureInfo.RepoDigest).To(HaveSuffix("@" + signatureDigest))
+		Expect(l.Tags(ctx, ociLayoutTestRepository)).To(ConsistOf("v1", "other", "sha256-abc.sig"))
+
+		_, err = l.PushReferrer(ctx, "", PushArtifactOptions{ArtifactType: "application/spdx+json", SubjectReference: ociLayoutTestRepository + ":missing"})
+		Expect(IsImageNotFoundError(err)).To(BeTrue(), fmt.Sprintf("unexpected error: %v", err))
+
+		_, err = l.PushReferrer(ctx, "", PushArtifactOptions{ArtifactType: "application/spdx+json"})
+		Expect(err).To(MatchError(ContainSubstring("subject reference is not specified")))
+	})
+
+	It("should keep all images written concurrently by different accessors", func() {
+		const numberOfWriters = 8
+
+		var wg sync.WaitGroup
+		images := make([]v1.Image, numberOfWriters)
+		for i := 0; i < numberOfWriters; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				images[i] = pushImage(newLayout(), fmt.Sprintf("tag-%d", i))
+			}(i)
+		}
+		wg.Wait()
+
+		l := newLayout()
+		tags, err := l.Tags(ctx, ociLayoutTestRepository)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(tags).To(HaveLen(numberOfWriters))
+
+		for i, img := range images {
+			info, err := l.GetRepoImage(ctx, fmt.Sprintf("%s:tag-%d", ociLayoutTestRepository, i))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			digest, err := img.Digest()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.RepoDigest).To(HaveSuffix("@" + digest.String()))
+
+			for _, blob := range imageBlobs(img) {
+				Expect(blobExists(blob)).To(BeTrue())
+			}
+		}
+	})
+})
@@ -0,0 +1,372 @@
+package docker_registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// credHelperCacheTTL limits the time the credentials of the helper are reused,
+	// the helpers do not report the expiration of the short-lived tokens (e.g. AWS ECR tokens).
+	credHelperCacheTTL = 5 * time.Minute
+
+	// exchangedTokenDefaultExpiresIn is used if the token exchange response has no expires_in as the docker token spec suggests.
+	exchangedTokenDefaultExpiresIn = 60 * time.Second
+
+	// tokenRefreshMargin is the time before the expiration when the token is refreshed.
+	tokenRefreshMargin = 10 * time.Second
+
+	dockerHubCredHelperServerURL = "https://index.docker.io/v1/"
+)
+
+// RegistryAuth describes the credentials of the registry in the werf registry auth config.
+// The credentials are the username and password, the identity token (OAuth2 refresh token), the registry token (bearer token)
+// or the credentials of the docker-credential-<credHelper> program.
+// The tokenExchange exchanges the username and password for the short-lived registry token, which is refreshed on expiration.
+type RegistryAuth struct {
+	Registry      string                     `yaml:"registry"`
+	Username      string                     `yaml:"username,omitempty"`
+	Password      string                     `yaml:"password,omitempty"`
+	IdentityToken string                     `yaml:"identityToken,omitempty"`
+	RegistryToken string                     `yaml:"registryToken,omitempty"`
+	CredHelper    string                     `yaml:"credHelper,omitempty"`
+	TokenExchange *RegistryAuthTokenExchange `yaml:"tokenExchange,omitempty"`
+}
+
+// RegistryAuthTokenExchange is the endpoint, which accepts the basic auth and returns the token in the docker token spec format:
+// {"token": "...", "expires_in": 300, "issued_at": "..."}.
+type RegistryAuthTokenExchange struct {
+	URL           string `yaml:"url"`
+	SkipTlsVerify bool   `yaml:"skipTlsVerify,omitempty"`
+}
+
+type registryAuthConfig struct {
+	RegistryAuth []RegistryAuth `yaml:"registryAuth"`
+}
+
+var keychain = newRegistryKeychain(nil, authn.DefaultKeychain)
+
+// LoadRegistryAuthConfig reads the registryAuth from the host config file, the missing file means no credentials.
+// The values are expanded with the environment variables, so the secrets can be passed as ${VAR}.
+func LoadRegistryAuthConfig(path string) ([]RegistryAuth, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read registry auth config %q: %w", path, err)
+	}
+
+	var config registryAuthConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse registry auth config %q: %w", path, err)
+	}
+
+	for i := range config.RegistryAuth {
+		registryAuth := &config.RegistryAuth[i]
+		registryAuth.Username = os.ExpandEnv(registryAuth.Username)
+		registryAuth.Password = os.ExpandEnv(registryAuth.Password)
+		registryAuth.IdentityToken = os.ExpandEnv(registryAuth.IdentityToken)
+		registryAuth.RegistryToken = os.ExpandEnv(registryAuth.RegistryToken)
+
+		if err := ValidateRegistryAuth(*registryAuth); err != nil {
+			return nil, fmt.Errorf("invalid registry auth config %q: %w", path, err)
+		}
+	}
+
+	return config.RegistryAuth, nil
+}
+
+func ValidateRegistryAuth(registryAuth RegistryAuth) error {
+	if registryAuth.Registry == "" {
+		return fmt.Errorf("registry is required")
+	}
+
+	if _, err := name.NewRegistry(registryAuth.Registry); err != nil {
+		return fmt.Errorf("invalid registry %q: %w", registryAuth.Registry, err)
+	}
+
+	var methods []string
+	if registryAuth.Username != "" || registryAuth.Password != "" {
+		methods = append(methods, "username/password")
+	}
+	if registryAuth.IdentityToken != "" {
+		methods = append(methods, "identityToken")
+	}
+	if registryAuth.RegistryToken != "" {
+		methods = append(methods, "registryToken")
+	}
+	if registryAuth.CredHelper != "" {
+		methods = append(methods, "credHelper")
+	}
+
+	switch {
+	case len(methods) == 0:
+		return fmt.Errorf("credentials are required for registry %q", registryAuth.Registry)
+	case len(methods) > 1:
+		return fmt.Errorf("only one of %v can be specified for registry %q", methods, registryAuth.Registry)
+	}
+
+	if registryAuth.TokenExchange != nil {
+		if registryAuth.TokenExchange.URL == "" {
+			return fmt.Errorf("tokenExchange url is required for registry %q", registryAuth.Registry)
+		}
+
+		if registryAuth.IdentityToken != "" || registryAuth.RegistryToken != "" {
+			return fmt.Errorf("tokenExchange requires username/password or credHelper for registry %q", registryAuth.Registry)
+		}
+	}
+
+	return nil
+}
+
+// AddRegistryAuth adds the credentials of the registries, the credentials added earlier have priority.
+func AddRegistryAuth(registryAuth ...RegistryAuth) {
+	keychain.add(registryAuth...)
+}
+
+// Keychain resolves the credentials from the werf registry auth config and falls back to the docker config.json.
+// The resolved authenticator refreshes the short-lived credentials on expiration, so long operations keep working.
+func Keychain() authn.Keychain {
+	return keychain
+}
+
+// RegistryAuthConfig returns the credentials of the registry from the werf registry auth config or nil if the registry is not configured.
+func RegistryAuthConfig(registry string) (*authn.AuthConfig, error) {
+	return keychain.authConfig(registry)
+}
+
+// resolveBasicAuth returns the username and password of the registry for the registry vendor APIs, which do not accept tokens.
+func resolveBasicAuth(registry string) (string, string, error) {
+	reg, err := name.NewRegistry(registry)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to parse registry %q: %w", registry, err)
+	}
+
+	auth, err := keychain.Resolve(reg)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to resolve credentials of registry %q: %w", registry, err)
+	}
+
+	cfg, err := auth.Authorization()
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get credentials of registry %q: %w", registry, err)
+	}
+
+	return cfg.Username, cfg.Password, nil
+}
+
+type registryKeychain struct {
+	auths    []RegistryAuth
+	cache    map[string]cachedAuthConfig
+	fallback authn.Keychain
+	now      func() time.Time
+	mutex    sync.Mutex
+}
+
+type cachedAuthConfig struct {
+	authConfig authn.AuthConfig
+	expiresAt  time.Time
+}
+
+func newRegistryKeychain(auths []RegistryAuth, fallback authn.Keychain) *registryKeychain {
+	k := &registryKeychain{
+		cache:    map[string]cachedAuthConfig{},
+		fallback: fallback,
+		now:      time.Now,
+	}
+	k.add(auths...)
+
+	return k
+}
+
+func (k *registryKeychain) add(auths ...RegistryAuth) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for _, auth := range auths {
+		auth.Registry = normalizeAuthRegistry(auth.Registry)
+		k.auths = append(k.auths, auth)
+	}
+}
+
+func (k *registryKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	registry := normalizeAuthRegistry(target.RegistryStr())
+	if k.registryAuth(registry) == nil {
+		return k.fallback.Resolve(target)
+	}
+
+	return &registryAuthenticator{keychain: k, registry: registry}, nil
+}
+
+func (k *registryKeychain) registryAuth(registry string) *RegistryAuth {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for i := range k.auths {
+		if k.auths[i].Registry == registry {
+			auth := k.auths[i]
+			return &auth
+		}
+	}
+
+	return nil
+}
+
+func (k *registryKeychain) authConfig(registry string) (*authn.AuthConfig, error) {
+	registry = normalizeAuthRegistry(registry)
+
+	registryAuth := k.registryAuth(registry)
+	if registryAuth == nil {
+		return nil, nil
+	}
+
+	k.mutex.Lock()
+	cached, ok := k.cache[registry]
+	k.mutex.Unlock()
+
+	if ok && (cached.expiresAt.IsZero() || k.now().Before(cached.expiresAt.Add(-tokenRefreshMargin))) {
+		return &cached.authConfig, nil
+	}
+
+	authConfig, expiresAt, err := k.newAuthConfig(*registryAuth)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get credentials of registry %q: %w", registry, err)
+	}
+
+	k.mutex.Lock()
+	k.cache[registry] = cachedAuthConfig{authConfig: authConfig, expiresAt: expiresAt}
+	k.mutex.Unlock()
+
+	return &authConfig, nil
+}
+
+// newAuthConfig returns the credentials and the time of their expiration, the zero time means the credentials never expire.
+func (k *registryKeychain) newAuthConfig(registryAuth RegistryAuth) (authn.AuthConfig, time.Time, error) {
+	authConfig := authn.AuthConfig{
+		Username:      registryAuth.Username,
+		Password:      registryAuth.Password,
+		IdentityToken: registryAuth.IdentityToken,
+		RegistryToken: registryAuth.RegistryToken,
+	}
+
+	var expiresAt time.Time
+	if registryAuth.CredHelper != "" {
+		var err error
+		authConfig, err = credHelperAuthConfig(registryAuth.CredHelper, registryAuth.Registry)
+		if err != nil {
+			return authn.AuthConfig{}, time.Time{}, err
+		}
+		expiresAt = k.now().Add(credHelperCacheTTL)
+	}
+
+	if registryAuth.TokenExchange != nil {
+		token, expiresIn, err := exchangeRegistryToken(*registryAuth.TokenExchange, authConfig.Username, authConfig.Password)
+		if err != nil {
+			return authn.AuthConfig{}, time.Time{}, err
+		}
+
+		return authn.AuthConfig{RegistryToken: token}, k.now().Add(expiresIn), nil
+	}
+
+	return authConfig, expiresAt, nil
+}
+
+func credHelperAuthConfig(credHelper, registry string) (authn.AuthConfig, error) {
+	serverURL := registry
+	if registry == name.DefaultRegistry {
+		serverURL = dockerHubCredHelperServerURL
+	}
+
+	creds, err := client.Get(client.NewShellProgramFunc("docker-credential-"+credHelper), serverURL)
+	if err != nil {
+		if credentials.IsErrCredentialsNotFound(err) {
+			return authn.AuthConfig{}, nil
+		}
+		return authn.AuthConfig{}, fmt.Errorf("docker-credential-%s failed: %w", credHelper, err)
+	}
+
+	// The helpers return the identity token with the special username as docker does.
+	if creds.Username == "<token>" {
+		return authn.AuthConfig{IdentityToken: creds.Secret}, nil
+	}
+
+	return authn.AuthConfig{Username: creds.Username, Password: creds.Secret}, nil
+}
+
+func exchangeRegistryToken(tokenExchange RegistryAuthTokenExchange, username, password string) (string, time.Duration, error) {
+	_, respBody, err := doRequest(context.Background(), http.MethodGet, tokenExchange.URL, nil, doRequestOptions{
+		BasicAuth:     doRequestBasicAuth{username: username, password: password},
+		AcceptedCodes: []int{http.StatusOK},
+		SkipTlsVerify: tokenExchange.SkipTlsVerify,
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("token exchange failed: %w", err)
+	}
+
+	var resp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", 0, fmt.Errorf("unable to parse token exchange response: %w", err)
+	}
+
+	token := resp.Token
+	if token == "" {
+		token = resp.AccessToken
+	}
+	if token == "" {
+		return "", 0, fmt.Errorf("unexpected token exchange response: no token")
+	}
+
+	expiresIn := exchangedTokenDefaultExpiresIn
+	if resp.ExpiresIn > 0 {
+		expiresIn = time.Duration(resp.ExpiresIn) * time.Second
+	}
+
+	return token, expiresIn, nil
+}
+
+// registryAuthenticator resolves the credentials on each call, the registry transport calls it again
+// on the authorization failure, so the expired short-lived token is replaced during the operation.
+type registryAuthenticator struct {
+	keychain *registryKeychain
+	registry string
+}
+
+func (a *registryAuthenticator) Authorization() (*authn.AuthConfig, error) {
+	authConfig, err := a.keychain.authConfig(a.registry)
+	if err != nil {
+		return nil, err
+	}
+
+	if authConfig == nil {
+		return &authn.AuthConfig{}, nil
+	}
+
+	return authConfig, nil
+}
+
+// normalizeAuthRegistry makes docker.io, index.docker.io and registry-1.docker.io (the docker hub API host) the same registry.
+func normalizeAuthRegistry(registry string) string {
+	if registry == "registry-1.docker.io" {
+		return name.DefaultRegistry
+	}
+
+	return normalizeMirrorRegistry(registry)
+}
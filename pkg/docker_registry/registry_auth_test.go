@@ -0,0 +1,100 @@
+package docker_registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("ValidateRegistryAuth", func(registryAuth RegistryAuth, expectedErr string) {
+	err := ValidateRegistryAuth(registryAuth)
+	if expectedErr == "" {
+		Ω(err).ShouldNot(HaveOccurred())
+	} else {
+		Ω(err).Should(MatchError(ContainSubstring(expectedErr)))
+	}
+},
+	Entry("username and password", RegistryAuth{Registry: "ghcr.io", Username: "user", Password: "pass"}, ""),
+	Entry("credential helper with token exchange", RegistryAuth{Registry: "registry.example.com", CredHelper: "pass", TokenExchange: &RegistryAuthTokenExchange{URL: "https://auth.example.com/token"}}, ""),
+	Entry("no registry", RegistryAuth{Username: "user", Password: "pass"}, "registry is required"),
+	Entry("no credentials", RegistryAuth{Registry: "ghcr.io"}, "credentials are required"),
+	Entry("several credentials", RegistryAuth{Registry: "ghcr.io", Username: "user", Password: "pass", RegistryToken: "token"}, "only one of"),
+	Entry("token exchange without url", RegistryAuth{Registry: "ghcr.io", Username: "user", Password: "pass", TokenExchange: &RegistryAuthTokenExchange{}}, "url is required"),
+	Entry("token exchange of token", RegistryAuth{Registry: "ghcr.io", IdentityToken: "token", TokenExchange: &RegistryAuthTokenExchange{URL: "https://auth.example.com/token"}}, "requires username/password"),
+)
+
+var _ = Describe("registryKeychain", func() {
+	var now time.Time
+
+	newKeychain := func(auths ...RegistryAuth) *registryKeychain {
+		k := newRegistryKeychain(auths, authn.NewMultiKeychain())
+		k.now = func() time.Time { return now }
+		return k
+	}
+
+	authorization := func(k *registryKeychain, registry string) *authn.AuthConfig {
+		reg, err := name.NewRegistry(registry)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		auth, err := k.Resolve(reg)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		cfg, err := auth.Authorization()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return cfg
+	}
+
+	BeforeEach(func() {
+		now = time.Now()
+	})
+
+	It("resolves the credentials of the registry and the docker hub aliases", func() {
+		k := newKeychain(
+			RegistryAuth{Registry: "docker.io", Username: "hub-user", Password: "hub-pass"},
+			RegistryAuth{Registry: "ghcr.io", RegistryToken: "ghcr-token"},
+		)
+
+		Ω(authorization(k, "index.docker.io")).Should(Equal(&authn.AuthConfig{Username: "hub-user", Password: "hub-pass"}))
+		Ω(authorization(k, "registry-1.docker.io")).Should(Equal(&authn.AuthConfig{Username: "hub-user", Password: "hub-pass"}))
+		Ω(authorization(k, "ghcr.io")).Should(Equal(&authn.AuthConfig{RegistryToken: "ghcr-token"}))
+		Ω(authorization(k, "quay.io")).Should(Equal(&authn.AuthConfig{}))
+	})
+
+	It("exchanges the credentials for the token and refreshes it on expiration", func() {
+		var exchanges int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			exchanges++
+			fmt.Fprintf(w, `{"token": "token-%d", "expires_in": 300}`, exchanges)
+		}))
+		defer server.Close()
+
+		k := newKeychain(RegistryAuth{
+			Registry:      "registry.example.com",
+			Username:      "user",
+			Password:      "pass",
+			TokenExchange: &RegistryAuthTokenExchange{URL: server.URL},
+		})
+
+		Ω(authorization(k, "registry.example.com")).Should(Equal(&authn.AuthConfig{RegistryToken: "token-1"}))
+
+		now = now.Add(time.Minute)
+		Ω(authorization(k, "registry.example.com")).Should(Equal(&authn.AuthConfig{RegistryToken: "token-1"}))
+
+		now = now.Add(5 * time.Minute)
+		Ω(authorization(k, "registry.example.com")).Should(Equal(&authn.AuthConfig{RegistryToken: "token-2"}))
+		Ω(exchanges).Should(Equal(2))
+	})
+})
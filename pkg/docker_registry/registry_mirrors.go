@@ -0,0 +1,170 @@
+package docker_registry
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"gopkg.in/yaml.v2"
+)
+
+// RegistryMirror describes the mirrors of the registry, the mirrors are tried in order before the registry itself.
+// The mirror is the registry host with the optional path prefix, e.g. mirror.gcr.io or registry.example.com/dockerhub.
+type RegistryMirror struct {
+	Registry string   `yaml:"registry"`
+	Mirrors  []string `yaml:"mirrors"`
+}
+
+type registryMirrorsConfig struct {
+	RegistryMirrors []RegistryMirror `yaml:"registryMirrors"`
+}
+
+var (
+	registryMirrors      []RegistryMirror
+	registryMirrorsMutex sync.RWMutex
+)
+
+// LoadRegistryMirrorsConfig reads the registryMirrors from the host config file, the missing file means no mirrors.
+func LoadRegistryMirrorsConfig(path string) ([]RegistryMirror, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read registry mirrors config %q: %w", path, err)
+	}
+
+	var config registryMirrorsConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse registry mirrors config %q: %w", path, err)
+	}
+
+	for _, mirror := range config.RegistryMirrors {
+		if err := ValidateRegistryMirror(mirror); err != nil {
+			return nil, fmt.Errorf("invalid registry mirrors config %q: %w", path, err)
+		}
+	}
+
+	return config.RegistryMirrors, nil
+}
+
+func ValidateRegistryMirror(mirror RegistryMirror) error {
+	if mirror.Registry == "" {
+		return fmt.Errorf("registry is required")
+	}
+
+	if _, err := name.NewRegistry(mirror.Registry); err != nil {
+		return fmt.Errorf("invalid registry %q: %w", mirror.Registry, err)
+	}
+
+	if len(mirror.Mirrors) == 0 {
+		return fmt.Errorf("mirrors are required for registry %q", mirror.Registry)
+	}
+
+	for _, m := range mirror.Mirrors {
+		if _, err := name.NewRepository(trimMirrorScheme(m) + "/library/test"); err != nil {
+			return fmt.Errorf("invalid mirror %q of registry %q: %w", m, mirror.Registry, err)
+		}
+	}
+
+	return nil
+}
+
+// AddRegistryMirrors adds the mirrors after the already added ones, the mirrors added earlier have priority.
+func AddRegistryMirrors(mirrors ...RegistryMirror) {
+	registryMirrorsMutex.Lock()
+	defer registryMirrorsMutex.Unlock()
+
+	registryMirrors = appendRegistryMirrors(registryMirrors, mirrors...)
+}
+
+func appendRegistryMirrors(list []RegistryMirror, mirrors ...RegistryMirror) []RegistryMirror {
+	for _, mirror := range mirrors {
+		registry := normalizeMirrorRegistry(mirror.Registry)
+
+		ind := -1
+		for i := range list {
+			if list[i].Registry == registry {
+				ind = i
+				break
+			}
+		}
+
+		if ind == -1 {
+			list = append(list, RegistryMirror{Registry: registry})
+			ind = len(list) - 1
+		}
+
+	mirrorsLoop:
+		for _, m := range mirror.Mirrors {
+			m = trimMirrorScheme(m)
+			for _, existing := range list[ind].Mirrors {
+				if existing == m {
+					continue mirrorsLoop
+				}
+			}
+
+			list[ind].Mirrors = append(list[ind].Mirrors, m)
+		}
+	}
+
+	return list
+}
+
+// RegistryMirrorReferences returns the references of the image in the registry mirrors in order of priority.
+// The references by digest are not mirrored because the image pulled from the mirror cannot be tagged with the original reference.
+func RegistryMirrorReferences(reference string) ([]string, error) {
+	registryMirrorsMutex.RLock()
+	defer registryMirrorsMutex.RUnlock()
+
+	return registryMirrorReferences(registryMirrors, reference)
+}
+
+func registryMirrorReferences(mirrors []RegistryMirror, reference string) ([]string, error) {
+	if len(mirrors) == 0 {
+		return nil, nil
+	}
+
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse reference %q: %w", reference, err)
+	}
+
+	tag, ok := ref.(name.Tag)
+	if !ok {
+		return nil, nil
+	}
+
+	var res []string
+	for _, mirror := range mirrors {
+		if mirror.Registry != tag.RegistryStr() {
+			continue
+		}
+
+		for _, m := range mirror.Mirrors {
+			res = append(res, fmt.Sprintf("%s/%s:%s", m, tag.RepositoryStr(), tag.TagStr()))
+		}
+	}
+
+	return res, nil
+}
+
+// normalizeMirrorRegistry makes docker.io and index.docker.io the same registry.
+func normalizeMirrorRegistry(registry string) string {
+	if r, err := name.NewRegistry(registry); err == nil {
+		return r.RegistryStr()
+	}
+
+	return registry
+}
+
+// trimMirrorScheme allows mirrors in the format of the docker daemon registry-mirrors, e.g. https://mirror.gcr.io.
+func trimMirrorScheme(mirror string) string {
+	mirror = strings.TrimPrefix(mirror, "https://")
+	mirror = strings.TrimPrefix(mirror, "http://")
+	return strings.TrimSuffix(mirror, "/")
+}
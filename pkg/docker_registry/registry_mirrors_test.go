@@ -0,0 +1,68 @@
+package docker_registry
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type RegistryMirrorReferencesEntry struct {
+	mirrors     []RegistryMirror
+	reference   string
+	expectation []string
+}
+
+var _ = DescribeTable("RegistryMirrorReferences", func(entry RegistryMirrorReferencesEntry) {
+	references, err := registryMirrorReferences(appendRegistryMirrors(nil, entry.mirrors...), entry.reference)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(references).Should(Equal(entry.expectation))
+},
+	Entry("no mirrors", RegistryMirrorReferencesEntry{
+		reference: "alpine:3.18",
+	}),
+	Entry("docker.io official image", RegistryMirrorReferencesEntry{
+		mirrors: []RegistryMirror{
+			{Registry: "docker.io", Mirrors: []string{"https://mirror.gcr.io", "registry.example.com/dockerhub"}},
+		},
+		reference: "alpine:3.18",
+		expectation: []string{
+			"mirror.gcr.io/library/alpine:3.18",
+			"registry.example.com/dockerhub/library/alpine:3.18",
+		},
+	}),
+	Entry("index.docker.io is docker.io", RegistryMirrorReferencesEntry{
+		mirrors: []RegistryMirror{
+			{Registry: "index.docker.io", Mirrors: []string{"mirror.gcr.io"}},
+		},
+		reference: "docker.io/account/project",
+		expectation: []string{
+			"mirror.gcr.io/account/project:latest",
+		},
+	}),
+	Entry("other registry", RegistryMirrorReferencesEntry{
+		mirrors: []RegistryMirror{
+			{Registry: "docker.io", Mirrors: []string{"mirror.gcr.io"}},
+			{Registry: "ghcr.io", Mirrors: []string{"registry.example.com/ghcr"}},
+		},
+		reference: "ghcr.io/account/project:tag",
+		expectation: []string{
+			"registry.example.com/ghcr/account/project:tag",
+		},
+	}),
+	Entry("merged mirrors of the same registry keep the order", RegistryMirrorReferencesEntry{
+		mirrors: []RegistryMirror{
+			{Registry: "docker.io", Mirrors: []string{"mirror-a.example.com"}},
+			{Registry: "index.docker.io", Mirrors: []string{"mirror-b.example.com", "https://mirror-a.example.com/"}},
+		},
+		reference: "alpine",
+		expectation: []string{
+			"mirror-a.example.com/library/alpine:latest",
+			"mirror-b.example.com/library/alpine:latest",
+		},
+	}),
+	Entry("reference by digest", RegistryMirrorReferencesEntry{
+		mirrors: []RegistryMirror{
+			{Registry: "docker.io", Mirrors: []string{"mirror.gcr.io"}},
+		},
+		reference: "alpine@sha256:db6697a61d5679b7ca69dbde3dad6be0d17064d5b6b0e9f7be8d456ebb337209",
+	}),
+)
@@ -0,0 +1,232 @@
+package docker_registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// TransferOptions allows to track the blobs transferred by CopyImage, PullImageLayout and PushImageLayout.
+type TransferOptions struct {
+	// OnBlob is called for each blob of the image after the blob is transferred or skipped, because it already exists at the destination.
+	OnBlob func(blob BlobTransfer)
+}
+
+type BlobTransfer struct {
+	Digest  v1.Hash
+	Size    int64
+	Skipped bool
+
+	// Index is the sequence number of the blob starting from 1, Total is the number of the blobs of the image.
+	Index, Total int
+}
+
+func (opts TransferOptions) onBlob(blob BlobTransfer) {
+	if opts.OnBlob != nil {
+		opts.OnBlob(blob)
+	}
+}
+
+// writeBlobsToRemote uploads the blobs of the image or the image index missing in the repo one by one before the manifests are written,
+// thus the blobs uploaded by the interrupted attempt are not uploaded again.
+func (api *api) writeBlobsToRemote(ctx context.Context, repo name.Repository, imageOrIndex interface{}, opts TransferOptions) error {
+	blobs, err := imageBlobs(imageOrIndex)
+	if err != nil {
+		return err
+	}
+
+	for ind, blob := range blobs {
+		digest, size, err := blobDigestAndSize(blob)
+		if err != nil {
+			return err
+		}
+
+		remoteBlob, err := remote.Layer(repo.Digest(digest.String()), api.defaultRemoteOptions(ctx)...)
+		if err != nil {
+			return fmt.Errorf("unable to get blob %s: %w", digest, err)
+		}
+
+		exists, err := partial.Exists(remoteBlob)
+		if err != nil {
+			return fmt.Errorf("unable to check blob %s existence: %w", digest, err)
+		}
+
+		if !exists {
+			if err := remote.WriteLayer(repo, blob, api.defaultRemoteOptions(ctx)...); err != nil {
+				return fmt.Errorf("unable to upload blob %s: %w", digest, err)
+			}
+		}
+
+		opts.onBlob(BlobTransfer{Digest: digest, Size: size, Skipped: exists, Index: ind + 1, Total: len(blobs)})
+	}
+
+	return nil
+}
+
+// writeBlobsToLayout writes the blobs of the image or the image index missing in the layout one by one before the manifests are written.
+// The blob is written into the tmp file first, so the blob interrupted by the failure is not considered as existing.
+func writeBlobsToLayout(layoutPath layout.Path, imageOrIndex interface{}, opts TransferOptions) error {
+	blobs, err := imageBlobs(imageOrIndex)
+	if err != nil {
+		return err
+	}
+
+	for ind, blob := range blobs {
+		digest, size, err := blobDigestAndSize(blob)
+		if err != nil {
+			return err
+		}
+
+		blobPath := filepath.Join(string(layoutPath), "blobs", digest.Algorithm, digest.Hex)
+
+		exists := false
+		if info, err := os.Stat(blobPath); err == nil {
+			exists = !info.IsDir() && info.Size() == size
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to stat %q: %w", blobPath, err)
+		}
+
+		if !exists {
+			if err := writeLayoutBlob(blobPath, blob, size); err != nil {
+				return fmt.Errorf("unable to write blob %s: %w", digest, err)
+			}
+		}
+
+		opts.onBlob(BlobTransfer{Digest: digest, Size: size, Skipped: exists, Index: ind + 1, Total: len(blobs)})
+	}
+
+	return nil
+}
+
+func writeLayoutBlob(blobPath string, blob v1.Layer, size int64) error {
+	if err := os.MkdirAll(filepath.Dir(blobPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	rc, err := blob.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.CreateTemp(filepath.Dir(blobPath), filepath.Base(blobPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	n, err := io.Copy(f, rc)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("expected blob size %d, but only wrote %d", size, n)
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), blobPath)
+}
+
+// imageBlobs returns the config and the layers of the image or of all images of the image index without duplicates.
+// The non-distributable layers are skipped the same way as the manifests are written.
+func imageBlobs(imageOrIndex interface{}) ([]v1.Layer, error) {
+	var res []v1.Layer
+	seen := map[v1.Hash]bool{}
+
+	var collect func(imageOrIndex interface{}) error
+	collect = func(imageOrIndex interface{}) error {
+		switch i := imageOrIndex.(type) {
+		case v1.Image:
+			config, err := partial.ConfigLayer(i)
+			if err != nil {
+				return fmt.Errorf("unable to get image config: %w", err)
+			}
+
+			layers, err := i.Layers()
+			if err != nil {
+				return fmt.Errorf("unable to get image layers: %w", err)
+			}
+
+			for _, blob := range append([]v1.Layer{config}, layers...) {
+				digest, err := blob.Digest()
+				if err != nil {
+					return fmt.Errorf("unable to get blob digest: %w", err)
+				}
+
+				mediaType, err := blob.MediaType()
+				if err != nil {
+					return fmt.Errorf("unable to get blob %s media type: %w", digest, err)
+				}
+
+				if seen[digest] || !mediaType.IsDistributable() {
+					continue
+				}
+				seen[digest] = true
+
+				res = append(res, blob)
+			}
+		case v1.ImageIndex:
+			im, err := i.IndexManifest()
+			if err != nil {
+				return fmt.Errorf("unable to get index manifest: %w", err)
+			}
+
+			for _, desc := range im.Manifests {
+				var child interface{}
+				switch {
+				case desc.MediaType.IsIndex():
+					child, err = i.ImageIndex(desc.Digest)
+				case desc.MediaType.IsImage():
+					child, err = i.Image(desc.Digest)
+				default:
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("unable to get manifest %s: %w", desc.Digest, err)
+				}
+
+				if err := collect(child); err != nil {
+					return err
+				}
+			}
+		default:
+			panic(fmt.Sprintf("unexpected object type %#v", i))
+		}
+
+		return nil
+	}
+
+	if err := collect(imageOrIndex); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func blobDigestAndSize(blob v1.Layer) (v1.Hash, int64, error) {
+	digest, err := blob.Digest()
+	if err != nil {
+		return v1.Hash{}, 0, fmt.Errorf("unable to get blob digest: %w", err)
+	}
+
+	size, err := blob.Size()
+	if err != nil {
+		return v1.Hash{}, 0, fmt.Errorf("unable to get blob %s size: %w", digest, err)
+	}
+
+	return digest, size, nil
+}
@@ -0,0 +1,154 @@
+package docker_registry
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var errTransferTestInterrupted = errors.New("interrupted")
+
+// interruptedLayer fails after the half of the compressed blob is read as the interrupted download does.
+type interruptedLayer struct {
+	v1.Layer
+}
+
+func (l *interruptedLayer) Compressed() (io.ReadCloser, error) {
+	rc, err := l.Layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := l.Layer.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(io.LimitReader(rc, size/2), errorReader{errTransferTestInterrupted}), rc}, nil
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read(_ []byte) (int, error) {
+	return 0, r.err
+}
+
+var _ = Describe("Transfer to OCI layout", func() {
+	var layoutPath layout.Path
+	var transfers []BlobTransfer
+
+	transferOptions := TransferOptions{OnBlob: func(blob BlobTransfer) {
+		transfers = append(transfers, blob)
+	}}
+
+	blobPath := func(digest v1.Hash) string {
+		return filepath.Join(string(layoutPath), "blobs", digest.Algorithm, digest.Hex)
+	}
+
+	blobExists := func(digest v1.Hash) bool {
+		_, err := os.Stat(blobPath(digest))
+		if os.IsNotExist(err) {
+			return false
+		}
+		Expect(err).ShouldNot(HaveOccurred())
+		return true
+	}
+
+	tmpBlobs := func() []string {
+		matches, err := filepath.Glob(filepath.Join(string(layoutPath), "blobs", "*", "*.tmp"))
+		Expect(err).ShouldNot(HaveOccurred())
+		return matches
+	}
+
+	BeforeEach(func() {
+		var err error
+		layoutPath, err = layout.Write(GinkgoT().TempDir(), empty.Index)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		transfers = nil
+	})
+
+	It("should resume the interrupted writing and skip the blobs already written", func() {
+		baseImage, err := random.Image(1024, 2)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		layer, err := random.Layer(1024, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+		Expect(err).ShouldNot(HaveOccurred())
+		layerDigest, err := layer.Digest()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		img, err := mutate.AppendLayers(baseImage, layer)
+		Expect(err).ShouldNot(HaveOccurred())
+		interruptedImg, err := mutate.AppendLayers(baseImage, &interruptedLayer{Layer: layer})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// config, 2 base layers and the interrupted layer
+		Expect(writeBlobsToLayout(layoutPath, interruptedImg, transferOptions)).To(MatchError(errTransferTestInterrupted))
+
+		Expect(transfers).To(HaveLen(3))
+		for i, blob := range transfers {
+			Expect(blob.Skipped).To(BeFalse())
+			Expect(blob.Index).To(Equal(i + 1))
+			Expect(blob.Total).To(Equal(4))
+			Expect(blobExists(blob.Digest)).To(BeTrue())
+		}
+		Expect(blobExists(layerDigest)).To(BeFalse())
+		Expect(tmpBlobs()).To(BeEmpty())
+
+		writtenBlobs := transfers
+		transfers = nil
+		Expect(writeBlobsToLayout(layoutPath, img, transferOptions)).To(Succeed())
+
+		Expect(transfers).To(HaveLen(4))
+		for i, blob := range transfers[:3] {
+			Expect(blob.Digest).To(Equal(writtenBlobs[i].Digest))
+			Expect(blob.Skipped).To(BeTrue())
+		}
+		Expect(transfers[3].Digest).To(Equal(layerDigest))
+		Expect(transfers[3].Skipped).To(BeFalse())
+		Expect(transfers[3].Index).To(Equal(4))
+		Expect(transfers[3].Total).To(Equal(4))
+		Expect(blobExists(layerDigest)).To(BeTrue())
+		Expect(tmpBlobs()).To(BeEmpty())
+	})
+
+	It("should rewrite the truncated blob", func() {
+		img, err := random.Image(1024, 1)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		layers, err := img.Layers()
+		Expect(err).ShouldNot(HaveOccurred())
+		layerDigest, err := layers[0].Digest()
+		Expect(err).ShouldNot(HaveOccurred())
+		layerSize, err := layers[0].Size()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Dir(blobPath(layerDigest)), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(blobPath(layerDigest), []byte("truncated"), 0o644)).To(Succeed())
+
+		Expect(writeBlobsToLayout(layoutPath, img, transferOptions)).To(Succeed())
+
+		Expect(transfers).To(HaveLen(2))
+		for _, blob := range transfers {
+			Expect(blob.Skipped).To(BeFalse())
+		}
+
+		info, err := os.Stat(blobPath(layerDigest))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Size()).To(Equal(layerSize))
+	})
+})
@@ -27,9 +27,10 @@ type HostCleanupOptions struct {
 	AllowedLocalCacheVolumeUsageMarginPercentage    *uint
 	DockerServerStoragePath                         *string
 
-	CleanupDockerServer bool
-	DryRun              bool
-	Force               bool
+	CleanupDockerServer   bool
+	CleanupBuildahStorage bool
+	DryRun                bool
+	Force                 bool
 }
 
 type AutoHostCleanupOptions struct {
@@ -48,9 +49,9 @@ func getOptionValueOrDefault(optionValue *uint, defaultValue float64) float64 {
 	return res
 }
 
-func RunAutoHostCleanup(ctx context.Context, options AutoHostCleanupOptions) error {
+func RunAutoHostCleanup(ctx context.Context, containerBackend container_backend.ContainerBackend, options AutoHostCleanupOptions) error {
 	if !options.ForceShouldRun {
-		shouldRun, err := ShouldRunAutoHostCleanup(ctx, options.HostCleanupOptions)
+		shouldRun, err := ShouldRunAutoHostCleanup(ctx, containerBackend, options.HostCleanupOptions)
 		if err != nil {
 			logboek.Context(ctx).Warn().LogF("WARNING: unable to check if auto host cleanup should be run: %s\n", err)
 			return nil
@@ -144,6 +145,20 @@ func RunHostCleanup(ctx context.Context, containerBackend container_backend.Cont
 		return err
 	}
 
+	if options.CleanupBuildahStorage {
+		buildahBackend, ok := containerBackend.(*container_backend.BuildahBackend)
+		if !ok {
+			return fmt.Errorf("buildah storage cleanup is not supported for %s", containerBackend)
+		}
+
+		return logboek.Context(ctx).Default().LogProcess("Running GC for local buildah storage").DoError(func() error {
+			if err := RunGCForLocalBuildahStorage(ctx, buildahBackend, allowedDockerStorageVolumeUsagePercentage, allowedDockerStorageVolumeUsageMarginPercentage, buildahBackend.GetStoragePath(), options.Force, options.DryRun); err != nil {
+				return fmt.Errorf("local buildah storage GC failed: %w", err)
+			}
+			return nil
+		})
+	}
+
 	if options.CleanupDockerServer {
 		dockerServerStoragePath, err := getDockerServerStoragePath(ctx, options.DockerServerStoragePath)
 		if err != nil {
@@ -161,7 +176,7 @@ func RunHostCleanup(ctx context.Context, containerBackend container_backend.Cont
 	return nil
 }
 
-func ShouldRunAutoHostCleanup(ctx context.Context, options HostCleanupOptions) (bool, error) {
+func ShouldRunAutoHostCleanup(ctx context.Context, containerBackend container_backend.ContainerBackend, options HostCleanupOptions) (bool, error) {
 	shouldRun, err := tmp_manager.ShouldRunAutoGC()
 	if err != nil {
 		return false, fmt.Errorf("failed to check tmp manager GC: %w", err)
@@ -170,9 +185,8 @@ func ShouldRunAutoHostCleanup(ctx context.Context, options HostCleanupOptions) (
 		return true, nil
 	}
 
-	if options.CleanupDockerServer {
+	if options.CleanupDockerServer || options.CleanupBuildahStorage {
 		allowedLocalCacheVolumeUsagePercentage := getOptionValueOrDefault(options.AllowedLocalCacheVolumeUsagePercentage, DefaultAllowedLocalCacheVolumeUsagePercentage)
-		allowedDockerStorageVolumeUsagePercentage := getOptionValueOrDefault(options.AllowedDockerStorageVolumeUsagePercentage, DefaultAllowedDockerStorageVolumeUsagePercentage)
 
 		shouldRun, err = gitdata.ShouldRunAutoGC(ctx, allowedLocalCacheVolumeUsagePercentage)
 		if err != nil {
@@ -181,7 +195,23 @@ func ShouldRunAutoHostCleanup(ctx context.Context, options HostCleanupOptions) (
 		if shouldRun {
 			return true, nil
 		}
+	}
 
+	allowedDockerStorageVolumeUsagePercentage := getOptionValueOrDefault(options.AllowedDockerStorageVolumeUsagePercentage, DefaultAllowedDockerStorageVolumeUsagePercentage)
+
+	if options.CleanupBuildahStorage {
+		if buildahBackend, ok := containerBackend.(*container_backend.BuildahBackend); ok {
+			shouldRun, err = ShouldRunAutoGCForLocalBuildahStorage(ctx, allowedDockerStorageVolumeUsagePercentage, buildahBackend.GetStoragePath())
+			if err != nil {
+				return false, fmt.Errorf("failed to check local buildah storage host cleaner GC: %w", err)
+			}
+			if shouldRun {
+				return true, nil
+			}
+		}
+	}
+
+	if options.CleanupDockerServer {
 		dockerServerStoragePath, err := getDockerServerStoragePath(ctx, options.DockerServerStoragePath)
 		if err != nil {
 			return false, fmt.Errorf("error getting local docker server storage path: %w", err)
@@ -29,6 +29,34 @@ func HostPurge(ctx context.Context, containerBackend container_backend.Container
 		DryRun:                        options.DryRun,
 	}
 
+	if buildahBackend, ok := containerBackend.(*container_backend.BuildahBackend); ok {
+		if err := buildahHostPurge(ctx, buildahBackend, commonOptions); err != nil {
+			return err
+		}
+	} else if err := dockerHostPurge(ctx, commonOptions); err != nil {
+		return err
+	}
+
+	if err := tmp_manager.Purge(ctx, commonOptions.DryRun, containerBackend); err != nil {
+		return fmt.Errorf("tmp files purge failed: %w", err)
+	}
+
+	if err := logboek.Context(ctx).LogProcess("Running werf home data purge").DoError(func() error {
+		return purgeHomeWerfFiles(ctx, commonOptions.DryRun, containerBackend)
+	}); err != nil {
+		return err
+	}
+
+	if err := logboek.Context(ctx).LogProcess("Deleting stapel").DoError(func() error {
+		return deleteStapel(ctx, commonOptions.DryRun, containerBackend)
+	}); err != nil {
+		return fmt.Errorf("stapel delete failed: %w", err)
+	}
+
+	return nil
+}
+
+func dockerHostPurge(ctx context.Context, commonOptions CommonOptions) error {
 	if err := logboek.Context(ctx).LogProcess("Running werf docker containers purge").DoError(func() error {
 		if err := werfContainersFlushByFilterSet(ctx, filters.NewArgs(), commonOptions); err != nil {
 			return err
@@ -39,7 +67,7 @@ func HostPurge(ctx context.Context, containerBackend container_backend.Container
 		return err
 	}
 
-	if err := logboek.Context(ctx).LogProcess("Running werf docker images purge").DoError(func() error {
+	return logboek.Context(ctx).LogProcess("Running werf docker images purge").DoError(func() error {
 		filterSet := filters.NewArgs()
 		filterSet.Add("label", image.WerfLabel)
 
@@ -64,30 +92,14 @@ func HostPurge(ctx context.Context, containerBackend container_backend.Container
 		}
 
 		return nil
-	}); err != nil {
-		return err
-	}
-
-	if err := tmp_manager.Purge(ctx, commonOptions.DryRun, containerBackend); err != nil {
-		return fmt.Errorf("tmp files purge failed: %w", err)
-	}
-
-	if err := logboek.Context(ctx).LogProcess("Running werf home data purge").DoError(func() error {
-		return purgeHomeWerfFiles(ctx, commonOptions.DryRun, containerBackend)
-	}); err != nil {
-		return err
-	}
+	})
+}
 
-	if err := logboek.Context(ctx).LogProcess("Deleting stapel").DoError(func() error {
-		return deleteStapel(ctx, commonOptions.DryRun)
-	}); err != nil {
-		return fmt.Errorf("stapel delete failed: %w", err)
+func deleteStapel(ctx context.Context, dryRun bool, containerBackend container_backend.ContainerBackend) error {
+	if buildahBackend, ok := containerBackend.(*container_backend.BuildahBackend); ok {
+		return deleteBuildahStapel(ctx, buildahBackend, dryRun)
 	}
 
-	return nil
-}
-
-func deleteStapel(ctx context.Context, dryRun bool) error {
 	if dryRun {
 		return nil
 	}
@@ -0,0 +1,482 @@
+package host_cleaning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/werf/kubedog/pkg/utils"
+	"github.com/werf/lockgate"
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/container_backend"
+	"github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/stapel"
+	"github.com/werf/werf/pkg/storage/lrumeta"
+	"github.com/werf/werf/pkg/util"
+	"github.com/werf/werf/pkg/volumeutils"
+	"github.com/werf/werf/pkg/werf"
+)
+
+// Buildah stores images built or pulled without registry in the localhost registry namespace,
+// while werf refers to such images (and stores them in the LRU images cache) without this prefix.
+const buildahLocalhostRepoPrefix = "localhost/"
+
+// Working containers are created by the buildah backend with this prefix.
+const buildahWorkingContainerNamePrefix = "werf-"
+
+func ShouldRunAutoGCForLocalBuildahStorage(ctx context.Context, allowedVolumeUsagePercentage float64, storagePath string) (bool, error) {
+	return shouldRunAutoGCForStoragePath(ctx, allowedVolumeUsagePercentage, storagePath)
+}
+
+type LocalBuildahStorageCheckResult struct {
+	VolumeUsage      volumeutils.VolumeUsage
+	TotalImagesBytes uint64
+	ImagesDescs      []*LocalBuildahImageDesc
+}
+
+func (checkResult *LocalBuildahStorageCheckResult) GetBytesToFree(targetVolumeUsage float64) uint64 {
+	return getBytesToFree(checkResult.VolumeUsage, targetVolumeUsage)
+}
+
+type LocalBuildahImageDesc struct {
+	Summary    image.Summary
+	LastUsedAt time.Time
+}
+
+type BuildahImagesLruSort []*LocalBuildahImageDesc
+
+func (a BuildahImagesLruSort) Len() int { return len(a) }
+func (a BuildahImagesLruSort) Less(i, j int) bool {
+	return a[i].LastUsedAt.Before(a[j].LastUsedAt)
+}
+func (a BuildahImagesLruSort) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+func GetLocalBuildahStorageCheck(ctx context.Context, backend *container_backend.BuildahBackend, storagePath string) (*LocalBuildahStorageCheckResult, error) {
+	res := &LocalBuildahStorageCheckResult{}
+
+	vu, err := volumeutils.GetVolumeUsageByPath(ctx, storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting volume usage by path %q: %w", storagePath, err)
+	}
+	res.VolumeUsage = vu
+
+	images, err := backend.Images(ctx, container_backend.ImagesOptions{
+		Filters:           []util.Pair[string, string]{util.NewPair("label", image.WerfLabel)},
+		WithLabelsAndSize: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get werf buildah images: %w", err)
+	}
+
+CreateImagesDescs:
+	for _, img := range images {
+		if _, hasDigestLabel := img.Labels[image.WerfStageDigestLabel]; !hasDigestLabel {
+			continue
+		}
+
+		// IMPORTANT: ignore none images, these may be either orphans or just built fresh images and we shall not delete these
+		if len(img.RepoTags) == 0 {
+			continue
+		}
+
+		// Do not remove local stages storage images, because this is primary stages storage data,
+		// and it can only be cleaned by the werf-cleanup command
+		projectName := img.Labels[image.WerfLabel]
+		for _, ref := range img.RepoTags {
+			if strings.HasPrefix(trimBuildahLocalhostRepoPrefix(ref), fmt.Sprintf("%s:", projectName)) {
+				continue CreateImagesDescs
+			}
+		}
+
+		res.TotalImagesBytes += uint64(img.Size)
+
+		lastUsedAt := img.Created
+		for _, ref := range img.RepoTags {
+			lastRecentlyUsedAt, err := getBuildahImageLastAccessTime(ctx, ref)
+			if err != nil {
+				return nil, fmt.Errorf("error accessing last recently used images cache: %w", err)
+			}
+
+			if !lastRecentlyUsedAt.IsZero() {
+				lastUsedAt = lastRecentlyUsedAt
+				break
+			}
+		}
+
+		res.ImagesDescs = append(res.ImagesDescs, &LocalBuildahImageDesc{
+			Summary:    img,
+			LastUsedAt: lastUsedAt,
+		})
+	}
+
+	sort.Sort(BuildahImagesLruSort(res.ImagesDescs))
+
+	return res, nil
+}
+
+func getBuildahImageLastAccessTime(ctx context.Context, ref string) (time.Time, error) {
+	for _, name := range util.UniqStrings([]string{ref, trimBuildahLocalhostRepoPrefix(ref)}) {
+		t, err := lrumeta.CommonLRUImagesCache.GetImageLastAccessTime(ctx, name)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if !t.IsZero() {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, nil
+}
+
+func trimBuildahLocalhostRepoPrefix(ref string) string {
+	return strings.TrimPrefix(ref, buildahLocalhostRepoPrefix)
+}
+
+func RunGCForLocalBuildahStorage(ctx context.Context, backend *container_backend.BuildahBackend, allowedVolumeUsagePercentage, allowedVolumeUsageMarginPercentage float64, storagePath string, force, dryRun bool) error {
+	if storagePath == "" {
+		return nil
+	}
+
+	targetVolumeUsage := allowedVolumeUsagePercentage - allowedVolumeUsageMarginPercentage
+	if targetVolumeUsage < 0 {
+		targetVolumeUsage = 0
+	}
+
+	checkResult, err := GetLocalBuildahStorageCheck(ctx, backend, storagePath)
+	if err != nil {
+		return fmt.Errorf("error getting local buildah storage check: %w", err)
+	}
+
+	bytesToFree := checkResult.GetBytesToFree(targetVolumeUsage)
+
+	if checkResult.VolumeUsage.Percentage <= allowedVolumeUsagePercentage {
+		logboek.Context(ctx).Default().LogBlock("Local buildah storage check").Do(func() {
+			logboek.Context(ctx).Default().LogF("Buildah storage path: %s\n", storagePath)
+			logboek.Context(ctx).Default().LogF("Volume usage: %s / %s\n", humanize.Bytes(checkResult.VolumeUsage.UsedBytes), humanize.Bytes(checkResult.VolumeUsage.TotalBytes))
+			logboek.Context(ctx).Default().LogF("Allowed volume usage percentage: %s <= %s — %s\n", utils.GreenF("%0.2f%%", checkResult.VolumeUsage.Percentage), utils.BlueF("%0.2f%%", allowedVolumeUsagePercentage), utils.GreenF("OK"))
+		})
+
+		return nil
+	}
+
+	logboek.Context(ctx).Default().LogBlock("Local buildah storage check").Do(func() {
+		logboek.Context(ctx).Default().LogF("Buildah storage path: %s\n", storagePath)
+		logboek.Context(ctx).Default().LogF("Volume usage: %s / %s\n", humanize.Bytes(checkResult.VolumeUsage.UsedBytes), humanize.Bytes(checkResult.VolumeUsage.TotalBytes))
+		logboek.Context(ctx).Default().LogF("Allowed percentage level exceeded: %s > %s — %s\n", utils.RedF("%0.2f%%", checkResult.VolumeUsage.Percentage), utils.YellowF("%0.2f%%", allowedVolumeUsagePercentage), utils.RedF("HIGH VOLUME USAGE"))
+		logboek.Context(ctx).Default().LogF("Target percentage level after cleanup: %0.2f%% - %0.2f%% (margin) = %s\n", allowedVolumeUsagePercentage, allowedVolumeUsageMarginPercentage, utils.BlueF("%0.2f%%", targetVolumeUsage))
+		logboek.Context(ctx).Default().LogF("Needed to free: %s\n", utils.RedF("%s", humanize.Bytes(bytesToFree)))
+		logboek.Context(ctx).Default().LogF("Available images to free: %s\n", utils.YellowF("%d", len(checkResult.ImagesDescs)))
+	})
+
+	var processedImagesIDs []string
+	var processedContainersIDs []string
+
+	for {
+		var freedBytes uint64
+		var freedImagesCount uint64
+
+		// Working containers are removed first, so that images used only by leftover containers can be freed.
+		if err := logboek.Context(ctx).Default().LogProcess("Running cleanup for buildah working containers created by werf").DoError(func() error {
+			newProcessedContainersIDs, err := safeBuildahContainersCleanup(ctx, backend, processedContainersIDs, dryRun)
+			if err != nil {
+				return fmt.Errorf("safe buildah containers cleanup failed: %w", err)
+			}
+
+			processedContainersIDs = newProcessedContainersIDs
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if len(checkResult.ImagesDescs) > 0 {
+			if err := logboek.Context(ctx).Default().LogProcess("Running cleanup for least recently used buildah images created by werf").DoError(func() error {
+				usedImagesIDs, err := getBuildahUsedImagesIDs(ctx, backend)
+				if err != nil {
+					return err
+				}
+
+			DeleteImages:
+				for _, desc := range checkResult.ImagesDescs {
+					for _, id := range processedImagesIDs {
+						if desc.Summary.ID == id {
+							logboek.Context(ctx).Default().LogFDetails("Skip already processed image %q\n", desc.Summary.ID)
+							continue DeleteImages
+						}
+					}
+					processedImagesIDs = append(processedImagesIDs, desc.Summary.ID)
+
+					if !force && util.IsStringsContainValue(usedImagesIDs, desc.Summary.ID) {
+						logboek.Context(ctx).Default().LogFDetails("Skip image %q used by buildah container\n", desc.Summary.RepoTags[0])
+						continue DeleteImages
+					}
+
+					removed, err := removeBuildahImageTags(ctx, backend, desc.Summary, dryRun)
+					if err != nil {
+						return err
+					}
+
+					if removed {
+						freedBytes += uint64(desc.Summary.Size)
+						freedImagesCount++
+					}
+
+					if freedImagesCount < MinImagesToDelete {
+						continue
+					}
+
+					if freedBytes > bytesToFree {
+						break
+					}
+				}
+
+				logboek.Context(ctx).Default().LogF("Freed images: %s\n", utils.GreenF("%d", freedImagesCount))
+
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		if freedImagesCount == 0 {
+			logboek.Context(ctx).Warn().LogF("WARNING: Detected high buildah storage volume usage, while no werf images available to cleanup!\n")
+			logboek.Context(ctx).Warn().LogF("WARNING:\n")
+			logboek.Context(ctx).Warn().LogF("WARNING: Werf tries to maintain host clean by deleting:\n")
+			logboek.Context(ctx).Warn().LogF("WARNING:  - old unused files from werf caches (which are stored in the ~/.werf/local_cache);\n")
+			logboek.Context(ctx).Warn().LogF("WARNING:  - old temporary service files /tmp/werf-project-data-* and /tmp/werf-config-render-*;\n")
+			logboek.Context(ctx).Warn().LogF("WARNING:  - leftover buildah working containers created by werf;\n")
+			logboek.Context(ctx).Warn().LogF("WARNING:  - least recently used werf images except local stages storage images (images built with 'werf build' without '--repo' param).\n")
+			logboek.Context(ctx).Warn().LogOptionalLn()
+
+			break
+		}
+		if dryRun {
+			break
+		}
+
+		logboek.Context(ctx).Default().LogOptionalLn()
+
+		checkResult, err = GetLocalBuildahStorageCheck(ctx, backend, storagePath)
+		if err != nil {
+			return fmt.Errorf("error getting local buildah storage check: %w", err)
+		}
+
+		if checkResult.VolumeUsage.Percentage <= targetVolumeUsage {
+			logboek.Context(ctx).Default().LogBlock("Local buildah storage check").Do(func() {
+				logboek.Context(ctx).Default().LogF("Buildah storage path: %s\n", storagePath)
+				logboek.Context(ctx).Default().LogF("Volume usage: %s / %s\n", humanize.Bytes(checkResult.VolumeUsage.UsedBytes), humanize.Bytes(checkResult.VolumeUsage.TotalBytes))
+				logboek.Context(ctx).Default().LogF("Target volume usage percentage: %s <= %s — %s\n", utils.GreenF("%0.2f%%", checkResult.VolumeUsage.Percentage), utils.BlueF("%0.2f%%", targetVolumeUsage), utils.GreenF("OK"))
+			})
+
+			break
+		}
+
+		bytesToFree = checkResult.GetBytesToFree(targetVolumeUsage)
+
+		logboek.Context(ctx).Default().LogBlock("Local buildah storage check").Do(func() {
+			logboek.Context(ctx).Default().LogF("Buildah storage path: %s\n", storagePath)
+			logboek.Context(ctx).Default().LogF("Volume usage: %s / %s\n", humanize.Bytes(checkResult.VolumeUsage.UsedBytes), humanize.Bytes(checkResult.VolumeUsage.TotalBytes))
+			logboek.Context(ctx).Default().LogF("Target volume usage percentage: %s > %s — %s\n", utils.RedF("%0.2f%%", checkResult.VolumeUsage.Percentage), utils.BlueF("%0.2f%%", targetVolumeUsage), utils.RedF("HIGH VOLUME USAGE"))
+			logboek.Context(ctx).Default().LogF("Needed to free: %s\n", utils.RedF("%s", humanize.Bytes(bytesToFree)))
+			logboek.Context(ctx).Default().LogF("Available images to free: %s\n", utils.YellowF("%d", len(checkResult.ImagesDescs)))
+		})
+	}
+
+	return nil
+}
+
+// removeBuildahImageTags removes all tags of the image, which are not locked by other werf processes at the moment.
+func removeBuildahImageTags(ctx context.Context, backend *container_backend.BuildahBackend, img image.Summary, dryRun bool) (bool, error) {
+	allTagsRemoved := true
+
+	for _, ref := range img.RepoTags {
+		lockName := container_backend.ImageLockName(trimBuildahLocalhostRepoPrefix(ref))
+
+		isLocked, lock, err := werf.AcquireHostLock(ctx, lockName, lockgate.AcquireOptions{NonBlocking: true})
+		if err != nil {
+			return false, fmt.Errorf("error locking image %q: %w", lockName, err)
+		}
+
+		if !isLocked {
+			logboek.Context(ctx).Default().LogFDetails("Image %q is locked at the moment: skip removal\n", ref)
+			allTagsRemoved = false
+			continue
+		}
+
+		if err := removeBuildahImage(ctx, backend, ref, dryRun); err != nil {
+			logboek.Context(ctx).Warn().LogF("failed to remove local buildah image by repo tag %q: %s\n", ref, err)
+			allTagsRemoved = false
+		}
+
+		if err := werf.ReleaseHostLock(lock); err != nil {
+			return false, fmt.Errorf("unable to release lock %q: %w", lock.LockName, err)
+		}
+	}
+
+	return allTagsRemoved, nil
+}
+
+func removeBuildahImage(ctx context.Context, backend *container_backend.BuildahBackend, ref string, dryRun bool) error {
+	logboek.Context(ctx).Default().LogF("Removing %s\n", ref)
+	if dryRun {
+		return nil
+	}
+
+	return backend.Rmi(ctx, ref, container_backend.RmiOpts{})
+}
+
+func getBuildahUsedImagesIDs(ctx context.Context, backend *container_backend.BuildahBackend) ([]string, error) {
+	containers, err := backend.Containers(ctx, container_backend.ContainersOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get buildah containers: %w", err)
+	}
+
+	var res []string
+	for _, container := range containers {
+		res = append(res, container.ImageID)
+	}
+
+	return res, nil
+}
+
+// safeBuildahContainersCleanup removes leftover working containers of the buildah backend, which are not in use by running werf processes.
+func safeBuildahContainersCleanup(ctx context.Context, backend *container_backend.BuildahBackend, processedContainersIDs []string, dryRun bool) ([]string, error) {
+	containers, err := werfBuildahContainers(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+
+ProcessContainers:
+	for _, container := range containers {
+		for _, id := range processedContainersIDs {
+			if id == container.ID {
+				continue ProcessContainers
+			}
+		}
+		processedContainersIDs = append(processedContainersIDs, container.ID)
+
+		if err := func() error {
+			containerLockName := container_backend.ContainerLockName(container.Names[0])
+			isLocked, lock, err := werf.AcquireHostLock(ctx, containerLockName, lockgate.AcquireOptions{NonBlocking: true})
+			if err != nil {
+				return fmt.Errorf("failed to lock %s for container %s: %w", containerLockName, container.LogName(), err)
+			}
+
+			if !isLocked {
+				logboek.Context(ctx).Default().LogFDetails("Ignore container %s used by another process\n", container.LogName())
+				return nil
+			}
+			defer werf.ReleaseHostLock(lock)
+
+			if err := buildahContainersRemove(ctx, backend, []image.Container{container}, dryRun); err != nil {
+				return fmt.Errorf("failed to remove container %s: %w", container.LogName(), err)
+			}
+
+			return nil
+		}(); err != nil {
+			return nil, err
+		}
+	}
+
+	return processedContainersIDs, nil
+}
+
+func werfBuildahContainers(ctx context.Context, backend *container_backend.BuildahBackend) (image.ContainerList, error) {
+	containers, err := backend.Containers(ctx, container_backend.ContainersOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get buildah containers: %w", err)
+	}
+
+	var res image.ContainerList
+	for _, container := range containers {
+		if len(container.Names) == 0 || !strings.HasPrefix(container.Names[0], buildahWorkingContainerNamePrefix) {
+			continue
+		}
+		res = append(res, container)
+	}
+
+	return res, nil
+}
+
+func buildahContainersRemove(ctx context.Context, backend *container_backend.BuildahBackend, containers image.ContainerList, dryRun bool) error {
+	for _, container := range containers {
+		if dryRun {
+			logboek.Context(ctx).LogLn(container.LogName())
+			logboek.Context(ctx).LogOptionalLn()
+		} else {
+			if err := backend.Rm(ctx, container.Names[0], container_backend.RmOpts{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildahHostPurge removes all buildah working containers and images created by werf.
+func buildahHostPurge(ctx context.Context, backend *container_backend.BuildahBackend, options CommonOptions) error {
+	if err := logboek.Context(ctx).LogProcess("Running werf buildah containers purge").DoError(func() error {
+		containers, err := werfBuildahContainers(ctx, backend)
+		if err != nil {
+			return err
+		}
+
+		return buildahContainersRemove(ctx, backend, containers, options.DryRun)
+	}); err != nil {
+		return err
+	}
+
+	return logboek.Context(ctx).LogProcess("Running werf buildah images purge").DoError(func() error {
+		images, err := backend.Images(ctx, container_backend.ImagesOptions{
+			Filters: []util.Pair[string, string]{util.NewPair("label", image.WerfLabel)},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to get werf buildah images: %w", err)
+		}
+
+		usedImagesIDs, err := getBuildahUsedImagesIDs(ctx, backend)
+		if err != nil {
+			return err
+		}
+
+		for _, img := range images {
+			if !options.RmContainersThatUseWerfImages && util.IsStringsContainValue(usedImagesIDs, img.ID) {
+				logboek.Context(ctx).Default().LogFDetails("Skip image %s used by buildah container\n", img.ID)
+				continue
+			}
+
+			refs := img.RepoTags
+			if len(refs) == 0 {
+				refs = []string{img.ID}
+			}
+
+			for _, ref := range refs {
+				if err := removeBuildahImage(ctx, backend, ref, options.DryRun); err != nil {
+					return fmt.Errorf("unable to remove image %q: %w", ref, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func deleteBuildahStapel(ctx context.Context, backend *container_backend.BuildahBackend, dryRun bool) error {
+	images, err := backend.Images(ctx, container_backend.ImagesOptions{
+		Filters: []util.Pair[string, string]{util.NewPair("reference", stapel.ImageName())},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get stapel buildah images: %w", err)
+	}
+
+	if len(images) == 0 {
+		return nil
+	}
+
+	return removeBuildahImage(ctx, backend, stapel.ImageName(), dryRun)
+}
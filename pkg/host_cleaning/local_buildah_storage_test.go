@@ -0,0 +1,306 @@
+package host_cleaning
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/werf/lockgate"
+	"github.com/werf/werf/pkg/buildah"
+	"github.com/werf/werf/pkg/buildah/thirdparty"
+	"github.com/werf/werf/pkg/container_backend"
+	"github.com/werf/werf/pkg/image"
+	"github.com/werf/werf/pkg/storage/lrumeta"
+	"github.com/werf/werf/pkg/werf"
+)
+
+type fakeBuildah struct {
+	buildah.Buildah
+
+	images     image.ImagesList
+	containers image.ContainerList
+
+	removedImages     []string
+	removedContainers []string
+}
+
+func (b *fakeBuildah) Images(_ context.Context, opts buildah.ImagesOptions) (image.ImagesList, error) {
+	var res image.ImagesList
+
+ListImages:
+	for _, img := range b.images {
+		for _, filter := range opts.Filters {
+			switch filter.First {
+			case "label":
+				if _, hasLabel := img.Labels[filter.Second]; !hasLabel {
+					continue ListImages
+				}
+			case "reference":
+				if !fakeImageHasRef(img, filter.Second) {
+					continue ListImages
+				}
+			}
+		}
+
+		if !opts.WithLabelsAndSize {
+			img.Labels = nil
+			img.Size = 0
+		}
+		res = append(res, img)
+	}
+
+	return res, nil
+}
+
+func (b *fakeBuildah) Containers(_ context.Context, _ buildah.ContainersOptions) (image.ContainerList, error) {
+	return b.containers, nil
+}
+
+func (b *fakeBuildah) Rmi(_ context.Context, ref string, _ buildah.RmiOpts) error {
+	b.removedImages = append(b.removedImages, ref)
+
+	var images image.ImagesList
+	for _, img := range b.images {
+		if img.ID == ref {
+			continue
+		}
+
+		var repoTags []string
+		for _, repoTag := range img.RepoTags {
+			if repoTag != ref {
+				repoTags = append(repoTags, repoTag)
+			}
+		}
+		if len(img.RepoTags) > 0 && len(repoTags) == 0 {
+			continue
+		}
+
+		img.RepoTags = repoTags
+		images = append(images, img)
+	}
+	b.images = images
+
+	return nil
+}
+
+func (b *fakeBuildah) Rm(_ context.Context, ref string, _ buildah.RmOpts) error {
+	b.removedContainers = append(b.removedContainers, ref)
+
+	var containers image.ContainerList
+	for _, container := range b.containers {
+		if container.Names[0] != ref {
+			containers = append(containers, container)
+		}
+	}
+	b.containers = containers
+
+	return nil
+}
+
+func (b *fakeBuildah) Inspect(_ context.Context, _ string) (*thirdparty.BuilderInfo, error) {
+	return nil, nil
+}
+
+func fakeImageHasRef(img image.Summary, ref string) bool {
+	for _, repoTag := range img.RepoTags {
+		if repoTag == ref || strings.HasPrefix(repoTag, ref+":") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newFakeBuildahBackend(t *testing.T, images image.ImagesList, containers image.ContainerList) (*fakeBuildah, *container_backend.BuildahBackend) {
+	t.Helper()
+
+	t.Setenv("WERF_TMP_DIR", t.TempDir())
+	t.Setenv("WERF_HOME", t.TempDir())
+	if err := werf.Init("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := lrumeta.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeBuildah{images: images, containers: containers}
+	return fake, container_backend.NewBuildahBackend(fake, container_backend.BuildahBackendOptions{TmpDir: t.TempDir()})
+}
+
+func newWerfImageSummary(id string, repoTags []string, created time.Time, size int64) image.Summary {
+	return image.Summary{
+		ID:       id,
+		RepoTags: repoTags,
+		Labels: map[string]string{
+			image.WerfLabel:            "project",
+			image.WerfStageDigestLabel: "digest",
+		},
+		Created: created,
+		Size:    size,
+	}
+}
+
+func lockForTest(t *testing.T, lockName string) {
+	t.Helper()
+
+	_, lock, err := werf.AcquireHostLock(context.Background(), lockName, lockgate.AcquireOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = werf.ReleaseHostLock(lock) })
+}
+
+func TestGetLocalBuildahStorageCheck(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	notWerfStage := newWerfImageSummary("not-stage", []string{"localhost/not-stage:latest"}, now.Add(-10*time.Hour), 1)
+	delete(notWerfStage.Labels, image.WerfStageDigestLabel)
+
+	_, backend := newFakeBuildahBackend(t, image.ImagesList{
+		newWerfImageSummary("old", []string{"registry.example.com/app:old"}, now.Add(-3*time.Hour), 10),
+		newWerfImageSummary("recently-used", []string{"localhost/app:recently-used"}, now.Add(-4*time.Hour), 20),
+		newWerfImageSummary("new", []string{"registry.example.com/app:new"}, now.Add(-time.Hour), 30),
+		newWerfImageSummary("local-stage", []string{"localhost/project:digest-1611836746968"}, now.Add(-5*time.Hour), 40),
+		newWerfImageSummary("none", nil, now.Add(-6*time.Hour), 50),
+		notWerfStage,
+	}, nil)
+
+	// Buildah reports the localhost/ prefix, while werf records image access without it.
+	if err := lrumeta.CommonLRUImagesCache.AccessImage(ctx, "app:recently-used"); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := GetLocalBuildahStorageCheck(ctx, backend, t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var ids []string
+	for _, desc := range res.ImagesDescs {
+		ids = append(ids, desc.Summary.ID)
+	}
+	if expected := []string{"old", "new", "recently-used"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected images in LRU order %v, got %v", expected, ids)
+	}
+	if res.TotalImagesBytes != 60 {
+		t.Errorf("expected 60 total images bytes, got %d", res.TotalImagesBytes)
+	}
+}
+
+func TestRemoveBuildahImageTagsSkipsLockedTags(t *testing.T) {
+	ctx := context.Background()
+	img := newWerfImageSummary("id", []string{"localhost/app:locked", "localhost/app:free"}, time.Now(), 1)
+	fake, backend := newFakeBuildahBackend(t, image.ImagesList{img}, nil)
+
+	lockForTest(t, container_backend.ImageLockName("app:locked"))
+
+	removed, err := removeBuildahImageTags(ctx, backend, img, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if removed {
+		t.Errorf("expected image not to be removed completely")
+	}
+	if expected := []string{"localhost/app:free"}; !reflect.DeepEqual(fake.removedImages, expected) {
+		t.Errorf("expected removed images %v, got %v", expected, fake.removedImages)
+	}
+}
+
+func TestSafeBuildahContainersCleanup(t *testing.T) {
+	ctx := context.Background()
+	containers := image.ContainerList{
+		{ID: "1", Names: []string{"werf-free"}},
+		{ID: "2", Names: []string{"werf-locked"}},
+		{ID: "3", Names: []string{"user-container"}},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		fake, backend := newFakeBuildahBackend(t, nil, containers)
+		lockForTest(t, container_backend.ContainerLockName("werf-locked"))
+
+		processed, err := safeBuildahContainersCleanup(ctx, backend, nil, dryRun)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if expected := []string{"1", "2"}; !reflect.DeepEqual(processed, expected) {
+			t.Errorf("expected processed containers %v, got %v", expected, processed)
+		}
+
+		var expected []string
+		if !dryRun {
+			expected = []string{"werf-free"}
+		}
+		if !reflect.DeepEqual(fake.removedContainers, expected) {
+			t.Errorf("dry-run=%v: expected removed containers %v, got %v", dryRun, expected, fake.removedContainers)
+		}
+	}
+}
+
+func TestRunGCForLocalBuildahStorageDryRun(t *testing.T) {
+	ctx := context.Background()
+	fake, backend := newFakeBuildahBackend(t, image.ImagesList{
+		newWerfImageSummary("old", []string{"registry.example.com/app:old"}, time.Now().Add(-time.Hour), 10),
+	}, image.ContainerList{
+		{ID: "1", Names: []string{"werf-container"}},
+	})
+
+	if err := RunGCForLocalBuildahStorage(ctx, backend, 0, 0, t.TempDir(), false, true); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fake.removedImages) > 0 || len(fake.removedContainers) > 0 {
+		t.Errorf("expected nothing to be removed in dry-run mode, got images %v and containers %v", fake.removedImages, fake.removedContainers)
+	}
+}
+
+func TestBuildahHostPurge(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name               string
+		options            CommonOptions
+		expectedImages     []string
+		expectedContainers []string
+	}{
+		{
+			name:               "skip images used by containers",
+			expectedImages:     []string{"untagged", "registry.example.com/app:used-by-werf"},
+			expectedContainers: []string{"werf-container"},
+		},
+		{
+			name:               "remove images used by containers",
+			options:            CommonOptions{RmContainersThatUseWerfImages: true},
+			expectedImages:     []string{"registry.example.com/app:used-by-user", "untagged", "registry.example.com/app:used-by-werf"},
+			expectedContainers: []string{"werf-container"},
+		},
+		{
+			name:    "dry run",
+			options: CommonOptions{RmContainersThatUseWerfImages: true, DryRun: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, backend := newFakeBuildahBackend(t, image.ImagesList{
+				newWerfImageSummary("used-by-user", []string{"registry.example.com/app:used-by-user"}, time.Now(), 1),
+				newWerfImageSummary("untagged", nil, time.Now(), 1),
+				newWerfImageSummary("used-by-werf", []string{"registry.example.com/app:used-by-werf"}, time.Now(), 1),
+			}, image.ContainerList{
+				{ID: "1", ImageID: "used-by-user", Names: []string{"user-container"}},
+				{ID: "2", ImageID: "used-by-werf", Names: []string{"werf-container"}},
+			})
+
+			if err := buildahHostPurge(ctx, backend, tt.options); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(fake.removedImages, tt.expectedImages) {
+				t.Errorf("expected removed images %v, got %v", tt.expectedImages, fake.removedImages)
+			}
+			if !reflect.DeepEqual(fake.removedContainers, tt.expectedContainers) {
+				t.Errorf("expected removed containers %v, got %v", tt.expectedContainers, fake.removedContainers)
+			}
+		})
+	}
+}
@@ -74,13 +74,17 @@ func getDockerServerStoragePath(ctx context.Context, dockerServerStoragePathOpti
 }
 
 func ShouldRunAutoGCForLocalDockerServer(ctx context.Context, allowedVolumeUsagePercentage float64, dockerServerStoragePath string) (bool, error) {
-	if dockerServerStoragePath == "" {
+	return shouldRunAutoGCForStoragePath(ctx, allowedVolumeUsagePercentage, dockerServerStoragePath)
+}
+
+func shouldRunAutoGCForStoragePath(ctx context.Context, allowedVolumeUsagePercentage float64, storagePath string) (bool, error) {
+	if storagePath == "" {
 		return false, nil
 	}
 
-	vu, err := volumeutils.GetVolumeUsageByPath(ctx, dockerServerStoragePath)
+	vu, err := volumeutils.GetVolumeUsageByPath(ctx, storagePath)
 	if err != nil {
-		return false, fmt.Errorf("error getting volume usage by path %q: %w", dockerServerStoragePath, err)
+		return false, fmt.Errorf("error getting volume usage by path %q: %w", storagePath, err)
 	}
 
 	return vu.Percentage > allowedVolumeUsagePercentage, nil
@@ -93,8 +97,12 @@ type LocalDockerServerStorageCheckResult struct {
 }
 
 func (checkResult *LocalDockerServerStorageCheckResult) GetBytesToFree(targetVolumeUsage float64) uint64 {
-	allowedVolumeUsageToFree := checkResult.VolumeUsage.Percentage - targetVolumeUsage
-	bytesToFree := uint64((float64(checkResult.VolumeUsage.TotalBytes) / 100.0) * allowedVolumeUsageToFree)
+	return getBytesToFree(checkResult.VolumeUsage, targetVolumeUsage)
+}
+
+func getBytesToFree(volumeUsage volumeutils.VolumeUsage, targetVolumeUsage float64) uint64 {
+	allowedVolumeUsageToFree := volumeUsage.Percentage - targetVolumeUsage
+	bytesToFree := uint64((float64(volumeUsage.TotalBytes) / 100.0) * allowedVolumeUsageToFree)
 	return bytesToFree
 }
 
@@ -0,0 +1,124 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/werf/werf/pkg/werf"
+)
+
+const cycloneDXSpecVersion = "1.5"
+
+type cycloneDXDocument struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     cycloneDXTools     `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Type               string                       `json:"type"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	PURL               string                       `json:"purl,omitempty"`
+	Licenses           []cycloneDXLicenseChoice     `json:"licenses,omitempty"`
+	ExternalReferences []cycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []cycloneDXProperty          `json:"properties,omitempty"`
+}
+
+type cycloneDXLicenseChoice struct {
+	License cycloneDXLicense `json:"license"`
+}
+
+type cycloneDXLicense struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXExternalReference struct {
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (d *Document) marshalCycloneDX() ([]byte, error) {
+	imageComponent := cycloneDXComponent{
+		BOMRef:  d.ImageDigest,
+		Type:    "container",
+		Name:    d.ImageName,
+		Version: d.ImageDigest,
+	}
+
+	for _, source := range d.Sources {
+		imageComponent.Properties = append(imageComponent.Properties, cycloneDXProperty{
+			Name:  fmt.Sprintf("werf:git:%s:commit", source.Name),
+			Value: source.Commit,
+		})
+
+		if source.URL != "" {
+			imageComponent.ExternalReferences = append(imageComponent.ExternalReferences, cycloneDXExternalReference{
+				Type:    "vcs",
+				URL:     source.URL,
+				Comment: fmt.Sprintf("%s@%s", source.Name, source.Commit),
+			})
+		}
+	}
+
+	doc := cycloneDXDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: fmt.Sprintf("urn:uuid:%s", d.uuid()),
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: d.Created.UTC().Format(time.RFC3339),
+			Tools: cycloneDXTools{
+				Components: []cycloneDXComponent{
+					{Type: "application", Name: "werf", Version: werf.Version},
+				},
+			},
+			Component: imageComponent,
+		},
+		Components: []cycloneDXComponent{},
+	}
+
+	for _, pkg := range d.Packages {
+		purl := pkg.PURL(d.Distro)
+
+		component := cycloneDXComponent{
+			BOMRef:  purl,
+			Type:    "library",
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			PURL:    purl,
+			Properties: []cycloneDXProperty{
+				{Name: "werf:package:type", Value: string(pkg.Type)},
+			},
+		}
+
+		if pkg.License != "" {
+			component.Licenses = []cycloneDXLicenseChoice{{License: cycloneDXLicense{Name: pkg.License}}}
+		}
+
+		doc.Components = append(doc.Components, component)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
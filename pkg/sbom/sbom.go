@@ -0,0 +1,126 @@
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Format string
+
+const (
+	FormatSPDX      Format = "spdx"
+	FormatCycloneDX Format = "cyclonedx"
+)
+
+const (
+	SPDXMediaType      = "application/spdx+json"
+	CycloneDXMediaType = "application/vnd.cyclonedx+json"
+
+	ArtifactTagSuffix = ".sbom"
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch f := Format(format); f {
+	case FormatSPDX, FormatCycloneDX:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown SBOM format %q: expected %q or %q", format, FormatSPDX, FormatCycloneDX)
+	}
+}
+
+func (f Format) MediaType() string {
+	switch f {
+	case FormatSPDX:
+		return SPDXMediaType
+	case FormatCycloneDX:
+		return CycloneDXMediaType
+	default:
+		panic(fmt.Sprintf("unknown SBOM format %q", f))
+	}
+}
+
+// ArtifactTag returns the tag of the SBOM artifact for the image digest (sha256:abc -> sha256-abc.sbom).
+// The tag allows to find SBOM in registries without OCI referrers API support.
+func ArtifactTag(imageDigest string) string {
+	return strings.Replace(imageDigest, ":", "-", 1) + ArtifactTagSuffix
+}
+
+type PackageType string
+
+const (
+	PackageTypeDeb PackageType = "deb"
+	PackageTypeApk PackageType = "apk"
+)
+
+type Package struct {
+	Type         PackageType
+	Name         string
+	Version      string
+	Architecture string
+	License      string
+}
+
+// PURL returns the package URL (https://github.com/package-url/purl-spec) of the package installed into the distro.
+func (p Package) PURL(distro Distro) string {
+	var namespace string
+	if distro.ID != "" {
+		namespace = url.PathEscape(distro.ID) + "/"
+	}
+
+	purl := fmt.Sprintf("pkg:%s/%s%s@%s", p.Type, namespace, url.QueryEscape(p.Name), url.QueryEscape(p.Version))
+
+	var qualifiers []string
+	if p.Architecture != "" {
+		qualifiers = append(qualifiers, "arch="+url.QueryEscape(p.Architecture))
+	}
+	if distro.ID != "" && distro.VersionID != "" {
+		qualifiers = append(qualifiers, "distro="+url.QueryEscape(distro.ID+"-"+distro.VersionID))
+	}
+	if len(qualifiers) > 0 {
+		purl += "?" + strings.Join(qualifiers, "&")
+	}
+
+	return purl
+}
+
+type Distro struct {
+	ID         string
+	VersionID  string
+	PrettyName string
+}
+
+// Source is the git commit the image is built from.
+type Source struct {
+	Name   string
+	URL    string
+	Commit string
+}
+
+type Document struct {
+	ImageName   string
+	ImageDigest string
+	Created     time.Time
+
+	Distro   Distro
+	Packages []Package
+	Sources  []Source
+}
+
+func (d *Document) Marshal(format Format) ([]byte, error) {
+	switch format {
+	case FormatSPDX:
+		return d.marshalSPDX()
+	case FormatCycloneDX:
+		return d.marshalCycloneDX()
+	default:
+		return nil, fmt.Errorf("unknown SBOM format %q", format)
+	}
+}
+
+func (d *Document) uuid() uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(d.ImageName+"@"+d.ImageDigest))
+}
@@ -0,0 +1,112 @@
+package sbom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func newTestDocument() *Document {
+	return &Document{
+		ImageName:   "registry.example.com/app:tag",
+		ImageDigest: "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		Created:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Distro:      Distro{ID: "alpine", VersionID: "3.19.1"},
+		Packages: []Package{
+			{Type: PackageTypeApk, Name: "musl", Version: "1.2.4-r2", Architecture: "x86_64", License: "MIT"},
+			{Type: PackageTypeApk, Name: "busybox", Version: "1.36.1-r5", Architecture: "x86_64"},
+		},
+		Sources: []Source{
+			{Name: "project", URL: "https://github.com/werf/werf.git", Commit: "c0ffee"},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range []string{"spdx", "cyclonedx"} {
+		if f, err := ParseFormat(format); err != nil || string(f) != format {
+			t.Errorf("expected format %q, got %q and error %v", format, f, err)
+		}
+	}
+
+	if _, err := ParseFormat("swid"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
+
+func TestArtifactTag(t *testing.T) {
+	if tag := ArtifactTag("sha256:abc"); tag != "sha256-abc.sbom" {
+		t.Errorf("expected tag %q, got %q", "sha256-abc.sbom", tag)
+	}
+}
+
+func TestDocument_MarshalSPDX(t *testing.T) {
+	data, err := newTestDocument().Marshal(FormatSPDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc spdxDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Created != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected document header: %s", data)
+	}
+
+	if len(doc.Packages) != 4 {
+		t.Fatalf("expected image, 2 packages and source in the document, got: %s", data)
+	}
+	if pkg := doc.Packages[1]; pkg.Name != "musl" || pkg.LicenseDeclared != "MIT" || pkg.ExternalRefs[0].ReferenceLocator != "pkg:apk/alpine/musl@1.2.4-r2?arch=x86_64&distro=alpine-3.19.1" {
+		t.Errorf("unexpected package: %+v", pkg)
+	}
+	if pkg := doc.Packages[2]; pkg.LicenseDeclared != "NOASSERTION" {
+		t.Errorf("expected NOASSERTION license for package without license, got %q", pkg.LicenseDeclared)
+	}
+	if pkg := doc.Packages[3]; pkg.DownloadLocation != "git+https://github.com/werf/werf.git@c0ffee" || pkg.PrimaryPackagePurpose != "SOURCE" {
+		t.Errorf("unexpected source package: %+v", pkg)
+	}
+
+	if len(doc.Relationships) != 4 || doc.Relationships[0].RelationshipType != "DESCRIBES" || doc.Relationships[3].RelationshipType != "GENERATED_FROM" {
+		t.Errorf("unexpected relationships: %+v", doc.Relationships)
+	}
+
+	otherData, err := newTestDocument().Marshal(FormatSPDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(otherData) {
+		t.Errorf("expected reproducible document")
+	}
+}
+
+func TestDocument_MarshalCycloneDX(t *testing.T) {
+	data, err := newTestDocument().Marshal(FormatCycloneDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc cycloneDXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.BOMFormat != "CycloneDX" || doc.SpecVersion != "1.5" || doc.Metadata.Timestamp != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected document header: %s", data)
+	}
+
+	if component := doc.Metadata.Component; component.Type != "container" || component.Version != newTestDocument().ImageDigest || len(component.ExternalReferences) != 1 {
+		t.Errorf("unexpected image component: %+v", component)
+	}
+
+	if len(doc.Components) != 2 {
+		t.Fatalf("expected 2 components, got: %s", data)
+	}
+	if component := doc.Components[0]; component.PURL != "pkg:apk/alpine/musl@1.2.4-r2?arch=x86_64&distro=alpine-3.19.1" || component.Licenses[0].License.Name != "MIT" {
+		t.Errorf("unexpected component: %+v", component)
+	}
+	if component := doc.Components[1]; len(component.Licenses) != 0 {
+		t.Errorf("expected no licenses, got %+v", component.Licenses)
+	}
+}
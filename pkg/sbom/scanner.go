@@ -0,0 +1,203 @@
+package sbom
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/util"
+)
+
+const (
+	dpkgStatusPath    = "var/lib/dpkg/status"
+	dpkgStatusDirPath = "var/lib/dpkg/status.d"
+	apkInstalledPath  = "lib/apk/db/installed"
+)
+
+var (
+	osReleasePaths = []string{"etc/os-release", "usr/lib/os-release"}
+	rpmDBPaths     = []string{"var/lib/rpm/Packages", "var/lib/rpm/Packages.db", "var/lib/rpm/rpmdb.sqlite", "usr/lib/sysimage/rpm/rpmdb.sqlite"}
+)
+
+// ScanFilesystem reads the flattened image filesystem tar stream and fills the document with the distro and installed packages.
+// Supported package databases are dpkg and apk.
+func ScanFilesystem(ctx context.Context, fsReader io.Reader, doc *Document) error {
+	osReleaseData := map[string][]byte{}
+	var rpmDBPath string
+
+	tr := tar.NewReader(fsReader)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("unable to read filesystem archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		filePath := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+
+		switch {
+		case filePath == dpkgStatusPath, path.Dir(filePath) == dpkgStatusDirPath && !strings.HasSuffix(filePath, ".md5sums"):
+			packages, err := parseDpkgStatus(tr)
+			if err != nil {
+				return fmt.Errorf("unable to parse %q: %w", filePath, err)
+			}
+			doc.Packages = append(doc.Packages, packages...)
+
+		case filePath == apkInstalledPath:
+			packages, err := parseApkInstalled(tr)
+			if err != nil {
+				return fmt.Errorf("unable to parse %q: %w", filePath, err)
+			}
+			doc.Packages = append(doc.Packages, packages...)
+
+		case util.IsStringsContainValue(osReleasePaths, filePath):
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("unable to read %q: %w", filePath, err)
+			}
+			osReleaseData[filePath] = data
+
+		case util.IsStringsContainValue(rpmDBPaths, filePath):
+			rpmDBPath = filePath
+		}
+	}
+
+	for _, osReleasePath := range osReleasePaths {
+		if data, ok := osReleaseData[osReleasePath]; ok {
+			doc.Distro = parseOSRelease(data)
+			break
+		}
+	}
+
+	if rpmDBPath != "" {
+		logboek.Context(ctx).Warn().LogF("WARNING: rpm package database %q is not supported, rpm packages will not be included into the SBOM of image %s\n", "/"+rpmDBPath, doc.ImageName)
+	}
+
+	sort.SliceStable(doc.Packages, func(i, j int) bool {
+		if doc.Packages[i].Type != doc.Packages[j].Type {
+			return doc.Packages[i].Type < doc.Packages[j].Type
+		}
+		return doc.Packages[i].Name < doc.Packages[j].Name
+	})
+
+	return nil
+}
+
+// parseDpkgStatus parses dpkg status file, only installed packages are returned.
+func parseDpkgStatus(r io.Reader) ([]Package, error) {
+	var res []Package
+
+	err := parseControlParagraphs(r, func(fields map[string]string) {
+		if fields["Package"] == "" {
+			return
+		}
+
+		// status.d files of distroless images have no Status field
+		if status, hasStatus := fields["Status"]; hasStatus {
+			if statusParts := strings.Fields(status); len(statusParts) != 3 || statusParts[2] != "installed" {
+				return
+			}
+		}
+
+		res = append(res, Package{
+			Type:         PackageTypeDeb,
+			Name:         fields["Package"],
+			Version:      fields["Version"],
+			Architecture: fields["Architecture"],
+		})
+	})
+
+	return res, err
+}
+
+// parseApkInstalled parses apk installed database, which consists of one-letter key:value fields.
+func parseApkInstalled(r io.Reader) ([]Package, error) {
+	var res []Package
+
+	err := parseControlParagraphs(r, func(fields map[string]string) {
+		if fields["P"] == "" {
+			return
+		}
+
+		res = append(res, Package{
+			Type:         PackageTypeApk,
+			Name:         fields["P"],
+			Version:      fields["V"],
+			Architecture: fields["A"],
+			License:      fields["L"],
+		})
+	})
+
+	return res, err
+}
+
+// parseControlParagraphs calls handleParagraph for each blank line separated paragraph of key-value fields.
+// Continuation lines (starting with a whitespace) are ignored.
+func parseControlParagraphs(r io.Reader, handleParagraph func(fields map[string]string)) error {
+	fields := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			if len(fields) > 0 {
+				handleParagraph(fields)
+				fields = map[string]string{}
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			continue
+		default:
+			if key, value, found := strings.Cut(line, ":"); found {
+				fields[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		handleParagraph(fields)
+	}
+
+	return nil
+}
+
+func parseOSRelease(data []byte) Distro {
+	fields := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if key, value, found := strings.Cut(line, "="); found {
+			fields[key] = strings.Trim(value, `"'`)
+		}
+	}
+
+	return Distro{
+		ID:         fields["ID"],
+		VersionID:  fields["VERSION_ID"],
+		PrettyName: fields["PRETTY_NAME"],
+	}
+}
@@ -0,0 +1,134 @@
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestScanFilesystem(t *testing.T) {
+	dpkgStatus := `Package: libc6
+Status: install ok installed
+Architecture: amd64
+Version: 2.36-9+deb12u3
+Description: GNU C Library: Shared libraries
+ Contains the standard libraries that are used by nearly all programs on
+ the system.
+
+Package: removed-package
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+
+Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.15-2+b2
+`
+
+	distrolessStatus := `Package: tzdata
+Version: 2024a-0+deb12u1
+Architecture: all
+`
+
+	apkInstalled := `C:Q1abc=
+P:musl
+V:1.2.4-r2
+A:x86_64
+L:MIT
+
+C:Q1def=
+P:busybox
+V:1.36.1-r5
+A:x86_64
+L:GPL-2.0-only
+`
+
+	osRelease := `PRETTY_NAME="Debian GNU/Linux 12 (bookworm)"
+NAME="Debian GNU/Linux"
+VERSION_ID="12"
+ID=debian
+`
+
+	fsArchive := newTarArchive(t, map[string]string{
+		"etc/os-release":                       osRelease,
+		"var/lib/dpkg/status":                  dpkgStatus,
+		"./var/lib/dpkg/status.d/tzdata":       distrolessStatus,
+		"var/lib/dpkg/status.d/tzdata.md5sums": "d41d8cd98f00b204e9800998ecf8427e  usr/share/zoneinfo/UTC\n",
+		"lib/apk/db/installed":                 apkInstalled,
+		"usr/bin/bash":                         "",
+	})
+
+	doc := &Document{ImageName: "app"}
+	if err := ScanFilesystem(context.Background(), fsArchive, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedDistro := Distro{ID: "debian", VersionID: "12", PrettyName: "Debian GNU/Linux 12 (bookworm)"}
+	if doc.Distro != expectedDistro {
+		t.Errorf("expected distro %+v, got %+v", expectedDistro, doc.Distro)
+	}
+
+	expectedPackages := []Package{
+		{Type: PackageTypeApk, Name: "busybox", Version: "1.36.1-r5", Architecture: "x86_64", License: "GPL-2.0-only"},
+		{Type: PackageTypeApk, Name: "musl", Version: "1.2.4-r2", Architecture: "x86_64", License: "MIT"},
+		{Type: PackageTypeDeb, Name: "bash", Version: "5.2.15-2+b2", Architecture: "amd64"},
+		{Type: PackageTypeDeb, Name: "libc6", Version: "2.36-9+deb12u3", Architecture: "amd64"},
+		{Type: PackageTypeDeb, Name: "tzdata", Version: "2024a-0+deb12u1", Architecture: "all"},
+	}
+	if !reflect.DeepEqual(doc.Packages, expectedPackages) {
+		t.Errorf("expected packages:\n%+v\ngot:\n%+v", expectedPackages, doc.Packages)
+	}
+}
+
+func TestPackage_PURL(t *testing.T) {
+	tests := []struct {
+		pkg      Package
+		distro   Distro
+		expected string
+	}{
+		{
+			pkg:      Package{Type: PackageTypeDeb, Name: "libc6", Version: "2.36-9+deb12u3", Architecture: "amd64"},
+			distro:   Distro{ID: "debian", VersionID: "12"},
+			expected: "pkg:deb/debian/libc6@2.36-9%2Bdeb12u3?arch=amd64&distro=debian-12",
+		},
+		{
+			pkg:      Package{Type: PackageTypeDeb, Name: "perl", Version: "1:5.36.0-7"},
+			distro:   Distro{},
+			expected: "pkg:deb/perl@1%3A5.36.0-7",
+		},
+		{
+			pkg:      Package{Type: PackageTypeApk, Name: "musl", Version: "1.2.4-r2", Architecture: "x86_64"},
+			distro:   Distro{ID: "alpine", VersionID: "3.19.1"},
+			expected: "pkg:apk/alpine/musl@1.2.4-r2?arch=x86_64&distro=alpine-3.19.1",
+		},
+	}
+
+	for _, tt := range tests {
+		if purl := tt.pkg.PURL(tt.distro); purl != tt.expected {
+			t.Errorf("expected purl %q, got %q", tt.expected, purl)
+		}
+	}
+}
+
+func newTarArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
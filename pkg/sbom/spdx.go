@@ -0,0 +1,120 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/werf/werf/pkg/werf"
+)
+
+const (
+	spdxVersion        = "SPDX-2.3"
+	spdxNoAssertion    = "NOASSERTION"
+	spdxDocumentID     = "SPDXRef-DOCUMENT"
+	spdxImagePackageID = "SPDXRef-Image"
+)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	LicenseDeclared       string            `json:"licenseDeclared,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func (d *Document) marshalSPDX() ([]byte, error) {
+	doc := spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              d.ImageName,
+		DocumentNamespace: fmt.Sprintf("https://werf.io/spdx/%s", d.uuid()),
+		CreationInfo: spdxCreationInfo{
+			Created:  d.Created.UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: werf-%s", werf.Version)},
+		},
+		Packages: []spdxPackage{
+			{
+				SPDXID:                spdxImagePackageID,
+				Name:                  d.ImageName,
+				VersionInfo:           d.ImageDigest,
+				DownloadLocation:      spdxNoAssertion,
+				PrimaryPackagePurpose: "CONTAINER",
+			},
+		},
+		Relationships: []spdxRelationship{
+			{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: spdxImagePackageID},
+		},
+	}
+
+	for i, pkg := range d.Packages {
+		license := pkg.License
+		if license == "" {
+			license = spdxNoAssertion
+		}
+
+		id := fmt.Sprintf("SPDXRef-Package-%s-%d", pkg.Type, i+1)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:           id,
+			Name:             pkg.Name,
+			VersionInfo:      pkg.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseDeclared:  license,
+			ExternalRefs: []spdxExternalRef{
+				{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: pkg.PURL(d.Distro)},
+			},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: spdxImagePackageID, RelationshipType: "CONTAINS", RelatedSPDXElement: id})
+	}
+
+	for i, source := range d.Sources {
+		downloadLocation := spdxNoAssertion
+		if source.URL != "" {
+			downloadLocation = fmt.Sprintf("git+%s@%s", source.URL, source.Commit)
+		}
+
+		id := fmt.Sprintf("SPDXRef-Source-%d", i+1)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:                id,
+			Name:                  source.Name,
+			VersionInfo:           source.Commit,
+			DownloadLocation:      downloadLocation,
+			PrimaryPackagePurpose: "SOURCE",
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: spdxImagePackageID, RelationshipType: "GENERATED_FROM", RelatedSPDXElement: id})
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,118 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// ageEncryptedDataHeader is the first line of the age encrypted binary data.
+const ageEncryptedDataHeader = "age-encryption.org/v1\n"
+
+// AgeEncoder encrypts data for the list of age X25519 recipients, any of the corresponding identities is able to decrypt the data.
+// Encrypted data is base64 encoded, so it can be stored as a yaml string value as well as the AesEncoder data.
+type AgeEncoder struct {
+	Recipients []age.Recipient
+	Identities []age.Identity
+}
+
+// GenerateAgeIdentity returns a new identity and the corresponding recipient in the age-keygen format.
+func GenerateAgeIdentity() (string, string, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", err
+	}
+
+	return identity.String(), identity.Recipient().String(), nil
+}
+
+// NewAgeEncoder creates encoder for the recipients (age1...), identities (AGE-SECRET-KEY-1...) are parsed from the key,
+// which is a usual secret key in the age identities file format: one identity per line, empty lines and #-comments are ignored.
+func NewAgeEncoder(recipients []string, key []byte) (*AgeEncoder, error) {
+	encoder := &AgeEncoder{}
+
+	for _, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("bad age recipient %q: %w", recipient, err)
+		}
+		encoder.Recipients = append(encoder.Recipients, r)
+	}
+
+	if len(bytes.TrimSpace(key)) > 0 {
+		identities, err := age.ParseIdentities(bytes.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse age identities from the secret key: %w", err)
+		}
+		encoder.Identities = identities
+	}
+
+	return encoder, nil
+}
+
+func (s *AgeEncoder) Encrypt(data []byte) ([]byte, error) {
+	if len(s.Recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients specified")
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, s.Recipients...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(result, buf.Bytes())
+
+	return result, nil
+}
+
+func (s *AgeEncoder) Decrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	if len(s.Identities) == 0 {
+		return nil, fmt.Errorf("no age identities specified")
+	}
+
+	encryptedData, ok := decodeAgeEncryptedData(data)
+	if !ok {
+		return nil, fmt.Errorf("age encrypted data expected")
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(encryptedData), s.Identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+// IsAgeEncryptedData returns true if data is encrypted by the AgeEncoder.
+func IsAgeEncryptedData(data []byte) bool {
+	_, ok := decodeAgeEncryptedData(data)
+	return ok
+}
+
+func decodeAgeEncryptedData(data []byte) ([]byte, bool) {
+	result := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(result, bytes.TrimSpace(data))
+	if err != nil || !strings.HasPrefix(string(result[:n]), ageEncryptedDataHeader) {
+		return nil, false
+	}
+
+	return result[:n], true
+}
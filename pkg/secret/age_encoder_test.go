@@ -0,0 +1,147 @@
+package secret
+
+import (
+	"fmt"
+	"testing"
+)
+
+func generateAgeIdentityForTest(t *testing.T) (string, string) {
+	t.Helper()
+
+	identity, recipient, err := GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return identity, recipient
+}
+
+func TestAgeEncoder_EncryptDecrypt(t *testing.T) {
+	identity1, recipient1 := generateAgeIdentityForTest(t)
+	identity2, recipient2 := generateAgeIdentityForTest(t)
+	otherIdentity, _ := generateAgeIdentityForTest(t)
+
+	enc, err := NewAgeEncoder([]string{recipient1, recipient2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("secret data")
+	encryptedData, err := enc.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsAgeEncryptedData(encryptedData) {
+		t.Fatalf("expected age encrypted data, got %q", encryptedData)
+	}
+
+	for _, identity := range []string{identity1, identity2, fmt.Sprintf("# comment\n%s\n%s\n", otherIdentity, identity2)} {
+		dec, err := NewAgeEncoder(nil, []byte(identity))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decryptedData, err := dec.Decrypt(encryptedData)
+		if err != nil {
+			t.Fatalf("unexpected decryption error: %s", err)
+		}
+
+		if string(decryptedData) != string(data) {
+			t.Errorf("expected %q, got %q", data, decryptedData)
+		}
+	}
+
+	dec, err := NewAgeEncoder(nil, []byte(otherIdentity))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dec.Decrypt(encryptedData); err == nil {
+		t.Errorf("expected decryption error for identity, which is not in recipients")
+	}
+}
+
+func TestAgeEncoder_Decrypt_negative(t *testing.T) {
+	identity, _ := generateAgeIdentityForTest(t)
+
+	withoutIdentities, err := NewAgeEncoder(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := withoutIdentities.Decrypt(nil); err != nil || len(data) != 0 {
+		t.Errorf("expected empty data to be decrypted as is, got %q and error %v", data, err)
+	}
+
+	if _, err := withoutIdentities.Decrypt([]byte("data")); err == nil {
+		t.Errorf("expected error without identities")
+	}
+
+	withIdentity, err := NewAgeEncoder(nil, []byte(identity))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aesEncoder, err := NewAesEncoder(AesSecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aesEncryptedData, err := aesEncoder.Encrypt([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if IsAgeEncryptedData(aesEncryptedData) {
+		t.Errorf("expected aes encrypted data not to be detected as age encrypted data")
+	}
+
+	if _, err := withIdentity.Decrypt(aesEncryptedData); err == nil {
+		t.Errorf("expected error for aes encrypted data")
+	}
+}
+
+func TestNewAgeEncoder_negative(t *testing.T) {
+	if _, err := NewAgeEncoder([]string{"age1bad"}, nil); err == nil {
+		t.Errorf("expected error for bad recipient")
+	}
+
+	if _, err := NewAgeEncoder(nil, []byte("AGE-SECRET-KEY-1BAD")); err == nil {
+		t.Errorf("expected error for bad identity")
+	}
+
+	enc, err := NewAgeEncoder(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := enc.Encrypt([]byte("data")); err == nil {
+		t.Errorf("expected error without recipients")
+	}
+}
+
+func TestAgeEncoder_YamlEncoder(t *testing.T) {
+	identity, recipient := generateAgeIdentityForTest(t)
+
+	enc, err := NewAgeEncoder([]string{recipient}, []byte(identity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlEncoder := NewYamlEncoder(enc)
+
+	data := "a: one\nb:\n  c: two\n"
+	encryptedData, err := yamlEncoder.EncryptYamlData([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decryptedData, err := yamlEncoder.DecryptYamlData(encryptedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(decryptedData) != data {
+		t.Errorf("expected %q, got %q", data, decryptedData)
+	}
+}
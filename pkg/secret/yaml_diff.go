@@ -0,0 +1,135 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+
+	yaml_v3 "gopkg.in/yaml.v3"
+)
+
+type YamlDiffType string
+
+const (
+	YamlDiffAdded   YamlDiffType = "+"
+	YamlDiffRemoved YamlDiffType = "-"
+	YamlDiffChanged YamlDiffType = "~"
+)
+
+// YamlDiffEntry describes the change of a single leaf value, Path is a dot-separated path to the value (e.g. mysql.hosts[0]).
+type YamlDiffEntry struct {
+	Type     YamlDiffType
+	Path     string
+	OldValue string
+	NewValue string
+}
+
+// DiffYamlData compares leaf values of two yaml documents. Entries are returned in the order of the new document,
+// removed values follow the preceding value of the old document.
+func DiffYamlData(oldData, newData []byte) ([]YamlDiffEntry, error) {
+	oldPaths, oldValues, err := flattenYamlData(oldData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process old yaml data: %w", err)
+	}
+
+	newPaths, newValues, err := flattenYamlData(newData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process new yaml data: %w", err)
+	}
+
+	paths := append([]string{}, newPaths...)
+	insertPos := 0
+	for _, path := range oldPaths {
+		if _, exists := newValues[path]; exists {
+			for i, p := range paths {
+				if p == path {
+					insertPos = i + 1
+					break
+				}
+			}
+			continue
+		}
+
+		paths = append(paths[:insertPos], append([]string{path}, paths[insertPos:]...)...)
+		insertPos++
+	}
+
+	var res []YamlDiffEntry
+	for _, path := range paths {
+		oldValue, oldExists := oldValues[path]
+		newValue, newExists := newValues[path]
+
+		switch {
+		case !oldExists:
+			res = append(res, YamlDiffEntry{Type: YamlDiffAdded, Path: path, NewValue: newValue})
+		case !newExists:
+			res = append(res, YamlDiffEntry{Type: YamlDiffRemoved, Path: path, OldValue: oldValue})
+		case oldValue != newValue:
+			res = append(res, YamlDiffEntry{Type: YamlDiffChanged, Path: path, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	return res, nil
+}
+
+func flattenYamlData(data []byte) ([]string, map[string]string, error) {
+	var config yaml_v3.Node
+	if err := yaml_v3.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("unable to unmarshal yaml data: %w", err)
+	}
+
+	var paths []string
+	values := map[string]string{}
+	flattenYamlNode(&config, "", func(path, value string) {
+		if _, exists := values[path]; !exists {
+			paths = append(paths, path)
+		}
+		values[path] = value
+	})
+
+	return paths, values, nil
+}
+
+func flattenYamlNode(node *yaml_v3.Node, path string, addValue func(path, value string)) {
+	switch node.Kind {
+	case yaml_v3.DocumentNode:
+		for _, n := range node.Content {
+			flattenYamlNode(n, path, addValue)
+		}
+
+	case yaml_v3.MappingNode:
+		if len(node.Content) == 0 {
+			addValue(path, "{}")
+		}
+
+		for pos := 0; pos+1 < len(node.Content); pos += 2 {
+			flattenYamlNode(node.Content[pos+1], joinYamlPath(path, node.Content[pos].Value), addValue)
+		}
+
+	case yaml_v3.SequenceNode:
+		if len(node.Content) == 0 {
+			addValue(path, "[]")
+		}
+
+		for pos, n := range node.Content {
+			flattenYamlNode(n, fmt.Sprintf("%s[%d]", path, pos), addValue)
+		}
+
+	case yaml_v3.AliasNode:
+		flattenYamlNode(node.Alias, path, addValue)
+
+	case yaml_v3.ScalarNode:
+		addValue(path, node.Value)
+	}
+}
+
+func joinYamlPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		key = fmt.Sprintf("%q", key)
+	}
+
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
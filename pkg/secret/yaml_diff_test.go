@@ -0,0 +1,67 @@
+package secret
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffYamlData(t *testing.T) {
+	oldData := []byte(`
+mysql:
+  user: root
+  password: old
+removed: value
+hosts:
+  - a
+  - b
+cert: |
+  line1
+  line2
+`)
+
+	newData := []byte(`
+mysql:
+  password: new
+  user: root
+hosts:
+  - a
+added:
+  "dotted.key": value
+cert: |
+  line1
+  line2
+empty: {}
+`)
+
+	entries, err := DiffYamlData(oldData, newData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []YamlDiffEntry{
+		{Type: YamlDiffChanged, Path: "mysql.password", OldValue: "old", NewValue: "new"},
+		{Type: YamlDiffRemoved, Path: "removed", OldValue: "value"},
+		{Type: YamlDiffRemoved, Path: "hosts[1]", OldValue: "b"},
+		{Type: YamlDiffAdded, Path: `added."dotted.key"`, NewValue: "value"},
+		{Type: YamlDiffAdded, Path: "empty", NewValue: "{}"},
+	}
+
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("expected %+v, got %+v", expected, entries)
+	}
+}
+
+func TestDiffYamlData_emptyData(t *testing.T) {
+	entries, err := DiffYamlData(nil, []byte("a: one\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []YamlDiffEntry{{Type: YamlDiffAdded, Path: "a", NewValue: "one"}}; !reflect.DeepEqual(entries, expected) {
+		t.Errorf("expected %+v, got %+v", expected, entries)
+	}
+
+	if _, err := DiffYamlData([]byte("a: [b"), nil); err == nil {
+		t.Errorf("expected error for bad yaml data")
+	}
+}
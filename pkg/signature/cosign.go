@@ -0,0 +1,93 @@
+package signature
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// CosignSignatureTagSuffix is the suffix of the tag cosign looks for the image signatures at (sha256-<image digest>.sig).
+	CosignSignatureTagSuffix = ".sig"
+
+	CosignConfigMediaType        = "application/vnd.oci.image.config.v1+json"
+	CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	CosignSignatureAnnotation    = "dev.cosignproject.cosign/signature"
+
+	cosignSignatureType = "cosign container image signature"
+)
+
+// SimpleSigningPayload is the signed payload of the image signature in the Red Hat simple signing format used by cosign.
+type SimpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// CosignSignature is the signature layer of the cosign signature image.
+type CosignSignature struct {
+	Payload     []byte
+	Annotations map[string]string
+}
+
+func CosignSignatureTag(imageDigest string) string {
+	return strings.Replace(imageDigest, ":", "-", 1) + CosignSignatureTagSuffix
+}
+
+// SignImage signs the image manifest digest, dockerReference is the image repository without tag.
+func SignImage(ctx context.Context, signer Signer, dockerReference, imageDigest string) (*CosignSignature, error) {
+	var payload SimpleSigningPayload
+	payload.Critical.Identity.DockerReference = dockerReference
+	payload.Critical.Image.DockerManifestDigest = imageDigest
+	payload.Critical.Type = cosignSignatureType
+
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal signature payload: %w", err)
+	}
+
+	sig, err := signer.Sign(ctx, payloadData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign image %s@%s: %w", dockerReference, imageDigest, err)
+	}
+
+	return &CosignSignature{
+		Payload: payloadData,
+		Annotations: map[string]string{
+			CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+		},
+	}, nil
+}
+
+// VerifyImageSignature checks the signature and that it is issued for the image manifest digest.
+func VerifyImageSignature(publicKey crypto.PublicKey, signature *CosignSignature, imageDigest string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature.Annotations[CosignSignatureAnnotation])
+	if err != nil {
+		return fmt.Errorf("unable to decode signature: %w", err)
+	}
+
+	if err := Verify(publicKey, signature.Payload, sig); err != nil {
+		return err
+	}
+
+	var payload SimpleSigningPayload
+	if err := json.Unmarshal(signature.Payload, &payload); err != nil {
+		return fmt.Errorf("unable to unmarshal signature payload: %w", err)
+	}
+
+	if payload.Critical.Image.DockerManifestDigest != imageDigest {
+		return fmt.Errorf("signature is issued for %s, expected %s", payload.Critical.Image.DockerManifestDigest, imageDigest)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PluginSigner delegates signing to an external program (e.g. KMS client):
+//   - `PLUGIN sign` receives the payload on stdin and prints the base64 encoded ASN.1 ECDSA signature of its SHA-256 digest;
+//   - `PLUGIN public-key` prints the PEM encoded public key.
+type PluginSigner struct {
+	path string
+}
+
+func NewPluginSigner(path string) *PluginSigner {
+	return &PluginSigner{path: path}
+}
+
+func (s *PluginSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	output, err := s.run(ctx, payload, "sign")
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode signing plugin %q output: %w", s.path, err)
+	}
+
+	return sig, nil
+}
+
+func (s *PluginSigner) PublicKey(ctx context.Context) (crypto.PublicKey, error) {
+	output, err := s.run(ctx, nil, "public-key")
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePublicKey(output)
+}
+
+func (s *PluginSigner) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, s.path, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("signing plugin %q %s failed: %w\n%s", s.path, strings.Join(args, " "), err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
+)
+
+const (
+	PluginKeyRefPrefix = "exec://"
+
+	pemTypeECPrivateKey      = "EC PRIVATE KEY"
+	pemTypePrivateKey        = "PRIVATE KEY"
+	pemTypeEncryptedSigstore = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	pemTypeEncryptedCosign   = "ENCRYPTED COSIGN PRIVATE KEY"
+	pemTypePublicKey         = "PUBLIC KEY"
+)
+
+// Signer signs payloads with ECDSA-P256-SHA256 signatures, which is the default signature scheme of cosign.
+type Signer interface {
+	Sign(ctx context.Context, payload []byte) ([]byte, error)
+	PublicKey(ctx context.Context) (crypto.PublicKey, error)
+}
+
+// NewSigner returns signer by the key reference, which is either the path to the private key file or exec://PLUGIN_PATH.
+// The password is required for the encrypted cosign private key.
+func NewSigner(keyRef string, password []byte) (Signer, error) {
+	if pluginPath, isPlugin := strings.CutPrefix(keyRef, PluginKeyRefPrefix); isPlugin {
+		if pluginPath == "" {
+			return nil, fmt.Errorf("plugin path expected in %q", keyRef)
+		}
+		return NewPluginSigner(pluginPath), nil
+	}
+
+	data, err := os.ReadFile(keyRef)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key file: %w", err)
+	}
+
+	key, err := ParsePrivateKey(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load private key %q: %w", keyRef, err)
+	}
+
+	return NewKeySigner(key), nil
+}
+
+// ParsePrivateKey parses PEM encoded ECDSA private key: SEC 1, PKCS #8 or the encrypted private key generated by cosign.
+func ParsePrivateKey(data, password []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case pemTypeECPrivateKey:
+		return x509.ParseECPrivateKey(block.Bytes)
+	case pemTypePrivateKey:
+		return parsePKCS8PrivateKey(block.Bytes)
+	case pemTypeEncryptedSigstore, pemTypeEncryptedCosign:
+		if len(password) == 0 {
+			return nil, errors.New("password required for the encrypted private key")
+		}
+
+		der, err := encrypted.Decrypt(block.Bytes, password)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt private key: %w", err)
+		}
+
+		return parsePKCS8PrivateKey(der)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+func parsePKCS8PrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected private key type %T: only ECDSA keys are supported", key)
+	}
+
+	return ecdsaKey, nil
+}
+
+type KeySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+func (s *KeySigner) Sign(_ context.Context, payload []byte) ([]byte, error) {
+	digest := sha256.Sum256(payload)
+	return ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+}
+
+func (s *KeySigner) PublicKey(_ context.Context) (crypto.PublicKey, error) {
+	return s.key.Public(), nil
+}
@@ -0,0 +1,177 @@
+package signature
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
+)
+
+func TestNewSigner_KeyFormats(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedDER, err := encrypted.Encrypt(pkcs8DER, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyPath := writePEMFile(t, pemTypePublicKey, publicKeyDER)
+
+	publicKey, err := LoadPublicKey(publicKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		keyPath  string
+		password string
+	}{
+		{name: "sec1", keyPath: writePEMFile(t, pemTypeECPrivateKey, ecDER)},
+		{name: "pkcs8", keyPath: writePEMFile(t, pemTypePrivateKey, pkcs8DER)},
+		{name: "cosign", keyPath: writePEMFile(t, pemTypeEncryptedSigstore, encryptedDER), password: "password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewSigner(tt.keyPath, []byte(tt.password))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sig, err := signer.Sign(context.Background(), []byte("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := Verify(publicKey, []byte("payload"), sig); err != nil {
+				t.Errorf("expected valid signature, got %v", err)
+			}
+
+			if err := Verify(publicKey, []byte("other payload"), sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected invalid signature error, got %v", err)
+			}
+		})
+	}
+
+	if _, err := NewSigner(writePEMFile(t, pemTypeEncryptedSigstore, encryptedDER), []byte("wrong")); err == nil {
+		t.Errorf("expected error for wrong password")
+	}
+
+	if _, err := NewSigner(writePEMFile(t, pemTypeEncryptedSigstore, encryptedDER), nil); err == nil {
+		t.Errorf("expected error for empty password")
+	}
+}
+
+func TestPluginSigner(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := NewKeySigner(key).Sign(context.Background(), []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	sigPath := filepath.Join(dir, "sig")
+	if err := os.WriteFile(sigPath, sig, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	publicKeyPath := writePEMFile(t, pemTypePublicKey, publicKeyDER)
+
+	pluginPath := filepath.Join(dir, "plugin")
+	plugin := "#!/bin/sh\ncase \"$1\" in\n  sign) cat >/dev/null; base64 < " + sigPath + " ;;\n  public-key) cat " + publicKeyPath + " ;;\n  *) exit 1 ;;\nesac\n"
+	if err := os.WriteFile(pluginPath, []byte(plugin), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewSigner(PluginKeyRefPrefix+pluginPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pluginSig, err := signer.Sign(context.Background(), []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := signer.PublicKey(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(publicKey, []byte("payload"), pluginSig); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+}
+
+func TestSignImage(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewKeySigner(key)
+
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	sig, err := SignImage(context.Background(), signer, "registry.example.com/app", digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPayload := `{"critical":{"identity":{"docker-reference":"registry.example.com/app"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"},"optional":null}`
+	if string(sig.Payload) != expectedPayload {
+		t.Errorf("expected payload:\n%s\ngot:\n%s", expectedPayload, sig.Payload)
+	}
+
+	if err := VerifyImageSignature(key.Public(), sig, digest); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+
+	if err := VerifyImageSignature(key.Public(), sig, "sha256:other"); err == nil {
+		t.Errorf("expected error for other image digest")
+	}
+
+	if tag := CosignSignatureTag(digest); tag != "sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.sig" {
+		t.Errorf("unexpected signature tag %q", tag)
+	}
+}
+
+func writePEMFile(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,80 @@
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key file: %w", err)
+	}
+
+	key, err := ParsePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load public key %q: %w", path, err)
+	}
+
+	return key, nil
+}
+
+// LoadPublicKeyRef loads the public key from the file or gets it from the signing plugin if keyRef is exec://PLUGIN.
+func LoadPublicKeyRef(ctx context.Context, keyRef string) (crypto.PublicKey, error) {
+	if pluginPath, isPlugin := strings.CutPrefix(keyRef, PluginKeyRefPrefix); isPlugin {
+		if pluginPath == "" {
+			return nil, fmt.Errorf("plugin path expected in %q", keyRef)
+		}
+		return NewPluginSigner(pluginPath).PublicKey(ctx)
+	}
+
+	return LoadPublicKey(keyRef)
+}
+
+// ParsePublicKey parses PEM encoded PKIX ECDSA public key (as generated by cosign).
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if block.Type != pemTypePublicKey {
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := key.(*ecdsa.PublicKey); !ok {
+		return nil, fmt.Errorf("unexpected public key type %T: only ECDSA keys are supported", key)
+	}
+
+	return key, nil
+}
+
+// Verify checks ECDSA-P256-SHA256 signature of the payload.
+func Verify(publicKey crypto.PublicKey, payload, sig []byte) error {
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unexpected public key type %T: only ECDSA keys are supported", publicKey)
+	}
+
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(ecdsaKey, digest[:], sig) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/werf/lockgate/pkg/distributed_locker/optimistic_locking_store"
+)
+
+// Values read by the optimistic locking backend are written back almost immediately,
+// snapshots of values which were not written back are forgotten after this timeout.
+const boltLockStoreSnapshotTimeout = time.Minute
+
+type boltLockStoreSnapshot struct {
+	Data     string
+	Found    bool
+	ReadTime time.Time
+}
+
+// BoltLockStore is an optimistic locking store for lockgate distributed locker backed by BoltSynchronizationDB.
+// Use it with distributed_locker.NewOptimisticLockingStorageBasedBackend.
+type BoltLockStore struct {
+	DB       *BoltSynchronizationDB
+	ClientID string
+
+	mux       sync.Mutex
+	snapshots map[*optimistic_locking_store.Value]boltLockStoreSnapshot
+}
+
+func NewBoltLockStore(db *BoltSynchronizationDB, clientID string) *BoltLockStore {
+	return &BoltLockStore{
+		DB:        db,
+		ClientID:  clientID,
+		snapshots: make(map[*optimistic_locking_store.Value]boltLockStoreSnapshot),
+	}
+}
+
+func (store *BoltLockStore) GetValue(key string) (*optimistic_locking_store.Value, error) {
+	var data string
+	var found bool
+
+	if err := store.DB.DB.View(func(tx *bbolt.Tx) error {
+		var err error
+		found, err = store.DB.getRecord(getBoltBucket(tx, clientBucketPath(store.ClientID, boltLocksBucket)), key, &data)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("unable to get lock %q from %s: %w", key, store.DB, err)
+	}
+
+	value := &optimistic_locking_store.Value{Data: data}
+
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	now := time.Now()
+	for v, snapshot := range store.snapshots {
+		if now.Sub(snapshot.ReadTime) > boltLockStoreSnapshotTimeout {
+			delete(store.snapshots, v)
+		}
+	}
+	store.snapshots[value] = boltLockStoreSnapshot{Data: data, Found: found, ReadTime: now}
+
+	return value, nil
+}
+
+// PutValue writes the value only if the stored value has not been changed since it was read with GetValue.
+func (store *BoltLockStore) PutValue(key string, value *optimistic_locking_store.Value) error {
+	store.mux.Lock()
+	snapshot, hasSnapshot := store.snapshots[value]
+	delete(store.snapshots, value)
+	store.mux.Unlock()
+
+	if !hasSnapshot {
+		return optimistic_locking_store.ErrRecordVersionChanged
+	}
+
+	return store.DB.DB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := createBoltBucket(tx, clientBucketPath(store.ClientID, boltLocksBucket))
+		if err != nil {
+			return fmt.Errorf("unable to put lock %q into %s: %w", key, store.DB, err)
+		}
+
+		var currentData string
+		found, err := store.DB.getRecord(bucket, key, &currentData)
+		if err != nil {
+			return fmt.Errorf("unable to get lock %q from %s: %w", key, store.DB, err)
+		}
+
+		if found != snapshot.Found || currentData != snapshot.Data {
+			return optimistic_locking_store.ErrRecordVersionChanged
+		}
+
+		if err := store.DB.putRecord(bucket, key, value.Data); err != nil {
+			return fmt.Errorf("unable to put lock %q into %s: %w", key, store.DB, err)
+		}
+
+		return nil
+	})
+}
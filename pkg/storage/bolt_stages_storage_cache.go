@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+func NewBoltStagesStorageCache(db *BoltSynchronizationDB, clientID string) *BoltStagesStorageCache {
+	return &BoltStagesStorageCache{DB: db, ClientID: clientID}
+}
+
+type BoltStagesStorageCache struct {
+	DB       *BoltSynchronizationDB
+	ClientID string
+}
+
+func (cache *BoltStagesStorageCache) String() string {
+	return fmt.Sprintf("%s client %s", cache.DB, cache.ClientID)
+}
+
+func (cache *BoltStagesStorageCache) projectBucketPath(projectName string) [][]byte {
+	return clientBucketPath(cache.ClientID, boltStagesStorageCacheBucket, projectName)
+}
+
+func (cache *BoltStagesStorageCache) GetAllStages(_ context.Context, projectName string) (bool, []image.StageID, error) {
+	var found bool
+	var res []image.StageID
+
+	err := cache.DB.DB.View(func(tx *bbolt.Tx) error {
+		bucket := getBoltBucket(tx, cache.projectBucketPath(projectName))
+		if bucket == nil {
+			return nil
+		}
+		found = true
+
+		return bucket.ForEach(func(k, _ []byte) error {
+			var record StagesStorageCacheRecord
+			if ok, err := cache.DB.getRecord(bucket, string(k), &record); err != nil {
+				return err
+			} else if ok {
+				res = append(res, record.Stages...)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return false, nil, fmt.Errorf("unable to get all stages of project %q from %s: %w", projectName, cache, err)
+	}
+
+	return found, res, nil
+}
+
+func (cache *BoltStagesStorageCache) DeleteAllStages(_ context.Context, projectName string) error {
+	err := cache.DB.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := getBoltBucket(tx, clientBucketPath(cache.ClientID, boltStagesStorageCacheBucket))
+		if bucket == nil || bucket.Bucket([]byte(projectName)) == nil {
+			return nil
+		}
+		return bucket.DeleteBucket([]byte(projectName))
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete all stages of project %q from %s: %w", projectName, cache, err)
+	}
+
+	return nil
+}
+
+func (cache *BoltStagesStorageCache) GetStagesByDigest(_ context.Context, projectName, digest string) (bool, []image.StageID, error) {
+	var found bool
+	var record StagesStorageCacheRecord
+
+	err := cache.DB.DB.View(func(tx *bbolt.Tx) error {
+		var err error
+		found, err = cache.DB.getRecord(getBoltBucket(tx, cache.projectBucketPath(projectName)), digest, &record)
+		return err
+	})
+	if err != nil {
+		return false, nil, fmt.Errorf("unable to get stages by digest %q of project %q from %s: %w", digest, projectName, cache, err)
+	}
+
+	return found, record.Stages, nil
+}
+
+func (cache *BoltStagesStorageCache) StoreStagesByDigest(_ context.Context, projectName, digest string, stages []image.StageID) error {
+	err := cache.DB.DB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := createBoltBucket(tx, cache.projectBucketPath(projectName))
+		if err != nil {
+			return err
+		}
+		return cache.DB.putRecord(bucket, digest, StagesStorageCacheRecord{Stages: stages})
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store stages by digest %q of project %q into %s: %w", digest, projectName, cache, err)
+	}
+
+	return nil
+}
+
+func (cache *BoltStagesStorageCache) DeleteStagesByDigest(_ context.Context, projectName, digest string) error {
+	err := cache.DB.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := getBoltBucket(tx, cache.projectBucketPath(projectName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(digest))
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete stages by digest %q of project %q from %s: %w", digest, projectName, cache, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+func TestBoltStagesStorageCache(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "synchronization.db")
+
+	db, err := OpenBoltSynchronizationDB(ctx, dbPath, BoltSynchronizationDBOptions{})
+	if err != nil {
+		t.Fatalf("unable to open db: %s", err)
+	}
+
+	cache := NewBoltStagesStorageCache(db, "client-1")
+	stages := []image.StageID{{Digest: "digest", UniqueID: 1}, {Digest: "digest", UniqueID: 2}}
+
+	if found, _, err := cache.GetAllStages(ctx, "project"); err != nil || found {
+		t.Fatalf("expected no stages, got found=%v err=%v", found, err)
+	}
+
+	if err := cache.StoreStagesByDigest(ctx, "project", "digest", stages); err != nil {
+		t.Fatalf("unable to store stages: %s", err)
+	}
+
+	if found, _, err := NewBoltStagesStorageCache(db, "client-2").GetStagesByDigest(ctx, "project", "digest"); err != nil || found {
+		t.Fatalf("expected stages to be isolated by client, got found=%v err=%v", found, err)
+	}
+
+	// Records should survive the server restart.
+	if err := db.Close(); err != nil {
+		t.Fatalf("unable to close db: %s", err)
+	}
+	if db, err = OpenBoltSynchronizationDB(ctx, dbPath, BoltSynchronizationDBOptions{Compact: true}); err != nil {
+		t.Fatalf("unable to reopen db: %s", err)
+	}
+	defer db.Close()
+	cache = NewBoltStagesStorageCache(db, "client-1")
+
+	if found, got, err := cache.GetStagesByDigest(ctx, "project", "digest"); err != nil || !found || !reflect.DeepEqual(got, stages) {
+		t.Fatalf("unexpected stages by digest: found=%v stages=%v err=%v", found, got, err)
+	}
+
+	if found, got, err := cache.GetAllStages(ctx, "project"); err != nil || !found || !reflect.DeepEqual(got, stages) {
+		t.Fatalf("unexpected all stages: found=%v stages=%v err=%v", found, got, err)
+	}
+
+	records, err := db.ExportStagesStorageCache(ctx)
+	if err != nil {
+		t.Fatalf("unable to export: %s", err)
+	}
+	expectedRecords := []StagesStorageCacheDumpRecord{{ClientID: "client-1", ProjectName: "project", Digest: "digest", Stages: stages}}
+	if !reflect.DeepEqual(records, expectedRecords) {
+		t.Fatalf("unexpected exported records: %v", records)
+	}
+
+	if err := cache.DeleteAllStages(ctx, "project"); err != nil {
+		t.Fatalf("unable to delete all stages: %s", err)
+	}
+	if found, _, err := cache.GetAllStages(ctx, "project"); err != nil || found {
+		t.Fatalf("expected no stages after delete, got found=%v err=%v", found, err)
+	}
+
+	if err := db.ImportStagesStorageCache(ctx, records); err != nil {
+		t.Fatalf("unable to import: %s", err)
+	}
+	if found, got, err := cache.GetStagesByDigest(ctx, "project", "digest"); err != nil || !found || !reflect.DeepEqual(got, stages) {
+		t.Fatalf("unexpected stages after import: found=%v stages=%v err=%v", found, got, err)
+	}
+}
+
+func TestBoltSynchronizationDBExpiredRecords(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenBoltSynchronizationDB(ctx, filepath.Join(t.TempDir(), "synchronization.db"), BoltSynchronizationDBOptions{TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("unable to open db: %s", err)
+	}
+	defer db.Close()
+
+	cache := NewBoltStagesStorageCache(db, "client")
+	if err := cache.StoreStagesByDigest(ctx, "project", "fresh", []image.StageID{{Digest: "fresh", UniqueID: 1}}); err != nil {
+		t.Fatalf("unable to store stages: %s", err)
+	}
+
+	if err := db.DB.Update(func(tx *bbolt.Tx) error {
+		return db.putRecordAt(getBoltBucket(tx, cache.projectBucketPath("project")), "stale", StagesStorageCacheRecord{}, time.Now().Add(-2*time.Hour))
+	}); err != nil {
+		t.Fatalf("unable to store stale record: %s", err)
+	}
+
+	if found, _, err := cache.GetStagesByDigest(ctx, "project", "stale"); err != nil || found {
+		t.Fatalf("expected expired record to be ignored, got found=%v err=%v", found, err)
+	}
+
+	if deleted, err := db.DeleteExpiredRecords(); err != nil || deleted != 1 {
+		t.Fatalf("expected 1 deleted record, got %d err=%v", deleted, err)
+	}
+
+	if found, _, err := cache.GetStagesByDigest(ctx, "project", "fresh"); err != nil || !found {
+		t.Fatalf("expected fresh record to be kept, got found=%v err=%v", found, err)
+	}
+}
+
+func TestOpenBoltSynchronizationDBReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "synchronization.db")
+
+	if _, err := OpenBoltSynchronizationDB(ctx, dbPath, BoltSynchronizationDBOptions{ReadOnly: true}); err == nil {
+		t.Fatalf("expected error for missing database in read-only mode")
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Fatalf("expected database not to be created in read-only mode, got %v", err)
+	}
+
+	db, err := OpenBoltSynchronizationDB(ctx, dbPath, BoltSynchronizationDBOptions{})
+	if err != nil {
+		t.Fatalf("unable to open db: %s", err)
+	}
+	if err := NewBoltStagesStorageCache(db, "client").StoreStagesByDigest(ctx, "project", "digest", []image.StageID{{Digest: "digest", UniqueID: 1}}); err != nil {
+		t.Fatalf("unable to store stages: %s", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("unable to close db: %s", err)
+	}
+
+	dataBefore, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db, err = OpenBoltSynchronizationDB(ctx, dbPath, BoltSynchronizationDBOptions{ReadOnly: true}); err != nil {
+		t.Fatalf("unable to open db in read-only mode: %s", err)
+	}
+	if records, err := db.ExportStagesStorageCache(ctx); err != nil || len(records) != 1 {
+		t.Fatalf("unexpected exported records %v: %v", records, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("unable to close db: %s", err)
+	}
+
+	dataAfter, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dataBefore, dataAfter) {
+		t.Errorf("database file is modified in read-only mode")
+	}
+}
@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/werf/logboek"
+)
+
+const (
+	boltClientsBucket            = "clients"
+	boltStagesStorageCacheBucket = "stages_storage_cache"
+	boltLocksBucket              = "locks"
+
+	boltOpenTimeout      = 10 * time.Second
+	boltCompactTxMaxSize = 64 * 1024 * 1024
+)
+
+type BoltSynchronizationDBOptions struct {
+	// TTL of stages-storage-cache records and lock-manager locks. Records never expire when TTL is zero.
+	TTL time.Duration
+
+	// Compact rewrites existing database file before opening to reclaim space freed by deleted records.
+	Compact bool
+
+	// ReadOnly opens existing database file without modifying it, the file is locked in shared mode.
+	ReadOnly bool
+}
+
+// BoltSynchronizationDB is an embedded database file used by the synchronization server as a persistent
+// backend for stages-storage-cache records and lock-manager locks of all clients.
+//
+// The file is locked exclusively, so only one synchronization server process can use it at a time,
+// but the file can be moved to another host or pod along with the server without losing state.
+type BoltSynchronizationDB struct {
+	DB  *bbolt.DB
+	TTL time.Duration
+}
+
+type boltRecord struct {
+	Data      json.RawMessage `json:"data"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+func OpenBoltSynchronizationDB(ctx context.Context, path string, opts BoltSynchronizationDBOptions) (*BoltSynchronizationDB, error) {
+	if opts.ReadOnly {
+		return openReadOnlyBoltSynchronizationDB(path, opts)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create dir %q: %w", filepath.Dir(path), err)
+	}
+
+	if opts.Compact {
+		if err := compactBoltDBFile(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database %q: %w", path, err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(boltClientsBucket))
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to init database %q: %w", path, err)
+	}
+
+	return &BoltSynchronizationDB{DB: db, TTL: opts.TTL}, nil
+}
+
+func openReadOnlyBoltSynchronizationDB(path string, opts BoltSynchronizationDBOptions) (*BoltSynchronizationDB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("error accessing database %q: %w", path, err)
+	}
+
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: boltOpenTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database %q: %w", path, err)
+	}
+
+	return &BoltSynchronizationDB{DB: db, TTL: opts.TTL}, nil
+}
+
+// compactBoltDBFile rewrites existing database file to reclaim space freed by deleted records.
+func compactBoltDBFile(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error accessing %q: %w", path, err)
+	}
+
+	compactedPath := path + ".compact"
+	if err := os.RemoveAll(compactedPath); err != nil {
+		return fmt.Errorf("unable to remove %q: %w", compactedPath, err)
+	}
+
+	return logboek.Context(ctx).Default().LogProcess("Compacting database %s", path).DoError(func() error {
+		src, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: boltOpenTimeout, ReadOnly: true})
+		if err != nil {
+			return fmt.Errorf("unable to open database %q: %w", path, err)
+		}
+		defer src.Close()
+
+		dst, err := bbolt.Open(compactedPath, 0o600, &bbolt.Options{Timeout: boltOpenTimeout})
+		if err != nil {
+			return fmt.Errorf("unable to open database %q: %w", compactedPath, err)
+		}
+
+		if err := bbolt.Compact(dst, src, boltCompactTxMaxSize); err != nil {
+			dst.Close()
+			return fmt.Errorf("unable to compact database %q: %w", path, err)
+		}
+
+		if err := dst.Close(); err != nil {
+			return fmt.Errorf("unable to close database %q: %w", compactedPath, err)
+		}
+
+		if err := os.Rename(compactedPath, path); err != nil {
+			return fmt.Errorf("unable to replace %q with compacted database: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+func (db *BoltSynchronizationDB) Close() error {
+	return db.DB.Close()
+}
+
+func (db *BoltSynchronizationDB) String() string {
+	return fmt.Sprintf("bolt %s", db.DB.Path())
+}
+
+// RunExpiredRecordsCleaner periodically deletes records expired according to the TTL until ctx is done.
+func (db *BoltSynchronizationDB) RunExpiredRecordsCleaner(ctx context.Context, interval time.Duration) {
+	if db.TTL == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if deleted, err := db.DeleteExpiredRecords(); err != nil {
+				logboek.Context(ctx).Warn().LogF("WARNING: unable to delete expired records from %s: %s\n", db, err)
+			} else if deleted > 0 {
+				logboek.Context(ctx).Debug().LogF("Deleted %d expired records from %s\n", deleted, db)
+			}
+		}
+	}
+}
+
+func (db *BoltSynchronizationDB) DeleteExpiredRecords() (int, error) {
+	var deleted int
+
+	err := db.DB.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(boltClientsBucket)).ForEachBucket(func(clientID []byte) error {
+			clientBucket := tx.Bucket([]byte(boltClientsBucket)).Bucket(clientID)
+
+			if locksBucket := clientBucket.Bucket([]byte(boltLocksBucket)); locksBucket != nil {
+				n, err := db.deleteExpiredKeys(locksBucket)
+				if err != nil {
+					return err
+				}
+				deleted += n
+			}
+
+			if cacheBucket := clientBucket.Bucket([]byte(boltStagesStorageCacheBucket)); cacheBucket != nil {
+				if err := cacheBucket.ForEachBucket(func(projectName []byte) error {
+					n, err := db.deleteExpiredKeys(cacheBucket.Bucket(projectName))
+					deleted += n
+					return err
+				}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+
+	return deleted, err
+}
+
+func (db *BoltSynchronizationDB) deleteExpiredKeys(bucket *bbolt.Bucket) (int, error) {
+	var expiredKeys [][]byte
+	if err := bucket.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil
+		}
+
+		var record boltRecord
+		if err := json.Unmarshal(v, &record); err != nil || db.isExpired(record) {
+			expiredKeys = append(expiredKeys, k)
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	for _, k := range expiredKeys {
+		if err := bucket.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(expiredKeys), nil
+}
+
+func (db *BoltSynchronizationDB) isExpired(record boltRecord) bool {
+	return db.TTL != 0 && time.Since(record.UpdatedAt) > db.TTL
+}
+
+func (db *BoltSynchronizationDB) getRecord(bucket *bbolt.Bucket, key string, data interface{}) (bool, error) {
+	if bucket == nil {
+		return false, nil
+	}
+
+	v := bucket.Get([]byte(key))
+	if v == nil {
+		return false, nil
+	}
+
+	var record boltRecord
+	if err := json.Unmarshal(v, &record); err != nil {
+		return false, fmt.Errorf("unable to unmarshal record %q: %w", key, err)
+	}
+
+	if db.isExpired(record) {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(record.Data, data); err != nil {
+		return false, fmt.Errorf("unable to unmarshal record %q data: %w", key, err)
+	}
+
+	return true, nil
+}
+
+func (db *BoltSynchronizationDB) putRecord(bucket *bbolt.Bucket, key string, data interface{}) error {
+	return db.putRecordAt(bucket, key, data, time.Now())
+}
+
+func (db *BoltSynchronizationDB) putRecordAt(bucket *bbolt.Bucket, key string, data interface{}, updatedAt time.Time) error {
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal record %q data: %w", key, err)
+	}
+
+	v, err := json.Marshal(boltRecord{Data: rawData, UpdatedAt: updatedAt})
+	if err != nil {
+		return fmt.Errorf("unable to marshal record %q: %w", key, err)
+	}
+
+	return bucket.Put([]byte(key), v)
+}
+
+func clientBucketPath(clientID string, names ...string) [][]byte {
+	path := [][]byte{[]byte(boltClientsBucket), []byte(clientID)}
+	for _, name := range names {
+		path = append(path, []byte(name))
+	}
+	return path
+}
+
+func getBoltBucket(tx *bbolt.Tx, path [][]byte) *bbolt.Bucket {
+	bucket := tx.Bucket(path[0])
+	for _, name := range path[1:] {
+		if bucket == nil {
+			return nil
+		}
+		bucket = bucket.Bucket(name)
+	}
+	return bucket
+}
+
+func createBoltBucket(tx *bbolt.Tx, path [][]byte) (*bbolt.Bucket, error) {
+	bucket, err := tx.CreateBucketIfNotExists(path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range path[1:] {
+		if bucket, err = bucket.CreateBucketIfNotExists(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return bucket, nil
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Artifacts referring to the image by digest are tagged as sha256-<digest>[.<suffix>]:
+// cosign signatures (.sig), SBOMs (.sbom), attestations (.att) and OCI referrers tag schema indexes (without suffix).
+var imageReferrerTagRegexp = regexp.MustCompile(`^(sha256)-([0-9a-f]{64})(\.[a-z]+)?$`)
+
+func IsImageReferrerTag(tag string) bool {
+	return imageReferrerTagRegexp.MatchString(tag)
+}
+
+// GetImageDigestByReferrerTag returns the digest of the image the artifact tag refers to (sha256-abc.sig -> sha256:abc).
+func GetImageDigestByReferrerTag(tag string) (string, bool) {
+	parts := imageReferrerTagRegexp.FindStringSubmatch(tag)
+	if parts == nil {
+		return "", false
+	}
+
+	return strings.Join(parts[1:3], ":"), true
+}
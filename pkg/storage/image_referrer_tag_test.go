@@ -0,0 +1,26 @@
+package storage
+
+import "testing"
+
+func TestGetImageDigestByReferrerTag(t *testing.T) {
+	hex := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		tag            string
+		expectedDigest string
+	}{
+		{tag: "sha256-" + hex + ".sig", expectedDigest: "sha256:" + hex},
+		{tag: "sha256-" + hex + ".sbom", expectedDigest: "sha256:" + hex},
+		{tag: "sha256-" + hex, expectedDigest: "sha256:" + hex},
+		{tag: "sha256-" + hex[1:] + ".sig"},
+		{tag: hex[:56] + "-1611836746968"},
+		{tag: "latest"},
+	}
+
+	for _, tt := range tests {
+		digest, ok := GetImageDigestByReferrerTag(tt.tag)
+		if ok != (tt.expectedDigest != "") || digest != tt.expectedDigest {
+			t.Errorf("tag %q: expected digest %q, got %q (%v)", tt.tag, tt.expectedDigest, digest, ok)
+		}
+	}
+}
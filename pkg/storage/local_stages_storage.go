@@ -197,6 +197,18 @@ func (storage *LocalStagesStorage) DeleteStageCustomTag(ctx context.Context, tag
 	return fmt.Errorf("not implemented")
 }
 
+func (storage *LocalStagesStorage) GetImageReferrerTags(ctx context.Context, opts ...Option) ([]string, error) {
+	return nil, nil
+}
+
+func (storage *LocalStagesStorage) DeleteImageReferrerTag(ctx context.Context, tag string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (storage *LocalStagesStorage) IsImageDigestExist(ctx context.Context, digest string) (bool, error) {
+	return false, nil
+}
+
 func (storage *LocalStagesStorage) RejectStage(ctx context.Context, projectName, digest string, uniqueID int64) error {
 	return nil
 }
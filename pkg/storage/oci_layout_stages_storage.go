@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/werf/werf/pkg/container_backend"
+	"github.com/werf/werf/pkg/docker_registry"
+	"github.com/werf/werf/pkg/werf"
+)
+
+const (
+	OCILayoutStorageAddressPrefix = "oci:"
+
+	// OCILayoutStage_RepositoryFormat is the name of the repository of the layout images, which is never accessed over the network.
+	OCILayoutStage_RepositoryFormat = "oci-layout.werf.local/%s"
+)
+
+func IsOCILayoutStorageAddress(address string) bool {
+	return strings.HasPrefix(address, OCILayoutStorageAddressPrefix)
+}
+
+// OCILayoutStagesStorage keeps the stages, custom tags, managed images and metadata records in the OCI image layout directory the same way as RepoStagesStorage does in the container registry.
+// The layout images are named OCILayoutStage_RepositoryFormat:TAG, the repository is unique for the layout directory.
+type OCILayoutStagesStorage struct {
+	*RepoStagesStorage
+	LayoutDir string
+}
+
+func NewOCILayoutStagesStorage(address string, containerBackend container_backend.ContainerBackend, dockerRegistryOptions docker_registry.DockerRegistryOptions) (*OCILayoutStagesStorage, error) {
+	layoutDir, err := filepath.Abs(strings.TrimPrefix(address, OCILayoutStorageAddressPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get absolute path of %q: %w", address, err)
+	}
+
+	repository := fmt.Sprintf(OCILayoutStage_RepositoryFormat, fmt.Sprintf("%x", sha256.Sum256([]byte(layoutDir)))[:12])
+
+	dockerRegistry, err := docker_registry.NewOCILayout(layoutDir, repository, dockerRegistryOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OCI layout accessor for %q: %w", layoutDir, err)
+	}
+
+	return &OCILayoutStagesStorage{
+		RepoStagesStorage: NewRepoStagesStorage(repository, containerBackend, dockerRegistry),
+		LayoutDir:         layoutDir,
+	}, nil
+}
+
+// FetchImage loads the layout image into the container backend.
+func (storage *OCILayoutStagesStorage) FetchImage(ctx context.Context, img container_backend.LegacyImageInterface) error {
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(storage.DockerRegistry.PullImageArchive(ctx, pw, img.Name()))
+	}()
+
+	err := storage.ContainerBackend.LoadImageFromStream(ctx, pr)
+	pr.CloseWithError(err)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "unknown blob") {
+			return ErrBrokenImage
+		}
+		return fmt.Errorf("unable to load image %s: %w", img.Name(), err)
+	}
+
+	info, err := storage.ContainerBackend.GetImageInfo(ctx, img.Name(), container_backend.GetImageInfoOpts{TargetPlatform: img.GetTargetPlatform()})
+	if err != nil {
+		return fmt.Errorf("unable to get inspect of image %s: %w", img.Name(), err)
+	}
+	img.SetInfo(info)
+
+	return nil
+}
+
+// StoreImage saves the container backend image into the temporary archive and writes it into the layout.
+func (storage *OCILayoutStagesStorage) StoreImage(ctx context.Context, img container_backend.LegacyImageInterface) error {
+	if img.BuiltID() != "" {
+		if err := storage.ContainerBackend.Tag(ctx, img.BuiltID(), img.Name(), container_backend.TagOpts{TargetPlatform: img.GetTargetPlatform()}); err != nil {
+			return fmt.Errorf("unable to tag built image %q by %q: %w", img.BuiltID(), img.Name(), err)
+		}
+	}
+
+	archive, err := os.CreateTemp(werf.GetTmpDir(), "oci-layout-image-*.tar")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary image archive: %w", err)
+	}
+	defer os.Remove(archive.Name())
+
+	if err := storage.saveImageArchive(ctx, img.Name(), archive); err != nil {
+		return err
+	}
+
+	if err := storage.DockerRegistry.PushImageArchive(ctx, fileArchiveOpener(archive.Name()), img.Name()); err != nil {
+		return fmt.Errorf("unable to store image %q into the OCI layout %s: %w", img.Name(), storage.LayoutDir, err)
+	}
+
+	return nil
+}
+
+func (storage *OCILayoutStagesStorage) saveImageArchive(ctx context.Context, ref string, archive *os.File) error {
+	defer archive.Close()
+
+	stream, err := storage.ContainerBackend.SaveImageToStream(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("unable to save image %q: %w", ref, err)
+	}
+	defer stream.Close()
+
+	if _, err := io.Copy(archive, stream); err != nil {
+		return fmt.Errorf("unable to save image %q into %q: %w", ref, archive.Name(), err)
+	}
+
+	return archive.Close()
+}
+
+// SynchronizationLocksDir is the dir of the file locks synchronizing the builders sharing the layout, e.g. on NFS.
+func (storage *OCILayoutStagesStorage) SynchronizationLocksDir() string {
+	return filepath.Join(storage.LayoutDir, ".werf-locks", "synchronization")
+}
+
+func (storage *OCILayoutStagesStorage) String() string {
+	return OCILayoutStorageAddressPrefix + storage.LayoutDir
+}
+
+type fileArchiveOpener string
+
+func (path fileArchiveOpener) Open() (io.ReadCloser, error) {
+	return os.Open(string(path))
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/container_backend"
+	"github.com/werf/werf/pkg/docker_registry"
+	"github.com/werf/werf/pkg/image"
+)
+
+// ociLayoutTestBackend keeps the built images in memory, only the methods used by the OCI layout stages storage are implemented.
+type ociLayoutTestBackend struct {
+	container_backend.ContainerBackend
+
+	mux    sync.Mutex
+	images map[string]v1.Image
+}
+
+func (backend *ociLayoutTestBackend) Tag(_ context.Context, ref, newRef string, _ container_backend.TagOpts) error {
+	backend.mux.Lock()
+	defer backend.mux.Unlock()
+
+	img, ok := backend.images[ref]
+	if !ok {
+		return fmt.Errorf("image %q not found", ref)
+	}
+	backend.images[newRef] = img
+
+	return nil
+}
+
+func (backend *ociLayoutTestBackend) SaveImageToStream(_ context.Context, ref string) (io.ReadCloser, error) {
+	backend.mux.Lock()
+	img, ok := backend.images[ref]
+	backend.mux.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("image %q not found", ref)
+	}
+
+	tag, err := name.NewTag(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarball.Write(tag, img, pw))
+	}()
+
+	return pr, nil
+}
+
+type ociLayoutTestImage struct {
+	container_backend.LegacyImageInterface
+
+	name    string
+	builtID string
+}
+
+func (img *ociLayoutTestImage) Name() string              { return img.name }
+func (img *ociLayoutTestImage) BuiltID() string           { return img.builtID }
+func (img *ociLayoutTestImage) GetTargetPlatform() string { return "" }
+
+func newOCILayoutTestStagesStorage(t *testing.T) (*OCILayoutStagesStorage, *ociLayoutTestBackend) {
+	backend := &ociLayoutTestBackend{images: map[string]v1.Image{}}
+
+	storage, err := NewOCILayoutStagesStorage(OCILayoutStorageAddressPrefix+t.TempDir(), backend, docker_registry.DockerRegistryOptions{})
+	if err != nil {
+		t.Fatalf("unable to create storage: %s", err)
+	}
+
+	return storage, backend
+}
+
+func ociLayoutBlobExists(t *testing.T, storage *OCILayoutStagesStorage, digest string) bool {
+	algorithm, hex, _ := strings.Cut(digest, ":")
+	_, err := os.Stat(filepath.Join(storage.LayoutDir, "blobs", algorithm, hex))
+	if os.IsNotExist(err) {
+		return false
+	}
+	if err != nil {
+		t.Fatalf("unable to stat blob %s: %s", digest, err)
+	}
+
+	return true
+}
+
+func TestOCILayoutStagesStorageStoresAndDeletesStages(t *testing.T) {
+	ctx := logboek.NewContext(context.Background(), logboek.DefaultLogger())
+	storage, backend := newOCILayoutTestStagesStorage(t)
+
+	const numberOfStages = 4
+	stageIDs := make([]image.StageID, numberOfStages)
+	for i := range stageIDs {
+		stageIDs[i] = *image.NewStageID(fmt.Sprintf("%056x", i+1), int64(1611836746968+i))
+	}
+
+	for i := range stageIDs {
+		img, err := random.Image(256, 2)
+		if err != nil {
+			t.Fatalf("unable to create image: %s", err)
+		}
+		backend.images[fmt.Sprintf("built-%d", i)] = img
+	}
+
+	// the stages are stored concurrently as by the parallel build
+	var wg sync.WaitGroup
+	errCh := make(chan error, numberOfStages)
+	for i, stageID := range stageIDs {
+		wg.Add(1)
+		go func(stageID image.StageID, builtID string) {
+			defer wg.Done()
+			errCh <- storage.StoreImage(ctx, &ociLayoutTestImage{
+				name:    storage.ConstructStageImageName("project", stageID.Digest, stageID.UniqueID),
+				builtID: builtID,
+			})
+		}(stageID, fmt.Sprintf("built-%d", i))
+	}
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		if err != nil {
+			t.Fatalf("unable to store image: %s", err)
+		}
+	}
+
+	ids, err := storage.GetStagesIDs(ctx, "project")
+	if err != nil {
+		t.Fatalf("unable to get stages: %s", err)
+	}
+	if len(ids) != numberOfStages {
+		t.Fatalf("expected %d stages, got %v", numberOfStages, ids)
+	}
+
+	descs := make([]*image.StageDescription, numberOfStages)
+	for i, stageID := range stageIDs {
+		if descs[i], err = storage.GetStageDescription(ctx, "project", stageID); err != nil {
+			t.Fatalf("unable to get stage %s: %s", stageID.String(), err)
+		}
+		if descs[i] == nil || descs[i].Info.GetDigest() == "" {
+			t.Fatalf("expected stage %s with digest, got %+v", stageID.String(), descs[i])
+		}
+	}
+
+	deletedDesc := descs[0]
+	referrerDigest, err := storage.DockerRegistry.PushReferrer(ctx, "", docker_registry.PushArtifactOptions{
+		ArtifactType:     "application/spdx+json",
+		SubjectReference: deletedDesc.Info.Name,
+		Layers:           []docker_registry.ArtifactLayer{{MediaType: "application/spdx+json", Data: []byte("{}")}},
+	})
+	if err != nil {
+		t.Fatalf("unable to push referrer: %s", err)
+	}
+
+	if err := storage.DeleteStage(ctx, deletedDesc, DeleteImageOptions{}); err != nil {
+		t.Fatalf("unable to delete stage: %s", err)
+	}
+
+	if desc, err := storage.GetStageDescription(ctx, "project", stageIDs[0]); err != nil || desc != nil {
+		t.Errorf("expected deleted stage not to be found, got %+v, %v", desc, err)
+	}
+
+	if ids, err := storage.GetStagesIDs(ctx, "project"); err != nil || len(ids) != numberOfStages-1 {
+		t.Errorf("expected %d stages, got %v, %v", numberOfStages-1, ids, err)
+	}
+
+	if ociLayoutBlobExists(t, storage, deletedDesc.Info.GetDigest()) {
+		t.Errorf("expected the manifest of the deleted stage to be removed")
+	}
+	if ociLayoutBlobExists(t, storage, referrerDigest) {
+		t.Errorf("expected the referrer of the deleted stage to be removed")
+	}
+	for _, desc := range descs[1:] {
+		if !ociLayoutBlobExists(t, storage, desc.Info.GetDigest()) {
+			t.Errorf("expected the manifest of the stage %s to be kept", desc.StageID.String())
+		}
+	}
+}
+
+func TestOCILayoutStagesStorageManagedImages(t *testing.T) {
+	ctx := logboek.NewContext(context.Background(), logboek.DefaultLogger())
+	storage, _ := newOCILayoutTestStagesStorage(t)
+
+	for _, imageName := range []string{"backend", "frontend"} {
+		if err := storage.AddManagedImage(ctx, "project", imageName); err != nil {
+			t.Fatalf("unable to add managed image: %s", err)
+		}
+	}
+
+	if err := storage.RmManagedImage(ctx, "project", "frontend"); err != nil {
+		t.Fatalf("unable to remove managed image: %s", err)
+	}
+
+	managedImages, err := storage.GetManagedImages(ctx, "project")
+	if err != nil {
+		t.Fatalf("unable to get managed images: %s", err)
+	}
+	if len(managedImages) != 1 || managedImages[0] != "backend" {
+		t.Errorf("expected only backend managed image, got %v", managedImages)
+	}
+
+	if !strings.HasPrefix(storage.SynchronizationLocksDir(), storage.LayoutDir+string(filepath.Separator)) {
+		t.Errorf("expected synchronization locks inside the layout, got %q", storage.SynchronizationLocksDir())
+	}
+}
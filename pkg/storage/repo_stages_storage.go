@@ -147,6 +147,10 @@ func mutateExportStageConfig(mutateConfigFunc func(config v1.Config) (v1.Config,
 }
 
 func (storage *RepoStagesStorage) DeleteStage(ctx context.Context, stageDescription *image.StageDescription, _ DeleteImageOptions) error {
+	if err := storage.deleteImageReferrers(ctx, stageDescription.Info); err != nil {
+		return fmt.Errorf("unable to remove repo image %s referrers: %w", stageDescription.Info.Name, err)
+	}
+
 	if err := storage.DockerRegistry.DeleteRepoImage(ctx, stageDescription.Info); err != nil {
 		return fmt.Errorf("unable to remove repo image %s: %w", stageDescription.Info.Name, err)
 	}
@@ -165,6 +169,61 @@ func (storage *RepoStagesStorage) DeleteStage(ctx context.Context, stageDescript
 	return nil
 }
 
+// deleteImageReferrers deletes the artifacts referring to the image and its platform images (signatures, SBOMs, attestations etc.),
+// so they are not left orphaned when the image is deleted. The referrers tag schema indexes left by the registries without the OCI referrers API
+// are deleted by cleanup and purge with the other orphaned referrers tags.
+func (storage *RepoStagesStorage) deleteImageReferrers(ctx context.Context, info *image.Info) error {
+	if digest := info.GetDigest(); digest != "" {
+		if err := storage.deleteReferrers(ctx, fmt.Sprintf("%s@%s", storage.RepoAddress, digest)); err != nil {
+			return err
+		}
+	}
+
+	for _, platformInfo := range info.Index {
+		if err := storage.deleteImageReferrers(ctx, platformInfo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (storage *RepoStagesStorage) deleteReferrers(ctx context.Context, reference string) error {
+	referrers, err := storage.DockerRegistry.Referrers(ctx, reference, docker_registry.ReferrersOptions{})
+	if err != nil {
+		if docker_registry.IsImageNotFoundError(err) || docker_registry.IsStatusNotFoundErr(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, desc := range referrers {
+		referrerReference := fmt.Sprintf("%s@%s", storage.RepoAddress, desc.Digest)
+
+		// artifacts can refer to other artifacts, e.g. the signature of the SBOM
+		if err := storage.deleteReferrers(ctx, referrerReference); err != nil {
+			return err
+		}
+
+		logboek.Context(ctx).Debug().LogF("-- RepoStagesStorage.deleteReferrers %s referrer: %s (%s)\n", reference, desc.Digest, desc.ArtifactType)
+
+		if err := storage.DockerRegistry.DeleteRepoImage(ctx, &image.Info{
+			Name:       referrerReference,
+			Repository: storage.RepoAddress,
+			RepoDigest: referrerReference,
+		}); err != nil {
+			if docker_registry.IsImageNotFoundError(err) || docker_registry.IsStatusNotFoundErr(err) {
+				continue
+			}
+
+			return fmt.Errorf("unable to delete referrer %s: %w", referrerReference, err)
+		}
+	}
+
+	return nil
+}
+
 func makeRepoRejectedStageImageRecord(repoAddress, digest string, uniqueID int64) string {
 	return fmt.Sprintf(RepoRejectedStageImageRecord_ImageNameFormat, repoAddress, digest, uniqueID)
 }
@@ -335,6 +394,37 @@ func (storage *RepoStagesStorage) DeleteStageCustomTag(ctx context.Context, tag
 	return nil
 }
 
+func (storage *RepoStagesStorage) GetImageReferrerTags(ctx context.Context, opts ...Option) ([]string, error) {
+	o := makeOptions(opts...)
+	tags, err := storage.DockerRegistry.Tags(ctx, storage.RepoAddress, o.dockerRegistryOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get repo %s tags: %w", storage.RepoAddress, err)
+	}
+
+	var res []string
+	for _, tag := range tags {
+		if IsImageReferrerTag(tag) {
+			res = append(res, tag)
+		}
+	}
+
+	return res, nil
+}
+
+func (storage *RepoStagesStorage) DeleteImageReferrerTag(ctx context.Context, tag string) error {
+	return storage.DeleteStageCustomTag(ctx, tag)
+}
+
+func (storage *RepoStagesStorage) IsImageDigestExist(ctx context.Context, digest string) (bool, error) {
+	fullImageName := fmt.Sprintf("%s@%s", storage.RepoAddress, digest)
+	imgInfo, err := storage.DockerRegistry.TryGetRepoImage(ctx, fullImageName)
+	if err != nil {
+		return false, fmt.Errorf("unable to get repo image %q info: %w", fullImageName, err)
+	}
+
+	return imgInfo != nil, nil
+}
+
 func (storage *RepoStagesStorage) addStageCustomTagMetadata(ctx context.Context, projectName string, stageDescription *image.StageDescription, tag string) error {
 	fullImageName := makeRepoCustomTagMetadataRecord(storage.RepoAddress, tag)
 	metadata := newCustomTagMetadata(stageDescription.StageID.String(), tag)
@@ -903,9 +993,15 @@ func (storage *RepoStagesStorage) CopyFromStorage(ctx context.Context, src Stage
 		return desc, nil
 	}
 
+	// the OCI layout images are accessible only through the layout accessor
+	dockerRegistry := storage.DockerRegistry
+	if srcOCILayout, ok := src.(*OCILayoutStagesStorage); ok {
+		dockerRegistry = srcOCILayout.DockerRegistry
+	}
+
 	srcRef := src.ConstructStageImageName(projectName, stageID.Digest, stageID.UniqueID)
 	dstRef := storage.ConstructStageImageName(projectName, stageID.Digest, stageID.UniqueID)
-	if err := storage.DockerRegistry.CopyImage(ctx, srcRef, dstRef, docker_registry.CopyImageOptions{}); err != nil {
+	if err := dockerRegistry.CopyImage(ctx, srcRef, dstRef, docker_registry.CopyImageOptions{}); err != nil {
 		return nil, fmt.Errorf("unable to copy image into registry: %w", err)
 	}
 
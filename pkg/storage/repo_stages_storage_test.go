@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/werf/logboek"
+	"github.com/werf/werf/pkg/docker_registry"
+	"github.com/werf/werf/pkg/image"
+)
+
+const repoStagesStorageTestRepository = "registry.werf.local/project"
+
+// referrersTestRegistry returns the referrers by the subject digest and records the deleted images,
+// only the methods used by the stages deletion are implemented.
+type referrersTestRegistry struct {
+	docker_registry.Interface
+
+	referrers    map[string][]v1.Descriptor
+	referrersErr error
+	deleted      []string
+}
+
+func (r *referrersTestRegistry) Referrers(_ context.Context, reference string, _ docker_registry.ReferrersOptions) ([]v1.Descriptor, error) {
+	if r.referrersErr != nil {
+		return nil, r.referrersErr
+	}
+
+	_, digest, _ := strings.Cut(reference, "@")
+	return r.referrers[digest], nil
+}
+
+func (r *referrersTestRegistry) DeleteRepoImage(_ context.Context, repoImage *image.Info) error {
+	r.deleted = append(r.deleted, repoImage.RepoDigest)
+	return nil
+}
+
+func (r *referrersTestRegistry) TryGetRepoImage(_ context.Context, _ string) (*image.Info, error) {
+	return nil, nil
+}
+
+func repoStagesStorageTestDigest(n int) string {
+	return fmt.Sprintf("sha256:%064x", n)
+}
+
+func repoStagesStorageTestReference(digest string) string {
+	return fmt.Sprintf("%s@%s", repoStagesStorageTestRepository, digest)
+}
+
+func newRepoStagesStorageTestStage(digest string, platformDigests ...string) *image.StageDescription {
+	info := &image.Info{
+		Name:       repoStagesStorageTestRepository + ":stage",
+		Repository: repoStagesStorageTestRepository,
+		Tag:        "stage",
+		RepoDigest: repoStagesStorageTestReference(digest),
+	}
+
+	for _, platformDigest := range platformDigests {
+		info.Index = append(info.Index, &image.Info{
+			Repository: repoStagesStorageTestRepository,
+			RepoDigest: repoStagesStorageTestReference(platformDigest),
+		})
+	}
+
+	return &image.StageDescription{
+		StageID: image.NewStageID(strings.Repeat("a", 56), 1611836746968),
+		Info:    info,
+	}
+}
+
+func TestRepoStagesStorageDeleteStageDeletesReferrers(t *testing.T) {
+	ctx := logboek.NewContext(context.Background(), logboek.DefaultLogger())
+
+	indexDigest := repoStagesStorageTestDigest(1)
+	platformDigest := repoStagesStorageTestDigest(2)
+	signatureDigest := repoStagesStorageTestDigest(3)
+	sbomDigest := repoStagesStorageTestDigest(4)
+	sbomSignatureDigest := repoStagesStorageTestDigest(5)
+
+	registry := &referrersTestRegistry{
+		referrers: map[string][]v1.Descriptor{
+			indexDigest:    {{Digest: v1.Hash{Algorithm: "sha256", Hex: signatureDigest[7:]}}},
+			platformDigest: {{Digest: v1.Hash{Algorithm: "sha256", Hex: sbomDigest[7:]}}},
+			sbomDigest:     {{Digest: v1.Hash{Algorithm: "sha256", Hex: sbomSignatureDigest[7:]}}},
+		},
+	}
+
+	storage := NewRepoStagesStorage(repoStagesStorageTestRepository, nil, registry)
+	if err := storage.DeleteStage(ctx, newRepoStagesStorageTestStage(indexDigest, platformDigest), DeleteImageOptions{}); err != nil {
+		t.Fatalf("unable to delete stage: %s", err)
+	}
+
+	// the referrers are deleted before their subjects, so they are not left orphaned if the deletion fails
+	expected := []string{
+		repoStagesStorageTestReference(signatureDigest),
+		repoStagesStorageTestReference(sbomSignatureDigest),
+		repoStagesStorageTestReference(sbomDigest),
+		repoStagesStorageTestReference(indexDigest),
+	}
+	if !reflect.DeepEqual(registry.deleted, expected) {
+		t.Errorf("expected deleted %v, got %v", expected, registry.deleted)
+	}
+}
+
+func TestRepoStagesStorageDeleteStageReferrersErrors(t *testing.T) {
+	ctx := logboek.NewContext(context.Background(), logboek.DefaultLogger())
+	digest := repoStagesStorageTestDigest(1)
+
+	for _, tt := range []struct {
+		name          string
+		referrersErr  error
+		expectDeleted bool
+	}{
+		{name: "not found", referrersErr: &transport.Error{StatusCode: 404}, expectDeleted: true},
+		{name: "manifest unknown", referrersErr: fmt.Errorf("%s: image not found", transport.ManifestUnknownErrorCode), expectDeleted: true},
+		{name: "unauthorized", referrersErr: &transport.Error{StatusCode: 401}},
+		{name: "network", referrersErr: errors.New("connection refused")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := &referrersTestRegistry{referrersErr: tt.referrersErr}
+			storage := NewRepoStagesStorage(repoStagesStorageTestRepository, nil, registry)
+
+			err := storage.DeleteStage(ctx, newRepoStagesStorageTestStage(digest), DeleteImageOptions{})
+			if tt.expectDeleted {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if len(registry.deleted) != 1 {
+					t.Errorf("expected the stage to be deleted, got %v", registry.deleted)
+				}
+				return
+			}
+
+			if err == nil || !errors.Is(err, tt.referrersErr) {
+				t.Fatalf("expected referrers error, got %v", err)
+			}
+			if len(registry.deleted) != 0 {
+				t.Errorf("expected the stage not to be deleted, got %v", registry.deleted)
+			}
+		})
+	}
+}
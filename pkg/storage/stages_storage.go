@@ -42,6 +42,11 @@ type StagesStorage interface {
 	CheckStageCustomTag(ctx context.Context, stageDescription *image.StageDescription, tag string) error
 	DeleteStageCustomTag(ctx context.Context, tag string) error
 
+	// GetImageReferrerTags returns tags of the artifacts referring to the images by digest (signatures, SBOMs etc.).
+	GetImageReferrerTags(ctx context.Context, opts ...Option) ([]string, error)
+	DeleteImageReferrerTag(ctx context.Context, tag string) error
+	IsImageDigestExist(ctx context.Context, digest string) (bool, error)
+
 	RejectStage(ctx context.Context, projectName, digest string, uniqueID int64) error
 
 	ConstructStageImageName(projectName, digest string, uniqueID int64) string
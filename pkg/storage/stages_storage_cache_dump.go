@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/werf/werf/pkg/image"
+)
+
+const StagesStorageCacheDumpVersion = 1
+
+// StagesStorageCacheDump contains stages-storage-cache records of all synchronization server clients.
+type StagesStorageCacheDump struct {
+	Version int                            `json:"version"`
+	Records []StagesStorageCacheDumpRecord `json:"records"`
+}
+
+type StagesStorageCacheDumpRecord struct {
+	ClientID    string          `json:"clientID"`
+	ProjectName string          `json:"projectName"`
+	Digest      string          `json:"digest"`
+	Stages      []image.StageID `json:"stages"`
+}
+
+// StagesStorageCacheDumper is implemented by synchronization server backends,
+// which allow moving stages-storage-cache records of all clients to another backend.
+type StagesStorageCacheDumper interface {
+	ExportStagesStorageCache(ctx context.Context) ([]StagesStorageCacheDumpRecord, error)
+	ImportStagesStorageCache(ctx context.Context, records []StagesStorageCacheDumpRecord) error
+}
+
+func (db *BoltSynchronizationDB) ExportStagesStorageCache(_ context.Context) ([]StagesStorageCacheDumpRecord, error) {
+	var records []StagesStorageCacheDumpRecord
+
+	err := db.DB.View(func(tx *bbolt.Tx) error {
+		clientsBucket := tx.Bucket([]byte(boltClientsBucket))
+		if clientsBucket == nil {
+			return nil
+		}
+
+		return clientsBucket.ForEachBucket(func(clientID []byte) error {
+			cacheBucket := clientsBucket.Bucket(clientID).Bucket([]byte(boltStagesStorageCacheBucket))
+			if cacheBucket == nil {
+				return nil
+			}
+
+			return cacheBucket.ForEachBucket(func(projectName []byte) error {
+				projectBucket := cacheBucket.Bucket(projectName)
+
+				return projectBucket.ForEach(func(digest, _ []byte) error {
+					var record StagesStorageCacheRecord
+					if found, err := db.getRecord(projectBucket, string(digest), &record); err != nil {
+						return err
+					} else if !found {
+						return nil
+					}
+
+					records = append(records, StagesStorageCacheDumpRecord{
+						ClientID:    string(clientID),
+						ProjectName: string(projectName),
+						Digest:      string(digest),
+						Stages:      record.Stages,
+					})
+
+					return nil
+				})
+			})
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to export stages storage cache from %s: %w", db, err)
+	}
+
+	return records, nil
+}
+
+func (db *BoltSynchronizationDB) ImportStagesStorageCache(_ context.Context, records []StagesStorageCacheDumpRecord) error {
+	err := db.DB.Update(func(tx *bbolt.Tx) error {
+		for _, rec := range records {
+			bucket, err := createBoltBucket(tx, clientBucketPath(rec.ClientID, boltStagesStorageCacheBucket, rec.ProjectName))
+			if err != nil {
+				return err
+			}
+
+			if err := db.putRecord(bucket, rec.Digest, StagesStorageCacheRecord{Stages: rec.Stages}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to import stages storage cache into %s: %w", db, err)
+	}
+
+	return nil
+}
+
+// FileStagesStorageCacheDumper exports and imports records of FileStagesStorageCache of all clients,
+// which are stored in the BaseDir/CLIENT_ID directories.
+type FileStagesStorageCacheDumper struct {
+	BaseDir string
+}
+
+func NewFileStagesStorageCacheDumper(baseDir string) *FileStagesStorageCacheDumper {
+	return &FileStagesStorageCacheDumper{BaseDir: baseDir}
+}
+
+func (dumper *FileStagesStorageCacheDumper) ExportStagesStorageCache(ctx context.Context) ([]StagesStorageCacheDumpRecord, error) {
+	var records []StagesStorageCacheDumpRecord
+
+	clientDirs, err := readDirNames(dumper.BaseDir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, clientID := range clientDirs {
+		cache := NewFileStagesStorageCache(filepath.Join(dumper.BaseDir, clientID))
+
+		projectDirs, err := readDirNames(cache.CacheDir, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, projectName := range projectDirs {
+			digests, err := readDirNames(filepath.Join(cache.CacheDir, projectName), false)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, digest := range digests {
+				if found, stages, err := cache.GetStagesByDigest(ctx, projectName, digest); err != nil {
+					return nil, err
+				} else if found {
+					records = append(records, StagesStorageCacheDumpRecord{
+						ClientID:    clientID,
+						ProjectName: projectName,
+						Digest:      digest,
+						Stages:      stages,
+					})
+				}
+			}
+		}
+	}
+
+	return records, nil
+}
+
+func (dumper *FileStagesStorageCacheDumper) ImportStagesStorageCache(ctx context.Context, records []StagesStorageCacheDumpRecord) error {
+	for _, rec := range records {
+		cache := NewFileStagesStorageCache(filepath.Join(dumper.BaseDir, rec.ClientID))
+		if err := cache.StoreStagesByDigest(ctx, rec.ProjectName, rec.Digest, rec.Stages); err != nil {
+			return fmt.Errorf("unable to import stages storage cache into %s: %w", cache, err)
+		}
+	}
+
+	return nil
+}
+
+func readDirNames(dir string, dirsOnly bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() != dirsOnly {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+
+	return names, nil
+}
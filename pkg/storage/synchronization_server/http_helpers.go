@@ -3,9 +3,11 @@ package synchronization_server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 )
 
 func PerformPost(client *http.Client, url string, request, response interface{}) error {
@@ -14,33 +16,44 @@ func PerformPost(client *http.Client, url string, request, response interface{})
 		return fmt.Errorf("unable to marshal request data: %w", err)
 	}
 
+	redactedURL := RedactAddress(url)
+
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBodyData))
 	if err != nil {
-		return fmt.Errorf("unable to create POST request for %q: %w", url, err)
+		return fmt.Errorf("unable to create POST request for %q: %w", redactedURL, redactURLError(err, redactedURL))
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("error requesting url %q: %w", url, err)
+		return fmt.Errorf("error requesting url %q: %w", redactedURL, err)
 	}
 
 	defer resp.Body.Close()
 	respBodyData, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading response of %q request: %w", url, err)
+		return fmt.Errorf("error reading response of %q request: %w", redactedURL, err)
 	}
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("got bad response %s by url %q request:\n%s", resp.Status, url, string(respBodyData))
+		return fmt.Errorf("got bad response %s by url %q request:\n%s", resp.Status, redactedURL, string(respBodyData))
 	}
 
 	if err := json.Unmarshal(respBodyData, response); err != nil {
-		return fmt.Errorf("unable to unmarshal json body by url %q request: %w", url, err)
+		return fmt.Errorf("unable to unmarshal json body by url %q request: %w", redactedURL, err)
 	}
 
 	return nil
 }
 
+// redactURLError hides credentials in the URL of url.Error, which is returned by http.NewRequest on bad URL.
+func redactURLError(err error, redactedURL string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return &url.Error{Op: urlErr.Op, URL: redactedURL, Err: urlErr.Err}
+	}
+	return err
+}
+
 func HandleRequest(w http.ResponseWriter, r *http.Request, request, response interface{}, actionFunc func()) {
 	if r.Method == "POST" {
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -21,7 +21,7 @@ type StagesStorageCacheHttpClient struct {
 }
 
 func (client *StagesStorageCacheHttpClient) String() string {
-	return fmt.Sprintf("http-client %s", client.URL)
+	return fmt.Sprintf("http-client %s", RedactAddress(client.URL))
 }
 
 func (client *StagesStorageCacheHttpClient) GetAllStages(_ context.Context, projectName string) (bool, []image.StageID, error) {
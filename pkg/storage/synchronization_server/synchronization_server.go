@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -14,9 +15,30 @@ import (
 	"github.com/werf/werf/pkg/util"
 )
 
-func RunSynchronizationServer(_ context.Context, ip, port string, distributedLockerBackendFactoryFunc func(clientID string) (distributed_locker.DistributedLockerBackend, error), stagesStorageCacheFactoryFunc func(clientID string) (StagesStorageCacheInterface, error)) error {
-	handler := NewSynchronizationServerHandler(distributedLockerBackendFactoryFunc, stagesStorageCacheFactoryFunc)
-	return http.ListenAndServe(fmt.Sprintf("%s:%s", ip, port), handler)
+type RunSynchronizationServerOptions struct {
+	// Auth enables authentication and per-project authorization of requests when specified.
+	Auth *AuthConfig
+
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+func RunSynchronizationServer(_ context.Context, ip, port string, distributedLockerBackendFactoryFunc func(clientID string) (distributed_locker.DistributedLockerBackend, error), stagesStorageCacheFactoryFunc func(clientID string) (StagesStorageCacheInterface, error), opts RunSynchronizationServerOptions) error {
+	var handler http.Handler = NewSynchronizationServerHandler(distributedLockerBackendFactoryFunc, stagesStorageCacheFactoryFunc)
+	if opts.Auth != nil {
+		handler = NewAuthHandler(opts.Auth, handler)
+	}
+
+	addr := fmt.Sprintf("%s:%s", ip, port)
+
+	switch {
+	case opts.TLSCertFile != "" && opts.TLSKeyFile != "":
+		return http.ListenAndServeTLS(addr, opts.TLSCertFile, opts.TLSKeyFile, handler)
+	case opts.TLSCertFile != "" || opts.TLSKeyFile != "":
+		return fmt.Errorf("both tls certificate and tls key files should be specified")
+	default:
+		return http.ListenAndServe(addr, handler)
+	}
 }
 
 type SynchronizationServerHandler struct {
@@ -119,7 +141,14 @@ func (server *SynchronizationServerHandler) handleRequestByClientID(w http.Respo
 		return
 	}
 
-	if clientServer, err := server.getOrCreateHandlerByClientID(clientID); err != nil {
+	if err := ValidateClientID(clientID); err != nil {
+		http.Error(w, fmt.Sprintf("Bad request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	handlerID := getHandlerID(projectFromContext(r.Context()), clientID)
+
+	if clientServer, err := server.getOrCreateHandlerByClientID(handlerID); err != nil {
 		http.Error(w, fmt.Sprintf("Internal error: %s", err), http.StatusInternalServerError)
 		return
 	} else {
@@ -127,6 +156,26 @@ func (server *SynchronizationServerHandler) handleRequestByClientID(w http.Respo
 	}
 }
 
+// clientIDRegexp matches DNS-1123 labels, clientID is used as a part of file paths and kubernetes resource names.
+var clientIDRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+func ValidateClientID(clientID string) error {
+	if !clientIDRegexp.MatchString(clientID) {
+		return fmt.Errorf("bad clientID %q: should comply with regex %q", clientID, clientIDRegexp)
+	}
+	return nil
+}
+
+// getHandlerID returns the id of the handler, which is passed to the backend factory functions.
+// When authentication is enabled, data of different projects is isolated even if the same clientID is used.
+// Neither project name nor clientID can contain a dot, so ids of different project and clientID pairs never clash.
+func getHandlerID(projectName, clientID string) string {
+	if projectName == "" {
+		return clientID
+	}
+	return fmt.Sprintf("%s.%s", projectName, clientID)
+}
+
 func (server *SynchronizationServerHandler) getOrCreateHandlerByClientID(clientID string) (*SynchronizationServerHandlerByClientID, error) {
 	server.mux.Lock()
 	defer server.mux.Unlock()
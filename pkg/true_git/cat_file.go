@@ -0,0 +1,37 @@
+package true_git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadRevisionFile returns the file content at the revision, the path is relative to the workDir.
+// The error wraps os.ErrNotExist if there is no such file at the revision.
+func ReadRevisionFile(ctx context.Context, workDir, revision, path string) ([]byte, error) {
+	if filepath.IsAbs(path) {
+		absWorkDir, err := filepath.Abs(workDir)
+		if err != nil {
+			return nil, err
+		}
+
+		if path, err = filepath.Rel(absWorkDir, path); err != nil {
+			return nil, err
+		}
+	}
+
+	object := fmt.Sprintf("%s:./%s", revision, filepath.ToSlash(filepath.Clean(path)))
+
+	catFileCmd := NewGitCmd(ctx, &GitCmdOptions{RepoDir: workDir}, "cat-file", "blob", object)
+	if err := catFileCmd.Run(ctx); err != nil {
+		if errOutput := catFileCmd.ErrBuf.String(); strings.Contains(errOutput, "does not exist in") || strings.Contains(errOutput, "exists on disk, but not in") {
+			return nil, fmt.Errorf("file %q not found at revision %q: %w", path, revision, os.ErrNotExist)
+		}
+
+		return nil, fmt.Errorf("git cat-file command failed: %w", err)
+	}
+
+	return catFileCmd.OutBuf.Bytes(), nil
+}
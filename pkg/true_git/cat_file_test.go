@@ -0,0 +1,54 @@
+package true_git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/werf/werf/test/pkg/utils"
+)
+
+var _ = Describe("ReadRevisionFile", func() {
+	var gitRepoPath string
+
+	BeforeEach(func() {
+		gitRepoPath = filepath.Join(SuiteData.TestDirPath, "repo")
+		utils.MkdirAll(filepath.Join(gitRepoPath, "dir"))
+
+		utils.RunSucceedCommand(gitRepoPath, "git", "-c", "init.defaultBranch=main", "init")
+
+		Expect(os.WriteFile(filepath.Join(gitRepoPath, "dir", "file"), []byte("committed"), 0o644)).To(Succeed())
+		utils.RunSucceedCommand(gitRepoPath, "git", "add", "dir/file")
+		utils.RunSucceedCommand(gitRepoPath, "git", "commit", "-m", "Initial commit")
+
+		Expect(os.WriteFile(filepath.Join(gitRepoPath, "dir", "file"), []byte("changed"), 0o644)).To(Succeed())
+
+		Ω(Init(context.Background(), Options{})).Should(Succeed())
+	})
+
+	It("reads the file relative to the work dir at the revision", func() {
+		ctx := context.Background()
+
+		data, err := ReadRevisionFile(ctx, filepath.Join(gitRepoPath, "dir"), "HEAD", "file")
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(Equal("committed"))
+
+		data, err = ReadRevisionFile(ctx, gitRepoPath, "main", filepath.Join(gitRepoPath, "dir", "file"))
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(Equal("committed"))
+	})
+
+	It("returns not exist error if there is no such file at the revision", func() {
+		_, err := ReadRevisionFile(context.Background(), gitRepoPath, "HEAD", "dir/other")
+		Expect(err).To(MatchError(os.ErrNotExist))
+	})
+
+	It("fails on unknown revision", func() {
+		_, err := ReadRevisionFile(context.Background(), gitRepoPath, "unknown", "dir/file")
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(MatchError(os.ErrNotExist))
+	})
+})
@@ -14,7 +14,3 @@ func ExecWerfBinaryCmd(args ...string) *exec.Cmd {
 
 	return cmd
 }
-
-func ExecKubectlCmd(args ...string) *exec.Cmd {
-	return ExecWerfBinaryCmd(append([]string{"kubectl"}, args...)...)
-}
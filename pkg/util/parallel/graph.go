@@ -0,0 +1,344 @@
+package parallel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/werf/logboek"
+	"github.com/werf/logboek/pkg/style"
+	"github.com/werf/werf/pkg/docker"
+	"github.com/werf/werf/pkg/util"
+	"github.com/werf/werf/pkg/util/parallel/constant"
+)
+
+type DoGraphTasksOptions struct {
+	InitDockerCLIForEachWorker bool
+	MaxNumberOfWorkers         int
+	LiveOutput                 bool
+
+	// KeepGoing runs the tasks that do not depend on the failed ones instead of stopping on the first error.
+	KeepGoing bool
+}
+
+// DoGraphTasks runs every task as soon as all tasks it depends on are done, dependencies[taskId] are ids of such tasks.
+// In the keep-going mode the tasks depending on the failed ones are skipped and all task errors are returned joined.
+func DoGraphTasks(ctx context.Context, dependencies [][]int, options DoGraphTasksOptions, taskFunc func(ctx context.Context, taskId int) error) error {
+	numberOfTasks := len(dependencies)
+	if numberOfTasks == 0 {
+		return nil
+	}
+
+	dependents, err := getTasksDependents(dependencies)
+	if err != nil {
+		return err
+	}
+
+	// channels are buffered to never block workers, even if the handler has already returned
+	taskCh := make(chan *graphTask, numberOfTasks)
+	taskEventCh := make(chan graphTaskEvent, numberOfTasks*2)
+	defer close(taskCh)
+
+	ctx, cancelCtx := context.WithCancel(ctx)
+	defer cancelCtx()
+
+	numberOfWorkers := options.MaxNumberOfWorkers
+	if numberOfWorkers <= 0 || numberOfWorkers > numberOfTasks {
+		numberOfWorkers = numberOfTasks
+	}
+
+	for i := 0; i < numberOfWorkers; i++ {
+		workerID := i
+		go func() {
+			for task := range taskCh {
+				// the queued tasks are not started after the handler has returned
+				if ctx.Err() != nil {
+					return
+				}
+
+				if debug() {
+					logboek.Context(task.ctx).LogF("Running worker %d task %d (%d)\n", workerID, task.id, numberOfTasks)
+				}
+
+				taskEventCh <- graphTaskEvent{task: task}
+				task.err = taskFunc(context.WithValue(task.ctx, constant.CtxBackgroundTaskIDKey, workerID), task.id)
+				taskEventCh <- graphTaskEvent{task: task, done: true}
+			}
+		}()
+	}
+
+	numberOfPendingDependencies := make([]int, numberOfTasks)
+	for taskId := range dependencies {
+		numberOfPendingDependencies[taskId] = len(dependencies[taskId])
+	}
+
+	runTask := func(taskId int) error {
+		task, err := newGraphTask(ctx, taskId, options.InitDockerCLIForEachWorker)
+		if err != nil {
+			return err
+		}
+
+		taskCh <- task
+		return nil
+	}
+
+	for taskId := range dependencies {
+		if numberOfPendingDependencies[taskId] == 0 {
+			if err := runTask(taskId); err != nil {
+				return err
+			}
+		}
+	}
+
+	h := &graphTasksHandler{liveOutput: options.LiveOutput}
+	skipped := make([]bool, numberOfTasks)
+	var numberOfResolvedTasks int
+	var taskErrors []error
+
+	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
+
+	for numberOfResolvedTasks < numberOfTasks {
+		select {
+		case event := <-taskEventCh:
+			task := event.task
+			if !event.done {
+				h.running = append(h.running, task)
+				break
+			}
+
+			numberOfResolvedTasks++
+
+			if err := h.taskDone(ctx, task); err != nil {
+				return err
+			}
+
+			if task.err != nil {
+				if !options.KeepGoing {
+					if err := h.flushAll(ctx); err != nil {
+						return err
+					}
+
+					return task.err
+				}
+
+				taskErrors = append(taskErrors, task.err)
+				numberOfResolvedTasks += skipDependentTasks(task.id, dependents, skipped)
+				continue
+			}
+
+			for _, dependentTaskId := range dependents[task.id] {
+				numberOfPendingDependencies[dependentTaskId]--
+				if numberOfPendingDependencies[dependentTaskId] == 0 && !skipped[dependentTaskId] {
+					if err := runTask(dependentTaskId); err != nil {
+						return err
+					}
+				}
+			}
+		case <-ticker.C:
+		}
+
+		if err := h.flushLive(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := h.flushAll(ctx); err != nil {
+		return err
+	}
+
+	return errors.Join(taskErrors...)
+}
+
+// getTasksDependents inverts the dependencies and checks that tasks do not depend on each other cyclically.
+func getTasksDependents(dependencies [][]int) ([][]int, error) {
+	numberOfTasks := len(dependencies)
+	dependents := make([][]int, numberOfTasks)
+	numberOfDependencies := make([]int, numberOfTasks)
+
+	for taskId, taskDependencies := range dependencies {
+		for _, dependencyTaskId := range taskDependencies {
+			if dependencyTaskId < 0 || dependencyTaskId >= numberOfTasks || dependencyTaskId == taskId {
+				return nil, fmt.Errorf("invalid task %d dependency %d", taskId, dependencyTaskId)
+			}
+
+			dependents[dependencyTaskId] = append(dependents[dependencyTaskId], taskId)
+		}
+
+		numberOfDependencies[taskId] = len(taskDependencies)
+	}
+
+	var queue []int
+	for taskId := range dependencies {
+		if numberOfDependencies[taskId] == 0 {
+			queue = append(queue, taskId)
+		}
+	}
+
+	var numberOfSortedTasks int
+	for len(queue) > 0 {
+		taskId := queue[0]
+		queue = queue[1:]
+		numberOfSortedTasks++
+
+		for _, dependentTaskId := range dependents[taskId] {
+			numberOfDependencies[dependentTaskId]--
+			if numberOfDependencies[dependentTaskId] == 0 {
+				queue = append(queue, dependentTaskId)
+			}
+		}
+	}
+
+	if numberOfSortedTasks != numberOfTasks {
+		return nil, fmt.Errorf("tasks have cyclic dependencies")
+	}
+
+	return dependents, nil
+}
+
+// skipDependentTasks marks all tasks depending on the failed one directly or transitively as skipped and returns the number of newly skipped tasks.
+func skipDependentTasks(failedTaskId int, dependents [][]int, skipped []bool) int {
+	var numberOfSkippedTasks int
+
+	queue := append([]int{}, dependents[failedTaskId]...)
+	for len(queue) > 0 {
+		taskId := queue[0]
+		queue = queue[1:]
+
+		if skipped[taskId] {
+			continue
+		}
+
+		skipped[taskId] = true
+		numberOfSkippedTasks++
+		queue = append(queue, dependents[taskId]...)
+	}
+
+	return numberOfSkippedTasks
+}
+
+// graphTaskEvent is sent by the worker when the task is started and when it is done, a single channel keeps the order of both.
+type graphTaskEvent struct {
+	task *graphTask
+	done bool
+}
+
+type graphTask struct {
+	id  int
+	ctx context.Context
+	buf *util.GoroutineSafeBuffer
+	err error
+}
+
+func newGraphTask(ctx context.Context, taskId int, initDockerCLI bool) (*graphTask, error) {
+	buf := &util.GoroutineSafeBuffer{Buffer: bytes.NewBuffer([]byte{})}
+
+	taskCtx := logboek.NewContext(ctx, logboek.Context(ctx).NewSubLogger(buf, buf))
+	logboek.Context(taskCtx).Streams().SetPrefixStyle(style.Highlight())
+	if logboek.Context(taskCtx).Streams().IsPrefixTimeEnabled() {
+		logboek.Context(taskCtx).Streams().SetPrefixTimeFormat("15:04:05")
+	}
+
+	if initDockerCLI {
+		taskCtxWithDockerCli, err := docker.NewContext(taskCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		taskCtx = taskCtxWithDockerCli
+	}
+
+	return &graphTask{id: taskId, ctx: taskCtx, buf: buf}, nil
+}
+
+// graphTasksHandler prints the output of every task as a whole.
+// In the live output mode the earliest started running task is printed as it goes, other tasks are printed after it is done.
+type graphTasksHandler struct {
+	liveOutput bool
+
+	running []*graphTask
+	done    []*graphTask
+	live    *graphTask
+}
+
+func (h *graphTasksHandler) taskDone(ctx context.Context, task *graphTask) error {
+	for i := range h.running {
+		if h.running[i] == task {
+			h.running = append(h.running[:i], h.running[i+1:]...)
+			break
+		}
+	}
+
+	if !h.liveOutput {
+		return h.flush(ctx, task)
+	}
+
+	h.done = append(h.done, task)
+	if h.live == task {
+		h.live = nil
+	}
+
+	return nil
+}
+
+func (h *graphTasksHandler) flushLive(ctx context.Context) error {
+	if !h.liveOutput {
+		return nil
+	}
+
+	if h.live == nil {
+		if err := h.flushDone(ctx); err != nil {
+			return err
+		}
+
+		if len(h.running) == 0 {
+			return nil
+		}
+
+		h.live = h.running[0]
+	}
+
+	return h.copy(ctx, h.live)
+}
+
+func (h *graphTasksHandler) flushAll(ctx context.Context) error {
+	if h.live != nil {
+		if err := h.flush(ctx, h.live); err != nil {
+			return err
+		}
+		h.live = nil
+	}
+
+	return h.flushDone(ctx)
+}
+
+func (h *graphTasksHandler) flushDone(ctx context.Context) error {
+	for _, task := range h.done {
+		if err := h.flush(ctx, task); err != nil {
+			return err
+		}
+	}
+	h.done = nil
+
+	return nil
+}
+
+func (h *graphTasksHandler) flush(ctx context.Context, task *graphTask) error {
+	if err := h.copy(ctx, task); err != nil {
+		return err
+	}
+
+	logboek.Context(ctx).LogOptionalLn()
+
+	return nil
+}
+
+func (h *graphTasksHandler) copy(ctx context.Context, task *graphTask) error {
+	return logboek.Context(ctx).Streams().DoErrorWithoutIndent(func() error {
+		_, err := io.Copy(logboek.Context(ctx).OutStream(), task.buf)
+		return err
+	})
+}
@@ -0,0 +1,203 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/werf/logboek"
+)
+
+func newGraphTestContext() context.Context {
+	return logboek.NewContext(context.Background(), logboek.DefaultLogger())
+}
+
+type graphTestRecorder struct {
+	mux      sync.Mutex
+	finished []int
+	ran      map[int]bool
+	running  int
+	maxRun   int
+}
+
+func newGraphTestRecorder() *graphTestRecorder {
+	return &graphTestRecorder{ran: map[int]bool{}}
+}
+
+func (r *graphTestRecorder) start(taskId int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.ran[taskId] = true
+	r.running++
+	if r.running > r.maxRun {
+		r.maxRun = r.running
+	}
+}
+
+func (r *graphTestRecorder) finish(taskId int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.running--
+	r.finished = append(r.finished, taskId)
+}
+
+func (r *graphTestRecorder) hasRun(taskId int) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.ran[taskId]
+}
+
+func TestDoGraphTasksRunsTasksAfterDependencies(t *testing.T) {
+	// 3 depends on 0 and 2, 2 depends on 1, 0 is slow and should not delay 2
+	dependencies := [][]int{{}, {}, {1}, {0, 2}}
+	recorder := newGraphTestRecorder()
+	startTime := time.Now()
+	startedAt := make([]time.Duration, len(dependencies))
+
+	err := DoGraphTasks(newGraphTestContext(), dependencies, DoGraphTasksOptions{LiveOutput: true}, func(ctx context.Context, taskId int) error {
+		recorder.start(taskId)
+		defer recorder.finish(taskId)
+
+		recorder.mux.Lock()
+		startedAt[taskId] = time.Since(startTime)
+		recorder.mux.Unlock()
+
+		if taskId == 0 {
+			time.Sleep(300 * time.Millisecond)
+		} else {
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	position := map[int]int{}
+	for i, taskId := range recorder.finished {
+		position[taskId] = i
+	}
+
+	if len(position) != len(dependencies) {
+		t.Fatalf("expected all tasks to finish, got %v", recorder.finished)
+	}
+
+	for taskId, taskDependencies := range dependencies {
+		for _, dependencyTaskId := range taskDependencies {
+			if position[dependencyTaskId] > position[taskId] {
+				t.Errorf("task %d finished before its dependency %d: %v", taskId, dependencyTaskId, recorder.finished)
+			}
+		}
+	}
+
+	if startedAt[2] > 200*time.Millisecond {
+		t.Errorf("task 2 waited for the unrelated task 0: started after %s", startedAt[2])
+	}
+}
+
+func TestDoGraphTasksRejectsCyclicDependencies(t *testing.T) {
+	for name, dependencies := range map[string][][]int{
+		"cycle":              {{}, {2}, {1}},
+		"self dependency":    {{0}},
+		"unknown dependency": {{1}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var ran bool
+			err := DoGraphTasks(newGraphTestContext(), dependencies, DoGraphTasksOptions{}, func(ctx context.Context, taskId int) error {
+				ran = true
+				return nil
+			})
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if ran {
+				t.Errorf("expected no tasks to run")
+			}
+		})
+	}
+}
+
+func TestDoGraphTasksStopsOnFirstError(t *testing.T) {
+	dependencies := [][]int{{}, {0}, {1}}
+	recorder := newGraphTestRecorder()
+	taskErr := errors.New("task 0 failed")
+
+	err := DoGraphTasks(newGraphTestContext(), dependencies, DoGraphTasksOptions{MaxNumberOfWorkers: 1}, func(ctx context.Context, taskId int) error {
+		recorder.start(taskId)
+		defer recorder.finish(taskId)
+
+		if taskId == 0 {
+			return taskErr
+		}
+		return nil
+	})
+	if !errors.Is(err, taskErr) {
+		t.Fatalf("expected %q, got %v", taskErr, err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if recorder.hasRun(1) || recorder.hasRun(2) {
+		t.Errorf("expected dependent tasks not to run")
+	}
+}
+
+func TestDoGraphTasksKeepGoing(t *testing.T) {
+	// 1 and 2 depend on the failed 0 directly and transitively, 3 and 4 are independent, 4 fails too
+	dependencies := [][]int{{}, {0}, {1}, {}, {3}}
+	recorder := newGraphTestRecorder()
+	task0Err := errors.New("task 0 failed")
+	task4Err := errors.New("task 4 failed")
+
+	err := DoGraphTasks(newGraphTestContext(), dependencies, DoGraphTasksOptions{KeepGoing: true}, func(ctx context.Context, taskId int) error {
+		recorder.start(taskId)
+		defer recorder.finish(taskId)
+
+		switch taskId {
+		case 0:
+			return task0Err
+		case 4:
+			time.Sleep(20 * time.Millisecond)
+			return task4Err
+		}
+		return nil
+	})
+	if !errors.Is(err, task0Err) || !errors.Is(err, task4Err) {
+		t.Fatalf("expected joined errors of tasks 0 and 4, got %v", err)
+	}
+
+	if recorder.hasRun(1) || recorder.hasRun(2) {
+		t.Errorf("expected tasks depending on the failed task to be skipped")
+	}
+	if !recorder.hasRun(3) || !recorder.hasRun(4) {
+		t.Errorf("expected independent tasks to run")
+	}
+}
+
+func TestDoGraphTasksLimitsNumberOfWorkers(t *testing.T) {
+	dependencies := make([][]int, 6)
+	recorder := newGraphTestRecorder()
+
+	err := DoGraphTasks(newGraphTestContext(), dependencies, DoGraphTasksOptions{MaxNumberOfWorkers: 2}, func(ctx context.Context, taskId int) error {
+		recorder.start(taskId)
+		defer recorder.finish(taskId)
+
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if recorder.maxRun != 2 {
+		t.Errorf("expected 2 tasks running at the same time at most, got %d", recorder.maxRun)
+	}
+	if len(recorder.finished) != len(dependencies) {
+		t.Errorf("expected all tasks to finish, got %v", recorder.finished)
+	}
+}